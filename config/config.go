@@ -1,59 +1,427 @@
 package config
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"log/slog"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/tracing"
 )
 
+// Config is the typed configuration of the service. It starts from the defaults and is
+// overridden, in order, by the optional file, the environment and the command line flags.
 type Config struct {
-	HTTPPort    string
-	DatabaseURL string
+	// Store is where the data is kept: postgres or memory, which needs no database and
+	// loses the data on shutdown.
+	Store       string            `yaml:"store"`
+	HTTP        HTTPConfig        `yaml:"http"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Migrations  MigrationsConfig  `yaml:"migrations"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Reorder     ReorderConfig     `yaml:"reorder"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Features    FeaturesConfig    `yaml:"features"`
+	Business    BusinessConfig    `yaml:"business"`
+}
+
+// minJWTSecretLength is the length in bytes of the shortest secret accepted to sign the
+// tokens with HS256, the length of its hash.
+const minJWTSecretLength = 32
+
+// Stores the data can be kept in.
+const (
+	StorePostgres = "postgres"
+	StoreMemory   = "memory"
+)
+
+type HTTPConfig struct {
+	Port string `yaml:"port"`
+	// MetricsPort is where /metrics is served, apart from the API so it is not public.
+	MetricsPort  string        `yaml:"metricsPort"`
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// ShutdownTimeout is how long the in-flight requests are waited for on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	CORSOrigins     []string      `yaml:"corsOrigins"`
+	// TrustedProxies are the IPs or CIDR ranges of the proxies in front of the service, the
+	// client IP is only taken from the forwarding headers they send.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// DatabaseConfig takes the connection from URL or, when empty, from the separate fields.
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSL             bool          `yaml:"ssl"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+}
+
+// MigrationsConfig lets the server start against a schema it would otherwise refuse.
+type MigrationsConfig struct {
+	// AllowNewerSchema serves when the database has migrations this binary does not know.
+	AllowNewerSchema bool `yaml:"allowNewerSchema"`
+	// AllowDirtySchema serves when the last migration failed halfway.
+	AllowDirtySchema bool `yaml:"allowDirtySchema"`
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwtSecret"`
+	AccessTokenTTL  time.Duration `yaml:"accessTokenTTL"`
+	RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL"`
+	// InitialUser is created as admin on startup when there are no users, it is skipped when empty.
+	InitialUser InitialUserConfig `yaml:"initialUser"`
+}
+
+type InitialUserConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
 }
 
-type postgresConfig struct {
-	UserDB   string `json:"userDB"`
-	Password string `json:"password"`
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	Database string `json:"database"`
-	SSL      bool   `json:"ssl"`
+type ReorderConfig struct {
+	WindowDays      int `yaml:"windowDays"`
+	LeadTimeDays    int `yaml:"leadTimeDays"`
+	SafetyStockDays int `yaml:"safetyStockDays"`
+	// JobInterval is how often the reorder draft is generated when the job is enabled.
+	JobInterval time.Duration `yaml:"jobInterval"`
 }
 
-func LoadConfig() (Config, error) {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8000"
+type IdempotencyConfig struct {
+	// KeyTTL is how long the response of a request is replayed for its Idempotency-Key.
+	KeyTTL time.Duration `yaml:"keyTTL"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+type TracingConfig struct {
+	// Exporter is where the spans are sent: otlp, stdout or none.
+	Exporter string `yaml:"exporter"`
+}
+
+// BusinessConfig describes where the pharmacies operate.
+type BusinessConfig struct {
+	// Timezone is the IANA name of the timezone the dates are interpreted and returned in.
+	Timezone string `yaml:"timezone"`
+}
+
+type FeaturesConfig struct {
+	ReorderJob  bool `yaml:"reorderJob"`
+	Metrics     bool `yaml:"metrics"`
+	Idempotency bool `yaml:"idempotency"`
+}
+
+func defaults() Config {
+	return Config{
+		Store: StorePostgres,
+		HTTP: HTTPConfig{
+			Port:            "8000",
+			MetricsPort:     "9464",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			CORSOrigins:     []string{"*"},
+		},
+		Database: DatabaseConfig{
+			Port:            "5432",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+		},
+		Reorder: ReorderConfig{
+			WindowDays:      models.DefaultReorderWindowDays,
+			LeadTimeDays:    models.DefaultReorderLeadTimeDays,
+			SafetyStockDays: models.DefaultReorderSafetyStockDays,
+			JobInterval:     24 * time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Tracing: TracingConfig{
+			Exporter: tracing.ExporterNone,
+		},
+		Features: FeaturesConfig{
+			ReorderJob:  true,
+			Metrics:     true,
+			Idempotency: true,
+		},
+		Business: BusinessConfig{
+			Timezone: models.DefaultBusinessTimezone,
+		},
 	}
+}
+
+// Validate returns every invalid setting at once, naming them by their key.
+func (c Config) Validate() error {
+	v := &validation{}
 
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		content, err := ioutil.ReadFile("config/config.json")
-		if err != nil {
-			return Config{}, err
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port <= 0 || port > 65535 {
+		v.invalid("http.port", "must be a number between 1 and 65535, got [%s]", c.HTTP.Port)
+	}
+	if c.Features.Metrics {
+		if port, err := strconv.Atoi(c.HTTP.MetricsPort); err != nil || port <= 0 || port > 65535 {
+			v.invalid("http.metricsPort", "must be a number between 1 and 65535, got [%s]", c.HTTP.MetricsPort)
+		} else if c.HTTP.MetricsPort == c.HTTP.Port {
+			v.invalid("http.metricsPort", "must differ from http.port so the metrics are not public")
 		}
-		var pgConfig postgresConfig
-		err = json.Unmarshal(content, &pgConfig)
-		if err != nil {
-			return Config{}, err
+	}
+	for _, setting := range []struct {
+		key      string
+		duration time.Duration
+	}{
+		{key: "http.readTimeout", duration: c.HTTP.ReadTimeout},
+		{key: "http.writeTimeout", duration: c.HTTP.WriteTimeout},
+		{key: "http.shutdownTimeout", duration: c.HTTP.ShutdownTimeout},
+		{key: "auth.accessTokenTTL", duration: c.Auth.AccessTokenTTL},
+		{key: "auth.refreshTokenTTL", duration: c.Auth.RefreshTokenTTL},
+		{key: "idempotency.keyTTL", duration: c.Idempotency.KeyTTL},
+	} {
+		if setting.duration <= 0 {
+			v.invalid(setting.key, "must be a positive duration, got [%s]", setting.duration)
 		}
-		sslMode := "disable"
-		if pgConfig.SSL {
-			sslMode = "require"
+	}
+	if len(c.HTTP.CORSOrigins) == 0 {
+		v.invalid("http.corsOrigins", "at least one origin is required, use * to allow any")
+	}
+	for _, proxy := range c.HTTP.TrustedProxies {
+		if _, err := parseProxy(proxy); err != nil {
+			v.invalid("http.trustedProxies", "must be IPs or CIDR ranges, got [%s]", proxy)
 		}
-		databaseURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-			pgConfig.UserDB,
-			pgConfig.Password,
-			pgConfig.Host,
-			pgConfig.Port,
-			pgConfig.Database,
-			sslMode,
-		)
 	}
 
-	return Config{
-		HTTPPort:    port,
-		DatabaseURL: databaseURL,
-	}, nil
+	switch c.Store {
+	case StorePostgres:
+		c.Database.validate(v)
+	case StoreMemory:
+	default:
+		v.invalid("store", "must be %s or %s, got [%s]", StorePostgres, StoreMemory, c.Store)
+	}
+
+	if c.Auth.JWTSecret == "" {
+		v.invalid("auth.jwtSecret", "is required")
+	} else if len(c.Auth.JWTSecret) < minJWTSecretLength {
+		v.invalid("auth.jwtSecret", "must be at least %d bytes long, got %d", minJWTSecretLength, len(c.Auth.JWTSecret))
+	}
+	if c.Auth.AccessTokenTTL >= c.Auth.RefreshTokenTTL {
+		v.invalid("auth.accessTokenTTL", "must be shorter than refreshTokenTTL (%s), got [%s]",
+			c.Auth.RefreshTokenTTL, c.Auth.AccessTokenTTL)
+	}
+	if (c.Auth.InitialUser.Username == "") != (c.Auth.InitialUser.Password == "") {
+		v.invalid("auth.initialUser", "username and password must be set together")
+	}
+
+	if err := c.Reorder.Params().ValidateReorderParams(); err != nil {
+		v.invalid("reorder", "%v", err)
+	}
+	if c.Features.ReorderJob && c.Reorder.JobInterval <= 0 {
+		v.invalid("reorder.jobInterval", "must be a positive duration while features.reorderJob is on, got [%s]",
+			c.Reorder.JobInterval)
+	}
+
+	c.Log.validate(v)
+	if _, err := time.LoadLocation(c.Business.Timezone); err != nil || c.Business.Timezone == "" {
+		v.invalid("business.timezone", "must be an IANA timezone like %s, got [%s]",
+			models.DefaultBusinessTimezone, c.Business.Timezone)
+	}
+	switch c.Tracing.Exporter {
+	case tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone:
+	default:
+		v.invalid("tracing.exporter", "must be %s, %s or %s, got [%s]",
+			tracing.ExporterOTLP, tracing.ExporterStdout, tracing.ExporterNone, c.Tracing.Exporter)
+	}
+
+	return v.err()
+}
+
+// ValidateDatabase checks only the settings needed to run the migrations.
+func (c Config) ValidateDatabase() error {
+	v := &validation{}
+	c.Database.validate(v)
+	c.Log.validate(v)
+
+	return v.err()
+}
+
+func (c DatabaseConfig) validate(v *validation) {
+	if c.URL == "" && (c.Host == "" || c.Name == "") {
+		v.invalid("database", "either url or host and name are required")
+	}
+	if c.URL != "" {
+		if _, err := url.Parse(c.URL); err != nil {
+			v.invalid("database.url", "is not a valid URL")
+		}
+	}
+	if c.MaxOpenConns <= 0 {
+		v.invalid("database.maxOpenConns", "must be greater than 0, got [%d]", c.MaxOpenConns)
+	}
+	// zero lifetimes keep the connections open forever, as in database/sql.
+	if c.ConnMaxLifetime < 0 {
+		v.invalid("database.connMaxLifetime", "must not be negative, got [%s]", c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime < 0 {
+		v.invalid("database.connMaxIdleTime", "must not be negative, got [%s]", c.ConnMaxIdleTime)
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		v.invalid("database.maxIdleConns", "must be between 0 and maxOpenConns (%d), got [%d]",
+			c.MaxOpenConns, c.MaxIdleConns)
+	}
+}
+
+func (c LogConfig) validate(v *validation) {
+	if _, err := logging.ParseLevel(c.Level); err != nil {
+		v.invalid("log.level", "must be debug, info, warn or error, got [%s]", c.Level)
+	}
+}
+
+// validation collects the invalid settings to report them together.
+type validation struct {
+	errs []error
+}
+
+func (v *validation) invalid(key, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (v *validation) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(v.errs...))
+}
+
+// ConnectionURL returns the postgres URL of the database.
+func (c DatabaseConfig) ConnectionURL() string {
+	if c.URL != "" {
+		return c.URL
+	}
+
+	sslMode := "disable"
+	if c.SSL {
+		sslMode = "require"
+	}
+	connectionURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Host + ":" + c.Port,
+		Path:     c.Name,
+		RawQuery: "sslmode=" + sslMode,
+	}
+
+	return connectionURL.String()
+}
+
+// DatabaseURL returns the URL of the database with the sessions in the business timezone,
+// unless the URL already sets one.
+func (c Config) DatabaseURL() string {
+	connectionURL := c.Database.ConnectionURL()
+
+	parsed, err := url.Parse(connectionURL)
+	if err != nil || (parsed.Scheme != "postgres" && parsed.Scheme != "postgresql") {
+		return connectionURL
+	}
+	query := parsed.Query()
+	if query.Get("timezone") != "" {
+		return connectionURL
+	}
+	query.Set("timezone", c.Business.Location().String())
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
+
+// TrustedProxyPrefixes returns the parsed trusted proxies, Validate ensures they are valid.
+func (c HTTPConfig) TrustedProxyPrefixes() []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := parseProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// parseProxy takes a CIDR range, or a single IP as the range holding only it.
+func parseProxy(proxy string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(proxy); err == nil {
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()).Masked(), nil
+}
+
+func (c AuthConfig) Settings() models.AuthSettings {
+	return models.AuthSettings{
+		Secret:          []byte(c.JWTSecret),
+		AccessTokenTTL:  c.AccessTokenTTL,
+		RefreshTokenTTL: c.RefreshTokenTTL,
+	}
+}
+
+func (c AuthConfig) InitialUserRequest() models.UserCreationRequest {
+	return models.UserCreationRequest{
+		Username: c.InitialUser.Username,
+		Password: c.InitialUser.Password,
+		Role:     models.RoleAdmin,
+	}
+}
+
+func (c ReorderConfig) Params() models.ReorderParams {
+	return models.ReorderParams{
+		WindowDays:      c.WindowDays,
+		LeadTimeDays:    c.LeadTimeDays,
+		SafetyStockDays: c.SafetyStockDays,
+	}
+}
+
+// Location returns the business timezone, Validate ensures it exists.
+func (c BusinessConfig) Location() *time.Location {
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func (c BusinessConfig) Calendar() models.BusinessCalendar {
+	return models.NewBusinessCalendar(c.Location())
+}
+
+// SlogLevel returns the parsed level, Validate ensures it is valid.
+func (c LogConfig) SlogLevel() slog.Level {
+	level, err := logging.ParseLevel(c.Level)
+	if err != nil {
+		return slog.LevelInfo
+	}
+
+	return level
 }
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func TestLoadConfigDefaultFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "legacy keys",
+			content: `{"userDB": "aveonline", "password": "secreta", "host": "db", "port": "5433", "database": "farmacia", "ssl": true}`,
+		},
+		{
+			name:    "database section",
+			content: `{"database": {"user": "aveonline", "password": "secreta", "host": "db", "port": "5433", "name": "farmacia", "ssl": true}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv(envPrefix+"AUTH_JWT_SECRET", testJWTSecret)
+			if err := os.Mkdir("config", 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.FromSlash(defaultConfigFile), []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(nil)
+			if err != nil {
+				t.Fatalf("loading the configuration: %v", err)
+			}
+			want := "postgres://aveonline:secreta@db:5433/farmacia?sslmode=require"
+			if got := config.Database.ConnectionURL(); got != want {
+				t.Errorf("got database %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr string
+	}{
+		{name: "missing", secret: "", wantErr: "auth.jwtSecret: is required"},
+		{name: "too short", secret: testJWTSecret[:31], wantErr: "auth.jwtSecret: must be at least 32 bytes long, got 31"},
+		{name: "long enough", secret: testJWTSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaults()
+			config.Store = StoreMemory
+			config.Auth.JWTSecret = tt.secret
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
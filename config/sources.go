@@ -0,0 +1,354 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envPrefix namespaces the environment variables, AVEONLINE_HTTP_PORT sets http.port.
+	envPrefix = "AVEONLINE_"
+	// the variables and flags with these suffixes read a secret from the file they name.
+	envFileSuffix  = "_FILE"
+	flagFileSuffix = "-file"
+
+	configFileFlag = "config"
+	// defaultConfigFile is read when no file is given, as it was before the -config flag.
+	defaultConfigFile = "config/config.json"
+)
+
+// setting is a configuration key that can be overridden from the environment and the flags.
+type setting struct {
+	// key is the path of the setting in the configuration file, e.g. auth.jwtSecret.
+	key string
+	// legacyEnv is the variable the setting was read from before the prefix was added.
+	legacyEnv string
+	// secret settings have no flag, they are read from a file with the -file flag instead.
+	secret bool
+	usage  string
+	set    func(value string) error
+}
+
+func (s setting) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(splitKey(s.key, "_"), ".", "_"))
+}
+
+func (s setting) flag() string {
+	return strings.ToLower(strings.ReplaceAll(splitKey(s.key, "-"), ".", "-"))
+}
+
+// splitKey separates with sep the words of the camel case parts of the key.
+func splitKey(key, sep string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			b.WriteString(sep)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{key: "store", usage: "where the data is kept: postgres or memory", set: stringValue(&c.Store)},
+		{key: "http.port", legacyEnv: "PORT", usage: "port the HTTP server listens on", set: stringValue(&c.HTTP.Port)},
+		{key: "http.metricsPort", usage: "port of the internal listener serving /metrics", set: stringValue(&c.HTTP.MetricsPort)},
+		{key: "http.readTimeout", usage: "maximum duration to read a request", set: durationValue(&c.HTTP.ReadTimeout)},
+		{key: "http.writeTimeout", usage: "maximum duration to write a response", set: durationValue(&c.HTTP.WriteTimeout)},
+		{key: "http.shutdownTimeout", usage: "time given to in-flight requests on shutdown", set: durationValue(&c.HTTP.ShutdownTimeout)},
+		{key: "http.corsOrigins", usage: "comma separated origins allowed by CORS", set: listValue(&c.HTTP.CORSOrigins)},
+		{key: "http.trustedProxies", usage: "comma separated IPs or CIDR ranges of the proxies whose forwarding headers are honored", set: listValue(&c.HTTP.TrustedProxies)},
+		{key: "database.url", legacyEnv: "DATABASE_URL", secret: true, usage: "postgres connection URL", set: stringValue(&c.Database.URL)},
+		{key: "database.host", usage: "database host, when no url is given", set: stringValue(&c.Database.Host)},
+		{key: "database.port", usage: "database port, when no url is given", set: stringValue(&c.Database.Port)},
+		{key: "database.user", usage: "database user, when no url is given", set: stringValue(&c.Database.User)},
+		{key: "database.password", secret: true, usage: "database password, when no url is given", set: stringValue(&c.Database.Password)},
+		{key: "database.name", usage: "database name, when no url is given", set: stringValue(&c.Database.Name)},
+		{key: "database.ssl", usage: "require SSL, when no url is given", set: boolValue(&c.Database.SSL)},
+		{key: "database.maxOpenConns", usage: "maximum open connections", set: intValue(&c.Database.MaxOpenConns)},
+		{key: "database.maxIdleConns", usage: "maximum idle connections", set: intValue(&c.Database.MaxIdleConns)},
+		{key: "database.connMaxLifetime", usage: "maximum lifetime of a connection, 0 for no limit", set: durationValue(&c.Database.ConnMaxLifetime)},
+		{key: "database.connMaxIdleTime", usage: "maximum idle time of a connection, 0 for no limit", set: durationValue(&c.Database.ConnMaxIdleTime)},
+		{key: "migrations.allowNewerSchema", usage: "serve even if the database schema is newer than the binary", set: boolValue(&c.Migrations.AllowNewerSchema)},
+		{key: "migrations.allowDirtySchema", usage: "serve even if the last migration failed halfway", set: boolValue(&c.Migrations.AllowDirtySchema)},
+		{key: "auth.jwtSecret", legacyEnv: "JWT_SECRET", secret: true, usage: "secret signing the tokens", set: stringValue(&c.Auth.JWTSecret)},
+		{key: "auth.accessTokenTTL", legacyEnv: "ACCESS_TOKEN_TTL", usage: "lifetime of the access tokens", set: durationValue(&c.Auth.AccessTokenTTL)},
+		{key: "auth.refreshTokenTTL", legacyEnv: "REFRESH_TOKEN_TTL", usage: "lifetime of the refresh tokens", set: durationValue(&c.Auth.RefreshTokenTTL)},
+		{key: "auth.initialUser.username", legacyEnv: "INITIAL_USER_USERNAME", usage: "admin created when there are no users", set: stringValue(&c.Auth.InitialUser.Username)},
+		{key: "auth.initialUser.password", legacyEnv: "INITIAL_USER_PASSWORD", secret: true, usage: "password of the initial admin", set: stringValue(&c.Auth.InitialUser.Password)},
+		{key: "reorder.windowDays", legacyEnv: "REORDER_WINDOW_DAYS", usage: "days of sales averaged by the reorder report", set: intValue(&c.Reorder.WindowDays)},
+		{key: "reorder.leadTimeDays", legacyEnv: "REORDER_LEAD_TIME_DAYS", usage: "days the supplier takes to deliver", set: intValue(&c.Reorder.LeadTimeDays)},
+		{key: "reorder.safetyStockDays", legacyEnv: "REORDER_SAFETY_STOCK_DAYS", usage: "days of sales kept as safety stock", set: intValue(&c.Reorder.SafetyStockDays)},
+		{key: "reorder.jobInterval", legacyEnv: "REORDER_JOB_INTERVAL", usage: "how often the reorder draft is generated", set: durationValue(&c.Reorder.JobInterval)},
+		{key: "idempotency.keyTTL", legacyEnv: "IDEMPOTENCY_KEY_TTL", usage: "how long responses are replayed for an Idempotency-Key", set: durationValue(&c.Idempotency.KeyTTL)},
+		{key: "log.level", legacyEnv: "LOG_LEVEL", usage: "debug, info, warn or error", set: stringValue(&c.Log.Level)},
+		{key: "tracing.exporter", legacyEnv: "OTEL_TRACES_EXPORTER", usage: "otlp, stdout or none", set: stringValue(&c.Tracing.Exporter)},
+		{key: "business.timezone", usage: "IANA timezone the dates are interpreted in", set: stringValue(&c.Business.Timezone)},
+		{key: "features.reorderJob", usage: "generate the reorder draft periodically", set: boolValue(&c.Features.ReorderJob)},
+		{key: "features.metrics", usage: "serve /metrics on http.metricsPort", set: boolValue(&c.Features.Metrics)},
+		{key: "features.idempotency", usage: "honor the Idempotency-Key header", set: boolValue(&c.Features.Idempotency)},
+	}
+}
+
+// LoadConfig builds the configuration from the defaults, the file given with -config or
+// AVEONLINE_CONFIG_FILE, the environment and the flags in args, and validates it.
+func LoadConfig(args []string) (Config, error) {
+	config, rest, err := load(args)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(rest) > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments %v", rest)
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+// LoadMigrateConfig loads the configuration as LoadConfig but validates only the database
+// settings. It returns the arguments after the flags, which are the migrate command.
+func LoadMigrateConfig(args []string) (Config, []string, error) {
+	config, rest, err := load(args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := config.ValidateDatabase(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return config, rest, nil
+}
+
+func load(args []string) (Config, []string, error) {
+	config := defaults()
+	configSettings := settings(&config)
+
+	flags := flag.NewFlagSet("aveonline-backend", flag.ContinueOnError)
+	configFile := flags.String(configFileFlag, os.Getenv(envPrefix+"CONFIG_FILE"), "optional YAML or JSON configuration file")
+	for _, s := range configSettings {
+		if s.secret {
+			flags.String(s.flag()+flagFileSuffix, "", "file containing the "+s.usage)
+			continue
+		}
+		flags.String(s.flag(), "", s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *configFile == "" {
+		if _, err := os.Stat(defaultConfigFile); err == nil {
+			*configFile = defaultConfigFile
+		}
+	}
+	if *configFile != "" {
+		if err := loadFile(*configFile, &config); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	if err := applyEnv(configSettings); err != nil {
+		return Config{}, nil, err
+	}
+	if err := applyFlags(flags, configSettings); err != nil {
+		return Config{}, nil, err
+	}
+
+	return config, flags.Args(), nil
+}
+
+func loadFile(path string, config *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading configuration file: %w", err)
+	}
+
+	if isLegacyFile(content) {
+		return loadLegacyFile(path, content, config)
+	}
+
+	// JSON is valid YAML, so both formats are read by the same decoder.
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing configuration file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// legacyFile is the flat format of config/config.json before the settings were grouped,
+// it only had the database connection.
+type legacyFile struct {
+	UserDB   string `yaml:"userDB"`
+	Password string `yaml:"password"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Database string `yaml:"database"`
+	SSL      bool   `yaml:"ssl"`
+}
+
+// isLegacyFile tells the flat format by its userDB key or its database key holding the name
+// instead of the database section.
+func isLegacyFile(content []byte) bool {
+	var keys map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &keys); err != nil {
+		return false
+	}
+	_, hasUserDB := keys["userDB"]
+	database, hasDatabase := keys["database"]
+
+	return hasUserDB || (hasDatabase && database.Kind == yaml.ScalarNode)
+}
+
+func loadLegacyFile(path string, content []byte, config *Config) error {
+	var legacy legacyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&legacy); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing configuration file %s: %w", path, err)
+	}
+
+	config.Database.User = legacy.UserDB
+	config.Database.Password = legacy.Password
+	config.Database.Host = legacy.Host
+	if legacy.Port != "" {
+		config.Database.Port = legacy.Port
+	}
+	config.Database.Name = legacy.Database
+	config.Database.SSL = legacy.SSL
+
+	return nil
+}
+
+func applyEnv(configSettings []setting) error {
+	for _, s := range configSettings {
+		for _, env := range []string{s.legacyEnv, s.env()} {
+			if env == "" {
+				continue
+			}
+			if value, ok := os.LookupEnv(env); ok && value != "" {
+				if err := s.set(value); err != nil {
+					return fmt.Errorf("invalid %s: %w", env, err)
+				}
+			}
+			if !s.secret {
+				continue
+			}
+			if path, ok := os.LookupEnv(env + envFileSuffix); ok && path != "" {
+				if err := setFromFile(s, path); err != nil {
+					return fmt.Errorf("invalid %s: %w", env+envFileSuffix, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyFlags(flags *flag.FlagSet, configSettings []setting) error {
+	byFlag := make(map[string]setting, len(configSettings))
+	for _, s := range configSettings {
+		if s.secret {
+			byFlag[s.flag()+flagFileSuffix] = s
+			continue
+		}
+		byFlag[s.flag()] = s
+	}
+
+	var err error
+	flags.Visit(func(f *flag.Flag) {
+		s, ok := byFlag[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if s.secret {
+			err = setFromFile(s, f.Value.String())
+		} else {
+			err = s.set(f.Value.String())
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid -%s: %w", f.Name, err)
+		}
+	})
+
+	return err
+}
+
+func setFromFile(s setting, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading secret file: %w", err)
+	}
+
+	return s.set(strings.TrimSpace(string(content)))
+}
+
+func stringValue(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+func intValue(target *int) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a number", value)
+		}
+		*target = parsed
+		return nil
+	}
+}
+
+func boolValue(target *bool) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a boolean", value)
+		}
+		*target = parsed
+		return nil
+	}
+}
+
+func durationValue(target *time.Duration) func(string) error {
+	return func(value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a duration, use values like 30s or 15m", value)
+		}
+		*target = parsed
+		return nil
+	}
+}
+
+func listValue(target *[]string) func(string) error {
+	return func(value string) error {
+		items := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*target = items
+		return nil
+	}
+}
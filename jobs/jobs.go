@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+)
+
+// Every runs job each interval until ctx is done. Failures are logged, with the logger
+// of ctx, and the job keeps being scheduled.
+func Every(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	logger := logging.FromContext(ctx).With("job", name)
+	ctx = logging.ContextWithLogger(ctx, logger)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				logger.ErrorContext(ctx, "job failed", "error", err)
+				continue
+			}
+			logger.InfoContext(ctx, "job finished")
+		}
+	}
+}
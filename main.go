@@ -2,76 +2,214 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
+	// the business timezone must load even where the system has no timezone database
+	_ "time/tzdata"
 
 	"github.com/VictorDelgado94/aveonline-backend/config"
-	"github.com/VictorDelgado94/aveonline-backend/store"
+	"github.com/VictorDelgado94/aveonline-backend/jobs"
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/metrics"
+	"github.com/VictorDelgado94/aveonline-backend/rbac"
+	"github.com/VictorDelgado94/aveonline-backend/tracing"
 	"github.com/VictorDelgado94/aveonline-backend/transport"
 	"github.com/VictorDelgado94/aveonline-backend/usecase"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/labstack/echo/middleware"
 	_ "github.com/lib/pq"
 )
 
 const (
-	defaultTimeoutSeconds      = 10
-	targetDBSchemaVersion uint = 1
+	purgeInterval = time.Hour
 )
 
 func main() {
-	configValues, err := config.LoadConfig()
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		if err := runMigrate(os.Args[2:], os.Stdout); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stderr, "migrate: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	configValues, err := config.LoadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("error init config application: %v", err)
+		exitWithError(logging.New(os.Stderr, slog.LevelInfo), "error init config application", err)
 	}
 
-	storeAdapter, err := store.NewStore(configValues.DatabaseURL)
+	logger := logging.New(os.Stdout, configValues.Log.SlogLevel())
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), configValues.Tracing.Exporter)
 	if err != nil {
-		log.Fatalf("error initializing database: %v", err)
+		exitWithError(logger, "error initializing tracing", err)
 	}
 
-	promotionsStore := store.NewPromotions(storeAdapter.GetDB())
-	promotionsUsecase := usecase.NewPromotions(promotionsStore)
-	promotionsTransport := transport.NewPromotions(promotionsUsecase)
+	dataStores, err := newStores(configValues, logger)
+	if err != nil {
+		exitWithError(logger, "error initializing the stores", err)
+	}
+
+	calendar := configValues.Business.Calendar()
+
+	branchesUsecase := usecase.NewBranches(dataStores.branches)
+	branchesTransport := transport.NewBranches(rbac.NewBranches(branchesUsecase))
+
+	promotionsUsecase := usecase.NewPromotions(dataStores.promotions, dataStores.branches, calendar)
+	promotionsTransport := transport.NewPromotions(rbac.NewPromotions(promotionsUsecase))
+
+	medicinesUsecase := usecase.NewMedicines(dataStores.medicines, dataStores.branches)
+	medicinesTransport := transport.NewMedicines(rbac.NewMedicines(medicinesUsecase))
+
+	billingUsecase := usecase.NewBillings(dataStores.billings, dataStores.promotions, dataStores.medicines, dataStores.branches, calendar)
+	billingTransport := transport.NewBillings(rbac.NewBillings(billingUsecase, dataStores.medicines))
+
+	suppliersUsecase := usecase.NewSuppliers(dataStores.suppliers)
+	suppliersTransport := transport.NewSuppliers(rbac.NewSuppliers(suppliersUsecase))
+
+	purchaseOrdersUsecase := usecase.NewPurchaseOrders(dataStores.purchaseOrders, dataStores.suppliers, dataStores.medicines, dataStores.branches)
+	purchaseOrdersTransport := transport.NewPurchaseOrders(rbac.NewPurchaseOrders(purchaseOrdersUsecase))
+
+	reordersUsecase := usecase.NewReorders(dataStores.reorders, configValues.Reorder.Params())
+	reordersTransport := transport.NewReorders(rbac.NewReorders(reordersUsecase))
+
+	salesReportsUsecase := usecase.NewSalesReports(dataStores.salesReports, calendar)
+	salesReportsTransport := transport.NewSalesReports(rbac.NewSalesReports(salesReportsUsecase))
+
+	transfersUsecase := usecase.NewTransfers(dataStores.transfers, dataStores.branches, dataStores.medicines)
+	transfersTransport := transport.NewTransfers(rbac.NewTransfers(transfersUsecase))
+
+	inventoryCountsUsecase := usecase.NewInventoryCounts(dataStores.inventoryCounts, dataStores.branches, dataStores.medicines)
+	inventoryCountsTransport := transport.NewInventoryCounts(rbac.NewInventoryCounts(inventoryCountsUsecase))
 
-	medicineStore := store.NewMedicine(storeAdapter.GetDB())
-	medicinesUsecase := usecase.NewMedicines(medicineStore)
-	medicinesTransport := transport.NewMedicines(medicinesUsecase)
+	authUsecase := usecase.NewAuth(dataStores.users, dataStores.branches, configValues.Auth.Settings())
+	authTransport := transport.NewAuth(rbac.NewAuth(authUsecase))
 
-	billingStore := store.NewBilling(storeAdapter.GetDB())
-	billingUsecase := usecase.NewBillings(billingStore, promotionsStore, medicineStore)
-	billingTransport := transport.NewBillings(billingUsecase)
+	auditUsecase := usecase.NewAudit(dataStores.audit, calendar)
+	auditTransport := transport.NewAudit(rbac.NewAudit(auditUsecase))
 
-	echoHandler := transport.NewRouter(promotionsTransport, medicinesTransport, billingTransport)
+	idempotencyUsecase := usecase.NewIdempotency(dataStores.idempotency, configValues.Idempotency.KeyTTL)
+	idempotencyTransport := transport.NewIdempotency(idempotencyUsecase)
+
+	healthUsecase := usecase.NewHealth(dataStores.health, configValues.Migrations.AllowDirtySchema)
+	healthTransport := transport.NewHealth(healthUsecase)
+
+	echoHandler := transport.NewRouter(
+		logger,
+		transport.RouterOptions{
+			Idempotency:    configValues.Features.Idempotency,
+			TrustedProxies: configValues.HTTP.TrustedProxyPrefixes(),
+		},
+		promotionsTransport,
+		medicinesTransport,
+		billingTransport,
+		suppliersTransport,
+		purchaseOrdersTransport,
+		reordersTransport,
+		salesReportsTransport,
+		branchesTransport,
+		transfersTransport,
+		inventoryCountsTransport,
+		authTransport,
+		auditTransport,
+		idempotencyTransport,
+		healthTransport,
+	)
 
 	echoHandler.Pre(middleware.RemoveTrailingSlash())
-	echoHandler.Use(middleware.CORS())
-	echoHandler.Use(middleware.Logger())
+	echoHandler.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: configValues.HTTP.CORSOrigins,
+	}))
 	echoHandler.Use(middleware.Recover())
 
+	if configValues.Auth.InitialUser.Username != "" {
+		ctx := logging.ContextWithLogger(context.Background(), logger)
+		if err := authUsecase.EnsureInitialUser(ctx, configValues.Auth.InitialUserRequest()); err != nil {
+			exitWithError(logger, "error creating initial user", err)
+		}
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(logging.ContextWithLogger(context.Background(), logger))
+	defer stopJobs()
+	go jobs.Every(jobsCtx, "revoked-tokens-purge", purgeInterval, authUsecase.PurgeRevokedTokens)
+	go jobs.Every(jobsCtx, "idempotency-keys-purge", purgeInterval, idempotencyUsecase.PurgeExpired)
+	if configValues.Features.ReorderJob {
+		go jobs.Every(jobsCtx, "reorder-draft", configValues.Reorder.JobInterval, func(ctx context.Context) error {
+			_, err := reordersUsecase.GenerateDraft(ctx)
+			return err
+		})
+	}
+
 	// Start server
+	echoHandler.HideBanner = true
+	echoHandler.Server.ReadTimeout = configValues.HTTP.ReadTimeout
+	echoHandler.Server.WriteTimeout = configValues.HTTP.WriteTimeout
+	serverErr := make(chan error, 2)
 	go func() {
-		if err := echoHandler.Start(fmt.Sprintf(":%s", configValues.HTTPPort)); err != nil {
-			echoHandler.Logger.Info("shutting down the server")
-		}
+		serverErr <- echoHandler.Start(fmt.Sprintf(":%s", configValues.HTTP.Port))
 	}()
 
-	if err := storeAdapter.Migrate(targetDBSchemaVersion); err != nil {
-		log.Fatalf("error in migration:  %s", err)
+	// the metrics are served apart from the API, on a port only reachable by the scraper
+	var metricsServer *http.Server
+	if configValues.Features.Metrics {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler(metrics.Default))
+		metricsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%s", configValues.HTTP.MetricsPort),
+			Handler:      metricsMux,
+			ReadTimeout:  configValues.HTTP.ReadTimeout,
+			WriteTimeout: configValues.HTTP.WriteTimeout,
+		}
+		go func() {
+			serverErr <- metricsServer.ListenAndServe()
+		}()
 	}
 
 	// Handle graceful shutdown
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeoutSeconds*time.Second)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	select {
+	case sig := <-quit:
+		logger.Info("shutting down the server", "signal", sig.String())
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			exitWithError(logger, "error starting the server", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), configValues.HTTP.ShutdownTimeout)
 	defer cancel()
 
+	// Shutdown stops accepting connections and waits for the in-flight requests.
 	if err := echoHandler.Shutdown(ctx); err != nil {
-		echoHandler.Logger.Fatal(err)
+		logger.Error("error draining the server", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			logger.Error("error draining the metrics server", "error", err)
+		}
 	}
+	stopJobs()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("error flushing traces", "error", err)
+	}
+	if err := dataStores.close(); err != nil {
+		logger.Error("error closing the database", "error", err)
+	}
+}
+
+func exitWithError(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+func TestHandlerExposition(t *testing.T) {
+	registry := NewRegistry()
+	factory := promauto.With(registry)
+	requests := factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_requests_total", Help: "Requests with a \\ and a\nnew line.",
+	}, []string{"path"})
+	latency := factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "test_latency_seconds", Help: "Latency.", Buckets: []float64{0.1, 1},
+	}, []string{"route"})
+
+	requests.WithLabelValues("a\"b\\c\nd").Inc()
+	latency.WithLabelValues("/billing").Observe(0.05)
+	latency.WithLabelValues("/billing").Observe(0.5)
+	latency.WithLabelValues("/billing").Observe(3)
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Accept", "text/plain")
+	recorder := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %s, want the text format", contentType)
+	}
+	body, _ := io.ReadAll(recorder.Body)
+
+	for _, want := range []string{
+		`# HELP test_requests_total Requests with a \\ and a\nnew line.`,
+		`# TYPE test_requests_total counter`,
+		`test_requests_total{path="a\"b\\c\nd"} 1`,
+		`# TYPE test_latency_seconds histogram`,
+		`test_latency_seconds_bucket{route="/billing",le="0.1"} 1`,
+		`test_latency_seconds_bucket{route="/billing",le="1"} 2`,
+		`test_latency_seconds_bucket{route="/billing",le="+Inf"} 3`,
+		`test_latency_seconds_sum{route="/billing"} 3.55`,
+		`test_latency_seconds_count{route="/billing"} 3`,
+		`# TYPE go_goroutines gauge`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("the exposition lacks the line %s", want)
+		}
+	}
+	if t.Failed() {
+		t.Logf("exposition:\n%s", body)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/config"
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/store"
+)
+
+const migrateCommand = "migrate"
+
+const migrateUsage = `usage: aveonline-backend migrate [configuration flags] <command> [-dry-run]
+
+commands:
+  status     shows the schema version and the pending migrations
+  up         applies every pending migration
+  down [N]   reverts the last N migrations, 1 by default
+  to N       migrates up or down to version N, 0 reverts everything
+  force N    marks the schema clean in version N without running any migration
+`
+
+var errMigrateUsage = errors.New("invalid migrate command, run with -h for the usage")
+
+// runMigrate runs the migrate subcommand in args, writing its result to out.
+func runMigrate(args []string, out io.Writer) error {
+	configValues, rest, err := config.LoadMigrateConfig(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		fmt.Fprint(out, migrateUsage)
+		return errMigrateUsage
+	}
+
+	command := rest[0]
+	flags := flag.NewFlagSet(migrateCommand+" "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	dryRun := flags.Bool("dry-run", false, "print the migrations that would run without applying them")
+	if err := flags.Parse(rest[1:]); err != nil {
+		return err
+	}
+	params := flags.Args()
+
+	logger := logging.New(os.Stderr, configValues.Log.SlogLevel())
+	storeAdapter, err := store.NewStore(configValues.DatabaseURL(), store.PoolSettings{MaxOpenConns: 1}, logger)
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	defer storeAdapter.Close()
+
+	ctx := logging.ContextWithLogger(context.Background(), logger)
+
+	var target uint
+	switch command {
+	case "status":
+		return printSchemaStatus(ctx, out, &storeAdapter)
+	case "force":
+		version, err := parseVersion(params)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			fmt.Fprintf(out, "would mark the schema clean in version %d\n", version)
+			return nil
+		}
+		if err := storeAdapter.ForceSchemaVersion(version); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema marked clean in version %d\n", version)
+		return nil
+	case "up":
+		target = storeAdapter.LatestMigration()
+	case "down":
+		target, err = downTarget(ctx, &storeAdapter, params)
+		if err != nil {
+			return err
+		}
+	case "to":
+		target, err = parseVersion(params)
+		if err != nil {
+			return err
+		}
+	default:
+		fmt.Fprint(out, migrateUsage)
+		return errMigrateUsage
+	}
+
+	if *dryRun {
+		steps, err := storeAdapter.PlanMigration(ctx, target)
+		if err != nil {
+			return err
+		}
+		printSteps(out, "would apply", steps)
+		return nil
+	}
+
+	steps, err := storeAdapter.MigrateTo(ctx, target)
+	if err != nil {
+		return err
+	}
+	printSteps(out, "applied", steps)
+
+	return nil
+}
+
+func parseVersion(params []string) (uint, error) {
+	if len(params) != 1 {
+		return 0, errMigrateUsage
+	}
+	version, err := strconv.ParseUint(params[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version [%s]", params[0])
+	}
+
+	return uint(version), nil
+}
+
+// downTarget returns the version left after reverting the number of migrations in
+// params, one when empty.
+func downTarget(ctx context.Context, storeAdapter *store.Store, params []string) (uint, error) {
+	count := 1
+	if len(params) > 1 {
+		return 0, errMigrateUsage
+	}
+	if len(params) == 1 {
+		parsed, err := strconv.Atoi(params[0])
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("invalid number of migrations to revert [%s]", params[0])
+		}
+		count = parsed
+	}
+
+	status, err := storeAdapter.SchemaStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	applied := make([]uint, 0)
+	for _, migration := range storeAdapter.Migrations() {
+		if migration.Version <= status.Version {
+			applied = append(applied, migration.Version)
+		}
+	}
+	if count > len(applied) {
+		return 0, fmt.Errorf("cannot revert %d migrations, only %d are applied", count, len(applied))
+	}
+	if count == len(applied) {
+		return 0, nil
+	}
+
+	return applied[len(applied)-count-1], nil
+}
+
+func printSchemaStatus(ctx context.Context, out io.Writer, storeAdapter *store.Store) error {
+	status, err := storeAdapter.SchemaStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "schema version: %d\n", status.Version)
+	fmt.Fprintf(out, "latest migration: %d\n", status.Latest)
+	switch {
+	case status.Dirty:
+		fmt.Fprintf(out, "state: dirty, migration %d failed halfway; fix the schema and run `migrate force`\n", status.Version)
+	case status.Version > status.Latest:
+		fmt.Fprintln(out, "state: the schema is newer than this binary")
+	default:
+		fmt.Fprintln(out, "state: clean")
+	}
+	if len(status.Pending) == 0 {
+		fmt.Fprintln(out, "pending: none")
+		return nil
+	}
+	fmt.Fprintln(out, "pending:")
+	for _, migration := range status.Pending {
+		fmt.Fprintf(out, "  %d_%s\n", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+func printSteps(out io.Writer, verb string, steps []models.MigrationStep) {
+	if len(steps) == 0 {
+		fmt.Fprintln(out, "no migrations to apply, the schema is already in that version")
+		return
+	}
+	for _, step := range steps {
+		fmt.Fprintf(out, "%s %s %d_%s\n", verb, step.Direction, step.Version, step.Name)
+	}
+}
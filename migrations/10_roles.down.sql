@@ -0,0 +1,5 @@
+ALTER TABLE "medicine"
+    DROP COLUMN "requires_prescription";
+
+ALTER TABLE "app_user"
+    DROP COLUMN "role";
@@ -0,0 +1,9 @@
+-- users created before roles existed had access to every operation
+ALTER TABLE "app_user"
+    ADD COLUMN "role" varchar NOT NULL DEFAULT 'admin';
+
+ALTER TABLE "app_user"
+    ALTER COLUMN "role" SET DEFAULT 'cashier';
+
+ALTER TABLE "medicine"
+    ADD COLUMN "requires_prescription" boolean NOT NULL DEFAULT false;
@@ -0,0 +1,29 @@
+-- before_data and after_data are json (not jsonb) to keep the exact text that was hashed.
+CREATE TABLE "audit_log" (
+    "id"               bigserial PRIMARY KEY,
+    "entity"           varchar NOT NULL,
+    "entity_id"        integer NOT NULL,
+    "action"           varchar NOT NULL,
+    "actor_id"         integer,
+    "actor_username"   varchar,
+    "request_id"       varchar,
+    "client_ip"        varchar,
+    "before_data"      json,
+    "after_data"       json,
+    "prev_hash"        varchar NOT NULL,
+    "hash"             varchar NOT NULL,
+    "created_at"       timestamp NOT NULL
+);
+
+CREATE INDEX "audit_log_entity_idx" ON "audit_log" ("entity", "entity_id");
+CREATE INDEX "audit_log_created_at_idx" ON "audit_log" ("created_at");
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_append_only"
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON "audit_log"
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,14 @@
+CREATE TABLE "idempotency_key" (
+    "user_id"           integer NOT NULL,
+    "idempotency_key"   varchar NOT NULL,
+    "request_hash"      varchar NOT NULL,
+    "status"            varchar NOT NULL,
+    "response_status"   integer,
+    "response_body"     bytea,
+    "content_type"      varchar,
+    "created_at"        timestamp NOT NULL,
+    "expires_at"        timestamp NOT NULL,
+    PRIMARY KEY ("user_id", "idempotency_key")
+);
+
+CREATE INDEX "idempotency_key_expires_at_idx" ON "idempotency_key" ("expires_at");
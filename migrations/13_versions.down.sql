@@ -0,0 +1,8 @@
+ALTER TABLE "billing"
+    DROP COLUMN "version";
+
+ALTER TABLE "promotion"
+    DROP COLUMN "version";
+
+ALTER TABLE "medicine"
+    DROP COLUMN "version";
@@ -0,0 +1,9 @@
+-- version is incremented on every change of the row and backs the ETags of the API.
+ALTER TABLE "medicine"
+    ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+ALTER TABLE "promotion"
+    ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+ALTER TABLE "billing"
+    ADD COLUMN "version" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,125 @@
+DROP TRIGGER "promotion_period_sync" ON "promotion_branch";
+DROP TRIGGER "promotion_period_sync" ON "promotion";
+DROP FUNCTION promotion_period_sync();
+DROP FUNCTION refresh_promotion_period(integer);
+DROP TABLE "promotion_period";
+
+ALTER TABLE "promotion"
+    DROP CONSTRAINT "promotion_dates_check",
+    DROP CONSTRAINT "promotion_percentage_check";
+
+ALTER TABLE "billing"
+    DROP CONSTRAINT "billing_total_check";
+
+ALTER TABLE "billing_detail"
+    DROP CONSTRAINT "billing_detail_medicine_price_check";
+
+ALTER TABLE "medicine_presentation"
+    DROP CONSTRAINT "medicine_presentation_price_check";
+
+ALTER TABLE "medicine"
+    DROP CONSTRAINT "medicine_price_check";
+
+DROP INDEX "promotion_dates_idx";
+DROP INDEX "billing_detail_billing_id_idx";
+DROP INDEX "billing_created_at_idx";
+
+ALTER TABLE "promotion"
+    ALTER COLUMN "start_date" TYPE timestamp USING "start_date" AT TIME ZONE 'UTC',
+    ALTER COLUMN "end_date" TYPE timestamp USING "end_date" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "billing"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "billing_detail"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine_presentation"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_movement"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "supplier"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "purchase_order"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "purchase_order_line"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "goods_receipt"
+    ALTER COLUMN "received_at" TYPE timestamp USING "received_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "goods_receipt_line"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "reorder_draft"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "branch"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine_stock"
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_transfer"
+    ALTER COLUMN "shipped_at" TYPE timestamp USING "shipped_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "received_at" TYPE timestamp USING "received_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_transfer_line"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "inventory_count"
+    ALTER COLUMN "approved_at" TYPE timestamp USING "approved_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "inventory_count_line"
+    ALTER COLUMN "counted_at" TYPE timestamp USING "counted_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "app_user"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamp USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamp USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "revoked_token"
+    ALTER COLUMN "expires_at" TYPE timestamp USING "expires_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "revoked_at" TYPE timestamp USING "revoked_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "audit_log"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "idempotency_key"
+    ALTER COLUMN "created_at" TYPE timestamp USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "expires_at" TYPE timestamp USING "expires_at" AT TIME ZONE 'UTC';
@@ -0,0 +1,179 @@
+-- The application always wrote UTC into the timestamp columns, so the existing values
+-- are read as UTC.
+ALTER TABLE "promotion"
+    ALTER COLUMN "start_date" TYPE timestamptz USING "start_date" AT TIME ZONE 'UTC',
+    ALTER COLUMN "end_date" TYPE timestamptz USING "end_date" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "billing"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "billing_detail"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine_presentation"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_movement"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "supplier"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "purchase_order"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "purchase_order_line"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "goods_receipt"
+    ALTER COLUMN "received_at" TYPE timestamptz USING "received_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "goods_receipt_line"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "reorder_draft"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "branch"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "medicine_stock"
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_transfer"
+    ALTER COLUMN "shipped_at" TYPE timestamptz USING "shipped_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "received_at" TYPE timestamptz USING "received_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "stock_transfer_line"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "inventory_count"
+    ALTER COLUMN "approved_at" TYPE timestamptz USING "approved_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "inventory_count_line"
+    ALTER COLUMN "counted_at" TYPE timestamptz USING "counted_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "app_user"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "deleted_at" TYPE timestamptz USING "deleted_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "revoked_token"
+    ALTER COLUMN "expires_at" TYPE timestamptz USING "expires_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "revoked_at" TYPE timestamptz USING "revoked_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "audit_log"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC';
+
+ALTER TABLE "idempotency_key"
+    ALTER COLUMN "created_at" TYPE timestamptz USING "created_at" AT TIME ZONE 'UTC',
+    ALTER COLUMN "expires_at" TYPE timestamptz USING "expires_at" AT TIME ZONE 'UTC';
+
+CREATE INDEX "billing_created_at_idx" ON "billing" ("created_at");
+CREATE INDEX "billing_detail_billing_id_idx" ON "billing_detail" ("billing_id");
+CREATE INDEX "promotion_dates_idx" ON "promotion" ("start_date", "end_date");
+
+ALTER TABLE "medicine"
+    ADD CONSTRAINT "medicine_price_check" CHECK ("price" >= 0);
+
+ALTER TABLE "medicine_presentation"
+    ADD CONSTRAINT "medicine_presentation_price_check" CHECK ("price" >= 0);
+
+ALTER TABLE "billing_detail"
+    ADD CONSTRAINT "billing_detail_medicine_price_check" CHECK ("medicine_price" >= 0);
+
+ALTER TABLE "billing"
+    ADD CONSTRAINT "billing_total_check" CHECK ("total" >= 0);
+
+ALTER TABLE "promotion"
+    ADD CONSTRAINT "promotion_percentage_check" CHECK ("percentage" BETWEEN 0 AND 100),
+    ADD CONSTRAINT "promotion_dates_check" CHECK ("start_date" <= "end_date");
+
+-- promotion_period has a row per branch a promotion applies to, or a single row covering
+-- every branch for the promotions without branches, so two promotions sharing a branch
+-- cannot overlap in time. It is kept by triggers and checked at commit, when the
+-- promotion and its branches have both been written.
+CREATE TABLE "promotion_period" (
+    "promotion_id"   integer NOT NULL REFERENCES "promotion" ("id") ON DELETE CASCADE,
+    "branch_scope"   int4range NOT NULL,
+    "period"         tstzrange NOT NULL,
+    CONSTRAINT "promotion_period_overlap_excl"
+        EXCLUDE USING gist ("branch_scope" WITH &&, "period" WITH &&)
+        DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX "promotion_period_promotion_id_idx" ON "promotion_period" ("promotion_id");
+
+CREATE FUNCTION refresh_promotion_period(promo_id integer) RETURNS void AS $$
+BEGIN
+    DELETE FROM "promotion_period" WHERE "promotion_id" = promo_id;
+
+    INSERT INTO "promotion_period" ("promotion_id", "branch_scope", "period")
+    SELECT p."id", scope."branch_scope", tstzrange(p."start_date", p."end_date", '[]')
+    FROM "promotion" p
+    CROSS JOIN LATERAL (
+        SELECT int4range(pb."branch_id", pb."branch_id", '[]') AS "branch_scope"
+        FROM "promotion_branch" pb
+        WHERE pb."promotion_id" = p."id"
+        UNION ALL
+        SELECT int4range(NULL, NULL)
+        WHERE NOT EXISTS (SELECT 1 FROM "promotion_branch" pb WHERE pb."promotion_id" = p."id")
+    ) scope
+    WHERE p."id" = promo_id AND p."deleted_at" IS NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION promotion_period_sync() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'promotion' THEN
+        PERFORM refresh_promotion_period(NEW."id");
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_promotion_period(OLD."promotion_id");
+    ELSE
+        PERFORM refresh_promotion_period(NEW."promotion_id");
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "promotion_period_sync"
+    AFTER INSERT OR UPDATE OF "start_date", "end_date", "deleted_at" ON "promotion"
+    FOR EACH ROW EXECUTE FUNCTION promotion_period_sync();
+
+CREATE TRIGGER "promotion_period_sync"
+    AFTER INSERT OR DELETE ON "promotion_branch"
+    FOR EACH ROW EXECUTE FUNCTION promotion_period_sync();
+
+-- fails when the existing promotions overlap, they have to be fixed before migrating.
+SELECT refresh_promotion_period("id") FROM "promotion";
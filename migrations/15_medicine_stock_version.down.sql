@@ -0,0 +1,2 @@
+ALTER TABLE "medicine"
+    DROP COLUMN "stock_version";
@@ -0,0 +1,4 @@
+-- stock_version is incremented on every stock movement so the sales do not change the
+-- version the catalog updates are conditioned on.
+ALTER TABLE "medicine"
+    ADD COLUMN "stock_version" integer NOT NULL DEFAULT 1;
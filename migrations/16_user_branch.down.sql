@@ -0,0 +1,2 @@
+ALTER TABLE "app_user"
+    DROP COLUMN "branch_id";
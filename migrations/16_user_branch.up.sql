@@ -0,0 +1,4 @@
+-- branch_id binds the user to a branch, the requests of the user only operate over it.
+-- Users without branch operate over all of them.
+ALTER TABLE "app_user"
+    ADD COLUMN "branch_id" integer REFERENCES "branch" ("id");
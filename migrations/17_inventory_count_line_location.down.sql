@@ -0,0 +1,15 @@
+-- Only the first line of each medicine is kept, the units counted in other locations are lost.
+DELETE FROM "inventory_count_line" l
+USING "inventory_count_line" first
+WHERE first."inventory_count_id" = l."inventory_count_id"
+    AND first."medicine_id" = l."medicine_id"
+    AND first."id" < l."id";
+
+ALTER TABLE "inventory_count_line"
+    DROP CONSTRAINT "inventory_count_line_inventory_count_id_medicine_id_location_key",
+    ADD CONSTRAINT "inventory_count_line_inventory_count_id_medicine_id_key"
+        UNIQUE ("inventory_count_id", "medicine_id"),
+    ALTER COLUMN "location" DROP NOT NULL,
+    ALTER COLUMN "location" DROP DEFAULT;
+
+UPDATE "inventory_count_line" SET "location" = NULL WHERE "location" = '';
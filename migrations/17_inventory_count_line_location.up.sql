@@ -0,0 +1,11 @@
+-- A medicine can be stored in several locations of the branch, so its units are counted
+-- on a line per location and the adjustment is posted for their sum. The lines without
+-- location are kept under the empty one, the unique key does not match NULLs.
+UPDATE "inventory_count_line" SET "location" = '' WHERE "location" IS NULL;
+
+ALTER TABLE "inventory_count_line"
+    ALTER COLUMN "location" SET DEFAULT '',
+    ALTER COLUMN "location" SET NOT NULL,
+    DROP CONSTRAINT "inventory_count_line_inventory_count_id_medicine_id_key",
+    ADD CONSTRAINT "inventory_count_line_inventory_count_id_medicine_id_location_key"
+        UNIQUE ("inventory_count_id", "medicine_id", "location");
@@ -0,0 +1,4 @@
+DROP TABLE "billing_detail";
+DROP TABLE "billing";
+DROP TABLE "promotion";
+DROP TABLE "medicine";
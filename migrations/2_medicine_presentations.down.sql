@@ -0,0 +1,12 @@
+ALTER TABLE "billing_detail"
+    DROP COLUMN "presentation_id",
+    DROP COLUMN "presentation_name",
+    DROP COLUMN "quantity",
+    DROP COLUMN "base_quantity";
+
+DROP TABLE "stock_movement";
+DROP TABLE "medicine_presentation";
+
+ALTER TABLE "medicine"
+    DROP COLUMN "base_unit",
+    DROP COLUMN "stock";
@@ -0,0 +1,38 @@
+ALTER TABLE "medicine"
+    ADD COLUMN "base_unit" varchar NOT NULL DEFAULT 'tablet',
+    ADD COLUMN "stock"     integer NOT NULL DEFAULT 0;
+
+CREATE TABLE "medicine_presentation" (
+    "id"                serial PRIMARY KEY,
+    "medicine_id"       integer NOT NULL,
+    "name"              varchar NOT NULL,
+    "conversion_factor" integer NOT NULL,
+    "price"             decimal NOT NULL,
+    "created_at"        timestamp default now(),
+    "updated_at"        timestamp default now(),
+    "deleted_at"        timestamp
+);
+
+CREATE TABLE "stock_movement" (
+    "id"             serial PRIMARY KEY,
+    "medicine_id"    integer NOT NULL,
+    "quantity"       integer NOT NULL,
+    "movement_type"  varchar NOT NULL,
+    "reference_id"   integer,
+    "created_at"     timestamp default now()
+);
+
+ALTER TABLE "billing_detail"
+    ADD COLUMN "presentation_id"   integer,
+    ADD COLUMN "presentation_name" varchar,
+    ADD COLUMN "quantity"          integer NOT NULL DEFAULT 1,
+    ADD COLUMN "base_quantity"     integer NOT NULL DEFAULT 1;
+
+ALTER TABLE "medicine_presentation"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
+
+ALTER TABLE "stock_movement"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
+
+ALTER TABLE "billing_detail"
+    ADD FOREIGN KEY ("presentation_id") REFERENCES "medicine_presentation" ("id");
@@ -0,0 +1,13 @@
+DROP INDEX "medicine_barcode_idx";
+DROP INDEX "medicine_active_ingredient_trgm_idx";
+DROP INDEX "medicine_name_trgm_idx";
+DROP INDEX "medicine_search_vector_idx";
+
+ALTER TABLE "medicine"
+    DROP COLUMN "search_vector",
+    DROP COLUMN "barcode",
+    DROP COLUMN "active_ingredient";
+
+DROP FUNCTION f_unaccent(text);
+
+-- unaccent and pg_trgm are kept, they may have been installed before the migration.
@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent is only STABLE, an IMMUTABLE wrapper is required to use it in indexes
+-- and generated columns.
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+$func$
+SELECT public.unaccent('public.unaccent', $1)
+$func$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+ALTER TABLE "medicine"
+    ADD COLUMN "active_ingredient" varchar,
+    ADD COLUMN "barcode"           varchar;
+
+ALTER TABLE "medicine"
+    ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('spanish', f_unaccent(coalesce("name", ''))), 'A') ||
+        setweight(to_tsvector('spanish', f_unaccent(coalesce("active_ingredient", ''))), 'B')
+    ) STORED;
+
+CREATE INDEX "medicine_search_vector_idx" ON "medicine" USING GIN ("search_vector");
+CREATE INDEX "medicine_name_trgm_idx" ON "medicine" USING GIN (f_unaccent(lower("name")) gin_trgm_ops);
+CREATE INDEX "medicine_active_ingredient_trgm_idx" ON "medicine" USING GIN (f_unaccent(lower("active_ingredient")) gin_trgm_ops);
+CREATE INDEX "medicine_barcode_idx" ON "medicine" ("barcode");
@@ -0,0 +1,8 @@
+DROP TABLE "goods_receipt_line";
+DROP TABLE "goods_receipt";
+DROP TABLE "purchase_order_line";
+DROP TABLE "purchase_order";
+DROP TABLE "supplier";
+
+ALTER TABLE "medicine"
+    DROP COLUMN "average_cost";
@@ -0,0 +1,72 @@
+ALTER TABLE "medicine"
+    ADD COLUMN "average_cost" decimal NOT NULL DEFAULT 0;
+
+CREATE TABLE "supplier" (
+    "id"             serial PRIMARY KEY,
+    "name"           varchar NOT NULL,
+    "tax_id"         varchar,
+    "email"          varchar,
+    "phone"          varchar,
+    "created_at"     timestamp default now(),
+    "updated_at"     timestamp default now(),
+    "deleted_at"     timestamp
+);
+
+CREATE TABLE "purchase_order" (
+    "id"             serial PRIMARY KEY,
+    "supplier_id"    integer NOT NULL,
+    "status"         varchar NOT NULL DEFAULT 'draft',
+    "notes"          varchar,
+    "created_at"     timestamp default now(),
+    "updated_at"     timestamp default now(),
+    "deleted_at"     timestamp
+);
+
+CREATE TABLE "purchase_order_line" (
+    "id"                 serial PRIMARY KEY,
+    "purchase_order_id"  integer NOT NULL,
+    "medicine_id"        integer NOT NULL,
+    "quantity"           integer NOT NULL,
+    "received_quantity"  integer NOT NULL DEFAULT 0,
+    "unit_cost"          decimal NOT NULL,
+    "created_at"         timestamp default now(),
+    "updated_at"         timestamp default now()
+);
+
+CREATE TABLE "goods_receipt" (
+    "id"                 serial PRIMARY KEY,
+    "purchase_order_id"  integer NOT NULL,
+    "received_at"        timestamp NOT NULL,
+    "created_at"         timestamp default now()
+);
+
+CREATE TABLE "goods_receipt_line" (
+    "id"                      serial PRIMARY KEY,
+    "goods_receipt_id"        integer NOT NULL,
+    "purchase_order_line_id"  integer NOT NULL,
+    "medicine_id"             integer NOT NULL,
+    "quantity"                integer NOT NULL,
+    "unit_cost"               decimal NOT NULL,
+    "created_at"              timestamp default now()
+);
+
+ALTER TABLE "purchase_order"
+    ADD FOREIGN KEY ("supplier_id") REFERENCES "supplier" ("id");
+
+ALTER TABLE "purchase_order_line"
+    ADD FOREIGN KEY ("purchase_order_id") REFERENCES "purchase_order" ("id");
+
+ALTER TABLE "purchase_order_line"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
+
+ALTER TABLE "goods_receipt"
+    ADD FOREIGN KEY ("purchase_order_id") REFERENCES "purchase_order" ("id");
+
+ALTER TABLE "goods_receipt_line"
+    ADD FOREIGN KEY ("goods_receipt_id") REFERENCES "goods_receipt" ("id");
+
+ALTER TABLE "goods_receipt_line"
+    ADD FOREIGN KEY ("purchase_order_line_id") REFERENCES "purchase_order_line" ("id");
+
+ALTER TABLE "goods_receipt_line"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
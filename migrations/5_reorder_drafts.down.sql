@@ -0,0 +1,2 @@
+DROP TABLE "reorder_draft_line";
+DROP TABLE "reorder_draft";
@@ -0,0 +1,24 @@
+CREATE TABLE "reorder_draft" (
+    "id"                 serial PRIMARY KEY,
+    "window_days"        integer NOT NULL,
+    "lead_time_days"     integer NOT NULL,
+    "safety_stock_days"  integer NOT NULL,
+    "created_at"         timestamp default now()
+);
+
+CREATE TABLE "reorder_draft_line" (
+    "id"                  serial PRIMARY KEY,
+    "reorder_draft_id"    integer NOT NULL,
+    "medicine_id"         integer NOT NULL,
+    "average_daily_sales" decimal NOT NULL,
+    "stock"               integer NOT NULL,
+    "pending_quantity"    integer NOT NULL,
+    "days_of_cover"       decimal,
+    "suggested_quantity"  integer NOT NULL
+);
+
+ALTER TABLE "reorder_draft_line"
+    ADD FOREIGN KEY ("reorder_draft_id") REFERENCES "reorder_draft" ("id");
+
+ALTER TABLE "reorder_draft_line"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
@@ -0,0 +1,13 @@
+-- the stock of every branch is merged back into the single store.
+UPDATE "medicine" m SET "stock" = COALESCE(
+    (SELECT sum(ms."stock") FROM "medicine_stock" ms WHERE ms."medicine_id" = m."id"), 0
+);
+
+ALTER TABLE "reorder_draft" DROP COLUMN "branch_id";
+ALTER TABLE "purchase_order" DROP COLUMN "branch_id";
+ALTER TABLE "stock_movement" DROP COLUMN "branch_id";
+ALTER TABLE "billing" DROP COLUMN "branch_id";
+
+DROP TABLE "promotion_branch";
+DROP TABLE "medicine_stock";
+DROP TABLE "branch";
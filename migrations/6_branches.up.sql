@@ -0,0 +1,65 @@
+CREATE TABLE "branch" (
+    "id"             serial PRIMARY KEY,
+    "name"           varchar NOT NULL,
+    "address"        varchar,
+    "created_at"     timestamp default now(),
+    "updated_at"     timestamp default now(),
+    "deleted_at"     timestamp
+);
+
+-- existing data belongs to the single store operated until now
+INSERT INTO "branch" ("name") VALUES ('Principal');
+
+CREATE TABLE "medicine_stock" (
+    "branch_id"      integer NOT NULL,
+    "medicine_id"    integer NOT NULL,
+    "stock"          integer NOT NULL DEFAULT 0,
+    "updated_at"     timestamp default now(),
+    PRIMARY KEY ("branch_id", "medicine_id")
+);
+
+INSERT INTO "medicine_stock" ("branch_id", "medicine_id", "stock")
+SELECT (SELECT min("id") FROM "branch"), "id", "stock" FROM "medicine";
+
+CREATE TABLE "promotion_branch" (
+    "promotion_id"   integer NOT NULL,
+    "branch_id"      integer NOT NULL,
+    PRIMARY KEY ("promotion_id", "branch_id")
+);
+
+ALTER TABLE "billing" ADD COLUMN "branch_id" integer;
+ALTER TABLE "stock_movement" ADD COLUMN "branch_id" integer;
+ALTER TABLE "purchase_order" ADD COLUMN "branch_id" integer;
+ALTER TABLE "reorder_draft" ADD COLUMN "branch_id" integer;
+
+UPDATE "billing" SET "branch_id" = (SELECT min("id") FROM "branch");
+UPDATE "stock_movement" SET "branch_id" = (SELECT min("id") FROM "branch");
+UPDATE "purchase_order" SET "branch_id" = (SELECT min("id") FROM "branch");
+
+ALTER TABLE "billing" ALTER COLUMN "branch_id" SET NOT NULL;
+ALTER TABLE "stock_movement" ALTER COLUMN "branch_id" SET NOT NULL;
+ALTER TABLE "purchase_order" ALTER COLUMN "branch_id" SET NOT NULL;
+
+ALTER TABLE "medicine_stock"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "medicine_stock"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
+
+ALTER TABLE "promotion_branch"
+    ADD FOREIGN KEY ("promotion_id") REFERENCES "promotion" ("id");
+
+ALTER TABLE "promotion_branch"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "billing"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "stock_movement"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "purchase_order"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "reorder_draft"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
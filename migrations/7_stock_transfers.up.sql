@@ -0,0 +1,35 @@
+CREATE TABLE "stock_transfer" (
+    "id"                     serial PRIMARY KEY,
+    "source_branch_id"       integer NOT NULL,
+    "destination_branch_id"  integer NOT NULL,
+    "status"                 varchar NOT NULL DEFAULT 'draft',
+    "notes"                  varchar,
+    "shipped_at"             timestamp,
+    "received_at"            timestamp,
+    "created_at"             timestamp default now(),
+    "updated_at"             timestamp default now(),
+    "deleted_at"             timestamp
+);
+
+CREATE TABLE "stock_transfer_line" (
+    "id"                  serial PRIMARY KEY,
+    "stock_transfer_id"   integer NOT NULL,
+    "medicine_id"         integer NOT NULL,
+    "quantity"            integer NOT NULL,
+    "received_quantity"   integer,
+    "discrepancy_notes"   varchar,
+    "created_at"          timestamp default now(),
+    "updated_at"          timestamp default now()
+);
+
+ALTER TABLE "stock_transfer"
+    ADD FOREIGN KEY ("source_branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "stock_transfer"
+    ADD FOREIGN KEY ("destination_branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "stock_transfer_line"
+    ADD FOREIGN KEY ("stock_transfer_id") REFERENCES "stock_transfer" ("id");
+
+ALTER TABLE "stock_transfer_line"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
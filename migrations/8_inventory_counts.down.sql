@@ -0,0 +1,2 @@
+DROP TABLE "inventory_count_line";
+DROP TABLE "inventory_count";
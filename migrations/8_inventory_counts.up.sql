@@ -0,0 +1,36 @@
+CREATE TABLE "inventory_count" (
+    "id"            serial PRIMARY KEY,
+    "branch_id"     integer NOT NULL,
+    "count_type"    varchar NOT NULL,
+    "status"        varchar NOT NULL DEFAULT 'open',
+    "notes"         varchar,
+    "approved_at"   timestamp,
+    "created_at"    timestamp default now(),
+    "updated_at"    timestamp default now(),
+    "deleted_at"    timestamp
+);
+
+-- system_quantity and unit_cost are snapshots taken when the line is counted, so the
+-- sales registered while the count is open do not show up as variance.
+CREATE TABLE "inventory_count_line" (
+    "id"                   serial PRIMARY KEY,
+    "inventory_count_id"   integer NOT NULL,
+    "medicine_id"          integer NOT NULL,
+    "location"             varchar,
+    "counted_quantity"     integer,
+    "system_quantity"      integer,
+    "unit_cost"            decimal,
+    "counted_at"           timestamp,
+    "created_at"           timestamp default now(),
+    "updated_at"           timestamp default now(),
+    UNIQUE ("inventory_count_id", "medicine_id")
+);
+
+ALTER TABLE "inventory_count"
+    ADD FOREIGN KEY ("branch_id") REFERENCES "branch" ("id");
+
+ALTER TABLE "inventory_count_line"
+    ADD FOREIGN KEY ("inventory_count_id") REFERENCES "inventory_count" ("id");
+
+ALTER TABLE "inventory_count_line"
+    ADD FOREIGN KEY ("medicine_id") REFERENCES "medicine" ("id");
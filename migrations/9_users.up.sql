@@ -0,0 +1,17 @@
+CREATE TABLE "app_user" (
+    "id"              serial PRIMARY KEY,
+    "username"        varchar NOT NULL UNIQUE,
+    "password_hash"   varchar NOT NULL,
+    "created_at"      timestamp default now(),
+    "updated_at"      timestamp default now(),
+    "deleted_at"      timestamp
+);
+
+-- revoked_token keeps the id (jti) of the tokens revoked before they expire.
+CREATE TABLE "revoked_token" (
+    "jti"          varchar PRIMARY KEY,
+    "expires_at"   timestamp NOT NULL,
+    "revoked_at"   timestamp default now()
+);
+
+CREATE INDEX "revoked_token_expires_at_idx" ON "revoked_token" ("expires_at");
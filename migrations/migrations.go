@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations of the database schema so the binary does
+// not depend on the directory it runs from.
+package migrations
+
+import "embed"
+
+// FS holds the N_name.up.sql and N_name.down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audited entities.
+const (
+	AuditEntityMedicine     = "medicine"
+	AuditEntityPresentation = "presentation"
+	AuditEntityPromotion    = "promotion"
+	AuditEntityBilling      = "billing"
+)
+
+// Audited actions.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+type requestInfoContextKey struct{}
+
+// RequestInfo identifies the HTTP request that originated an operation.
+type RequestInfo struct {
+	RequestID string
+	ClientIP  string
+}
+
+// AuditEntry records a change of an entity. Entries are chained: Hash covers the entry
+// and PrevHash, the hash of the previous entry, so altering or removing any entry breaks
+// the chain from that point on.
+type AuditEntry struct {
+	ID            int64           `json:"id"`
+	Entity        string          `json:"entity"`
+	EntityID      int64           `json:"entityID"`
+	Action        string          `json:"action"`
+	ActorID       int64           `json:"actorID"`
+	ActorUsername string          `json:"actorUsername"`
+	RequestID     string          `json:"requestID"`
+	ClientIP      string          `json:"clientIP"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	PrevHash      string          `json:"prevHash"`
+	Hash          string          `json:"hash"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// ComputeHash returns the hex encoded SHA-256 of the entry contents and PrevHash.
+func (entry AuditEntry) ComputeHash() string {
+	// marshalling a slice of strings and numbers is deterministic
+	content, _ := json.Marshal([]interface{}{
+		entry.PrevHash,
+		entry.Entity,
+		entry.EntityID,
+		entry.Action,
+		entry.ActorID,
+		entry.ActorUsername,
+		entry.RequestID,
+		entry.ClientIP,
+		string(entry.Before),
+		string(entry.After),
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// ContextWithRequestInfo returns a copy of ctx carrying the request info.
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoContextKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info, empty when the operation did not
+// come from an HTTP request.
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoContextKey{}).(RequestInfo)
+	return info
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+// AuditFilter zero values do not filter.
+type AuditFilter struct {
+	Entity   string
+	EntityID int64
+	From     time.Time
+	To       time.Time
+}
+
+// AuditVerification is the result of checking the hash chain, BrokenAtID is the first
+// entry that does not match when it is not valid.
+type AuditVerification struct {
+	Valid      bool  `json:"valid"`
+	Checked    int64 `json:"checked"`
+	BrokenAtID int64 `json:"brokenAtID,omitempty"`
+}
@@ -6,27 +6,64 @@ import (
 )
 
 type BillingDetail struct {
-	ID        int64      `json:"id"`
-	Promotion Promotion  `json:"promotion"`
-	Medicines []Medicine `json:"medicines"`
-	Total     float64    `json:"total"`
-	CreatedAt time.Time  `json:"createdAt"`
+	ID        int64         `json:"id"`
+	BranchID  int64         `json:"branchID"`
+	Promotion Promotion     `json:"promotion"`
+	Medicines []Medicine    `json:"medicines"`
+	Items     []BillingItem `json:"items"`
+	Total     float64       `json:"total"`
+	Version   int64         `json:"version"`
+	CreatedAt time.Time     `json:"createdAt"`
 }
 
 type Billing struct {
 	ID        int64     `json:"id"`
+	BranchID  int64     `json:"branchID"`
 	Total     float64   `json:"total"`
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// BillingItem is a billed line. Quantity is expressed in the sold presentation
+// and BaseQuantity in base units of the medicine, which is what leaves the stock.
+type BillingItem struct {
+	MedicineID       int64   `json:"medicineID"`
+	MedicineName     string  `json:"medicineName"`
+	PresentationID   int64   `json:"presentationID,omitempty"`
+	PresentationName string  `json:"presentationName,omitempty"`
+	Quantity         int64   `json:"quantity"`
+	BaseQuantity     int64   `json:"baseQuantity"`
+	UnitPrice        float64 `json:"unitPrice"`
+	Subtotal         float64 `json:"subtotal"`
+}
+
+// BaseQuantities returns the base units the billing takes out of the stock per medicine.
+func (billing BillingDetail) BaseQuantities() map[int64]int64 {
+	quantities := make(map[int64]int64, len(billing.Items))
+	for _, item := range billing.Items {
+		quantities[item.MedicineID] += item.BaseQuantity
+	}
+
+	return quantities
+}
+
 // ----------------------------------------------------------------------------
 //                            VIEW MODELS
 // ----------------------------------------------------------------------------
 
 type BillingCreationRequest struct {
-	PromotionID int64     `json:"promotionID"`
-	Medicines   []int64   `json:"medicines"`
-	CreatedDate time.Time `json:"createdDate"`
+	PromotionID int64                `json:"promotionID"`
+	Medicines   []int64              `json:"medicines"`
+	Items       []BillingItemRequest `json:"items"`
+	CreatedDate time.Time            `json:"createdDate"`
+}
+
+// BillingItemRequest is a requested line. When PresentationID is empty the
+// quantity is sold in base units at the medicine price.
+type BillingItemRequest struct {
+	MedicineID     int64 `json:"medicineID"`
+	PresentationID int64 `json:"presentationID"`
+	Quantity       int64 `json:"quantity"`
 }
 
 type SimulatorResponse struct {
@@ -48,5 +85,47 @@ func (billingReq BillingCreationRequest) ValidateBillingRequest() error {
 		}
 	}
 
+	for _, item := range billingReq.Items {
+		if item.MedicineID <= 0 {
+			return fmt.Errorf("invalid medicineID received: [%d]", item.MedicineID)
+		}
+		if item.PresentationID < 0 {
+			return fmt.Errorf("invalid presentationID received: [%d]", item.PresentationID)
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity received for medicine [%d]: [%d]", item.MedicineID, item.Quantity)
+		}
+	}
+
 	return nil
 }
+
+// ItemsRequested merges the legacy medicines list (one base unit per entry) with the
+// requested items, grouping repeated medicine/presentation pairs.
+func (billingReq BillingCreationRequest) ItemsRequested() []BillingItemRequest {
+	type itemKey struct {
+		medicineID     int64
+		presentationID int64
+	}
+
+	items := make([]BillingItemRequest, 0)
+	positions := make(map[itemKey]int)
+	add := func(item BillingItemRequest) {
+		key := itemKey{medicineID: item.MedicineID, presentationID: item.PresentationID}
+		if position, ok := positions[key]; ok {
+			items[position].Quantity += item.Quantity
+			return
+		}
+		positions[key] = len(items)
+		items = append(items, item)
+	}
+
+	for _, medicineID := range billingReq.Medicines {
+		add(BillingItemRequest{MedicineID: medicineID, Quantity: 1})
+	}
+	for _, item := range billingReq.Items {
+		add(item)
+	}
+
+	return items
+}
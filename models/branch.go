@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type branchContextKey struct{}
+
+type Branch struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BranchStock is the stock of a medicine in a branch, expressed in base units.
+type BranchStock struct {
+	BranchID   int64  `json:"branchID"`
+	BranchName string `json:"branchName"`
+	Stock      int64  `json:"stock"`
+}
+
+// ContextWithBranch returns a copy of ctx carrying the branch the caller operates in.
+func ContextWithBranch(ctx context.Context, branchID int64) context.Context {
+	return context.WithValue(ctx, branchContextKey{}, branchID)
+}
+
+// BranchFromContext returns the branch the caller operates in, zero when the caller did
+// not identify one (consolidated view).
+func BranchFromContext(ctx context.Context) int64 {
+	branchID, _ := ctx.Value(branchContextKey{}).(int64)
+	return branchID
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type BranchCreationRequest struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type BranchCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (branchReq BranchCreationRequest) ValidateBranchRequest() error {
+	if branchReq.Name == "" {
+		return fmt.Errorf("createBranch: branch name is empty")
+	}
+
+	return nil
+}
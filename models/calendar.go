@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultBusinessTimezone is the timezone the pharmacies operate in.
+const DefaultBusinessTimezone = "America/Bogota"
+
+const (
+	layoutDateOnly           = "2006-01-02"
+	layoutLocalDateTime      = "2006-01-02T15:04:05"
+	layoutLocalDateTimeSpace = "2006-01-02 15:04:05"
+
+	// databasePrecision is the resolution of the timestamps kept by postgres.
+	databasePrecision = time.Microsecond
+)
+
+// BusinessCalendar interprets dates in the timezone of the business: a date without time
+// is a whole business day there, and "today" is the local day, not the UTC one.
+type BusinessCalendar struct {
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewBusinessCalendar(location *time.Location) BusinessCalendar {
+	return BusinessCalendar{
+		location: location,
+		now:      time.Now,
+	}
+}
+
+// Location returns the timezone of the business, UTC for a zero calendar.
+func (c BusinessCalendar) Location() *time.Location {
+	if c.location == nil {
+		return time.UTC
+	}
+
+	return c.location
+}
+
+// Now returns the current time in the business timezone.
+func (c BusinessCalendar) Now() time.Time {
+	if c.now == nil {
+		return time.Now().In(c.Location())
+	}
+
+	return c.now().In(c.Location())
+}
+
+// Today returns the start of the current business day.
+func (c BusinessCalendar) Today() time.Time {
+	return c.StartOfDay(c.Now())
+}
+
+// In returns t in the business timezone, leaving the zero time untouched.
+func (c BusinessCalendar) In(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return t.In(c.Location())
+}
+
+// StartOfDay returns the first instant of the business day t falls in.
+func (c BusinessCalendar) StartOfDay(t time.Time) time.Time {
+	year, month, day := t.In(c.Location()).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, c.Location())
+}
+
+// EndOfDay returns the last instant the database can keep of the business day t falls in,
+// so it can be used as the inclusive end of a range.
+func (c BusinessCalendar) EndOfDay(t time.Time) time.Time {
+	year, month, day := t.In(c.Location()).Date()
+
+	return time.Date(year, month, day+1, 0, 0, 0, 0, c.Location()).Add(-databasePrecision)
+}
+
+// ParseStart parses the start of a range: an RFC3339 time, with any offset, or a date,
+// which starts at the beginning of that business day.
+func (c BusinessCalendar) ParseStart(value string) (time.Time, error) {
+	t, dateOnly, err := c.parse(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if dateOnly {
+		return c.StartOfDay(t), nil
+	}
+
+	return t, nil
+}
+
+// ParseEnd parses the inclusive end of a range as ParseStart, but a date covers the whole
+// business day.
+func (c BusinessCalendar) ParseEnd(value string) (time.Time, error) {
+	t, dateOnly, err := c.parse(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if dateOnly {
+		return c.EndOfDay(t), nil
+	}
+
+	return t, nil
+}
+
+// ParseDay returns the business day that contains the date or time in value.
+func (c BusinessCalendar) ParseDay(value string) (start, end time.Time, err error) {
+	t, _, err := c.parse(value)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return c.StartOfDay(t), c.EndOfDay(t), nil
+}
+
+// unescapedOffset matches an RFC3339 time whose + offset arrived as a space, as an unescaped
+// + does in query strings.
+var unescapedOffset = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?) (\d{2}:\d{2})$`)
+
+// parse reads value in the business timezone, reporting whether it had no time.
+func (c BusinessCalendar) parse(value string) (time.Time, bool, error) {
+	value = unescapedOffset.ReplaceAllString(strings.TrimSpace(value), "$1+$2")
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.In(c.Location()), false, nil
+	}
+	for _, layout := range []string{layoutLocalDateTime, layoutLocalDateTimeSpace} {
+		if t, err := time.ParseInLocation(layout, value, c.Location()); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.ParseInLocation(layoutDateOnly, value, c.Location()); err == nil {
+		return t, true, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid date [%s], use YYYY-MM-DD or RFC3339 like 2006-01-02T15:04:05-05:00", value)
+}
+
+// parseDateInput parses a date received in a request body: RFC3339 or a date, which is
+// kept as midnight UTC of that date until it is placed in the business timezone.
+func parseDateInput(value string) (time.Time, bool, error) {
+	return BusinessCalendar{location: time.UTC}.parse(value)
+}
+
+// inBusinessDay places the date-only value t, kept as midnight UTC, in the business day
+// of the same date, at its start or at its end.
+func (c BusinessCalendar) inBusinessDay(t time.Time, end bool) time.Time {
+	year, month, day := t.UTC().Date()
+	local := time.Date(year, month, day, 0, 0, 0, 0, c.Location())
+	if end {
+		return c.EndOfDay(local)
+	}
+
+	return local
+}
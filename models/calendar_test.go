@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusinessCalendarParseStart(t *testing.T) {
+	bogota := time.FixedZone("COT", -5*60*60)
+	calendar := NewBusinessCalendar(bogota)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "date", value: "2025-01-02", want: time.Date(2025, time.January, 2, 0, 0, 0, 0, bogota)},
+		{name: "local time", value: "2025-01-02T10:00:00", want: time.Date(2025, time.January, 2, 10, 0, 0, 0, bogota)},
+		{name: "local time with a space", value: "2025-01-02 10:00:00", want: time.Date(2025, time.January, 2, 10, 0, 0, 0, bogota)},
+		{name: "negative offset", value: "2025-01-02T10:00:00-03:00", want: time.Date(2025, time.January, 2, 13, 0, 0, 0, time.UTC)},
+		{name: "positive offset", value: "2025-01-02T10:00:00+02:00", want: time.Date(2025, time.January, 2, 8, 0, 0, 0, time.UTC)},
+		{name: "unescaped positive offset", value: "2025-01-02T10:00:00 02:00", want: time.Date(2025, time.January, 2, 8, 0, 0, 0, time.UTC)},
+		{name: "unescaped positive offset with fraction", value: "2025-01-02T10:00:00.5 02:00", want: time.Date(2025, time.January, 2, 8, 0, 0, 500000000, time.UTC)},
+		{name: "local time with a space and an unescaped offset", value: "2025-01-02 10:00:00 02:00", wantErr: true},
+		{name: "not a date", value: "manana", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calendar.ParseStart(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) || got.Location() != bogota {
+				t.Errorf("got %s, want %s in the business timezone", got, tt.want)
+			}
+		})
+	}
+}
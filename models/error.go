@@ -5,6 +5,21 @@ import "errors"
 // ErrNotFound error returned when we get Not found error in database.
 var ErrNotFound = errors.New("entity not found")
 
+// ErrVersionConflict error returned when an entity changed after the version the caller
+// based its update on.
+var ErrVersionConflict = errors.New("entity version is stale")
+
+// ErrPromotionOverlap error returned when the database rejects a promotion because it
+// overlaps another one in a shared branch.
+var ErrPromotionOverlap = errors.New("promotion overlaps another promotion")
+
+// ErrInsufficientStock error returned when a branch does not hold the units a sale takes.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrTokenRevoked error returned when revoking a token that was revoked already, like a
+// refresh token used twice at the same time.
+var ErrTokenRevoked = errors.New("token revoked already")
+
 type (
 	CustomError struct {
 		Err      error
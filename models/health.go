@@ -0,0 +1,38 @@
+package models
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck is the result of one of the readiness checks.
+type HealthCheck struct {
+	Status  string                 `json:"status"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport is ok only when every check is.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// AddCheck records the check, failing the report when the check failed.
+func (r *HealthReport) AddCheck(name string, check HealthCheck) {
+	if r.Checks == nil {
+		r.Checks = make(map[string]HealthCheck)
+	}
+	r.Checks[name] = check
+	if r.Status == "" {
+		r.Status = HealthStatusOK
+	}
+	if check.Status != HealthStatusOK {
+		r.Status = HealthStatusFail
+	}
+}
+
+// Ready tells whether the service can receive traffic.
+func (r HealthReport) Ready() bool {
+	return r.Status == HealthStatusOK
+}
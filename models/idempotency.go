@@ -0,0 +1,30 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Idempotency key statuses. A key is in progress while the first request that used it
+// is being processed.
+const (
+	IdempotencyInProgress = "in_progress"
+	IdempotencyCompleted  = "completed"
+)
+
+// ErrIdempotencyKeyReused error returned when a key is used again with a different request.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// IdempotencyRecord is the stored outcome of the request that first used a key. Keys
+// are scoped by user and RequestHash identifies the method, path and body of the request.
+type IdempotencyRecord struct {
+	UserID         int64
+	Key            string
+	RequestHash    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	ContentType    string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+}
@@ -0,0 +1,289 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Inventory count types. A full count covers every medicine of the catalog, a cycle
+// count only the requested ones.
+const (
+	InventoryCountFull  = "full"
+	InventoryCountCycle = "cycle"
+)
+
+// Inventory count statuses.
+const (
+	InventoryCountOpen      = "open"
+	InventoryCountApproved  = "approved"
+	InventoryCountCancelled = "cancelled"
+)
+
+// ErrInventoryCountIncomplete error returned when approving a count with medicines not counted yet.
+var ErrInventoryCountIncomplete = errors.New("inventory count has medicines not counted yet")
+
+type InventoryCount struct {
+	ID         int64                `json:"id"`
+	BranchID   int64                `json:"branchID"`
+	CountType  string               `json:"countType"`
+	Status     string               `json:"status"`
+	Notes      string               `json:"notes"`
+	Lines      []InventoryCountLine `json:"lines"`
+	ApprovedAt *time.Time           `json:"approvedAt"`
+	CreatedAt  time.Time            `json:"createdAt"`
+}
+
+// InventoryCountLine holds the units of a medicine in a location of the branch, a medicine
+// stored in several locations has a line for each one. Quantities are expressed in base
+// units. CountedQuantity is nil until the location is counted; SystemQuantity and UnitCost
+// are the branch stock and the average cost of the medicine at the moment it was last
+// counted in any location, so the movements registered afterwards (e.g. sales while the
+// count is open) are not taken as variance.
+type InventoryCountLine struct {
+	ID              int64      `json:"id"`
+	MedicineID      int64      `json:"medicineID"`
+	MedicineName    string     `json:"medicineName"`
+	Location        string     `json:"location"`
+	CountedQuantity *int64     `json:"countedQuantity"`
+	SystemQuantity  int64      `json:"systemQuantity"`
+	UnitCost        float64    `json:"unitCost"`
+	CountedAt       *time.Time `json:"countedAt"`
+}
+
+// Variance is the difference between the counted units and the system stock, negative
+// when units are missing. It is only meaningful for the lines returned by MedicineLines.
+func (line InventoryCountLine) Variance() int64 {
+	if line.CountedQuantity == nil {
+		return 0
+	}
+
+	return *line.CountedQuantity - line.SystemQuantity
+}
+
+// MedicineLines sums the lines of every medicine into one, in the order the medicines first
+// appear. The counted quantity is nil while any location of the medicine is not counted.
+func (count InventoryCount) MedicineLines() []InventoryCountLine {
+	lines := make([]InventoryCountLine, 0, len(count.Lines))
+	positions := make(map[int64]int, len(count.Lines))
+	pending := make(map[int64]bool)
+	for _, line := range count.Lines {
+		if line.CountedQuantity == nil {
+			pending[line.MedicineID] = true
+		}
+
+		position, ok := positions[line.MedicineID]
+		if !ok {
+			positions[line.MedicineID] = len(lines)
+			medicineLine := line
+			if line.CountedQuantity != nil {
+				counted := *line.CountedQuantity
+				medicineLine.CountedQuantity = &counted
+			}
+			lines = append(lines, medicineLine)
+			continue
+		}
+
+		medicineLine := &lines[position]
+		if line.Location != "" {
+			medicineLine.Location = strings.TrimPrefix(medicineLine.Location+", "+line.Location, ", ")
+		}
+		if line.CountedQuantity == nil {
+			continue
+		}
+		if medicineLine.CountedQuantity == nil {
+			counted := *line.CountedQuantity
+			medicineLine.CountedQuantity = &counted
+		} else {
+			*medicineLine.CountedQuantity += *line.CountedQuantity
+		}
+		if medicineLine.CountedAt == nil || (line.CountedAt != nil && line.CountedAt.After(*medicineLine.CountedAt)) {
+			medicineLine.SystemQuantity = line.SystemQuantity
+			medicineLine.UnitCost = line.UnitCost
+			medicineLine.CountedAt = line.CountedAt
+		}
+	}
+	for i := range lines {
+		if pending[lines[i].MedicineID] {
+			lines[i].CountedQuantity = nil
+		}
+	}
+
+	return lines
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type InventoryCountCreationRequest struct {
+	CountType   string  `json:"countType"`
+	MedicineIDs []int64 `json:"medicineIDs"`
+	Notes       string  `json:"notes"`
+	BranchID    int64   `json:"-"`
+}
+
+type InventoryCountCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+type InventoryCountRecordRequest struct {
+	Lines []InventoryCountRecordLineRequest `json:"lines"`
+}
+
+// InventoryCountRecordLineRequest records the counted units of a medicine in a location,
+// counting it again there replaces the previous record. Location is optional, the units
+// are recorded in the first location of the medicine in the count when it is empty.
+type InventoryCountRecordLineRequest struct {
+	MedicineID      int64  `json:"medicineID"`
+	Location        string `json:"location"`
+	CountedQuantity int64  `json:"countedQuantity"`
+}
+
+// InventoryVarianceReport values the variance of a count at the average cost of each medicine.
+type InventoryVarianceReport struct {
+	CountID       int64                   `json:"countID"`
+	BranchID      int64                   `json:"branchID"`
+	Status        string                  `json:"status"`
+	Lines         []InventoryVarianceLine `json:"lines"`
+	PendingLines  int                     `json:"pendingLines"`
+	ShortageValue float64                 `json:"shortageValue"`
+	SurplusValue  float64                 `json:"surplusValue"`
+	NetValue      float64                 `json:"netValue"`
+}
+
+type InventoryVarianceLine struct {
+	MedicineID      int64   `json:"medicineID"`
+	MedicineName    string  `json:"medicineName"`
+	Location        string  `json:"location"`
+	SystemQuantity  int64   `json:"systemQuantity"`
+	CountedQuantity int64   `json:"countedQuantity"`
+	Variance        int64   `json:"variance"`
+	UnitCost        float64 `json:"unitCost"`
+	VarianceValue   float64 `json:"varianceValue"`
+}
+
+// VarianceReport builds the variance report of the counted medicines of the count, adding up
+// the units counted in every location of each one.
+func (count InventoryCount) VarianceReport() InventoryVarianceReport {
+	report := InventoryVarianceReport{
+		CountID:  count.ID,
+		BranchID: count.BranchID,
+		Status:   count.Status,
+		Lines:    make([]InventoryVarianceLine, 0, len(count.Lines)),
+	}
+	for _, line := range count.MedicineLines() {
+		if line.CountedQuantity == nil {
+			report.PendingLines++
+			continue
+		}
+
+		varianceLine := InventoryVarianceLine{
+			MedicineID:      line.MedicineID,
+			MedicineName:    line.MedicineName,
+			Location:        line.Location,
+			SystemQuantity:  line.SystemQuantity,
+			CountedQuantity: *line.CountedQuantity,
+			Variance:        line.Variance(),
+			UnitCost:        line.UnitCost,
+			VarianceValue:   float64(line.Variance()) * line.UnitCost,
+		}
+		if varianceLine.VarianceValue < 0 {
+			report.ShortageValue -= varianceLine.VarianceValue
+		} else {
+			report.SurplusValue += varianceLine.VarianceValue
+		}
+		report.NetValue += varianceLine.VarianceValue
+		report.Lines = append(report.Lines, varianceLine)
+	}
+
+	return report
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (countReq InventoryCountCreationRequest) ValidateInventoryCountRequest() error {
+	switch countReq.CountType {
+	case InventoryCountFull:
+		if len(countReq.MedicineIDs) > 0 {
+			return fmt.Errorf("createInventoryCount: a full count covers every medicine, medicineIDs must be empty")
+		}
+	case InventoryCountCycle:
+		if len(countReq.MedicineIDs) == 0 {
+			return fmt.Errorf("createInventoryCount: a cycle count requires the medicines to count")
+		}
+	default:
+		return fmt.Errorf("createInventoryCount: invalid count type received: [%s]", countReq.CountType)
+	}
+
+	medicines := make(map[int64]bool, len(countReq.MedicineIDs))
+	for _, medicineID := range countReq.MedicineIDs {
+		if medicineID <= 0 {
+			return fmt.Errorf("createInventoryCount: invalid medicineID received: [%d]", medicineID)
+		}
+		if medicines[medicineID] {
+			return fmt.Errorf("createInventoryCount: medicine [%d] is repeated", medicineID)
+		}
+		medicines[medicineID] = true
+	}
+
+	return nil
+}
+
+// WithLocations returns the request with the empty locations replaced by the first location
+// of each medicine in the count.
+func (recordReq InventoryCountRecordRequest) WithLocations(count InventoryCount) InventoryCountRecordRequest {
+	locations := make(map[int64]string, len(count.Lines))
+	for _, line := range count.Lines {
+		if _, ok := locations[line.MedicineID]; !ok {
+			locations[line.MedicineID] = line.Location
+		}
+	}
+
+	lines := make([]InventoryCountRecordLineRequest, 0, len(recordReq.Lines))
+	for _, line := range recordReq.Lines {
+		if line.Location == "" {
+			line.Location = locations[line.MedicineID]
+		}
+		lines = append(lines, line)
+	}
+
+	return InventoryCountRecordRequest{Lines: lines}
+}
+
+// ValidateRecord checks that every recorded medicine is part of the count and that each of
+// its locations is recorded once.
+func (recordReq InventoryCountRecordRequest) ValidateRecord(count InventoryCount) error {
+	if len(recordReq.Lines) == 0 {
+		return fmt.Errorf("recordInventoryCount: no lines received")
+	}
+
+	included := make(map[int64]bool, len(count.Lines))
+	for _, line := range count.Lines {
+		included[line.MedicineID] = true
+	}
+
+	type recordKey struct {
+		medicineID int64
+		location   string
+	}
+	recorded := make(map[recordKey]bool, len(recordReq.Lines))
+	for _, line := range recordReq.Lines {
+		if !included[line.MedicineID] {
+			return fmt.Errorf("recordInventoryCount: medicine [%d] is not part of the count", line.MedicineID)
+		}
+		key := recordKey{medicineID: line.MedicineID, location: line.Location}
+		if recorded[key] {
+			return fmt.Errorf("recordInventoryCount: medicine [%d] is repeated in location [%s]", line.MedicineID, line.Location)
+		}
+		recorded[key] = true
+		if line.CountedQuantity < 0 {
+			return fmt.Errorf("recordInventoryCount: invalid counted quantity for medicine [%d], this must not be negative", line.MedicineID)
+		}
+	}
+
+	return nil
+}
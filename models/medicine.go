@@ -5,13 +5,38 @@ import (
 	"time"
 )
 
+const defaultBaseUnit = "tablet"
+
 type Medicine struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	Location  string    `json:"location"`
-	CreatedAt time.Time `json:"createdAt"`
-	// missing quantity field
+	ID               int64   `json:"id"`
+	Name             string  `json:"name"`
+	ActiveIngredient string  `json:"activeIngredient"`
+	Barcode          string  `json:"barcode"`
+	Price            float64 `json:"price"`
+	AverageCost      float64 `json:"averageCost"`
+	Location         string  `json:"location"`
+	BaseUnit         string  `json:"baseUnit"`
+	Stock            int64   `json:"stock"`
+	// RequiresPrescription medicines can only be billed by pharmacists.
+	RequiresPrescription bool `json:"requiresPrescription"`
+	// Version changes with every update of the medicine or its presentations.
+	Version int64 `json:"version"`
+	// StockVersion changes with every stock movement of the medicine.
+	StockVersion  int64          `json:"stockVersion"`
+	BranchStock   []BranchStock  `json:"branchStock,omitempty"`
+	Presentations []Presentation `json:"presentations,omitempty"`
+	CreatedAt     time.Time      `json:"createdAt"`
+}
+
+// Presentation is a sellable packaging of a medicine (box, blister...). ConversionFactor
+// is the amount of base units contained in one presentation.
+type Presentation struct {
+	ID               int64     `json:"id"`
+	MedicineID       int64     `json:"medicineID"`
+	Name             string    `json:"name"`
+	ConversionFactor int64     `json:"conversionFactor"`
+	Price            float64   `json:"price"`
+	CreatedAt        time.Time `json:"createdAt"`
 }
 
 // ----------------------------------------------------------------------------
@@ -19,15 +44,44 @@ type Medicine struct {
 // ----------------------------------------------------------------------------
 
 type MedicineCreationRequest struct {
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	Location string  `json:"location"`
+	Name                 string                        `json:"name"`
+	ActiveIngredient     string                        `json:"activeIngredient"`
+	Barcode              string                        `json:"barcode"`
+	Price                float64                       `json:"price"`
+	Location             string                        `json:"location"`
+	BaseUnit             string                        `json:"baseUnit"`
+	Stock                int64                         `json:"stock"`
+	RequiresPrescription bool                          `json:"requiresPrescription"`
+	Presentations        []PresentationCreationRequest `json:"presentations"`
+	// BranchID receives the initial stock, it is taken from the caller's branch.
+	BranchID int64 `json:"-"`
 }
 
 type MedicineCreationResponse struct {
 	ID int64 `json:"id"`
 }
 
+// MedicineSearchResult is a medicine matched by a search, Rank is its relevance for
+// the searched text, greater is better.
+type MedicineSearchResult struct {
+	Medicine
+	Rank float64 `json:"rank"`
+}
+
+type MedicinePriceUpdateRequest struct {
+	Price float64 `json:"price"`
+}
+
+type PresentationCreationRequest struct {
+	Name             string  `json:"name"`
+	ConversionFactor int64   `json:"conversionFactor"`
+	Price            float64 `json:"price"`
+}
+
+type PresentationCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
 // ----------------------------------------------------------------------------
 //                           VALIDATIONS
 // ----------------------------------------------------------------------------
@@ -39,6 +93,45 @@ func (medicineReq MedicineCreationRequest) ValidateMedicineRequest() error {
 	if medicineReq.Price <= 0 {
 		return fmt.Errorf("createMedicine: invalid medicine price, this must be greater than 0")
 	}
+	if medicineReq.Stock < 0 {
+		return fmt.Errorf("createMedicine: invalid medicine stock, this must not be negative")
+	}
+	for _, presentation := range medicineReq.Presentations {
+		if err := presentation.ValidatePresentationRequest(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (priceReq MedicinePriceUpdateRequest) ValidatePriceRequest() error {
+	if priceReq.Price <= 0 {
+		return fmt.Errorf("updatePrice: invalid medicine price, this must be greater than 0")
+	}
 
 	return nil
 }
+
+func (presentationReq PresentationCreationRequest) ValidatePresentationRequest() error {
+	if presentationReq.Name == "" {
+		return fmt.Errorf("createPresentation: presentation name is empty")
+	}
+	if presentationReq.ConversionFactor <= 0 {
+		return fmt.Errorf("createPresentation: invalid conversion factor, this must be greater than 0")
+	}
+	if presentationReq.Price <= 0 {
+		return fmt.Errorf("createPresentation: invalid presentation price, this must be greater than 0")
+	}
+
+	return nil
+}
+
+// BaseUnitOrDefault returns the requested base unit, falling back to tablets.
+func (medicineReq MedicineCreationRequest) BaseUnitOrDefault() string {
+	if medicineReq.BaseUnit == "" {
+		return defaultBaseUnit
+	}
+
+	return medicineReq.BaseUnit
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultSlowMoverDays is how long a medicine can go without sales before it is a slow mover.
+const DefaultSlowMoverDays = 30
+
+// ABC classes, by the share of the revenue the medicines add up to.
+const (
+	ABCClassA = "A"
+	ABCClassB = "B"
+	ABCClassC = "C"
+
+	abcClassALimit = 0.80
+	abcClassBLimit = 0.95
+)
+
+// Orders of the medicines ranking.
+const (
+	RankingSortRevenue  = "revenue"
+	RankingSortUnits    = "units"
+	RankingSortLocation = "location"
+)
+
+// MedicinePerformance is the input of the medicines ranking: the base units sold in the
+// period and their revenue at list price, before promotions. LastSoldAt is the last sale
+// up to the end of the period, nil when the medicine was never sold.
+type MedicinePerformance struct {
+	MedicineID   int64
+	MedicineName string
+	Location     string
+	Units        int64
+	Revenue      float64
+	LastSoldAt   *time.Time
+}
+
+// MedicineRanking places a medicine among the others by revenue and by units, 1 being the
+// best selling. The shares are fractions of the revenue of the period and CumulativeShare
+// adds up the medicines ranked before it, which gives its ABC class.
+type MedicineRanking struct {
+	MedicineID      int64      `json:"medicineID"`
+	MedicineName    string     `json:"medicineName"`
+	Location        string     `json:"location"`
+	Units           int64      `json:"units"`
+	Revenue         float64    `json:"revenue"`
+	RevenueRank     int        `json:"revenueRank"`
+	UnitsRank       int        `json:"unitsRank"`
+	RevenueShare    float64    `json:"revenueShare"`
+	CumulativeShare float64    `json:"cumulativeShare"`
+	Class           string     `json:"class"`
+	LastSoldAt      *time.Time `json:"lastSoldAt"`
+	SlowMover       bool       `json:"slowMover"`
+}
+
+// RankMedicines ranks the medicines, best revenue first. The medicines that make the first
+// 80% of the revenue are class A, the next 15% class B and the rest, including those
+// without sales, class C. A medicine not sold since slowMoverSince is a slow mover.
+func RankMedicines(performances []MedicinePerformance, slowMoverSince time.Time) []MedicineRanking {
+	rankings := make([]MedicineRanking, 0, len(performances))
+	totalRevenue := 0.0
+	for _, performance := range performances {
+		totalRevenue += performance.Revenue
+		rankings = append(rankings, MedicineRanking{
+			MedicineID:   performance.MedicineID,
+			MedicineName: performance.MedicineName,
+			Location:     performance.Location,
+			Units:        performance.Units,
+			Revenue:      performance.Revenue,
+			LastSoldAt:   performance.LastSoldAt,
+			SlowMover:    performance.LastSoldAt == nil || performance.LastSoldAt.Before(slowMoverSince),
+		})
+	}
+
+	SortMedicineRankings(rankings, RankingSortUnits)
+	for i := range rankings {
+		rankings[i].UnitsRank = i + 1
+	}
+
+	SortMedicineRankings(rankings, RankingSortRevenue)
+	cumulativeShare := 0.0
+	for i := range rankings {
+		rankings[i].RevenueRank = i + 1
+		rankings[i].Class = ABCClassC
+		if totalRevenue <= 0 || rankings[i].Revenue <= 0 {
+			continue
+		}
+
+		// the class is decided by the revenue ranked before, so the medicine that crosses
+		// a limit still belongs to the class it started in
+		switch {
+		case cumulativeShare < abcClassALimit:
+			rankings[i].Class = ABCClassA
+		case cumulativeShare < abcClassBLimit:
+			rankings[i].Class = ABCClassB
+		}
+		rankings[i].RevenueShare = rankings[i].Revenue / totalRevenue
+		cumulativeShare += rankings[i].RevenueShare
+		rankings[i].CumulativeShare = cumulativeShare
+	}
+
+	return rankings
+}
+
+// SortMedicineRankings orders the rankings by revenue or units, the best selling first, or
+// by location to walk the shelves. Ties are sorted by name.
+func SortMedicineRankings(rankings []MedicineRanking, sortBy string) {
+	slices.SortFunc(rankings, func(a, b MedicineRanking) int {
+		var byKey int
+		switch sortBy {
+		case RankingSortUnits:
+			byKey = cmp.Or(cmp.Compare(b.Units, a.Units), cmp.Compare(b.Revenue, a.Revenue))
+		case RankingSortLocation:
+			byKey = cmp.Compare(a.Location, b.Location)
+		default:
+			byKey = cmp.Or(cmp.Compare(b.Revenue, a.Revenue), cmp.Compare(b.Units, a.Units))
+		}
+
+		return cmp.Or(byKey, cmp.Compare(a.MedicineName, b.MedicineName), cmp.Compare(a.MedicineID, b.MedicineID))
+	})
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+// ParseRankingSort returns the order of the sortBy parameter, by revenue when it is empty.
+func ParseRankingSort(sortBy string) (string, error) {
+	switch sortBy {
+	case "":
+		return RankingSortRevenue, nil
+	case RankingSortRevenue, RankingSortUnits, RankingSortLocation:
+		return sortBy, nil
+	default:
+		return "", fmt.Errorf("invalid order [%s], use %s, %s or %s", sortBy, RankingSortRevenue, RankingSortUnits, RankingSortLocation)
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "errors"
+
+const (
+	MigrationUp   = "up"
+	MigrationDown = "down"
+)
+
+var (
+	// ErrSchemaDirty is returned when the last migration failed halfway.
+	ErrSchemaDirty = errors.New("database schema is dirty")
+	// ErrSchemaNewer is returned when the database has migrations the binary does not know.
+	ErrSchemaNewer = errors.New("database schema is newer than the migrations of this binary")
+	// ErrIrreversibleMigration is returned when a migration to revert has no down file.
+	ErrIrreversibleMigration = errors.New("migration cannot be reverted")
+	// ErrUnknownMigration is returned when the requested version has no migration.
+	ErrUnknownMigration = errors.New("unknown migration version")
+)
+
+// Migration is one of the migrations embedded in the binary.
+type Migration struct {
+	Version    uint   `json:"version"`
+	Name       string `json:"name"`
+	Reversible bool   `json:"reversible"`
+}
+
+// MigrationStep is a migration to apply in the given direction.
+type MigrationStep struct {
+	Migration
+	Direction string `json:"direction"`
+}
+
+// SchemaStatus compares the database schema with the migrations of the binary. Version
+// is zero when no migration ran.
+type SchemaStatus struct {
+	Version uint        `json:"version"`
+	Dirty   bool        `json:"dirty"`
+	Latest  uint        `json:"latest"`
+	Pending []Migration `json:"pending"`
+}
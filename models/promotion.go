@@ -1,27 +1,100 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
 
+// Promotion applies to the branches listed in BranchIDs, or to all of them when empty.
 type Promotion struct {
 	ID          int64     `json:"id"`
 	Description string    `json:"description"`
 	Percentage  float64   `json:"percentage"`
 	StartDate   time.Time `json:"startDate"`
 	EndtDate    time.Time `json:"endDate"`
+	BranchIDs   []int64   `json:"branchIDs"`
+	Version     int64     `json:"version"`
+}
+
+// Overlaps reports whether the promotion is active at any instant between startDate and
+// endDate. Both the period of the promotion and the range include their ends.
+func (promo Promotion) Overlaps(startDate, endDate time.Time) bool {
+	return !promo.StartDate.After(endDate) && !promo.EndtDate.Before(startDate)
+}
+
+// ActiveAt reports whether the instant falls within the period of the promotion.
+func (promo Promotion) ActiveAt(instant time.Time) bool {
+	return promo.Overlaps(instant, instant)
+}
+
+// AppliesToBranch reports whether the promotion can be used in the branch.
+func (promo Promotion) AppliesToBranch(branchID int64) bool {
+	if len(promo.BranchIDs) == 0 {
+		return true
+	}
+	for _, promoBranchID := range promo.BranchIDs {
+		if promoBranchID == branchID {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ----------------------------------------------------------------------------
 //                            VIEW MODELS
 // ----------------------------------------------------------------------------
 
+// PromotionCreationRequest accepts RFC3339 dates or plain dates, which cover whole
+// business days once the request is placed with InBusinessTime.
 type PromotionCreationRequest struct {
 	Description string    `json:"description"`
 	Percentage  float64   `json:"percentage"`
 	StartDate   time.Time `json:"startDate"`
 	EndDate     time.Time `json:"endDate"`
+	BranchIDs   []int64   `json:"branchIDs"`
+
+	startDateOnly bool
+	endDateOnly   bool
+}
+
+func (promoReq *PromotionCreationRequest) UnmarshalJSON(data []byte) error {
+	type promotionRequestFields PromotionCreationRequest
+	request := struct {
+		*promotionRequestFields
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}{promotionRequestFields: (*promotionRequestFields)(promoReq)}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+
+	var err error
+	if promoReq.StartDate, promoReq.startDateOnly, err = parseDateInput(request.StartDate); err != nil {
+		return fmt.Errorf("startDate: %w", err)
+	}
+	if promoReq.EndDate, promoReq.endDateOnly, err = parseDateInput(request.EndDate); err != nil {
+		return fmt.Errorf("endDate: %w", err)
+	}
+
+	return nil
+}
+
+// InBusinessTime returns the request with its dates in the business timezone, a plain
+// start date starting its business day and a plain end date ending it.
+func (promoReq PromotionCreationRequest) InBusinessTime(calendar BusinessCalendar) PromotionCreationRequest {
+	if promoReq.startDateOnly {
+		promoReq.StartDate = calendar.inBusinessDay(promoReq.StartDate, false)
+	}
+	if promoReq.endDateOnly {
+		promoReq.EndDate = calendar.inBusinessDay(promoReq.EndDate, true)
+	}
+	promoReq.StartDate = calendar.In(promoReq.StartDate)
+	promoReq.EndDate = calendar.In(promoReq.EndDate)
+	promoReq.startDateOnly, promoReq.endDateOnly = false, false
+
+	return promoReq
 }
 
 type PromotionCreationResponse struct {
@@ -32,21 +105,48 @@ type PromotionCreationResponse struct {
 //                           VALIDATIONS
 // ----------------------------------------------------------------------------
 
-func (promoReq PromotionCreationRequest) ValidatePromotionRequest() error {
+// ValidatePromotionRequest checks the request, which may start any time of the current
+// business day.
+func (promoReq PromotionCreationRequest) ValidatePromotionRequest(calendar BusinessCalendar) error {
+	if promoReq.StartDate.Before(calendar.Today()) {
+		return fmt.Errorf("createPromotion: invalid start time, this must not be before the current date")
+	}
+
+	return promoReq.validate("createPromotion", calendar)
+}
+
+// ValidatePromotionUpdate checks the request replacing the stored promotion. A promotion
+// that already started keeps its start date even if it is in the past; a new start date
+// may not be before the current business day.
+func (promoReq PromotionCreationRequest) ValidatePromotionUpdate(stored Promotion, calendar BusinessCalendar) error {
+	if !promoReq.StartDate.Equal(stored.StartDate) && promoReq.StartDate.Before(calendar.Today()) {
+		return fmt.Errorf("updatePromotion: invalid start time, a new one must not be before the current date")
+	}
+
+	return promoReq.validate("updatePromotion", calendar)
+}
+
+// validate checks everything but the start date of the request.
+func (promoReq PromotionCreationRequest) validate(operation string, calendar BusinessCalendar) error {
 	if promoReq.Description == "" {
-		return fmt.Errorf("createPromotion: promotion description is empty")
+		return fmt.Errorf("%s: promotion description is empty", operation)
 	}
-	if promoReq.Percentage > float64(70) {
-		return fmt.Errorf("createPromotion: invalid promotion percentage, this must be less than 70")
+	if promoReq.Percentage < 0 {
+		return fmt.Errorf("%s: invalid promotion percentage, this must not be negative", operation)
 	}
-	if promoReq.StartDate.Before(time.Now().UTC()) {
-		return fmt.Errorf("createPromotion: invalid start time, this must be greater than current date")
+	if promoReq.Percentage > float64(70) {
+		return fmt.Errorf("%s: invalid promotion percentage, this must be less than 70", operation)
 	}
-	if promoReq.EndDate.Before(time.Now().UTC()) {
-		return fmt.Errorf("createPromotion: invalid end time, this must be greater than current date")
+	if promoReq.EndDate.Before(calendar.Now()) {
+		return fmt.Errorf("%s: invalid end time, this must be greater than current date", operation)
 	}
 	if promoReq.StartDate.After(promoReq.EndDate) {
-		return fmt.Errorf("createPromotion: invalid Promotion times, Start date must be before end date")
+		return fmt.Errorf("%s: invalid Promotion times, Start date must be before end date", operation)
+	}
+	for _, branchID := range promoReq.BranchIDs {
+		if branchID <= 0 {
+			return fmt.Errorf("%s: invalid branchID received: [%d]", operation, branchID)
+		}
 	}
 
 	return nil
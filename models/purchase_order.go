@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Purchase order statuses, an order moves forward from draft to received.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+)
+
+// ErrReceiptExceedsOrder error returned when a goods receipt registers more units than pending in the order.
+var ErrReceiptExceedsOrder = errors.New("received quantity exceeds the pending quantity of the order")
+
+type PurchaseOrder struct {
+	ID         int64               `json:"id"`
+	SupplierID int64               `json:"supplierID"`
+	BranchID   int64               `json:"branchID"`
+	Status     string              `json:"status"`
+	Notes      string              `json:"notes"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+	CreatedAt  time.Time           `json:"createdAt"`
+}
+
+// PurchaseOrderLine quantities are expressed in base units of the medicine and
+// UnitCost is the agreed cost per base unit.
+type PurchaseOrderLine struct {
+	ID               int64   `json:"id"`
+	MedicineID       int64   `json:"medicineID"`
+	Quantity         int64   `json:"quantity"`
+	ReceivedQuantity int64   `json:"receivedQuantity"`
+	UnitCost         float64 `json:"unitCost"`
+}
+
+type GoodsReceipt struct {
+	ID              int64              `json:"id"`
+	PurchaseOrderID int64              `json:"purchaseOrderID"`
+	Lines           []GoodsReceiptLine `json:"lines"`
+	ReceivedAt      time.Time          `json:"receivedAt"`
+}
+
+type GoodsReceiptLine struct {
+	PurchaseOrderLineID int64   `json:"purchaseOrderLineID"`
+	MedicineID          int64   `json:"medicineID"`
+	Quantity            int64   `json:"quantity"`
+	UnitCost            float64 `json:"unitCost"`
+}
+
+// PendingQuantity returns the units of the line that have not been received yet.
+func (line PurchaseOrderLine) PendingQuantity() int64 {
+	return line.Quantity - line.ReceivedQuantity
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type PurchaseOrderCreationRequest struct {
+	SupplierID int64                      `json:"supplierID"`
+	Notes      string                     `json:"notes"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+	// BranchID receives the goods, it is taken from the caller's branch.
+	BranchID int64 `json:"-"`
+}
+
+type PurchaseOrderLineRequest struct {
+	MedicineID int64   `json:"medicineID"`
+	Quantity   int64   `json:"quantity"`
+	UnitCost   float64 `json:"unitCost"`
+}
+
+type PurchaseOrderCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+// GoodsReceiptRequest registers received units of an order. When UnitCost is empty
+// the agreed cost of the order line is used.
+type GoodsReceiptRequest struct {
+	ReceivedAt time.Time                 `json:"receivedAt"`
+	Lines      []GoodsReceiptLineRequest `json:"lines"`
+}
+
+type GoodsReceiptLineRequest struct {
+	MedicineID int64   `json:"medicineID"`
+	Quantity   int64   `json:"quantity"`
+	UnitCost   float64 `json:"unitCost"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (orderReq PurchaseOrderCreationRequest) ValidatePurchaseOrderRequest() error {
+	if orderReq.SupplierID <= 0 {
+		return fmt.Errorf("createPurchaseOrder: invalid supplierID received: [%d]", orderReq.SupplierID)
+	}
+	if len(orderReq.Lines) == 0 {
+		return fmt.Errorf("createPurchaseOrder: purchase order has no lines")
+	}
+
+	medicines := make(map[int64]bool, len(orderReq.Lines))
+	for _, line := range orderReq.Lines {
+		if line.MedicineID <= 0 {
+			return fmt.Errorf("createPurchaseOrder: invalid medicineID received: [%d]", line.MedicineID)
+		}
+		if medicines[line.MedicineID] {
+			return fmt.Errorf("createPurchaseOrder: medicine [%d] is repeated", line.MedicineID)
+		}
+		medicines[line.MedicineID] = true
+		if line.Quantity <= 0 {
+			return fmt.Errorf("createPurchaseOrder: invalid quantity for medicine [%d], this must be greater than 0", line.MedicineID)
+		}
+		if line.UnitCost <= 0 {
+			return fmt.Errorf("createPurchaseOrder: invalid cost for medicine [%d], this must be greater than 0", line.MedicineID)
+		}
+	}
+
+	return nil
+}
+
+func (receiptReq GoodsReceiptRequest) ValidateGoodsReceiptRequest() error {
+	if len(receiptReq.Lines) == 0 {
+		return fmt.Errorf("goodsReceipt: goods receipt has no lines")
+	}
+
+	medicines := make(map[int64]bool, len(receiptReq.Lines))
+	for _, line := range receiptReq.Lines {
+		if line.MedicineID <= 0 {
+			return fmt.Errorf("goodsReceipt: invalid medicineID received: [%d]", line.MedicineID)
+		}
+		if medicines[line.MedicineID] {
+			return fmt.Errorf("goodsReceipt: medicine [%d] is repeated", line.MedicineID)
+		}
+		medicines[line.MedicineID] = true
+		if line.Quantity <= 0 {
+			return fmt.Errorf("goodsReceipt: invalid quantity for medicine [%d], this must be greater than 0", line.MedicineID)
+		}
+		if line.UnitCost < 0 {
+			return fmt.Errorf("goodsReceipt: invalid cost for medicine [%d], this must not be negative", line.MedicineID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Default parameters of the reorder report.
+const (
+	DefaultReorderWindowDays      = 30
+	DefaultReorderLeadTimeDays    = 7
+	DefaultReorderSafetyStockDays = 3
+)
+
+// ReorderParams drives the reorder report: sales are averaged over WindowDays and the
+// suggested quantity covers LeadTimeDays plus SafetyStockDays of sales.
+type ReorderParams struct {
+	WindowDays      int `json:"windowDays"`
+	LeadTimeDays    int `json:"leadTimeDays"`
+	SafetyStockDays int `json:"safetyStockDays"`
+}
+
+// MedicineSales is the input of the reorder report for a medicine, quantities are
+// expressed in base units.
+type MedicineSales struct {
+	MedicineID      int64
+	MedicineName    string
+	Stock           int64
+	PendingQuantity int64
+	SoldQuantity    int64
+}
+
+type ReorderSuggestion struct {
+	MedicineID        int64    `json:"medicineID"`
+	MedicineName      string   `json:"medicineName"`
+	AverageDailySales float64  `json:"averageDailySales"`
+	Stock             int64    `json:"stock"`
+	PendingQuantity   int64    `json:"pendingQuantity"`
+	DaysOfCover       *float64 `json:"daysOfCover"`
+	SuggestedQuantity int64    `json:"suggestedQuantity"`
+}
+
+// ReorderDraft is a stored reorder list, BranchID is empty for consolidated drafts.
+type ReorderDraft struct {
+	ID       int64 `json:"id"`
+	BranchID int64 `json:"branchID,omitempty"`
+	ReorderParams
+	Suggestions []ReorderSuggestion `json:"suggestions"`
+	CreatedAt   time.Time           `json:"createdAt"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (params ReorderParams) ValidateReorderParams() error {
+	if params.WindowDays <= 0 {
+		return fmt.Errorf("reorder: invalid window, this must be greater than 0 days")
+	}
+	if params.LeadTimeDays < 0 {
+		return fmt.Errorf("reorder: invalid lead time, this must not be negative")
+	}
+	if params.SafetyStockDays < 0 {
+		return fmt.Errorf("reorder: invalid safety stock, this must not be negative")
+	}
+
+	return nil
+}
+
+// Suggest computes the reorder suggestion of a medicine. Days of cover is nil when the
+// medicine has no sales in the window. Units already ordered and not received are
+// discounted from the suggested quantity.
+func (params ReorderParams) Suggest(sales MedicineSales) ReorderSuggestion {
+	suggestion := ReorderSuggestion{
+		MedicineID:        sales.MedicineID,
+		MedicineName:      sales.MedicineName,
+		AverageDailySales: float64(sales.SoldQuantity) / float64(params.WindowDays),
+		Stock:             sales.Stock,
+		PendingQuantity:   sales.PendingQuantity,
+	}
+	if suggestion.AverageDailySales == 0 {
+		return suggestion
+	}
+
+	daysOfCover := float64(sales.Stock) / suggestion.AverageDailySales
+	suggestion.DaysOfCover = &daysOfCover
+
+	target := int64(math.Ceil(suggestion.AverageDailySales * float64(params.LeadTimeDays+params.SafetyStockDays)))
+	if missing := target - sales.Stock - sales.PendingQuantity; missing > 0 {
+		suggestion.SuggestedQuantity = missing
+	}
+
+	return suggestion
+}
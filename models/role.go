@@ -0,0 +1,111 @@
+package models
+
+import "slices"
+
+// User roles.
+const (
+	RoleCashier    = "cashier"
+	RolePharmacist = "pharmacist"
+	RoleManager    = "manager"
+	RoleAdmin      = "admin"
+)
+
+// Permissions granted to the roles, one per guarded operation.
+const (
+	PermissionViewMedicines        = "medicines:view"
+	PermissionManageMedicines      = "medicines:manage"
+	PermissionChangePrices         = "medicines:change_prices"
+	PermissionViewPromotions       = "promotions:view"
+	PermissionManagePromotions     = "promotions:manage"
+	PermissionViewBillings         = "billings:view"
+	PermissionBill                 = "billings:create"
+	PermissionSimulate             = "billings:simulate"
+	PermissionDispensePrescription = "billings:dispense_prescription"
+	PermissionManageUsers          = "users:manage"
+	PermissionViewAudit            = "audit:view"
+	PermissionViewReports          = "reports:view"
+	PermissionViewBranches         = "branches:view"
+	PermissionManageBranches       = "branches:manage"
+	PermissionViewSuppliers        = "suppliers:view"
+	PermissionManageSuppliers      = "suppliers:manage"
+	PermissionViewPurchaseOrders   = "purchase_orders:view"
+	PermissionManagePurchaseOrders = "purchase_orders:manage"
+	PermissionReceivePurchaseOrder = "purchase_orders:receive"
+	PermissionViewTransfers        = "transfers:view"
+	PermissionManageTransfers      = "transfers:manage"
+	PermissionReceiveTransfer      = "transfers:receive"
+	PermissionViewInventoryCounts  = "inventory_counts:view"
+	PermissionManageInventoryCount = "inventory_counts:manage"
+	PermissionRecordInventoryCount = "inventory_counts:record"
+)
+
+var cashierPermissions = []string{
+	PermissionViewMedicines,
+	PermissionViewPromotions,
+	PermissionViewBillings,
+	PermissionBill,
+	PermissionSimulate,
+	PermissionViewBranches,
+}
+
+// stockPermissions let the pharmacist see the stock operations, receive the goods and
+// count the shelves, which the manager and the admin also do.
+var stockPermissions = []string{
+	PermissionViewSuppliers,
+	PermissionViewPurchaseOrders,
+	PermissionReceivePurchaseOrder,
+	PermissionViewTransfers,
+	PermissionReceiveTransfer,
+	PermissionViewInventoryCounts,
+	PermissionRecordInventoryCount,
+}
+
+// stockManagementPermissions create the suppliers, orders, transfers and counts and
+// approve the counts, adjusting the stock.
+var stockManagementPermissions = []string{
+	PermissionManageSuppliers,
+	PermissionManagePurchaseOrders,
+	PermissionManageTransfers,
+	PermissionManageInventoryCount,
+}
+
+// rolePermissions only the pharmacist (and the admin) can dispense prescription items
+// and only the admin can create branches.
+var rolePermissions = map[string][]string{
+	RoleCashier:    cashierPermissions,
+	RolePharmacist: slices.Concat([]string{PermissionDispensePrescription}, cashierPermissions, stockPermissions),
+	RoleManager: slices.Concat([]string{
+		PermissionManageMedicines,
+		PermissionChangePrices,
+		PermissionManagePromotions,
+		PermissionViewAudit,
+		PermissionViewReports,
+	}, cashierPermissions, stockPermissions, stockManagementPermissions),
+	RoleAdmin: slices.Concat([]string{
+		PermissionManageMedicines,
+		PermissionChangePrices,
+		PermissionManagePromotions,
+		PermissionDispensePrescription,
+		PermissionManageUsers,
+		PermissionViewAudit,
+		PermissionViewReports,
+		PermissionManageBranches,
+	}, cashierPermissions, stockPermissions, stockManagementPermissions),
+}
+
+// IsValidRole tells whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission tells whether the role grants the permission.
+func HasPermission(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Periods the sales summary can be grouped by.
+const (
+	SalesPeriodDay   = "day"
+	SalesPeriodWeek  = "week"
+	SalesPeriodMonth = "month"
+)
+
+// Dimensions the sales summary can be broken down by.
+const (
+	SalesBreakdownBranch  = "branch"
+	SalesBreakdownCashier = "cashier"
+)
+
+// SalesSummaryFilter selects the billings created between From and To, both included,
+// of the branch when BranchID is not zero. They are grouped in periods of the business
+// timezone Location and, when asked, by branch and by the cashier who billed them.
+type SalesSummaryFilter struct {
+	From      time.Time
+	To        time.Time
+	GroupBy   string
+	Location  *time.Location
+	BranchID  int64
+	ByBranch  bool
+	ByCashier bool
+}
+
+// SalesSummary aggregates the billings of a period. Gross is the amount before the
+// promotions and Net what was charged. BranchID and the cashier are only set when the
+// summary is broken down by them; billings without a known cashier, like those created
+// before the audit log existed, have CashierID zero.
+type SalesSummary struct {
+	PeriodStart     time.Time               `json:"periodStart"`
+	BranchID        int64                   `json:"branchID,omitempty"`
+	CashierID       int64                   `json:"cashierID,omitempty"`
+	CashierUsername string                  `json:"cashierUsername,omitempty"`
+	Billings        int64                   `json:"billings"`
+	Gross           float64                 `json:"gross"`
+	Discounts       float64                 `json:"discounts"`
+	Net             float64                 `json:"net"`
+	AverageTicket   float64                 `json:"averageTicket"`
+	Promotions      []SalesSummaryPromotion `json:"promotions"`
+}
+
+// SalesSummaryPromotion is a promotion applied in the period and how many billings used it.
+type SalesSummaryPromotion struct {
+	PromotionID int64  `json:"promotionID"`
+	Description string `json:"description"`
+	Billings    int64  `json:"billings"`
+}
+
+// SalesPeriodStart returns the first instant of the period t falls in, in the location.
+// Weeks start on Monday, as in Postgres.
+func SalesPeriodStart(t time.Time, groupBy string, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	switch groupBy {
+	case SalesPeriodWeek:
+		weekday := time.Date(year, month, day, 0, 0, 0, 0, location).Weekday()
+		return time.Date(year, month, day-(int(weekday)+6)%7, 0, 0, 0, 0, location)
+	case SalesPeriodMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, location)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, location)
+	}
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+// ParseSalesPeriod returns the period of the groupBy parameter, a day when it is empty.
+func ParseSalesPeriod(groupBy string) (string, error) {
+	switch groupBy {
+	case "":
+		return SalesPeriodDay, nil
+	case SalesPeriodDay, SalesPeriodWeek, SalesPeriodMonth:
+		return groupBy, nil
+	default:
+		return "", fmt.Errorf("invalid period [%s], use %s, %s or %s", groupBy, SalesPeriodDay, SalesPeriodWeek, SalesPeriodMonth)
+	}
+}
+
+// ParseSalesBreakdown reads the comma separated dimensions of the breakdown parameter.
+func ParseSalesBreakdown(breakdown string) (byBranch, byCashier bool, err error) {
+	for _, dimension := range strings.Split(breakdown, ",") {
+		switch strings.TrimSpace(dimension) {
+		case "":
+		case SalesBreakdownBranch:
+			byBranch = true
+		case SalesBreakdownCashier:
+			byCashier = true
+		default:
+			return false, false, fmt.Errorf("invalid breakdown [%s], use %s and/or %s", dimension, SalesBreakdownBranch, SalesBreakdownCashier)
+		}
+	}
+
+	return byBranch, byCashier, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Stock movement types registered in the stock ledger.
+const (
+	StockMovementInitial     = "initial"
+	StockMovementSale        = "sale"
+	StockMovementPurchase    = "purchase"
+	StockMovementTransferOut = "transfer_out"
+	StockMovementTransferIn  = "transfer_in"
+	StockMovementTransitLoss = "transit_loss"
+	StockMovementAdjustment  = "adjustment"
+)
+
+// StockMovement is an entry of the stock ledger. Quantity is expressed in base
+// units, negative values decrement the stock.
+type StockMovement struct {
+	ID           int64     `json:"id"`
+	MedicineID   int64     `json:"medicineID"`
+	BranchID     int64     `json:"branchID"`
+	Quantity     int64     `json:"quantity"`
+	MovementType string    `json:"movementType"`
+	ReferenceID  int64     `json:"referenceID"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// CheckStock fails with ErrInsufficientStock when the stock per medicine does not cover
+// the quantities per medicine, naming the first medicine short of units.
+func CheckStock(stock, quantities map[int64]int64) error {
+	medicinesIDs := make([]int64, 0, len(quantities))
+	for medicineID := range quantities {
+		medicinesIDs = append(medicinesIDs, medicineID)
+	}
+	slices.Sort(medicinesIDs)
+
+	for _, medicineID := range medicinesIDs {
+		if stock[medicineID] < quantities[medicineID] {
+			return fmt.Errorf("%w of medicine [%d]: %d units available, %d requested",
+				ErrInsufficientStock, medicineID, stock[medicineID], quantities[medicineID])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type Supplier struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	TaxID     string    `json:"taxID"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type SupplierCreationRequest struct {
+	Name  string `json:"name"`
+	TaxID string `json:"taxID"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type SupplierCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (supplierReq SupplierCreationRequest) ValidateSupplierRequest() error {
+	if supplierReq.Name == "" {
+		return fmt.Errorf("createSupplier: supplier name is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Stock transfer statuses. Shipped transfers are in transit: the goods already left
+// the source branch and have not reached the destination.
+const (
+	TransferDraft    = "draft"
+	TransferShipped  = "shipped"
+	TransferReceived = "received"
+)
+
+// ErrInvalidTransition error returned when an entity is not in the status an operation expects.
+var ErrInvalidTransition = errors.New("entity is not in the expected status")
+
+type StockTransfer struct {
+	ID                  int64               `json:"id"`
+	SourceBranchID      int64               `json:"sourceBranchID"`
+	DestinationBranchID int64               `json:"destinationBranchID"`
+	Status              string              `json:"status"`
+	Notes               string              `json:"notes"`
+	Lines               []StockTransferLine `json:"lines"`
+	ShippedAt           *time.Time          `json:"shippedAt"`
+	ReceivedAt          *time.Time          `json:"receivedAt"`
+	CreatedAt           time.Time           `json:"createdAt"`
+}
+
+// StockTransferLine quantities are expressed in base units. ReceivedQuantity is nil
+// until the transfer is received, which cannot be more than the shipped units, and
+// Discrepancy is the shipped units that did not arrive, booked as a transit loss.
+type StockTransferLine struct {
+	ID               int64  `json:"id"`
+	MedicineID       int64  `json:"medicineID"`
+	Quantity         int64  `json:"quantity"`
+	ReceivedQuantity *int64 `json:"receivedQuantity"`
+	Discrepancy      int64  `json:"discrepancy"`
+	DiscrepancyNotes string `json:"discrepancyNotes,omitempty"`
+}
+
+// TransferReceiptMovements returns the stock movements of receiving the line in the
+// destination branch: the shipped units come in and the ones that did not arrive go
+// out as a transit loss, so every shipped unit shows in the stock ledger.
+func TransferReceiptMovements(transfer StockTransfer, line StockTransferLine) []StockMovement {
+	movements := make([]StockMovement, 0, 2)
+	if line.Quantity > 0 {
+		movements = append(movements, StockMovement{
+			MedicineID:   line.MedicineID,
+			BranchID:     transfer.DestinationBranchID,
+			Quantity:     line.Quantity,
+			MovementType: StockMovementTransferIn,
+			ReferenceID:  transfer.ID,
+		})
+	}
+	if line.ReceivedQuantity != nil && *line.ReceivedQuantity < line.Quantity {
+		movements = append(movements, StockMovement{
+			MedicineID:   line.MedicineID,
+			BranchID:     transfer.DestinationBranchID,
+			Quantity:     *line.ReceivedQuantity - line.Quantity,
+			MovementType: StockMovementTransitLoss,
+			ReferenceID:  transfer.ID,
+		})
+	}
+
+	return movements
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type StockTransferCreationRequest struct {
+	SourceBranchID      int64                      `json:"sourceBranchID"`
+	DestinationBranchID int64                      `json:"destinationBranchID"`
+	Notes               string                     `json:"notes"`
+	Lines               []StockTransferLineRequest `json:"lines"`
+}
+
+type StockTransferLineRequest struct {
+	MedicineID int64 `json:"medicineID"`
+	Quantity   int64 `json:"quantity"`
+}
+
+type StockTransferCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+// StockTransferReceiptRequest must report the received units of every line of the transfer.
+type StockTransferReceiptRequest struct {
+	Lines []StockTransferReceiptLineRequest `json:"lines"`
+}
+
+type StockTransferReceiptLineRequest struct {
+	MedicineID       int64  `json:"medicineID"`
+	ReceivedQuantity int64  `json:"receivedQuantity"`
+	DiscrepancyNotes string `json:"discrepancyNotes"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (transferReq StockTransferCreationRequest) ValidateStockTransferRequest() error {
+	if transferReq.SourceBranchID <= 0 {
+		return fmt.Errorf("createTransfer: invalid source branchID received: [%d]", transferReq.SourceBranchID)
+	}
+	if transferReq.DestinationBranchID <= 0 {
+		return fmt.Errorf("createTransfer: invalid destination branchID received: [%d]", transferReq.DestinationBranchID)
+	}
+	if transferReq.SourceBranchID == transferReq.DestinationBranchID {
+		return fmt.Errorf("createTransfer: source and destination branches must be different")
+	}
+	if len(transferReq.Lines) == 0 {
+		return fmt.Errorf("createTransfer: transfer has no lines")
+	}
+
+	medicines := make(map[int64]bool, len(transferReq.Lines))
+	for _, line := range transferReq.Lines {
+		if line.MedicineID <= 0 {
+			return fmt.Errorf("createTransfer: invalid medicineID received: [%d]", line.MedicineID)
+		}
+		if medicines[line.MedicineID] {
+			return fmt.Errorf("createTransfer: medicine [%d] is repeated", line.MedicineID)
+		}
+		medicines[line.MedicineID] = true
+		if line.Quantity <= 0 {
+			return fmt.Errorf("createTransfer: invalid quantity for medicine [%d], this must be greater than 0", line.MedicineID)
+		}
+	}
+
+	return nil
+}
+
+// ValidateReceipt checks that the receipt reports every line of the transfer exactly once.
+func (receiptReq StockTransferReceiptRequest) ValidateReceipt(transfer StockTransfer) error {
+	shipped := make(map[int64]int64, len(transfer.Lines))
+	for _, line := range transfer.Lines {
+		shipped[line.MedicineID] = line.Quantity
+	}
+
+	reported := make(map[int64]bool, len(receiptReq.Lines))
+	for _, line := range receiptReq.Lines {
+		shippedQuantity, ok := shipped[line.MedicineID]
+		if !ok {
+			return fmt.Errorf("receiveTransfer: medicine [%d] is not part of the transfer", line.MedicineID)
+		}
+		if reported[line.MedicineID] {
+			return fmt.Errorf("receiveTransfer: medicine [%d] is repeated", line.MedicineID)
+		}
+		reported[line.MedicineID] = true
+		if line.ReceivedQuantity < 0 {
+			return fmt.Errorf("receiveTransfer: invalid received quantity for medicine [%d], this must not be negative", line.MedicineID)
+		}
+		if line.ReceivedQuantity > shippedQuantity {
+			return fmt.Errorf("receiveTransfer: received quantity for medicine [%d] is [%d], more than the [%d] shipped",
+				line.MedicineID, line.ReceivedQuantity, shippedQuantity)
+		}
+	}
+	if len(reported) != len(shipped) {
+		return fmt.Errorf("receiveTransfer: the received quantity of every line of the transfer is required")
+	}
+
+	return nil
+}
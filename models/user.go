@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const minPasswordLength = 8
+
+type userContextKey struct{}
+
+// User BranchID is the branch the user is bound to, zero for the users of every branch.
+type User struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	BranchID     int64     `json:"branchID,omitempty"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// AuthenticatedUser is the caller identified by an access token. TokenID and ExpiresAt
+// belong to that token so it can be revoked. BranchID is the branch of the user when
+// the token was issued, zero when the user is not bound to one.
+type AuthenticatedUser struct {
+	ID        int64
+	Username  string
+	Role      string
+	BranchID  int64
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+// AuthSettings signs and limits the lifetime of the issued tokens.
+type AuthSettings struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// ContextWithUser returns a copy of ctx carrying the authenticated caller.
+func ContextWithUser(ctx context.Context, user AuthenticatedUser) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext returns the authenticated caller, false when the request was not authenticated.
+func UserFromContext(ctx context.Context) (AuthenticatedUser, bool) {
+	user, ok := ctx.Value(userContextKey{}).(AuthenticatedUser)
+	return user, ok
+}
+
+// ----------------------------------------------------------------------------
+//                            VIEW MODELS
+// ----------------------------------------------------------------------------
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// TokenResponse ExpiresIn is the lifetime of the access token in seconds.
+type TokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// UserCreationRequest BranchID binds the user to a branch, when it is not zero.
+type UserCreationRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+	BranchID int64  `json:"branchID"`
+}
+
+type UserCreationResponse struct {
+	ID int64 `json:"id"`
+}
+
+// ----------------------------------------------------------------------------
+//                           VALIDATIONS
+// ----------------------------------------------------------------------------
+
+func (userReq UserCreationRequest) ValidateUserRequest() error {
+	if userReq.Username == "" {
+		return fmt.Errorf("createUser: username is empty")
+	}
+	if len(userReq.Password) < minPasswordLength {
+		return fmt.Errorf("createUser: password must have at least %d characters", minPasswordLength)
+	}
+	if !IsValidRole(userReq.Role) {
+		return fmt.Errorf("createUser: invalid role received: [%s]", userReq.Role)
+	}
+	if userReq.BranchID < 0 {
+		return fmt.Errorf("createUser: invalid branchID received: [%d]", userReq.BranchID)
+	}
+
+	return nil
+}
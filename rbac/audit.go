@@ -0,0 +1,34 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Audit struct {
+	Usecase transport.AuditUsecase
+}
+
+func NewAudit(auc transport.AuditUsecase) Audit {
+	return Audit{
+		Usecase: auc,
+	}
+}
+
+func (a Audit) Get(ctx context.Context, entity, entityID, from, to string) ([]models.AuditEntry, error) {
+	if err := authorize(ctx, models.PermissionViewAudit, "getAudit"); err != nil {
+		return nil, err
+	}
+
+	return a.Usecase.Get(ctx, entity, entityID, from, to)
+}
+
+func (a Audit) Verify(ctx context.Context) (*models.AuditVerification, error) {
+	if err := authorize(ctx, models.PermissionViewAudit, "verifyAudit"); err != nil {
+		return nil, err
+	}
+
+	return a.Usecase.Verify(ctx)
+}
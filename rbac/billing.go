@@ -0,0 +1,86 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+// MedicineStore looks up the billed medicines to know whether they require prescription.
+type MedicineStore interface {
+	GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) ([]models.Medicine, error)
+}
+
+type Billings struct {
+	Usecase       transport.BillingsUsecase
+	MedicineStore MedicineStore
+}
+
+func NewBillings(buc transport.BillingsUsecase, ms MedicineStore) Billings {
+	return Billings{
+		Usecase:       buc,
+		MedicineStore: ms,
+	}
+}
+
+// Create requires the dispense prescription permission too when any billed medicine
+// requires prescription.
+func (b Billings) Create(ctx context.Context, billingRequest models.BillingCreationRequest) (*models.BillingDetail, error) {
+	if err := authorize(ctx, models.PermissionBill, "createBilling"); err != nil {
+		return nil, err
+	}
+
+	items := billingRequest.ItemsRequested()
+	medicinesIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		medicinesIDs = append(medicinesIDs, item.MedicineID)
+	}
+	if len(medicinesIDs) > 0 {
+		medicines, err := b.MedicineStore.GetMedicinesByIDs(ctx, medicinesIDs)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createBilling: getting medicines from the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "f3f8fb3f-3134-4ecd-bff6-aeba04eb5be9",
+			}
+		}
+		for _, medicine := range medicines {
+			if !medicine.RequiresPrescription {
+				continue
+			}
+			if err := authorize(ctx, models.PermissionDispensePrescription, "createBilling"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	return b.Usecase.Create(ctx, billingRequest)
+}
+
+func (b Billings) Get(ctx context.Context, startDate, endDate string) ([]models.Billing, error) {
+	if err := authorize(ctx, models.PermissionViewBillings, "getBillings"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.Get(ctx, startDate, endDate)
+}
+
+func (b Billings) GetByID(ctx context.Context, billingID string) (*models.BillingDetail, error) {
+	if err := authorize(ctx, models.PermissionViewBillings, "getBilling"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.GetByID(ctx, billingID)
+}
+
+func (b Billings) Simulator(ctx context.Context, date, medicinesIDs string) (*models.SimulatorResponse, error) {
+	if err := authorize(ctx, models.PermissionSimulate, "simulator"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.Simulator(ctx, date, medicinesIDs)
+}
@@ -0,0 +1,42 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Branches struct {
+	Usecase transport.BranchesUsecase
+}
+
+func NewBranches(buc transport.BranchesUsecase) Branches {
+	return Branches{
+		Usecase: buc,
+	}
+}
+
+func (b Branches) Create(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.BranchCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageBranches, "createBranch"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.Create(ctx, branchRequest)
+}
+
+func (b Branches) Get(ctx context.Context) ([]models.Branch, error) {
+	if err := authorize(ctx, models.PermissionViewBranches, "getBranches"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.Get(ctx)
+}
+
+func (b Branches) GetByID(ctx context.Context, branchID string) (*models.Branch, error) {
+	if err := authorize(ctx, models.PermissionViewBranches, "getBranch"); err != nil {
+		return nil, err
+	}
+
+	return b.Usecase.GetByID(ctx, branchID)
+}
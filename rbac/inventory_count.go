@@ -0,0 +1,79 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type InventoryCounts struct {
+	Usecase transport.InventoryCountsUsecase
+}
+
+func NewInventoryCounts(icuc transport.InventoryCountsUsecase) InventoryCounts {
+	return InventoryCounts{
+		Usecase: icuc,
+	}
+}
+
+func (ic InventoryCounts) Create(
+	ctx context.Context, countRequest models.InventoryCountCreationRequest,
+) (*models.InventoryCountCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageInventoryCount, "createInventoryCount"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.Create(ctx, countRequest)
+}
+
+func (ic InventoryCounts) Get(ctx context.Context, status string) ([]models.InventoryCount, error) {
+	if err := authorize(ctx, models.PermissionViewInventoryCounts, "getInventoryCounts"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.Get(ctx, status)
+}
+
+func (ic InventoryCounts) GetByID(ctx context.Context, countID string) (*models.InventoryCount, error) {
+	if err := authorize(ctx, models.PermissionViewInventoryCounts, "getInventoryCount"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.GetByID(ctx, countID)
+}
+
+func (ic InventoryCounts) GetVariance(ctx context.Context, countID string) (*models.InventoryVarianceReport, error) {
+	if err := authorize(ctx, models.PermissionViewInventoryCounts, "getInventoryVariance"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.GetVariance(ctx, countID)
+}
+
+func (ic InventoryCounts) Record(
+	ctx context.Context, countID string, recordRequest models.InventoryCountRecordRequest,
+) (*models.InventoryCount, error) {
+	if err := authorize(ctx, models.PermissionRecordInventoryCount, "recordInventoryCount"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.Record(ctx, countID, recordRequest)
+}
+
+// Approve adjusts the stock to the counted quantities.
+func (ic InventoryCounts) Approve(ctx context.Context, countID string) (*models.InventoryVarianceReport, error) {
+	if err := authorize(ctx, models.PermissionManageInventoryCount, "approveInventoryCount"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.Approve(ctx, countID)
+}
+
+func (ic InventoryCounts) Cancel(ctx context.Context, countID string) (*models.InventoryCount, error) {
+	if err := authorize(ctx, models.PermissionManageInventoryCount, "cancelInventoryCount"); err != nil {
+		return nil, err
+	}
+
+	return ic.Usecase.Cancel(ctx, countID)
+}
@@ -0,0 +1,75 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Medicines struct {
+	Usecase transport.MedicinesUsecase
+}
+
+func NewMedicines(muc transport.MedicinesUsecase) Medicines {
+	return Medicines{
+		Usecase: muc,
+	}
+}
+
+func (m Medicines) Create(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.MedicineCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageMedicines, "createMedicine"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.Create(ctx, medicineRequest)
+}
+
+func (m Medicines) Get(ctx context.Context) ([]models.Medicine, error) {
+	if err := authorize(ctx, models.PermissionViewMedicines, "getMedicines"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.Get(ctx)
+}
+
+func (m Medicines) GetByID(ctx context.Context, medicineID string) (*models.Medicine, error) {
+	if err := authorize(ctx, models.PermissionViewMedicines, "getMedicine"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.GetByID(ctx, medicineID)
+}
+
+func (m Medicines) Search(ctx context.Context, text, limit string) ([]models.MedicineSearchResult, error) {
+	if err := authorize(ctx, models.PermissionViewMedicines, "searchMedicines"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.Search(ctx, text, limit)
+}
+
+func (m Medicines) UpdatePrice(
+	ctx context.Context, medicineID string, expectedVersion int64, priceRequest models.MedicinePriceUpdateRequest) (*models.Medicine, error,
+) {
+	if err := authorize(ctx, models.PermissionChangePrices, "updatePrice"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.UpdatePrice(ctx, medicineID, expectedVersion, priceRequest)
+}
+
+// CreatePresentation sets the price of the new presentation, so it also requires the
+// change prices permission.
+func (m Medicines) CreatePresentation(
+	ctx context.Context, medicineID string, presentationRequest models.PresentationCreationRequest) (*models.PresentationCreationResponse, error,
+) {
+	if err := authorize(ctx, models.PermissionManageMedicines, "createPresentation"); err != nil {
+		return nil, err
+	}
+	if err := authorize(ctx, models.PermissionChangePrices, "createPresentation"); err != nil {
+		return nil, err
+	}
+
+	return m.Usecase.CreatePresentation(ctx, medicineID, presentationRequest)
+}
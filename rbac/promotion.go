@@ -0,0 +1,52 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Promotions struct {
+	Usecase transport.PromotionsUsecase
+}
+
+func NewPromotions(puc transport.PromotionsUsecase) Promotions {
+	return Promotions{
+		Usecase: puc,
+	}
+}
+
+func (p Promotions) Create(ctx context.Context, promotionRequest models.PromotionCreationRequest) (*models.PromotionCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManagePromotions, "createPromotion"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Create(ctx, promotionRequest)
+}
+
+func (p Promotions) Update(
+	ctx context.Context, promoID string, expectedVersion int64, promotionRequest models.PromotionCreationRequest) (*models.Promotion, error,
+) {
+	if err := authorize(ctx, models.PermissionManagePromotions, "updatePromotion"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Update(ctx, promoID, expectedVersion, promotionRequest)
+}
+
+func (p Promotions) GetByID(ctx context.Context, promoID string) (models.Promotion, error) {
+	if err := authorize(ctx, models.PermissionViewPromotions, "getPromotion"); err != nil {
+		return models.Promotion{}, err
+	}
+
+	return p.Usecase.GetByID(ctx, promoID)
+}
+
+func (p Promotions) Get(ctx context.Context) ([]models.Promotion, error) {
+	if err := authorize(ctx, models.PermissionViewPromotions, "getPromotions"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Get(ctx)
+}
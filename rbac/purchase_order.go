@@ -0,0 +1,63 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type PurchaseOrders struct {
+	Usecase transport.PurchaseOrdersUsecase
+}
+
+func NewPurchaseOrders(pouc transport.PurchaseOrdersUsecase) PurchaseOrders {
+	return PurchaseOrders{
+		Usecase: pouc,
+	}
+}
+
+func (p PurchaseOrders) Create(
+	ctx context.Context, orderRequest models.PurchaseOrderCreationRequest,
+) (*models.PurchaseOrderCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManagePurchaseOrders, "createPurchaseOrder"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Create(ctx, orderRequest)
+}
+
+func (p PurchaseOrders) Get(ctx context.Context, status string) ([]models.PurchaseOrder, error) {
+	if err := authorize(ctx, models.PermissionViewPurchaseOrders, "getPurchaseOrders"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Get(ctx, status)
+}
+
+func (p PurchaseOrders) GetByID(ctx context.Context, orderID string) (*models.PurchaseOrder, error) {
+	if err := authorize(ctx, models.PermissionViewPurchaseOrders, "getPurchaseOrder"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.GetByID(ctx, orderID)
+}
+
+func (p PurchaseOrders) Send(ctx context.Context, orderID string) (*models.PurchaseOrder, error) {
+	if err := authorize(ctx, models.PermissionManagePurchaseOrders, "sendPurchaseOrder"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Send(ctx, orderID)
+}
+
+// Receive changes the stock and the average cost of the received medicines.
+func (p PurchaseOrders) Receive(
+	ctx context.Context, orderID string, receiptRequest models.GoodsReceiptRequest,
+) (*models.PurchaseOrder, error) {
+	if err := authorize(ctx, models.PermissionReceivePurchaseOrder, "goodsReceipt"); err != nil {
+		return nil, err
+	}
+
+	return p.Usecase.Receive(ctx, orderID, receiptRequest)
+}
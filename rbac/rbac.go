@@ -0,0 +1,33 @@
+// Package rbac wraps the usecases exposed by the transport layer checking that the role
+// of the authenticated caller grants the permission each operation requires.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+// authorize fails with a 403 CustomError when the caller's role does not grant the permission.
+func authorize(ctx context.Context, permission, operation string) error {
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return models.CustomError{
+			Err:      fmt.Errorf("%s: the request is not authenticated", operation),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "0a2f8b6f-b4cc-4fcb-8e6b-a2032db2cca2",
+		}
+	}
+
+	if !models.HasPermission(user.Role, permission) {
+		return models.CustomError{
+			Err:      fmt.Errorf("%s: role [%s] does not have the [%s] permission", operation, user.Role, permission),
+			HTTPCode: http.StatusForbidden,
+			Code:     "41ee3af6-af8e-45df-92e0-906e6c48d1ec",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+var roles = []string{models.RoleCashier, models.RolePharmacist, models.RoleManager, models.RoleAdmin}
+
+// errDelegated is returned by the fake usecases, telling the wrapper let the call through.
+var errDelegated = errors.New("delegated to the usecase")
+
+type fakeBranches struct {
+	transport.BranchesUsecase
+}
+
+func (fakeBranches) Create(context.Context, models.BranchCreationRequest) (*models.BranchCreationResponse, error) {
+	return nil, errDelegated
+}
+
+func (fakeBranches) Get(context.Context) ([]models.Branch, error) {
+	return nil, errDelegated
+}
+
+func (fakeBranches) GetByID(context.Context, string) (*models.Branch, error) {
+	return nil, errDelegated
+}
+
+type fakeSuppliers struct {
+	transport.SuppliersUsecase
+}
+
+func (fakeSuppliers) Create(context.Context, models.SupplierCreationRequest) (*models.SupplierCreationResponse, error) {
+	return nil, errDelegated
+}
+
+func (fakeSuppliers) Get(context.Context) ([]models.Supplier, error) {
+	return nil, errDelegated
+}
+
+func (fakeSuppliers) GetByID(context.Context, string) (*models.Supplier, error) {
+	return nil, errDelegated
+}
+
+type fakePurchaseOrders struct {
+	transport.PurchaseOrdersUsecase
+}
+
+func (fakePurchaseOrders) Create(context.Context, models.PurchaseOrderCreationRequest) (*models.PurchaseOrderCreationResponse, error) {
+	return nil, errDelegated
+}
+
+func (fakePurchaseOrders) Get(context.Context, string) ([]models.PurchaseOrder, error) {
+	return nil, errDelegated
+}
+
+func (fakePurchaseOrders) GetByID(context.Context, string) (*models.PurchaseOrder, error) {
+	return nil, errDelegated
+}
+
+func (fakePurchaseOrders) Send(context.Context, string) (*models.PurchaseOrder, error) {
+	return nil, errDelegated
+}
+
+func (fakePurchaseOrders) Receive(context.Context, string, models.GoodsReceiptRequest) (*models.PurchaseOrder, error) {
+	return nil, errDelegated
+}
+
+type fakeReorders struct {
+	transport.ReordersUsecase
+}
+
+func (fakeReorders) Report(context.Context, string, string, string) ([]models.ReorderSuggestion, error) {
+	return nil, errDelegated
+}
+
+func (fakeReorders) GenerateDraft(context.Context) (*models.ReorderDraft, error) {
+	return nil, errDelegated
+}
+
+func (fakeReorders) GetLatestDraft(context.Context) (*models.ReorderDraft, error) {
+	return nil, errDelegated
+}
+
+type fakeTransfers struct {
+	transport.TransfersUsecase
+}
+
+func (fakeTransfers) Create(context.Context, models.StockTransferCreationRequest) (*models.StockTransferCreationResponse, error) {
+	return nil, errDelegated
+}
+
+func (fakeTransfers) Get(context.Context, string) ([]models.StockTransfer, error) {
+	return nil, errDelegated
+}
+
+func (fakeTransfers) GetInTransit(context.Context) ([]models.StockTransfer, error) {
+	return nil, errDelegated
+}
+
+func (fakeTransfers) GetByID(context.Context, string) (*models.StockTransfer, error) {
+	return nil, errDelegated
+}
+
+func (fakeTransfers) Ship(context.Context, string) (*models.StockTransfer, error) {
+	return nil, errDelegated
+}
+
+func (fakeTransfers) Receive(context.Context, string, models.StockTransferReceiptRequest) (*models.StockTransfer, error) {
+	return nil, errDelegated
+}
+
+type fakeInventoryCounts struct {
+	transport.InventoryCountsUsecase
+}
+
+func (fakeInventoryCounts) Create(context.Context, models.InventoryCountCreationRequest) (*models.InventoryCountCreationResponse, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) Get(context.Context, string) ([]models.InventoryCount, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) GetByID(context.Context, string) (*models.InventoryCount, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) GetVariance(context.Context, string) (*models.InventoryVarianceReport, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) Record(context.Context, string, models.InventoryCountRecordRequest) (*models.InventoryCount, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) Approve(context.Context, string) (*models.InventoryVarianceReport, error) {
+	return nil, errDelegated
+}
+
+func (fakeInventoryCounts) Cancel(context.Context, string) (*models.InventoryCount, error) {
+	return nil, errDelegated
+}
+
+// TestStockOperationsDenyByDefault checks every operation is denied to the roles it is
+// not granted to and to unauthenticated requests, before reaching the usecase.
+func TestStockOperationsDenyByDefault(t *testing.T) {
+	branches := NewBranches(fakeBranches{})
+	suppliers := NewSuppliers(fakeSuppliers{})
+	orders := NewPurchaseOrders(fakePurchaseOrders{})
+	reorders := NewReorders(fakeReorders{})
+	transfers := NewTransfers(fakeTransfers{})
+	counts := NewInventoryCounts(fakeInventoryCounts{})
+
+	everyone := roles
+	stock := []string{models.RolePharmacist, models.RoleManager, models.RoleAdmin}
+	managers := []string{models.RoleManager, models.RoleAdmin}
+
+	tests := []struct {
+		operation string
+		allowed   []string
+		call      func(ctx context.Context) error
+	}{
+		{"createBranch", []string{models.RoleAdmin}, func(ctx context.Context) error {
+			_, err := branches.Create(ctx, models.BranchCreationRequest{})
+			return err
+		}},
+		{"getBranches", everyone, func(ctx context.Context) error {
+			_, err := branches.Get(ctx)
+			return err
+		}},
+		{"getBranch", everyone, func(ctx context.Context) error {
+			_, err := branches.GetByID(ctx, "1")
+			return err
+		}},
+		{"createSupplier", managers, func(ctx context.Context) error {
+			_, err := suppliers.Create(ctx, models.SupplierCreationRequest{})
+			return err
+		}},
+		{"getSuppliers", stock, func(ctx context.Context) error {
+			_, err := suppliers.Get(ctx)
+			return err
+		}},
+		{"getSupplier", stock, func(ctx context.Context) error {
+			_, err := suppliers.GetByID(ctx, "1")
+			return err
+		}},
+		{"createPurchaseOrder", managers, func(ctx context.Context) error {
+			_, err := orders.Create(ctx, models.PurchaseOrderCreationRequest{})
+			return err
+		}},
+		{"getPurchaseOrders", stock, func(ctx context.Context) error {
+			_, err := orders.Get(ctx, "")
+			return err
+		}},
+		{"getPurchaseOrder", stock, func(ctx context.Context) error {
+			_, err := orders.GetByID(ctx, "1")
+			return err
+		}},
+		{"sendPurchaseOrder", managers, func(ctx context.Context) error {
+			_, err := orders.Send(ctx, "1")
+			return err
+		}},
+		{"goodsReceipt", stock, func(ctx context.Context) error {
+			_, err := orders.Receive(ctx, "1", models.GoodsReceiptRequest{})
+			return err
+		}},
+		{"reorderReport", managers, func(ctx context.Context) error {
+			_, err := reorders.Report(ctx, "", "", "")
+			return err
+		}},
+		{"generateReorderDraft", managers, func(ctx context.Context) error {
+			_, err := reorders.GenerateDraft(ctx)
+			return err
+		}},
+		{"getReorderDraft", stock, func(ctx context.Context) error {
+			_, err := reorders.GetLatestDraft(ctx)
+			return err
+		}},
+		{"createTransfer", managers, func(ctx context.Context) error {
+			_, err := transfers.Create(ctx, models.StockTransferCreationRequest{})
+			return err
+		}},
+		{"getTransfers", stock, func(ctx context.Context) error {
+			_, err := transfers.Get(ctx, "")
+			return err
+		}},
+		{"getTransfersInTransit", stock, func(ctx context.Context) error {
+			_, err := transfers.GetInTransit(ctx)
+			return err
+		}},
+		{"getTransfer", stock, func(ctx context.Context) error {
+			_, err := transfers.GetByID(ctx, "1")
+			return err
+		}},
+		{"shipTransfer", managers, func(ctx context.Context) error {
+			_, err := transfers.Ship(ctx, "1")
+			return err
+		}},
+		{"receiveTransfer", stock, func(ctx context.Context) error {
+			_, err := transfers.Receive(ctx, "1", models.StockTransferReceiptRequest{})
+			return err
+		}},
+		{"createInventoryCount", managers, func(ctx context.Context) error {
+			_, err := counts.Create(ctx, models.InventoryCountCreationRequest{})
+			return err
+		}},
+		{"getInventoryCounts", stock, func(ctx context.Context) error {
+			_, err := counts.Get(ctx, "")
+			return err
+		}},
+		{"getInventoryCount", stock, func(ctx context.Context) error {
+			_, err := counts.GetByID(ctx, "1")
+			return err
+		}},
+		{"getInventoryVariance", stock, func(ctx context.Context) error {
+			_, err := counts.GetVariance(ctx, "1")
+			return err
+		}},
+		{"recordInventoryCount", stock, func(ctx context.Context) error {
+			_, err := counts.Record(ctx, "1", models.InventoryCountRecordRequest{})
+			return err
+		}},
+		{"approveInventoryCount", managers, func(ctx context.Context) error {
+			_, err := counts.Approve(ctx, "1")
+			return err
+		}},
+		{"cancelInventoryCount", managers, func(ctx context.Context) error {
+			_, err := counts.Cancel(ctx, "1")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			assertDenied(t, "unauthenticated", tt.call(context.Background()), http.StatusUnauthorized)
+
+			for _, role := range roles {
+				ctx := models.ContextWithUser(context.Background(), models.AuthenticatedUser{ID: 1, Role: role})
+				err := tt.call(ctx)
+				if slices.Contains(tt.allowed, role) {
+					if !errors.Is(err, errDelegated) {
+						t.Errorf("%s: got error %v, want the call to reach the usecase", role, err)
+					}
+					continue
+				}
+				assertDenied(t, role, err, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func assertDenied(t *testing.T, caller string, err error, wantCode int) {
+	t.Helper()
+
+	var customErr models.CustomError
+	if !errors.As(err, &customErr) || customErr.HTTPCode != wantCode {
+		t.Errorf("%s: got error %v, want a %d before reaching the usecase", caller, err, wantCode)
+	}
+}
@@ -0,0 +1,44 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Reorders struct {
+	Usecase transport.ReordersUsecase
+}
+
+func NewReorders(ruc transport.ReordersUsecase) Reorders {
+	return Reorders{
+		Usecase: ruc,
+	}
+}
+
+func (r Reorders) Report(ctx context.Context, windowDays, leadTimeDays, safetyStockDays string) ([]models.ReorderSuggestion, error) {
+	if err := authorize(ctx, models.PermissionViewReports, "reorderReport"); err != nil {
+		return nil, err
+	}
+
+	return r.Usecase.Report(ctx, windowDays, leadTimeDays, safetyStockDays)
+}
+
+// GenerateDraft and GetLatestDraft handle the list of medicines to order, so they
+// require the purchase orders permissions rather than the reports one.
+func (r Reorders) GenerateDraft(ctx context.Context) (*models.ReorderDraft, error) {
+	if err := authorize(ctx, models.PermissionManagePurchaseOrders, "generateReorderDraft"); err != nil {
+		return nil, err
+	}
+
+	return r.Usecase.GenerateDraft(ctx)
+}
+
+func (r Reorders) GetLatestDraft(ctx context.Context) (*models.ReorderDraft, error) {
+	if err := authorize(ctx, models.PermissionViewPurchaseOrders, "getReorderDraft"); err != nil {
+		return nil, err
+	}
+
+	return r.Usecase.GetLatestDraft(ctx)
+}
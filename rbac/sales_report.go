@@ -0,0 +1,34 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type SalesReports struct {
+	Usecase transport.SalesReportsUsecase
+}
+
+func NewSalesReports(sruc transport.SalesReportsUsecase) SalesReports {
+	return SalesReports{
+		Usecase: sruc,
+	}
+}
+
+func (sr SalesReports) Summary(ctx context.Context, from, to, groupBy, breakdown string) ([]models.SalesSummary, error) {
+	if err := authorize(ctx, models.PermissionViewReports, "salesSummary"); err != nil {
+		return nil, err
+	}
+
+	return sr.Usecase.Summary(ctx, from, to, groupBy, breakdown)
+}
+
+func (sr SalesReports) Medicines(ctx context.Context, from, to, slowMoverDays, sortBy string) ([]models.MedicineRanking, error) {
+	if err := authorize(ctx, models.PermissionViewReports, "medicinesRanking"); err != nil {
+		return nil, err
+	}
+
+	return sr.Usecase.Medicines(ctx, from, to, slowMoverDays, sortBy)
+}
@@ -0,0 +1,42 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Suppliers struct {
+	Usecase transport.SuppliersUsecase
+}
+
+func NewSuppliers(suc transport.SuppliersUsecase) Suppliers {
+	return Suppliers{
+		Usecase: suc,
+	}
+}
+
+func (s Suppliers) Create(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.SupplierCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageSuppliers, "createSupplier"); err != nil {
+		return nil, err
+	}
+
+	return s.Usecase.Create(ctx, supplierRequest)
+}
+
+func (s Suppliers) Get(ctx context.Context) ([]models.Supplier, error) {
+	if err := authorize(ctx, models.PermissionViewSuppliers, "getSuppliers"); err != nil {
+		return nil, err
+	}
+
+	return s.Usecase.Get(ctx)
+}
+
+func (s Suppliers) GetByID(ctx context.Context, supplierID string) (*models.Supplier, error) {
+	if err := authorize(ctx, models.PermissionViewSuppliers, "getSupplier"); err != nil {
+		return nil, err
+	}
+
+	return s.Usecase.GetByID(ctx, supplierID)
+}
@@ -0,0 +1,70 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+type Transfers struct {
+	Usecase transport.TransfersUsecase
+}
+
+func NewTransfers(tuc transport.TransfersUsecase) Transfers {
+	return Transfers{
+		Usecase: tuc,
+	}
+}
+
+func (t Transfers) Create(
+	ctx context.Context, transferRequest models.StockTransferCreationRequest,
+) (*models.StockTransferCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageTransfers, "createTransfer"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.Create(ctx, transferRequest)
+}
+
+func (t Transfers) Get(ctx context.Context, status string) ([]models.StockTransfer, error) {
+	if err := authorize(ctx, models.PermissionViewTransfers, "getTransfers"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.Get(ctx, status)
+}
+
+func (t Transfers) GetInTransit(ctx context.Context) ([]models.StockTransfer, error) {
+	if err := authorize(ctx, models.PermissionViewTransfers, "getTransfersInTransit"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.GetInTransit(ctx)
+}
+
+func (t Transfers) GetByID(ctx context.Context, transferID string) (*models.StockTransfer, error) {
+	if err := authorize(ctx, models.PermissionViewTransfers, "getTransfer"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.GetByID(ctx, transferID)
+}
+
+func (t Transfers) Ship(ctx context.Context, transferID string) (*models.StockTransfer, error) {
+	if err := authorize(ctx, models.PermissionManageTransfers, "shipTransfer"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.Ship(ctx, transferID)
+}
+
+func (t Transfers) Receive(
+	ctx context.Context, transferID string, receiptRequest models.StockTransferReceiptRequest,
+) (*models.StockTransfer, error) {
+	if err := authorize(ctx, models.PermissionReceiveTransfer, "receiveTransfer"); err != nil {
+		return nil, err
+	}
+
+	return t.Usecase.Receive(ctx, transferID, receiptRequest)
+}
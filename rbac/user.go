@@ -0,0 +1,28 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/transport"
+)
+
+// Auth only guards the creation of users, the rest of the operations are available to
+// anyone (login) or to any authenticated caller (logout).
+type Auth struct {
+	transport.AuthUsecase
+}
+
+func NewAuth(auc transport.AuthUsecase) Auth {
+	return Auth{
+		AuthUsecase: auc,
+	}
+}
+
+func (a Auth) CreateUser(ctx context.Context, userRequest models.UserCreationRequest) (*models.UserCreationResponse, error) {
+	if err := authorize(ctx, models.PermissionManageUsers, "createUser"); err != nil {
+		return nil, err
+	}
+
+	return a.AuthUsecase.CreateUser(ctx, userRequest)
+}
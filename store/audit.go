@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableAuditLog = "audit_log"
+	// auditLockKey is the advisory lock that serializes the writers of the audit chain.
+	auditLockKey = 7305014
+)
+
+type Audit struct {
+	db *sqlx.DB
+}
+
+func NewAudit(db *sqlx.DB) Audit {
+	return Audit{
+		db: db,
+	}
+}
+
+// registerAudit appends an entry for the change to the audit chain within the given
+// transaction. The actor and the request are taken from ctx; before and after are
+// the snapshots of the entity, nil when it did not exist.
+func registerAudit(ctx context.Context, tx *sql.Tx, entity string, entityID int64, action string, before, after interface{}) error {
+	getLastHashSQL := fmt.Sprintf(`
+	SELECT hash FROM %s ORDER BY id desc LIMIT 1
+	`, tableAuditLog)
+
+	createEntrySQL := fmt.Sprintf(`
+	INSERT INTO %s (
+		entity, entity_id, action, actor_id, actor_username, request_id, client_ip,
+		before_data, after_data, prev_hash, hash, created_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+	`, tableAuditLog)
+
+	user, _ := models.UserFromContext(ctx)
+	requestInfo := models.RequestInfoFromContext(ctx)
+	entry := models.AuditEntry{
+		Entity:        entity,
+		EntityID:      entityID,
+		Action:        action,
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+		RequestID:     requestInfo.RequestID,
+		ClientIP:      requestInfo.ClientIP,
+		// the database keeps microseconds, the hash must be computed over the same value
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	var err error
+	if entry.Before, err = auditSnapshot(before); err != nil {
+		return fmt.Errorf("could not encode audit snapshot of %s [%d]: %w", entity, entityID, err)
+	}
+	if entry.After, err = auditSnapshot(after); err != nil {
+		return fmt.Errorf("could not encode audit snapshot of %s [%d]: %w", entity, entityID, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+		return fmt.Errorf("could not lock the audit log: %w", err)
+	}
+	if err := tx.QueryRowContext(ctx, getLastHashSQL).Scan(&entry.PrevHash); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("could not read the last audit entry: %w", err)
+	}
+	entry.Hash = entry.ComputeHash()
+
+	_, err = tx.ExecContext(
+		ctx,
+		createEntrySQL,
+		entry.Entity,
+		entry.EntityID,
+		entry.Action,
+		sql.NullInt64{Int64: entry.ActorID, Valid: entry.ActorID > 0},
+		nullString(entry.ActorUsername),
+		nullString(entry.RequestID),
+		nullString(entry.ClientIP),
+		nullString(string(entry.Before)),
+		nullString(string(entry.After)),
+		entry.PrevHash,
+		entry.Hash,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("could not register audit entry of %s [%d]: %w", entity, entityID, err)
+	}
+
+	return nil
+}
+
+func auditSnapshot(snapshot interface{}) (json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// GetEntries returns the entries matching the filter, oldest first.
+func (as Audit) GetEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	getEntriesSQL := fmt.Sprintf(`
+	SELECT id, entity, entity_id, action, actor_id, actor_username, request_id, client_ip,
+		before_data, after_data, prev_hash, hash, created_at
+	FROM %s
+	WHERE ($1 = '' OR entity = $1)
+	AND ($2 = 0 OR entity_id = $2)
+	AND ($3::timestamptz IS NULL OR created_at >= $3)
+	AND ($4::timestamptz IS NULL OR created_at <= $4)
+	ORDER BY id asc
+	`, tableAuditLog)
+
+	rows, err := as.db.QueryContext(
+		ctx,
+		getEntriesSQL,
+		filter.Entity,
+		filter.EntityID,
+		sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+
+	return scanAuditEntries(ctx, rows)
+}
+
+// GetChain returns every entry, in chain order, to verify it.
+func (as Audit) GetChain(ctx context.Context) ([]models.AuditEntry, error) {
+	return as.GetEntries(ctx, models.AuditFilter{})
+}
+
+func scanAuditEntries(ctx context.Context, rows *sql.Rows) ([]models.AuditEntry, error) {
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			entry         models.AuditEntry
+			actorID       sql.NullInt64
+			actorUsername sql.NullString
+			requestID     sql.NullString
+			clientIP      sql.NullString
+			before        sql.NullString
+			after         sql.NullString
+		)
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Entity,
+			&entry.EntityID,
+			&entry.Action,
+			&actorID,
+			&actorUsername,
+			&requestID,
+			&clientIP,
+			&before,
+			&after,
+			&entry.PrevHash,
+			&entry.Hash,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting audit entries: %w", err)
+		}
+		entry.ActorID = actorID.Int64
+		entry.ActorUsername = actorUsername.String
+		entry.RequestID = requestID.String
+		entry.ClientIP = clientIP.String
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/VictorDelgado94/aveonline-backend/logging"
 	"github.com/VictorDelgado94/aveonline-backend/models"
 	"github.com/jmoiron/sqlx"
 )
@@ -26,14 +26,17 @@ func NewBilling(db *sqlx.DB) Billing {
 	}
 }
 
-func (b Billing) GetBillingsByDates(ctx context.Context, startDate, endDate time.Time) ([]models.Billing, error) {
+// GetBillingsByDates returns the billings created between the dates, only those of the
+// given branch when branchID is not zero.
+func (b Billing) GetBillingsByDates(ctx context.Context, startDate, endDate time.Time, branchID int64) ([]models.Billing, error) {
 	getBillingsBetweenDatesSQL := fmt.Sprintf(`
-	SELECT id, total, created_at
+	SELECT id, branch_id, total, version, created_at
 	FROM %s
 	WHERE created_at BETWEEN $1 AND $2 AND deleted_at IS NULL
+	AND ($3 = 0 OR branch_id = $3)
 	`, tableBilling)
 
-	rows, err := b.db.QueryContext(ctx, getBillingsBetweenDatesSQL, startDate, endDate)
+	rows, err := b.db.QueryContext(ctx, getBillingsBetweenDatesSQL, startDate, endDate, branchID)
 	if err != nil {
 		return nil, fmt.Errorf("error while building query: %w", err)
 	}
@@ -41,24 +44,28 @@ func (b Billing) GetBillingsByDates(ctx context.Context, startDate, endDate time
 		errClose := rows.Close()
 		errRows := rows.Err()
 		if errClose != nil || errRows != nil {
-			log.Printf("something went wrong while closing rows: %v, %v", errClose, errRows)
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
 		}
 	}()
 	billings := make([]models.Billing, 0)
 	for rows.Next() {
 		var (
 			id        int64
+			branchID  int64
 			total     float64
+			version   int64
 			createdAt sql.NullTime
 		)
-		if err := rows.Scan(&id, &total, &createdAt); err != nil {
+		if err := rows.Scan(&id, &branchID, &total, &version, &createdAt); err != nil {
 			return nil, fmt.Errorf("error getting billings between dates: %w", err)
 		}
 		billings = append(
 			billings,
 			models.Billing{
 				ID:        id,
+				BranchID:  branchID,
 				Total:     total,
+				Version:   version,
 				CreatedAt: createdAt.Time,
 			},
 		)
@@ -69,20 +76,24 @@ func (b Billing) GetBillingsByDates(ctx context.Context, startDate, endDate time
 
 func (b Billing) GetBillingByID(ctx context.Context, billingID int64) (*models.BillingDetail, error) {
 	getBillingSQL := fmt.Sprintf(`
-	SELECT promotion_id, total, created_at
+	SELECT branch_id, promotion_id, total, version, created_at
 	FROM %s
 	WHERE id = $1 AND deleted_at IS NULL
 	`, tableBilling)
 
 	row := b.db.QueryRowContext(ctx, getBillingSQL, billingID)
 	var (
+		branchID    int64
 		promotionID sql.NullInt64
 		total       float64
+		version     int64
 		createdAt   sql.NullTime
 	)
 	if err := row.Scan(
+		&branchID,
 		&promotionID,
 		&total,
+		&version,
 		&createdAt,
 	); err != nil {
 		if err == sql.ErrNoRows {
@@ -93,7 +104,9 @@ func (b Billing) GetBillingByID(ctx context.Context, billingID int64) (*models.B
 
 	billing := &models.BillingDetail{
 		ID:        billingID,
+		BranchID:  branchID,
 		Total:     total,
+		Version:   version,
 		CreatedAt: createdAt.Time,
 	}
 
@@ -103,10 +116,18 @@ func (b Billing) GetBillingByID(ctx context.Context, billingID int64) (*models.B
 		return nil, fmt.Errorf("error reading billing's promotion: %w", err)
 	}
 
-	billing.Medicines, err = b.getBillingDetail(ctx, billingID)
+	billing.Items, err = b.getBillingDetail(ctx, billingID)
 	if err != nil {
 		return nil, fmt.Errorf("error reading billing's medicines: %w", err)
 	}
+	billing.Medicines = make([]models.Medicine, 0, len(billing.Items))
+	for _, item := range billing.Items {
+		billing.Medicines = append(billing.Medicines, models.Medicine{
+			ID:    item.MedicineID,
+			Name:  item.MedicineName,
+			Price: item.UnitPrice,
+		})
+	}
 
 	return billing, nil
 }
@@ -152,11 +173,12 @@ func (b Billing) getPromotion(ctx context.Context, promoID int64) (models.Promot
 	}, nil
 }
 
-func (b Billing) getBillingDetail(ctx context.Context, billingID int64) ([]models.Medicine, error) {
+func (b Billing) getBillingDetail(ctx context.Context, billingID int64) ([]models.BillingItem, error) {
 	getBillingDetailSQL := fmt.Sprintf(`
-	SELECT medicine_id, medicine_name, medicine_price
+	SELECT medicine_id, medicine_name, medicine_price, presentation_id, presentation_name, quantity, base_quantity
 	FROM %s
 	WHERE billing_id = $1 AND deleted_at IS NULL
+	ORDER BY id asc
 	`, tableBillingDetail)
 
 	rows, err := b.db.QueryContext(ctx, getBillingDetailSQL, billingID)
@@ -167,57 +189,81 @@ func (b Billing) getBillingDetail(ctx context.Context, billingID int64) ([]model
 		errClose := rows.Close()
 		errRows := rows.Err()
 		if errClose != nil || errRows != nil {
-			log.Printf("something went wrong while closing rows: %v, %v", errClose, errRows)
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
 		}
 	}()
-	medicines := make([]models.Medicine, 0)
+	items := make([]models.BillingItem, 0)
 	for rows.Next() {
 		var (
-			medicineID    int64
-			medicineName  string
-			medicinePrice float64
+			medicineID       int64
+			medicineName     string
+			medicinePrice    float64
+			presentationID   sql.NullInt64
+			presentationName sql.NullString
+			quantity         int64
+			baseQuantity     int64
 		)
-		if err := rows.Scan(&medicineID, &medicineName, &medicinePrice); err != nil {
+		if err := rows.Scan(
+			&medicineID,
+			&medicineName,
+			&medicinePrice,
+			&presentationID,
+			&presentationName,
+			&quantity,
+			&baseQuantity,
+		); err != nil {
 			return nil, fmt.Errorf("error getting billings medicines: %w", err)
 		}
-		medicines = append(
-			medicines,
-			models.Medicine{
-				ID:    medicineID,
-				Name:  medicineName,
-				Price: medicinePrice,
+		items = append(
+			items,
+			models.BillingItem{
+				MedicineID:       medicineID,
+				MedicineName:     medicineName,
+				PresentationID:   presentationID.Int64,
+				PresentationName: presentationName.String,
+				Quantity:         quantity,
+				BaseQuantity:     baseQuantity,
+				UnitPrice:        medicinePrice,
+				Subtotal:         medicinePrice * float64(quantity),
 			},
 		)
 	}
 
-	return medicines, nil
+	return items, nil
 }
 
 func (b Billing) CreateBilling(ctx context.Context, billing models.BillingDetail) (*models.BillingDetail, error) {
 	createBillingSQL := fmt.Sprintf(`
-	INSERT INTO %s (promotion_id, total, created_at, updated_at)
-	VALUES ($1, $2, $3, $4) RETURNING id;
+	INSERT INTO %s (branch_id, promotion_id, total, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id;
 	`, tableBilling)
 
 	createBillingDetailSQL := fmt.Sprintf(`
-	INSERT INTO %s (billing_id, medicine_id, medicine_name, medicine_price, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6);
+	INSERT INTO %s (
+		billing_id, medicine_id, medicine_name, medicine_price,
+		presentation_id, presentation_name, quantity, base_quantity,
+		created_at, updated_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
 	`, tableBillingDetail)
 
-	tx, err := b.db.Begin()
+	tx, err := b.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("createBilling: could not begin transaction")
+		return nil, fmt.Errorf("createBilling: could not begin transaction: %w", err)
+	}
+
+	if err := checkBranchStock(ctx, tx, billing.BranchID, billing.BaseQuantities()); err != nil {
+		return nil, rollback(tx, fmt.Errorf("createBilling: %w", err))
 	}
 
 	var billingID int64
 	var promoID sql.NullInt64
-	fmt.Println("billing.Promotion", billing.Promotion)
 	if billing.Promotion.ID > 0 {
 		promoID.Int64 = billing.Promotion.ID
 		promoID.Valid = true
 	}
 	now := time.Now().UTC()
-	err = tx.QueryRowContext(ctx, createBillingSQL, promoID, billing.Total, billing.CreatedAt, now).Scan(&billingID)
+	err = tx.QueryRowContext(ctx, createBillingSQL, billing.BranchID, promoID, billing.Total, billing.CreatedAt, now).Scan(&billingID)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return nil, fmt.Errorf("createBilling: could not rollback transaction: %w", err)
@@ -225,14 +271,53 @@ func (b Billing) CreateBilling(ctx context.Context, billing models.BillingDetail
 		return nil, fmt.Errorf("createBilling: could not create billing within db: %w", err)
 	}
 
-	for _, m := range billing.Medicines {
-		_, err := tx.ExecContext(ctx, createBillingDetailSQL, billingID, m.ID, m.Name, m.Price, now, now)
+	for _, item := range billing.Items {
+		var presentationID sql.NullInt64
+		var presentationName sql.NullString
+		if item.PresentationID > 0 {
+			presentationID = sql.NullInt64{Int64: item.PresentationID, Valid: true}
+			presentationName = sql.NullString{String: item.PresentationName, Valid: true}
+		}
+		_, err := tx.ExecContext(
+			ctx,
+			createBillingDetailSQL,
+			billingID,
+			item.MedicineID,
+			item.MedicineName,
+			item.UnitPrice,
+			presentationID,
+			presentationName,
+			item.Quantity,
+			item.BaseQuantity,
+			now,
+			now,
+		)
 		if err != nil {
 			if err := tx.Rollback(); err != nil {
 				return nil, fmt.Errorf("createBilling: could not rollback transaction: %w", err)
 			}
 			return nil, fmt.Errorf("createBilling: could not create billing detail within db: %w", err)
 		}
+
+		err = registerStockMovement(ctx, tx, models.StockMovement{
+			MedicineID:   item.MedicineID,
+			BranchID:     billing.BranchID,
+			Quantity:     -item.BaseQuantity,
+			MovementType: models.StockMovementSale,
+			ReferenceID:  billingID,
+		})
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, fmt.Errorf("createBilling: could not rollback transaction: %w", err)
+			}
+			return nil, fmt.Errorf("createBilling: %w", err)
+		}
+	}
+
+	billing.ID = billingID
+	billing.Version = 1
+	if err := registerAudit(ctx, tx, models.AuditEntityBilling, billingID, models.AuditActionCreate, nil, billing); err != nil {
+		return nil, rollback(tx, fmt.Errorf("createBilling: %w", err))
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableBranch = "branch"
+)
+
+type Branches struct {
+	db *sqlx.DB
+}
+
+func NewBranches(db *sqlx.DB) Branches {
+	return Branches{
+		db: db,
+	}
+}
+
+func (bs Branches) GetAll(ctx context.Context) ([]models.Branch, error) {
+	getAllBranchesSQL := fmt.Sprintf(`
+	SELECT id, name, address, created_at
+	FROM %s
+	WHERE deleted_at IS NULL
+	ORDER BY id asc
+	`, tableBranch)
+
+	rows, err := bs.db.QueryContext(ctx, getAllBranchesSQL)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	branches := make([]models.Branch, 0)
+	for rows.Next() {
+		branch, err := scanBranch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting branches: %w", err)
+		}
+		branches = append(branches, branch)
+	}
+
+	return branches, nil
+}
+
+func (bs Branches) GetBranchByID(ctx context.Context, branchID int64) (*models.Branch, error) {
+	getBranchSQL := fmt.Sprintf(`
+	SELECT id, name, address, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tableBranch)
+
+	branch, err := scanBranch(bs.db.QueryRowContext(ctx, getBranchSQL, branchID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading branch row: %w", err)
+	}
+
+	return &branch, nil
+}
+
+func (bs Branches) CreateBranch(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.Branch, error) {
+	createBranchSQL := fmt.Sprintf(`
+	INSERT INTO %s (name, address, created_at, updated_at)
+	VALUES ($1, $2, $3, $4) RETURNING id;
+	`, tableBranch)
+
+	now := time.Now().UTC()
+	var branchID int64
+	err := bs.db.QueryRowContext(ctx, createBranchSQL, branchRequest.Name, nullString(branchRequest.Address), now, now).Scan(&branchID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create branch record within db: %w", err)
+	}
+
+	return &models.Branch{
+		ID:        branchID,
+		Name:      branchRequest.Name,
+		Address:   branchRequest.Address,
+		CreatedAt: now,
+	}, nil
+}
+
+func scanBranch(row rowScanner) (models.Branch, error) {
+	var (
+		id        int64
+		name      string
+		address   sql.NullString
+		createdAt sql.NullTime
+	)
+	if err := row.Scan(&id, &name, &address, &createdAt); err != nil {
+		return models.Branch{}, err
+	}
+
+	return models.Branch{
+		ID:        id,
+		Name:      name,
+		Address:   address.String,
+		CreatedAt: createdAt.Time,
+	}, nil
+}
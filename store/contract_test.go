@@ -0,0 +1,30 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/store"
+	"github.com/VictorDelgado94/aveonline-backend/store/storetest"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := newTestStore(t).GetDB()
+
+		return storetest.Stores{
+			Billings:        store.NewBilling(db),
+			Promotions:      store.NewPromotions(db),
+			Medicines:       store.NewMedicine(db),
+			Branches:        store.NewBranches(db),
+			Suppliers:       store.NewSuppliers(db),
+			PurchaseOrders:  store.NewPurchaseOrders(db),
+			Reorders:        store.NewReorders(db),
+			SalesReports:    store.NewSalesReports(db),
+			Transfers:       store.NewTransfers(db),
+			InventoryCounts: store.NewInventoryCounts(db),
+			Users:           store.NewUsers(db),
+			Audit:           store.NewAudit(db),
+			Idempotency:     store.NewIdempotencyKeys(db),
+		}
+	})
+}
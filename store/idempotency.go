@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableIdempotencyKey = "idempotency_key"
+)
+
+type IdempotencyKeys struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyKeys(db *sqlx.DB) IdempotencyKeys {
+	return IdempotencyKeys{
+		db: db,
+	}
+}
+
+// Reserve stores the key as in progress when it is new or its previous use expired and
+// returns true. Otherwise it returns false along with the stored record.
+func (iks IdempotencyKeys) Reserve(ctx context.Context, record models.IdempotencyRecord) (bool, *models.IdempotencyRecord, error) {
+	reserveKeySQL := fmt.Sprintf(`
+	INSERT INTO %[1]s (user_id, idempotency_key, request_hash, status, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, idempotency_key) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash, status = EXCLUDED.status,
+		response_status = NULL, response_body = NULL, content_type = NULL,
+		created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+	WHERE %[1]s.expires_at <= EXCLUDED.created_at
+	RETURNING user_id
+	`, tableIdempotencyKey)
+
+	getKeySQL := fmt.Sprintf(`
+	SELECT user_id, idempotency_key, request_hash, status, response_status, response_body, content_type, created_at, expires_at
+	FROM %s
+	WHERE user_id = $1 AND idempotency_key = $2
+	`, tableIdempotencyKey)
+
+	var userID int64
+	err := iks.db.QueryRowContext(
+		ctx,
+		reserveKeySQL,
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+		models.IdempotencyInProgress,
+		record.CreatedAt,
+		record.ExpiresAt,
+	).Scan(&userID)
+	if err == nil {
+		return true, nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, nil, fmt.Errorf("reserveIdempotencyKey: could not reserve key within db: %w", err)
+	}
+
+	var (
+		stored         models.IdempotencyRecord
+		responseStatus sql.NullInt64
+		contentType    sql.NullString
+	)
+	err = iks.db.QueryRowContext(ctx, getKeySQL, record.UserID, record.Key).Scan(
+		&stored.UserID,
+		&stored.Key,
+		&stored.RequestHash,
+		&stored.Status,
+		&responseStatus,
+		&stored.ResponseBody,
+		&contentType,
+		&stored.CreatedAt,
+		&stored.ExpiresAt,
+	)
+	if err != nil {
+		return false, nil, fmt.Errorf("reserveIdempotencyKey: could not read key within db: %w", err)
+	}
+	stored.ResponseStatus = int(responseStatus.Int64)
+	stored.ContentType = contentType.String
+
+	return false, &stored, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (iks IdempotencyKeys) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+	completeKeySQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, response_status = $2, response_body = $3, content_type = $4
+	WHERE user_id = $5 AND idempotency_key = $6 AND request_hash = $7
+	`, tableIdempotencyKey)
+
+	_, err := iks.db.ExecContext(
+		ctx,
+		completeKeySQL,
+		models.IdempotencyCompleted,
+		record.ResponseStatus,
+		record.ResponseBody,
+		nullString(record.ContentType),
+		record.UserID,
+		record.Key,
+		record.RequestHash,
+	)
+	if err != nil {
+		return fmt.Errorf("completeIdempotencyKey: could not store response within db: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes an in progress key so the request can be retried.
+func (iks IdempotencyKeys) Release(ctx context.Context, userID int64, key string) error {
+	releaseKeySQL := fmt.Sprintf(`
+	DELETE FROM %s WHERE user_id = $1 AND idempotency_key = $2 AND status = $3
+	`, tableIdempotencyKey)
+
+	if _, err := iks.db.ExecContext(ctx, releaseKeySQL, userID, key, models.IdempotencyInProgress); err != nil {
+		return fmt.Errorf("releaseIdempotencyKey: could not delete key within db: %w", err)
+	}
+
+	return nil
+}
+
+func (iks IdempotencyKeys) DeleteExpired(ctx context.Context) (int64, error) {
+	deleteExpiredSQL := fmt.Sprintf(`
+	DELETE FROM %s WHERE expires_at < $1
+	`, tableIdempotencyKey)
+
+	result, err := iks.db.ExecContext(ctx, deleteExpiredSQL, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("could not delete expired idempotency keys within db: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,405 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	tableInventoryCount     = "inventory_count"
+	tableInventoryCountLine = "inventory_count_line"
+)
+
+type InventoryCounts struct {
+	db *sqlx.DB
+}
+
+func NewInventoryCounts(db *sqlx.DB) InventoryCounts {
+	return InventoryCounts{
+		db: db,
+	}
+}
+
+// GetAll returns the counts without lines, filtered by status when it is not empty
+// and by branch when branchID is not zero.
+func (ics InventoryCounts) GetAll(ctx context.Context, status string, branchID int64) ([]models.InventoryCount, error) {
+	getAllCountsSQL := fmt.Sprintf(`
+	SELECT id, branch_id, count_type, status, notes, approved_at, created_at
+	FROM %s
+	WHERE deleted_at IS NULL AND ($1 = '' OR status = $1) AND ($2 = 0 OR branch_id = $2)
+	ORDER BY created_at desc
+	`, tableInventoryCount)
+
+	rows, err := ics.db.QueryContext(ctx, getAllCountsSQL, status, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	counts := make([]models.InventoryCount, 0)
+	for rows.Next() {
+		count, err := scanInventoryCount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting inventory counts: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+func (ics InventoryCounts) GetCountByID(ctx context.Context, countID int64) (*models.InventoryCount, error) {
+	getCountSQL := fmt.Sprintf(`
+	SELECT id, branch_id, count_type, status, notes, approved_at, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tableInventoryCount)
+
+	count, err := scanInventoryCount(ics.db.QueryRowContext(ctx, getCountSQL, countID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading inventory count row: %w", err)
+	}
+
+	count.Lines, err = ics.getLines(ctx, countID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inventory count lines: %w", err)
+	}
+
+	return &count, nil
+}
+
+func (ics InventoryCounts) getLines(ctx context.Context, countID int64) ([]models.InventoryCountLine, error) {
+	getLinesSQL := fmt.Sprintf(`
+	SELECT l.id, l.medicine_id, m.name, l.location, l.counted_quantity, l.system_quantity, l.unit_cost, l.counted_at
+	FROM %s l
+	JOIN %s m ON m.id = l.medicine_id
+	WHERE l.inventory_count_id = $1
+	ORDER BY l.location asc, m.name asc
+	`, tableInventoryCountLine, tableMedicine)
+
+	rows, err := ics.db.QueryContext(ctx, getLinesSQL, countID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	lines := make([]models.InventoryCountLine, 0)
+	for rows.Next() {
+		var (
+			line            models.InventoryCountLine
+			location        sql.NullString
+			countedQuantity sql.NullInt64
+			systemQuantity  sql.NullInt64
+			unitCost        sql.NullFloat64
+			countedAt       sql.NullTime
+		)
+		err := rows.Scan(
+			&line.ID,
+			&line.MedicineID,
+			&line.MedicineName,
+			&location,
+			&countedQuantity,
+			&systemQuantity,
+			&unitCost,
+			&countedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error getting inventory count lines: %w", err)
+		}
+		line.Location = location.String
+		if countedQuantity.Valid {
+			line.CountedQuantity = &countedQuantity.Int64
+		}
+		line.SystemQuantity = systemQuantity.Int64
+		line.UnitCost = unitCost.Float64
+		if countedAt.Valid {
+			line.CountedAt = &countedAt.Time
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// CreateCount opens a count with a line for each requested medicine, or for every medicine
+// of the catalog when it is a full count.
+func (ics InventoryCounts) CreateCount(ctx context.Context, countRequest models.InventoryCountCreationRequest) (*models.InventoryCount, error) {
+	createCountSQL := fmt.Sprintf(`
+	INSERT INTO %s (branch_id, count_type, status, notes, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tableInventoryCount)
+
+	createLinesSQL := fmt.Sprintf(`
+	INSERT INTO %s (inventory_count_id, medicine_id, location, created_at, updated_at)
+	SELECT $1, id, COALESCE(location, ''), $2, $2
+	FROM %s
+	WHERE deleted_at IS NULL AND (cardinality($3::integer[]) = 0 OR id = ANY($3))
+	`, tableInventoryCountLine, tableMedicine)
+
+	tx, err := ics.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createInventoryCount: could not begin transaction")
+	}
+
+	now := time.Now().UTC()
+	count := models.InventoryCount{
+		BranchID:  countRequest.BranchID,
+		CountType: countRequest.CountType,
+		Status:    models.InventoryCountOpen,
+		Notes:     countRequest.Notes,
+		CreatedAt: now,
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		createCountSQL,
+		count.BranchID,
+		count.CountType,
+		count.Status,
+		nullString(count.Notes),
+		now,
+		now,
+	).Scan(&count.ID)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("createInventoryCount: could not create inventory count within db: %w", err))
+	}
+
+	medicineIDs := countRequest.MedicineIDs
+	if count.CountType == models.InventoryCountFull {
+		medicineIDs = []int64{}
+	}
+	if _, err := tx.ExecContext(ctx, createLinesSQL, count.ID, now, pq.Int64Array(medicineIDs)); err != nil {
+		return nil, rollback(tx, fmt.Errorf("createInventoryCount: could not create inventory count lines within db: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createInventoryCount: could not commit transaction: %w", err)
+	}
+
+	return &count, nil
+}
+
+// RecordLines stores the counted units of the given medicines in their locations along with a
+// snapshot of the branch stock and the average cost at this moment. A location not counted yet
+// takes the line of the medicine still pending, or gets a new one. It fails with
+// models.ErrInvalidTransition when the count is not open.
+func (ics InventoryCounts) RecordLines(ctx context.Context, count models.InventoryCount, lines []models.InventoryCountRecordLineRequest) error {
+	lockCountSQL := fmt.Sprintf(`
+	SELECT status FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	FOR UPDATE
+	`, tableInventoryCount)
+
+	recordLocationSQL := fmt.Sprintf(`
+	UPDATE %s SET counted_quantity = $1, counted_at = $2, updated_at = $2
+	WHERE inventory_count_id = $3 AND medicine_id = $4 AND location = $5
+	`, tableInventoryCountLine)
+
+	recordPendingSQL := fmt.Sprintf(`
+	UPDATE %[1]s SET counted_quantity = $1, location = $5, counted_at = $2, updated_at = $2
+	WHERE id = (
+		SELECT id FROM %[1]s
+		WHERE inventory_count_id = $3 AND medicine_id = $4 AND counted_quantity IS NULL
+		ORDER BY id asc
+		LIMIT 1
+	)
+	`, tableInventoryCountLine)
+
+	recordNewLocationSQL := fmt.Sprintf(`
+	INSERT INTO %[1]s (inventory_count_id, medicine_id, location, counted_quantity, counted_at, created_at, updated_at)
+	SELECT $3, $4, $5::varchar, $1::integer, $2::timestamptz, $2, $2
+	WHERE EXISTS (SELECT 1 FROM %[1]s WHERE inventory_count_id = $3 AND medicine_id = $4)
+	`, tableInventoryCountLine)
+
+	// every location of the medicine shares the snapshot, so their sum is compared against it.
+	snapshotSQL := fmt.Sprintf(`
+	UPDATE %s SET
+		system_quantity = COALESCE((SELECT stock FROM %s WHERE branch_id = $1 AND medicine_id = $2), 0),
+		unit_cost = (SELECT average_cost FROM %s WHERE id = $2),
+		updated_at = $3
+	WHERE inventory_count_id = $4 AND medicine_id = $2
+	`, tableInventoryCountLine, tableMedicineStock, tableMedicine)
+
+	tx, err := ics.db.Begin()
+	if err != nil {
+		return fmt.Errorf("recordInventoryCount: could not begin transaction")
+	}
+
+	var status string
+	if err := tx.QueryRowContext(ctx, lockCountSQL, count.ID).Scan(&status); err != nil {
+		return rollback(tx, fmt.Errorf("recordInventoryCount: could not lock inventory count within db: %w", err))
+	}
+	if status != models.InventoryCountOpen {
+		return rollback(tx, fmt.Errorf("recordInventoryCount: %w", models.ErrInvalidTransition))
+	}
+
+	now := time.Now().UTC()
+	for _, line := range lines {
+		for _, recordSQL := range []string{recordLocationSQL, recordPendingSQL, recordNewLocationSQL} {
+			result, err := tx.ExecContext(ctx, recordSQL, line.CountedQuantity, now, count.ID, line.MedicineID, line.Location)
+			if err != nil {
+				return rollback(tx, fmt.Errorf("recordInventoryCount: could not record medicine [%d] within db: %w", line.MedicineID, err))
+			}
+			if recorded, err := result.RowsAffected(); err == nil && recorded > 0 {
+				break
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, snapshotSQL, count.BranchID, line.MedicineID, now, count.ID); err != nil {
+			return rollback(tx, fmt.Errorf("recordInventoryCount: could not take the snapshot of medicine [%d] within db: %w", line.MedicineID, err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("recordInventoryCount: could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Approve closes an open count and posts an adjustment movement for every medicine whose units
+// counted in all its locations differ from the system stock.
+// It fails with models.ErrInvalidTransition when the count is not open and with
+// models.ErrInventoryCountIncomplete when any medicine was not counted.
+func (ics InventoryCounts) Approve(ctx context.Context, count models.InventoryCount) error {
+	approveCountSQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, approved_at = $2, updated_at = $2
+	WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`, tableInventoryCount)
+
+	getVariancesSQL := fmt.Sprintf(`
+	SELECT medicine_id, bool_and(counted_quantity IS NOT NULL), COALESCE(SUM(counted_quantity), 0), COALESCE(MAX(system_quantity), 0)
+	FROM %s
+	WHERE inventory_count_id = $1
+	GROUP BY medicine_id
+	ORDER BY medicine_id asc
+	`, tableInventoryCountLine)
+
+	tx, err := ics.db.Begin()
+	if err != nil {
+		return fmt.Errorf("approveInventoryCount: could not begin transaction")
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, approveCountSQL, models.InventoryCountApproved, now, count.ID, models.InventoryCountOpen)
+	if err != nil {
+		return rollback(tx, fmt.Errorf("approveInventoryCount: could not update inventory count within db: %w", err))
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return rollback(tx, fmt.Errorf("approveInventoryCount: %w", models.ErrInvalidTransition))
+	}
+
+	// The lines are read again within the transaction, the count row is locked now so they
+	// can not be recorded again until it finishes.
+	rows, err := tx.QueryContext(ctx, getVariancesSQL, count.ID)
+	if err != nil {
+		return rollback(tx, fmt.Errorf("approveInventoryCount: could not read inventory count lines within db: %w", err))
+	}
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var (
+			medicineID      int64
+			counted         bool
+			countedQuantity int64
+			systemQuantity  int64
+		)
+		if err := rows.Scan(&medicineID, &counted, &countedQuantity, &systemQuantity); err != nil {
+			_ = rows.Close()
+			return rollback(tx, fmt.Errorf("approveInventoryCount: error reading inventory count lines: %w", err))
+		}
+		if !counted {
+			_ = rows.Close()
+			return rollback(tx, fmt.Errorf("approveInventoryCount: medicine [%d]: %w", medicineID, models.ErrInventoryCountIncomplete))
+		}
+		if variance := countedQuantity - systemQuantity; variance != 0 {
+			movements = append(movements, models.StockMovement{
+				MedicineID:   medicineID,
+				BranchID:     count.BranchID,
+				Quantity:     variance,
+				MovementType: models.StockMovementAdjustment,
+				ReferenceID:  count.ID,
+			})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return rollback(tx, fmt.Errorf("approveInventoryCount: error closing inventory count lines: %w", err))
+	}
+
+	for _, movement := range movements {
+		if err := registerStockMovement(ctx, tx, movement); err != nil {
+			return rollback(tx, fmt.Errorf("approveInventoryCount: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("approveInventoryCount: could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Cancel discards an open count without touching the stock. It fails with
+// models.ErrInvalidTransition when the count is not open.
+func (ics InventoryCounts) Cancel(ctx context.Context, countID int64) error {
+	cancelCountSQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, updated_at = $2
+	WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`, tableInventoryCount)
+
+	result, err := ics.db.ExecContext(ctx, cancelCountSQL, models.InventoryCountCancelled, time.Now().UTC(), countID, models.InventoryCountOpen)
+	if err != nil {
+		return fmt.Errorf("cancelInventoryCount: could not update inventory count within db: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return fmt.Errorf("cancelInventoryCount: %w", models.ErrInvalidTransition)
+	}
+
+	return nil
+}
+
+func scanInventoryCount(row rowScanner) (models.InventoryCount, error) {
+	var (
+		count      models.InventoryCount
+		notes      sql.NullString
+		approvedAt sql.NullTime
+		createdAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&count.ID,
+		&count.BranchID,
+		&count.CountType,
+		&count.Status,
+		&notes,
+		&approvedAt,
+		&createdAt,
+	); err != nil {
+		return models.InventoryCount{}, err
+	}
+	count.Notes = notes.String
+	if approvedAt.Valid {
+		count.ApprovedAt = &approvedAt.Time
+	}
+	count.CreatedAt = createdAt.Time
+
+	return count, nil
+}
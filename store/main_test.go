@@ -0,0 +1,116 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/store"
+	"github.com/VictorDelgado94/aveonline-backend/testdb"
+)
+
+// server hands out the databases of the tests, nil when there is no postgres to use.
+var (
+	server         *testdb.Server
+	errUnavailable error
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+
+	// only a missing server skips the tests, an unusable or required one fails them
+	if testing.Short() && !testdb.Required() {
+		errUnavailable = errors.New("skipped in short mode")
+	} else {
+		var err error
+		server, err = testdb.Start(migrateTemplate)
+		if errors.Is(err, testdb.ErrUnavailable) {
+			errUnavailable = err
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	code := m.Run()
+	if server != nil {
+		if err := server.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+func migrateTemplate(databaseURL string) error {
+	s, err := store.NewStore(databaseURL, store.PoolSettings{MaxOpenConns: 1}, discardLogger())
+	if err != nil {
+		return err
+	}
+	if _, err := s.MigrateTo(context.Background(), s.LatestMigration()); err != nil {
+		return errors.Join(err, s.Close())
+	}
+
+	return s.Close()
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func requireServer(t *testing.T) *testdb.Server {
+	t.Helper()
+
+	if server == nil {
+		t.Skip(errUnavailable)
+	}
+
+	return server
+}
+
+// newTestStore returns a store over a new database with every migration applied.
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	return openTestStore(t, requireServer(t).NewDatabase(t))
+}
+
+// newEmptyTestStore returns a store over a new database without migrations.
+func newEmptyTestStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	return openTestStore(t, requireServer(t).NewEmptyDatabase(t))
+}
+
+func openTestStore(t *testing.T, databaseURL string) *store.Store {
+	t.Helper()
+
+	s, err := store.NewStore(databaseURL, store.PoolSettings{MaxOpenConns: 4, MaxIdleConns: 2}, discardLogger())
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	// registered after the cleanup that drops the database, so it runs before it
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("closing the test database: %v", err)
+		}
+	})
+
+	return &s
+}
+
+func countRows(t *testing.T, s *store.Store, query string) int {
+	t.Helper()
+
+	var count int
+	if err := s.GetDB().QueryRowContext(context.Background(), query).Scan(&count); err != nil {
+		t.Fatalf("running %q: %v", query, err)
+	}
+
+	return count
+}
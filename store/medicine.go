@@ -4,15 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/VictorDelgado94/aveonline-backend/logging"
 	"github.com/VictorDelgado94/aveonline-backend/models"
 	"github.com/jmoiron/sqlx"
 )
 
 const (
 	tableMedicine = "medicine"
+
+	medicineColumns = "id, name, active_ingredient, barcode, price, average_cost, location, base_unit, stock, requires_prescription, version, stock_version, created_at"
 )
 
 type Medicine struct {
@@ -25,75 +27,79 @@ func NewMedicine(db *sqlx.DB) Medicine {
 	}
 }
 
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMedicine reads a row selected with medicineColumns.
+func scanMedicine(row rowScanner, extra ...interface{}) (models.Medicine, error) {
+	var (
+		id               int64
+		name             string
+		activeIngredient sql.NullString
+		barcode          sql.NullString
+		price            float64
+		averageCost      float64
+		location         sql.NullString
+		baseUnit         string
+		stock            int64
+		prescription     bool
+		version          int64
+		stockVersion     int64
+		createdAt        sql.NullTime
+	)
+	dest := []interface{}{
+		&id, &name, &activeIngredient, &barcode, &price, &averageCost, &location, &baseUnit, &stock, &prescription, &version, &stockVersion, &createdAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.Medicine{}, err
+	}
+
+	return models.Medicine{
+		ID:                   id,
+		Name:                 name,
+		ActiveIngredient:     activeIngredient.String,
+		Barcode:              barcode.String,
+		Price:                price,
+		AverageCost:          averageCost,
+		Location:             location.String,
+		BaseUnit:             baseUnit,
+		Stock:                stock,
+		RequiresPrescription: prescription,
+		Version:              version,
+		StockVersion:         stockVersion,
+		CreatedAt:            createdAt.Time,
+	}, nil
+}
+
 func (ms Medicine) GetAll(ctx context.Context) ([]models.Medicine, error) {
 	getAllMedicinesSQL := fmt.Sprintf(`
-	SELECT id, name, price, location, created_at
+	SELECT %s
 	FROM %s
 	WHERE deleted_at IS NULL
 	ORDER BY price asc
-	`, tableMedicine)
+	`, medicineColumns, tableMedicine)
 
 	rows, err := ms.db.QueryContext(ctx, getAllMedicinesSQL)
 	if err != nil {
 		return nil, fmt.Errorf("error while building query: %w", err)
 	}
-	defer func() {
-		errClose := rows.Close()
-		errRows := rows.Err()
-		if errClose != nil || errRows != nil {
-			log.Printf("something went wrong while closing rows: %v, %v", errClose, errRows)
-		}
-	}()
-	medicines := make([]models.Medicine, 0)
-	for rows.Next() {
-		var (
-			id        int64
-			name      string
-			price     float64
-			location  sql.NullString
-			createdAt sql.NullTime
-		)
-		if err := rows.Scan(&id, &name, &price, &location, &createdAt); err != nil {
-			return nil, fmt.Errorf("error getting medicines: %w", err)
-		}
-		medicines = append(
-			medicines,
-			models.Medicine{
-				ID:        id,
-				Name:      name,
-				Price:     price,
-				Location:  location.String,
-				CreatedAt: createdAt.Time,
-			},
-		)
-	}
 
-	return medicines, nil
+	return scanMedicines(ctx, rows)
 }
 
 func (ms Medicine) GetMedicineByID(ctx context.Context, medicineID int64) (*models.Medicine, error) {
 	getMedicineSQL := fmt.Sprintf(`
-	SELECT id, name, price, location, created_at
+	SELECT %s
 	FROM %s
 	WHERE id = $1 AND deleted_at IS NULL
-	`, tableMedicine)
+	`, medicineColumns, tableMedicine)
 
 	row := ms.db.QueryRowContext(ctx, getMedicineSQL, medicineID)
 
-	var (
-		id        int64
-		name      string
-		price     float64
-		location  sql.NullString
-		createdAt sql.NullTime
-	)
-	if err := row.Scan(
-		&id,
-		&name,
-		&price,
-		&location,
-		&createdAt,
-	); err != nil {
+	medicine, err := scanMedicine(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrNotFound
 		}
@@ -101,21 +107,15 @@ func (ms Medicine) GetMedicineByID(ctx context.Context, medicineID int64) (*mode
 		return nil, fmt.Errorf("error reading medicine row: %w", err)
 	}
 
-	return &models.Medicine{
-		ID:        id,
-		Name:      name,
-		Price:     price,
-		Location:  location.String,
-		CreatedAt: createdAt.Time,
-	}, nil
+	return &medicine, nil
 }
 
 func (ms Medicine) GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) ([]models.Medicine, error) {
 	getMedicineByIDsSQL := fmt.Sprintf(`
-	SELECT id, name, price, location, created_at
+	SELECT %s
 	FROM %s
 	WHERE id IN (?) AND deleted_at IS NULL
-	`, tableMedicine)
+	`, medicineColumns, tableMedicine)
 
 	query, args, err := sqlx.In(getMedicineByIDsSQL, medicineIDs)
 	if err != nil {
@@ -127,35 +127,25 @@ func (ms Medicine) GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) (
 	if err != nil {
 		return nil, fmt.Errorf("error while building query: %w", err)
 	}
+
+	return scanMedicines(ctx, rows)
+}
+
+func scanMedicines(ctx context.Context, rows *sql.Rows) ([]models.Medicine, error) {
 	defer func() {
 		errClose := rows.Close()
 		errRows := rows.Err()
 		if errClose != nil || errRows != nil {
-			log.Printf("something went wrong while closing rows: %v, %v", errClose, errRows)
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
 		}
 	}()
 	medicines := make([]models.Medicine, 0)
 	for rows.Next() {
-		var (
-			id        int64
-			name      string
-			price     float64
-			location  sql.NullString
-			createdAt sql.NullTime
-		)
-		if err := rows.Scan(&id, &name, &price, &location, &createdAt); err != nil {
+		medicine, err := scanMedicine(rows)
+		if err != nil {
 			return nil, fmt.Errorf("error getting medicines: %w", err)
 		}
-		medicines = append(
-			medicines,
-			models.Medicine{
-				ID:        id,
-				Name:      name,
-				Price:     price,
-				Location:  location.String,
-				CreatedAt: createdAt.Time,
-			},
-		)
+		medicines = append(medicines, medicine)
 	}
 
 	return medicines, nil
@@ -163,21 +153,184 @@ func (ms Medicine) GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) (
 
 func (ms Medicine) CreateMedicine(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.Medicine, error) {
 	createMedicineSQL := fmt.Sprintf(`
-	INSERT INTO %s (name, price, location, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5) RETURNING id;
+	INSERT INTO %s (name, active_ingredient, barcode, price, location, base_unit, requires_prescription, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id;
 	`, tableMedicine)
 
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createMedicine: could not begin transaction")
+	}
+
 	now := time.Now().UTC()
+	baseUnit := medicineRequest.BaseUnitOrDefault()
 	var medicineID int64
-	err := ms.db.QueryRowContext(ctx, createMedicineSQL, medicineRequest.Name, medicineRequest.Price, medicineRequest.Location, now, now).Scan(&medicineID)
+	err = tx.QueryRowContext(
+		ctx,
+		createMedicineSQL,
+		medicineRequest.Name,
+		nullString(medicineRequest.ActiveIngredient),
+		nullString(medicineRequest.Barcode),
+		medicineRequest.Price,
+		medicineRequest.Location,
+		baseUnit,
+		medicineRequest.RequiresPrescription,
+		now,
+		now,
+	).Scan(&medicineID)
 	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("createMedicine: could not rollback transaction: %w", err)
+		}
 		return nil, fmt.Errorf("could not create medicine record within db: %w", err)
 	}
 
-	return &models.Medicine{
-		ID:       medicineID,
-		Name:     medicineRequest.Name,
-		Price:    medicineRequest.Price,
-		Location: medicineRequest.Location,
-	}, nil
+	presentations := make([]models.Presentation, 0, len(medicineRequest.Presentations))
+	for _, presentationRequest := range medicineRequest.Presentations {
+		presentation, err := createPresentation(ctx, tx, medicineID, presentationRequest)
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, fmt.Errorf("createMedicine: could not rollback transaction: %w", err)
+			}
+			return nil, err
+		}
+		presentations = append(presentations, *presentation)
+	}
+
+	if medicineRequest.Stock > 0 {
+		err := registerStockMovement(ctx, tx, models.StockMovement{
+			MedicineID:   medicineID,
+			BranchID:     medicineRequest.BranchID,
+			Quantity:     medicineRequest.Stock,
+			MovementType: models.StockMovementInitial,
+		})
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, fmt.Errorf("createMedicine: could not rollback transaction: %w", err)
+			}
+			return nil, fmt.Errorf("createMedicine: %w", err)
+		}
+	}
+
+	medicine := models.Medicine{
+		ID:                   medicineID,
+		Name:                 medicineRequest.Name,
+		ActiveIngredient:     medicineRequest.ActiveIngredient,
+		Barcode:              medicineRequest.Barcode,
+		Price:                medicineRequest.Price,
+		Location:             medicineRequest.Location,
+		BaseUnit:             baseUnit,
+		Stock:                medicineRequest.Stock,
+		RequiresPrescription: medicineRequest.RequiresPrescription,
+		Version:              1,
+		StockVersion:         1,
+		Presentations:        presentations,
+		CreatedAt:            now,
+	}
+	if err := registerAudit(ctx, tx, models.AuditEntityMedicine, medicineID, models.AuditActionCreate, nil, medicine); err != nil {
+		return nil, rollback(tx, fmt.Errorf("createMedicine: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createMedicine: could not commit transaction: %w", err)
+	}
+
+	return &medicine, nil
+}
+
+// UpdatePrice changes the price of the medicine. When expectedVersion is not zero it fails
+// with models.ErrVersionConflict if the medicine is not in that version anymore.
+func (ms Medicine) UpdatePrice(ctx context.Context, medicineID, expectedVersion int64, price float64) (*models.Medicine, error) {
+	lockMedicineSQL := fmt.Sprintf(`
+	SELECT %s
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	FOR UPDATE
+	`, medicineColumns, tableMedicine)
+
+	updatePriceSQL := fmt.Sprintf(`
+	UPDATE %s SET price = $1, version = version + 1, updated_at = $2
+	WHERE id = $3
+	`, tableMedicine)
+
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("updatePrice: could not begin transaction")
+	}
+
+	before, err := scanMedicine(tx.QueryRowContext(ctx, lockMedicineSQL, medicineID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, rollback(tx, models.ErrNotFound)
+		}
+		return nil, rollback(tx, fmt.Errorf("updatePrice: could not read medicine within db: %w", err))
+	}
+	if expectedVersion > 0 && before.Version != expectedVersion {
+		return nil, rollback(tx, fmt.Errorf("updatePrice: %w", models.ErrVersionConflict))
+	}
+
+	if _, err := tx.ExecContext(ctx, updatePriceSQL, price, time.Now().UTC(), medicineID); err != nil {
+		return nil, rollback(tx, fmt.Errorf("updatePrice: could not update medicine price within db: %w", err))
+	}
+
+	after := before
+	after.Price = price
+	after.Version++
+	if err := registerAudit(ctx, tx, models.AuditEntityMedicine, medicineID, models.AuditActionUpdate, before, after); err != nil {
+		return nil, rollback(tx, fmt.Errorf("updatePrice: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("updatePrice: could not commit transaction: %w", err)
+	}
+
+	return &after, nil
+}
+
+// Search looks medicines up by name and active ingredient using full-text search and
+// trigram similarity (both accent-insensitive), and by exact barcode. Results are
+// sorted by relevance.
+func (ms Medicine) Search(ctx context.Context, text string, limit int) ([]models.MedicineSearchResult, error) {
+	searchMedicinesSQL := fmt.Sprintf(`
+	SELECT %s,
+		CASE WHEN barcode = $1 THEN 1 ELSE 0 END
+		+ ts_rank(search_vector, query)
+		+ GREATEST(
+			similarity(f_unaccent(lower(name)), f_unaccent(lower($1))),
+			similarity(f_unaccent(lower(coalesce(active_ingredient, ''))), f_unaccent(lower($1)))
+		) AS rank
+	FROM %s, websearch_to_tsquery('spanish', f_unaccent($1)) query
+	WHERE deleted_at IS NULL
+	AND (
+		search_vector @@ query
+		OR f_unaccent(lower(name)) %% f_unaccent(lower($1))
+		OR f_unaccent(lower(active_ingredient)) %% f_unaccent(lower($1))
+		OR barcode = $1
+	)
+	ORDER BY rank desc, name asc
+	LIMIT $2
+	`, medicineColumns, tableMedicine)
+
+	rows, err := ms.db.QueryContext(ctx, searchMedicinesSQL, text, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	results := make([]models.MedicineSearchResult, 0)
+	for rows.Next() {
+		var rank float64
+		medicine, err := scanMedicine(rows, &rank)
+		if err != nil {
+			return nil, fmt.Errorf("error searching medicines: %w", err)
+		}
+		results = append(results, models.MedicineSearchResult{Medicine: medicine, Rank: rank})
+	}
+
+	return results, nil
 }
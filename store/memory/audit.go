@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableAuditLog = "audit_log"
+)
+
+type Audit struct {
+	db *DB
+}
+
+func NewAudit(db *DB) Audit {
+	return Audit{
+		db: db,
+	}
+}
+
+// registerAudit appends an entry for the change to the audit chain. The actor and the
+// request are taken from ctx; before and after are the snapshots of the entity, nil when
+// it did not exist.
+func (d *data) registerAudit(ctx context.Context, entity string, entityID int64, action string, before, after interface{}) error {
+	user, _ := models.UserFromContext(ctx)
+	requestInfo := models.RequestInfoFromContext(ctx)
+	entry := models.AuditEntry{
+		Entity:        entity,
+		EntityID:      entityID,
+		Action:        action,
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+		RequestID:     requestInfo.RequestID,
+		ClientIP:      requestInfo.ClientIP,
+		// truncated as Postgres does, so both stores hash the same values
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	var err error
+	if entry.Before, err = auditSnapshot(before); err != nil {
+		return fmt.Errorf("could not encode audit snapshot of %s [%d]: %w", entity, entityID, err)
+	}
+	if entry.After, err = auditSnapshot(after); err != nil {
+		return fmt.Errorf("could not encode audit snapshot of %s [%d]: %w", entity, entityID, err)
+	}
+
+	if len(d.auditLog) > 0 {
+		entry.PrevHash = d.auditLog[len(d.auditLog)-1].Hash
+	}
+	entry.Hash = entry.ComputeHash()
+	entry.ID = d.nextID(tableAuditLog)
+	d.auditLog = append(d.auditLog, entry)
+
+	return nil
+}
+
+func auditSnapshot(snapshot interface{}) (json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// GetEntries returns the entries matching the filter, oldest first.
+func (as Audit) GetEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := make([]models.AuditEntry, 0)
+	err := as.db.read(ctx, func(d *data) error {
+		for _, entry := range d.auditLog {
+			if filter.Entity != "" && entry.Entity != filter.Entity {
+				continue
+			}
+			if filter.EntityID != 0 && entry.EntityID != filter.EntityID {
+				continue
+			}
+			if !filter.From.IsZero() && entry.CreatedAt.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && entry.CreatedAt.After(filter.To) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetChain returns every entry, in chain order, to verify it.
+func (as Audit) GetChain(ctx context.Context) ([]models.AuditEntry, error) {
+	return as.GetEntries(ctx, models.AuditFilter{})
+}
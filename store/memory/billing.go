@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableBilling = "billing"
+)
+
+// billingRow is a billing with its detail lines.
+type billingRow struct {
+	billing     models.Billing
+	promotionID int64
+	items       []models.BillingItem
+}
+
+type Billing struct {
+	db *DB
+}
+
+func NewBilling(db *DB) Billing {
+	return Billing{
+		db: db,
+	}
+}
+
+// GetBillingsByDates returns the billings created between the dates, only those of the
+// given branch when branchID is not zero.
+func (b Billing) GetBillingsByDates(ctx context.Context, startDate, endDate time.Time, branchID int64) ([]models.Billing, error) {
+	billings := make([]models.Billing, 0)
+	err := b.db.read(ctx, func(d *data) error {
+		for _, row := range sortedRows(d.billings, compareBillings) {
+			if row.billing.CreatedAt.Before(startDate) || row.billing.CreatedAt.After(endDate) {
+				continue
+			}
+			if branchID != 0 && row.billing.BranchID != branchID {
+				continue
+			}
+			billings = append(billings, row.billing)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return billings, nil
+}
+
+func (b Billing) GetBillingByID(ctx context.Context, billingID int64) (*models.BillingDetail, error) {
+	var billing *models.BillingDetail
+	err := b.db.read(ctx, func(d *data) error {
+		row, ok := d.billings[billingID]
+		if !ok {
+			return models.ErrNotFound
+		}
+
+		billing = &models.BillingDetail{
+			ID:        billingID,
+			BranchID:  row.billing.BranchID,
+			Total:     row.billing.Total,
+			Version:   row.billing.Version,
+			CreatedAt: row.billing.CreatedAt,
+		}
+		if row.promotionID > 0 {
+			promotion, ok := d.promotions[row.promotionID]
+			if !ok {
+				return fmt.Errorf("error reading billing's promotion: %w", models.ErrNotFound)
+			}
+			// the billing only keeps the data of the promotion, not its branches
+			billing.Promotion = models.Promotion{
+				ID:          promotion.ID,
+				Description: promotion.Description,
+				Percentage:  promotion.Percentage,
+				StartDate:   promotion.StartDate,
+				EndtDate:    promotion.EndtDate,
+			}
+		}
+
+		billing.Items = make([]models.BillingItem, 0, len(row.items))
+		billing.Medicines = make([]models.Medicine, 0, len(row.items))
+		for _, item := range row.items {
+			item.Subtotal = item.UnitPrice * float64(item.Quantity)
+			billing.Items = append(billing.Items, item)
+			billing.Medicines = append(billing.Medicines, models.Medicine{
+				ID:    item.MedicineID,
+				Name:  item.MedicineName,
+				Price: item.UnitPrice,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return billing, nil
+}
+
+func (b Billing) CreateBilling(ctx context.Context, billing models.BillingDetail) (*models.BillingDetail, error) {
+	err := b.db.write(ctx, func(d *data) error {
+		if err := d.checkBranch(billing.BranchID); err != nil {
+			return fmt.Errorf("createBilling: could not create billing: %w", err)
+		}
+		if billing.Promotion.ID > 0 {
+			if _, ok := d.promotions[billing.Promotion.ID]; !ok {
+				return fmt.Errorf("createBilling: could not create billing: promotion [%d] does not exist", billing.Promotion.ID)
+			}
+		}
+		if billing.Total < 0 {
+			return fmt.Errorf("createBilling: %w", violation("billing_total_check", "total must not be negative, got [%v]", billing.Total))
+		}
+
+		quantities := billing.BaseQuantities()
+		stock := make(map[int64]int64, len(quantities))
+		for medicineID := range quantities {
+			stock[medicineID] = d.branchStock[stockKey{branchID: billing.BranchID, medicineID: medicineID}]
+		}
+		if err := models.CheckStock(stock, quantities); err != nil {
+			return fmt.Errorf("createBilling: %w", err)
+		}
+
+		billingID := d.nextID(tableBilling)
+		for _, item := range billing.Items {
+			if err := d.checkMedicine(item.MedicineID); err != nil {
+				return fmt.Errorf("createBilling: could not create billing detail: %w", err)
+			}
+			if _, ok := d.presentations[item.PresentationID]; item.PresentationID > 0 && !ok {
+				return fmt.Errorf("createBilling: could not create billing detail: presentation [%d] does not exist", item.PresentationID)
+			}
+			if err := checkPrice("billing_detail_medicine_price_check", item.UnitPrice); err != nil {
+				return fmt.Errorf("createBilling: could not create billing detail: %w", err)
+			}
+
+			err := d.registerStockMovement(models.StockMovement{
+				MedicineID:   item.MedicineID,
+				BranchID:     billing.BranchID,
+				Quantity:     -item.BaseQuantity,
+				MovementType: models.StockMovementSale,
+				ReferenceID:  billingID,
+			})
+			if err != nil {
+				return fmt.Errorf("createBilling: %w", err)
+			}
+		}
+
+		billing.ID = billingID
+		billing.Version = 1
+		d.billings[billingID] = billingRow{
+			billing: models.Billing{
+				ID:        billingID,
+				BranchID:  billing.BranchID,
+				Total:     billing.Total,
+				Version:   billing.Version,
+				CreatedAt: billing.CreatedAt,
+			},
+			promotionID: billing.Promotion.ID,
+			items:       slices.Clone(billing.Items),
+		}
+		return d.registerAudit(ctx, models.AuditEntityBilling, billingID, models.AuditActionCreate, nil, billing)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &billing, nil
+}
+
+func compareBillings(a, b billingRow) int {
+	return cmp.Or(a.billing.CreatedAt.Compare(b.billing.CreatedAt), cmp.Compare(a.billing.ID, b.billing.ID))
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableBranch = "branch"
+)
+
+type Branches struct {
+	db *DB
+}
+
+func NewBranches(db *DB) Branches {
+	return Branches{
+		db: db,
+	}
+}
+
+func (bs Branches) GetAll(ctx context.Context) ([]models.Branch, error) {
+	var branches []models.Branch
+	err := bs.db.read(ctx, func(d *data) error {
+		branches = sortedRows(d.branches, func(a, b models.Branch) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return branches, nil
+}
+
+func (bs Branches) GetBranchByID(ctx context.Context, branchID int64) (*models.Branch, error) {
+	var branch models.Branch
+	err := bs.db.read(ctx, func(d *data) error {
+		var ok bool
+		if branch, ok = d.branches[branchID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &branch, nil
+}
+
+func (bs Branches) CreateBranch(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.Branch, error) {
+	var branch models.Branch
+	err := bs.db.write(ctx, func(d *data) error {
+		branch = d.insertBranch(models.Branch{
+			Name:      branchRequest.Name,
+			Address:   branchRequest.Address,
+			CreatedAt: time.Now().UTC(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &branch, nil
+}
+
+func (d *data) insertBranch(branch models.Branch) models.Branch {
+	branch.ID = d.nextID(tableBranch)
+	d.branches[branch.ID] = branch
+
+	return branch
+}
+
+// checkBranch fails as the foreign keys to the branch table would.
+func (d *data) checkBranch(branchID int64) error {
+	if _, ok := d.branches[branchID]; !ok {
+		return fmt.Errorf("branch [%d] does not exist", branchID)
+	}
+
+	return nil
+}
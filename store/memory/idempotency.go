@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type IdempotencyKeys struct {
+	db *DB
+}
+
+func NewIdempotencyKeys(db *DB) IdempotencyKeys {
+	return IdempotencyKeys{
+		db: db,
+	}
+}
+
+// Reserve stores the key as in progress when it is new or its previous use expired and
+// returns true. Otherwise it returns false along with the stored record.
+func (iks IdempotencyKeys) Reserve(ctx context.Context, record models.IdempotencyRecord) (bool, *models.IdempotencyRecord, error) {
+	var stored *models.IdempotencyRecord
+	err := iks.db.write(ctx, func(d *data) error {
+		key := idempotencyKey{userID: record.UserID, key: record.Key}
+		if existing, ok := d.idempotencyKeys[key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+			existing.ResponseBody = slices.Clone(existing.ResponseBody)
+			stored = &existing
+			return nil
+		}
+
+		d.idempotencyKeys[key] = models.IdempotencyRecord{
+			UserID:      record.UserID,
+			Key:         record.Key,
+			RequestHash: record.RequestHash,
+			Status:      models.IdempotencyInProgress,
+			CreatedAt:   record.CreatedAt,
+			ExpiresAt:   record.ExpiresAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return false, nil, err
+	}
+
+	return stored == nil, stored, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (iks IdempotencyKeys) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+	return iks.db.write(ctx, func(d *data) error {
+		key := idempotencyKey{userID: record.UserID, key: record.Key}
+		stored, ok := d.idempotencyKeys[key]
+		if !ok || stored.RequestHash != record.RequestHash {
+			return nil
+		}
+
+		stored.Status = models.IdempotencyCompleted
+		stored.ResponseStatus = record.ResponseStatus
+		stored.ResponseBody = slices.Clone(record.ResponseBody)
+		stored.ContentType = record.ContentType
+		d.idempotencyKeys[key] = stored
+		return nil
+	})
+}
+
+// Release removes an in progress key so the request can be retried.
+func (iks IdempotencyKeys) Release(ctx context.Context, userID int64, key string) error {
+	return iks.db.write(ctx, func(d *data) error {
+		storedKey := idempotencyKey{userID: userID, key: key}
+		if stored, ok := d.idempotencyKeys[storedKey]; ok && stored.Status == models.IdempotencyInProgress {
+			delete(d.idempotencyKeys, storedKey)
+		}
+		return nil
+	})
+}
+
+func (iks IdempotencyKeys) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+	err := iks.db.write(ctx, func(d *data) error {
+		now := time.Now().UTC()
+		for key, stored := range d.idempotencyKeys {
+			if stored.ExpiresAt.Before(now) {
+				delete(d.idempotencyKeys, key)
+				deleted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,245 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableInventoryCount     = "inventory_count"
+	tableInventoryCountLine = "inventory_count_line"
+)
+
+type InventoryCounts struct {
+	db *DB
+}
+
+func NewInventoryCounts(db *DB) InventoryCounts {
+	return InventoryCounts{
+		db: db,
+	}
+}
+
+// GetAll returns the counts without lines, filtered by status when it is not empty
+// and by branch when branchID is not zero.
+func (ics InventoryCounts) GetAll(ctx context.Context, status string, branchID int64) ([]models.InventoryCount, error) {
+	counts := make([]models.InventoryCount, 0)
+	err := ics.db.read(ctx, func(d *data) error {
+		for _, count := range sortedRows(d.inventoryCounts, compareInventoryCounts) {
+			if (status != "" && count.Status != status) || (branchID != 0 && count.BranchID != branchID) {
+				continue
+			}
+			count.Lines = nil
+			counts = append(counts, count)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (ics InventoryCounts) GetCountByID(ctx context.Context, countID int64) (*models.InventoryCount, error) {
+	var count models.InventoryCount
+	err := ics.db.read(ctx, func(d *data) error {
+		var ok bool
+		if count, ok = d.inventoryCounts[countID]; !ok {
+			return models.ErrNotFound
+		}
+
+		lines := cloneCountLines(count.Lines)
+		for i := range lines {
+			lines[i].MedicineName = d.medicines[lines[i].MedicineID].Name
+		}
+		slices.SortFunc(lines, func(a, b models.InventoryCountLine) int {
+			return cmp.Or(cmp.Compare(a.Location, b.Location), cmp.Compare(a.MedicineName, b.MedicineName))
+		})
+		count.Lines = lines
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &count, nil
+}
+
+// CreateCount opens a count with a line for each requested medicine, or for every medicine
+// of the catalog when it is a full count.
+func (ics InventoryCounts) CreateCount(ctx context.Context, countRequest models.InventoryCountCreationRequest) (*models.InventoryCount, error) {
+	var count models.InventoryCount
+	err := ics.db.write(ctx, func(d *data) error {
+		if err := d.checkBranch(countRequest.BranchID); err != nil {
+			return fmt.Errorf("createInventoryCount: could not create inventory count: %w", err)
+		}
+
+		count = models.InventoryCount{
+			ID:        d.nextID(tableInventoryCount),
+			BranchID:  countRequest.BranchID,
+			CountType: countRequest.CountType,
+			Status:    models.InventoryCountOpen,
+			Notes:     countRequest.Notes,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		medicineIDs := countRequest.MedicineIDs
+		if count.CountType == models.InventoryCountFull {
+			medicineIDs = []int64{}
+		}
+		lines := make([]models.InventoryCountLine, 0)
+		for _, medicine := range sortedRows(d.medicines, compareMedicineIDs) {
+			if len(medicineIDs) > 0 && !slices.Contains(medicineIDs, medicine.ID) {
+				continue
+			}
+			lines = append(lines, models.InventoryCountLine{
+				ID:         d.nextID(tableInventoryCountLine),
+				MedicineID: medicine.ID,
+				Location:   medicine.Location,
+			})
+		}
+
+		stored := count
+		stored.Lines = lines
+		d.inventoryCounts[count.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &count, nil
+}
+
+// RecordLines stores the counted units of the given medicines in their locations along with a
+// snapshot of the branch stock and the average cost at this moment. A location not counted yet
+// takes the line of the medicine still pending, or gets a new one. It fails with
+// models.ErrInvalidTransition when the count is not open.
+func (ics InventoryCounts) RecordLines(ctx context.Context, count models.InventoryCount, lines []models.InventoryCountRecordLineRequest) error {
+	return ics.db.write(ctx, func(d *data) error {
+		stored, ok := d.inventoryCounts[count.ID]
+		if !ok {
+			return fmt.Errorf("recordInventoryCount: could not lock inventory count: %w", models.ErrNotFound)
+		}
+		if stored.Status != models.InventoryCountOpen {
+			return fmt.Errorf("recordInventoryCount: %w", models.ErrInvalidTransition)
+		}
+
+		now := time.Now().UTC()
+		stored.Lines = cloneCountLines(stored.Lines)
+		for _, line := range lines {
+			index := slices.IndexFunc(stored.Lines, func(countLine models.InventoryCountLine) bool {
+				return countLine.MedicineID == line.MedicineID && countLine.Location == line.Location
+			})
+			if index < 0 {
+				index = slices.IndexFunc(stored.Lines, func(countLine models.InventoryCountLine) bool {
+					return countLine.MedicineID == line.MedicineID && countLine.CountedQuantity == nil
+				})
+			}
+			if index < 0 {
+				if !slices.ContainsFunc(stored.Lines, func(countLine models.InventoryCountLine) bool {
+					return countLine.MedicineID == line.MedicineID
+				}) {
+					continue
+				}
+				stored.Lines = append(stored.Lines, models.InventoryCountLine{
+					ID:         d.nextID(tableInventoryCountLine),
+					MedicineID: line.MedicineID,
+				})
+				index = len(stored.Lines) - 1
+			}
+
+			counted := line.CountedQuantity
+			stored.Lines[index].CountedQuantity = &counted
+			stored.Lines[index].Location = line.Location
+			stored.Lines[index].CountedAt = &now
+			for i := range stored.Lines {
+				if stored.Lines[i].MedicineID == line.MedicineID {
+					stored.Lines[i].SystemQuantity = d.branchStock[stockKey{branchID: count.BranchID, medicineID: line.MedicineID}]
+					stored.Lines[i].UnitCost = d.medicines[line.MedicineID].AverageCost
+				}
+			}
+		}
+		d.inventoryCounts[stored.ID] = stored
+		return nil
+	})
+}
+
+// Approve closes an open count and posts an adjustment movement for every medicine whose units
+// counted in all its locations differ from the system stock.
+// It fails with models.ErrInvalidTransition when the count is not open and with
+// models.ErrInventoryCountIncomplete when any medicine was not counted.
+func (ics InventoryCounts) Approve(ctx context.Context, count models.InventoryCount) error {
+	return ics.db.write(ctx, func(d *data) error {
+		stored, ok := d.inventoryCounts[count.ID]
+		if !ok || stored.Status != models.InventoryCountOpen {
+			return fmt.Errorf("approveInventoryCount: %w", models.ErrInvalidTransition)
+		}
+
+		for _, line := range stored.MedicineLines() {
+			if line.CountedQuantity == nil {
+				return fmt.Errorf("approveInventoryCount: medicine [%d]: %w", line.MedicineID, models.ErrInventoryCountIncomplete)
+			}
+			if variance := line.Variance(); variance != 0 {
+				err := d.registerStockMovement(models.StockMovement{
+					MedicineID:   line.MedicineID,
+					BranchID:     count.BranchID,
+					Quantity:     variance,
+					MovementType: models.StockMovementAdjustment,
+					ReferenceID:  count.ID,
+				})
+				if err != nil {
+					return fmt.Errorf("approveInventoryCount: %w", err)
+				}
+			}
+		}
+
+		now := time.Now().UTC()
+		stored.Status = models.InventoryCountApproved
+		stored.ApprovedAt = &now
+		d.inventoryCounts[stored.ID] = stored
+		return nil
+	})
+}
+
+// Cancel discards an open count without touching the stock. It fails with
+// models.ErrInvalidTransition when the count is not open.
+func (ics InventoryCounts) Cancel(ctx context.Context, countID int64) error {
+	return ics.db.write(ctx, func(d *data) error {
+		stored, ok := d.inventoryCounts[countID]
+		if !ok || stored.Status != models.InventoryCountOpen {
+			return fmt.Errorf("cancelInventoryCount: %w", models.ErrInvalidTransition)
+		}
+		stored.Status = models.InventoryCountCancelled
+		d.inventoryCounts[countID] = stored
+		return nil
+	})
+}
+
+// cloneCountLines copies the lines together with their counted quantities and times.
+func cloneCountLines(lines []models.InventoryCountLine) []models.InventoryCountLine {
+	lines = slices.Clone(lines)
+	for i, line := range lines {
+		if line.CountedQuantity != nil {
+			counted := *line.CountedQuantity
+			lines[i].CountedQuantity = &counted
+		}
+		if line.CountedAt != nil {
+			countedAt := *line.CountedAt
+			lines[i].CountedAt = &countedAt
+		}
+	}
+
+	return lines
+}
+
+// compareInventoryCounts sorts the newest counts first.
+func compareInventoryCounts(a, b models.InventoryCount) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	tableMedicine = "medicine"
+)
+
+type Medicine struct {
+	db *DB
+}
+
+func NewMedicine(db *DB) Medicine {
+	return Medicine{
+		db: db,
+	}
+}
+
+func (ms Medicine) GetAll(ctx context.Context) ([]models.Medicine, error) {
+	var medicines []models.Medicine
+	err := ms.db.read(ctx, func(d *data) error {
+		medicines = sortedRows(d.medicines, func(a, b models.Medicine) int {
+			return cmp.Or(cmp.Compare(a.Price, b.Price), cmp.Compare(a.ID, b.ID))
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return medicines, nil
+}
+
+func (ms Medicine) GetMedicineByID(ctx context.Context, medicineID int64) (*models.Medicine, error) {
+	var medicine models.Medicine
+	err := ms.db.read(ctx, func(d *data) error {
+		var ok bool
+		if medicine, ok = d.medicines[medicineID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &medicine, nil
+}
+
+func (ms Medicine) GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) ([]models.Medicine, error) {
+	medicines := make([]models.Medicine, 0)
+	err := ms.db.read(ctx, func(d *data) error {
+		for _, medicine := range sortedRows(d.medicines, compareMedicineIDs) {
+			if slices.Contains(medicineIDs, medicine.ID) {
+				medicines = append(medicines, medicine)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return medicines, nil
+}
+
+func (ms Medicine) CreateMedicine(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.Medicine, error) {
+	var medicine models.Medicine
+	err := ms.db.write(ctx, func(d *data) error {
+		if err := checkPrice("medicine_price_check", medicineRequest.Price); err != nil {
+			return fmt.Errorf("could not create medicine record: %w", err)
+		}
+
+		now := time.Now().UTC()
+		medicine = models.Medicine{
+			ID:                   d.nextID(tableMedicine),
+			Name:                 medicineRequest.Name,
+			ActiveIngredient:     medicineRequest.ActiveIngredient,
+			Barcode:              medicineRequest.Barcode,
+			Price:                medicineRequest.Price,
+			Location:             medicineRequest.Location,
+			BaseUnit:             medicineRequest.BaseUnitOrDefault(),
+			RequiresPrescription: medicineRequest.RequiresPrescription,
+			Version:              1,
+			StockVersion:         1,
+			CreatedAt:            now,
+		}
+		d.medicines[medicine.ID] = medicine
+
+		presentations := make([]models.Presentation, 0, len(medicineRequest.Presentations))
+		for _, presentationRequest := range medicineRequest.Presentations {
+			presentation, err := d.insertPresentation(medicine.ID, presentationRequest)
+			if err != nil {
+				return err
+			}
+			presentations = append(presentations, presentation)
+		}
+
+		if medicineRequest.Stock > 0 {
+			err := d.registerStockMovement(models.StockMovement{
+				MedicineID:   medicine.ID,
+				BranchID:     medicineRequest.BranchID,
+				Quantity:     medicineRequest.Stock,
+				MovementType: models.StockMovementInitial,
+			})
+			if err != nil {
+				return fmt.Errorf("createMedicine: %w", err)
+			}
+		}
+
+		// as in the store package, the created medicine is returned in its first version
+		medicine.Stock = medicineRequest.Stock
+		medicine.Presentations = presentations
+		return d.registerAudit(ctx, models.AuditEntityMedicine, medicine.ID, models.AuditActionCreate, nil, medicine)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &medicine, nil
+}
+
+// UpdatePrice changes the price of the medicine. When expectedVersion is not zero it fails
+// with models.ErrVersionConflict if the medicine is not in that version anymore.
+func (ms Medicine) UpdatePrice(ctx context.Context, medicineID, expectedVersion int64, price float64) (*models.Medicine, error) {
+	var after models.Medicine
+	err := ms.db.write(ctx, func(d *data) error {
+		before, ok := d.medicines[medicineID]
+		if !ok {
+			return models.ErrNotFound
+		}
+		if expectedVersion > 0 && before.Version != expectedVersion {
+			return fmt.Errorf("updatePrice: %w", models.ErrVersionConflict)
+		}
+		if err := checkPrice("medicine_price_check", price); err != nil {
+			return fmt.Errorf("updatePrice: could not update medicine price: %w", err)
+		}
+
+		after = before
+		after.Price = price
+		after.Version++
+		d.medicines[medicineID] = after
+		return d.registerAudit(ctx, models.AuditEntityMedicine, medicineID, models.AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// Search looks medicines up by name and active ingredient, ignoring case and accents, and
+// by exact barcode. A medicine matches when the text is part of its name or active
+// ingredient, or when every word of the text is. Results are sorted by relevance.
+func (ms Medicine) Search(ctx context.Context, text string, limit int) ([]models.MedicineSearchResult, error) {
+	query := foldText(text)
+	words := strings.Fields(query)
+
+	results := make([]models.MedicineSearchResult, 0)
+	err := ms.db.read(ctx, func(d *data) error {
+		for _, medicine := range d.medicines {
+			name := foldText(medicine.Name)
+			ingredient := foldText(medicine.ActiveIngredient)
+
+			rank := 0.0
+			if medicine.Barcode != "" && medicine.Barcode == text {
+				rank++
+			}
+			if strings.Contains(name, query) || (ingredient != "" && strings.Contains(ingredient, query)) {
+				rank++
+			} else if len(words) > 0 {
+				found := 0
+				for _, word := range words {
+					if strings.Contains(name, word) || strings.Contains(ingredient, word) {
+						found++
+					}
+				}
+				if found == len(words) {
+					rank += 0.5
+				}
+			}
+
+			if rank > 0 {
+				results = append(results, models.MedicineSearchResult{Medicine: medicine, Rank: rank})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(results, func(a, b models.MedicineSearchResult) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// foldText lowercases the text and removes its accents, as f_unaccent(lower()) does.
+func foldText(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(strings.TrimSpace(text))) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func compareMedicineIDs(a, b models.Medicine) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// checkMedicine fails as the foreign keys to the medicine table would.
+func (d *data) checkMedicine(medicineID int64) error {
+	if _, ok := d.medicines[medicineID]; !ok {
+		return fmt.Errorf("medicine [%d] does not exist", medicineID)
+	}
+
+	return nil
+}
+
+// checkPrice fails as the price check constraints would.
+func checkPrice(constraint string, price float64) error {
+	if price < 0 {
+		return violation(constraint, "price must not be negative, got [%v]", price)
+	}
+
+	return nil
+}
+
+func compareMedicineNames(a, b models.Medicine) int {
+	return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+}
@@ -0,0 +1,188 @@
+// Package memory keeps the data of the service in memory instead of Postgres, for local
+// development and tests. Its stores implement the store interfaces of the usecase package
+// with the behavior of the store package: the same errors, orderings and constraints.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+// DB holds the data shared by the stores. Every operation is a transaction: reads see a
+// consistent snapshot and writes work on a copy of the data that replaces it only when
+// the whole operation succeeds, so a failed operation leaves no partial changes.
+type DB struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+// NewDB returns a database with the data the migrations leave in an empty Postgres.
+func NewDB() *DB {
+	d := newData()
+	// the branch that held the data before branches existed
+	d.insertBranch(models.Branch{Name: "Principal", CreatedAt: time.Now().UTC()})
+
+	return &DB{data: d}
+}
+
+// read runs fn over the current data, fn must not modify it.
+func (db *DB) read(ctx context.Context, fn func(d *data) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return fn(db.data)
+}
+
+// write runs fn over a copy of the data, which becomes the current data when fn succeeds.
+func (db *DB) write(ctx context.Context, fn func(d *data) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx := db.data.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	db.data = tx
+
+	return nil
+}
+
+// Ping always succeeds, the data is in the process.
+func (db *DB) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// SchemaVersion reports the latest migration, the data has no schema to migrate.
+func (db *DB) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return db.LatestMigration(), false, nil
+}
+
+// PoolStats returns empty stats, there are no connections.
+func (db *DB) PoolStats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+// LatestMigration is zero, the data has no schema to migrate.
+func (db *DB) LatestMigration() uint {
+	return 0
+}
+
+// Close does nothing, the data is lost with the process.
+func (db *DB) Close() error {
+	return nil
+}
+
+type stockKey struct {
+	branchID   int64
+	medicineID int64
+}
+
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+// data has a map per table. The rows are values and their slices are never modified in
+// place, only replaced, so copying the maps is enough to isolate a transaction.
+type data struct {
+	// lastIDs is the last id given in each table.
+	lastIDs map[string]int64
+
+	branches        map[int64]models.Branch
+	suppliers       map[int64]models.Supplier
+	medicines       map[int64]models.Medicine
+	presentations   map[int64]models.Presentation
+	branchStock     map[stockKey]int64
+	stockMovements  []models.StockMovement
+	promotions      map[int64]models.Promotion
+	billings        map[int64]billingRow
+	purchaseOrders  map[int64]models.PurchaseOrder
+	goodsReceipts   map[int64]models.GoodsReceipt
+	reorderDrafts   map[int64]models.ReorderDraft
+	transfers       map[int64]models.StockTransfer
+	inventoryCounts map[int64]models.InventoryCount
+	users           map[int64]models.User
+	revokedTokens   map[string]time.Time
+	auditLog        []models.AuditEntry
+	idempotencyKeys map[idempotencyKey]models.IdempotencyRecord
+}
+
+func newData() *data {
+	return &data{
+		lastIDs:         map[string]int64{},
+		branches:        map[int64]models.Branch{},
+		suppliers:       map[int64]models.Supplier{},
+		medicines:       map[int64]models.Medicine{},
+		presentations:   map[int64]models.Presentation{},
+		branchStock:     map[stockKey]int64{},
+		promotions:      map[int64]models.Promotion{},
+		billings:        map[int64]billingRow{},
+		purchaseOrders:  map[int64]models.PurchaseOrder{},
+		goodsReceipts:   map[int64]models.GoodsReceipt{},
+		reorderDrafts:   map[int64]models.ReorderDraft{},
+		transfers:       map[int64]models.StockTransfer{},
+		inventoryCounts: map[int64]models.InventoryCount{},
+		users:           map[int64]models.User{},
+		revokedTokens:   map[string]time.Time{},
+		idempotencyKeys: map[idempotencyKey]models.IdempotencyRecord{},
+	}
+}
+
+// clone copies the tables of a transaction. The logs are clipped so appending to the copy
+// never writes into the array of the original.
+func (d *data) clone() *data {
+	return &data{
+		lastIDs:         maps.Clone(d.lastIDs),
+		branches:        maps.Clone(d.branches),
+		suppliers:       maps.Clone(d.suppliers),
+		medicines:       maps.Clone(d.medicines),
+		presentations:   maps.Clone(d.presentations),
+		branchStock:     maps.Clone(d.branchStock),
+		stockMovements:  slices.Clip(d.stockMovements),
+		promotions:      maps.Clone(d.promotions),
+		billings:        maps.Clone(d.billings),
+		purchaseOrders:  maps.Clone(d.purchaseOrders),
+		goodsReceipts:   maps.Clone(d.goodsReceipts),
+		reorderDrafts:   maps.Clone(d.reorderDrafts),
+		transfers:       maps.Clone(d.transfers),
+		inventoryCounts: maps.Clone(d.inventoryCounts),
+		users:           maps.Clone(d.users),
+		revokedTokens:   maps.Clone(d.revokedTokens),
+		auditLog:        slices.Clip(d.auditLog),
+		idempotencyKeys: maps.Clone(d.idempotencyKeys),
+	}
+}
+
+// nextID returns the id of a new row of the table.
+func (d *data) nextID(table string) int64 {
+	d.lastIDs[table]++
+	return d.lastIDs[table]
+}
+
+// sortedRows returns the rows of the table ordered by compare.
+func sortedRows[K comparable, V any](table map[K]V, compare func(a, b V) int) []V {
+	rows := slices.Collect(maps.Values(table))
+	slices.SortFunc(rows, compare)
+
+	return rows
+}
+
+// violation is the error of a write breaking a constraint the database would enforce.
+func violation(constraint, format string, args ...interface{}) error {
+	return fmt.Errorf("violates constraint %s: %s", constraint, fmt.Sprintf(format, args...))
+}
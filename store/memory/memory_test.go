@@ -0,0 +1,30 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/store/memory"
+	"github.com/VictorDelgado94/aveonline-backend/store/storetest"
+)
+
+func TestStoreContract(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := memory.NewDB()
+
+		return storetest.Stores{
+			Billings:        memory.NewBilling(db),
+			Promotions:      memory.NewPromotions(db),
+			Medicines:       memory.NewMedicine(db),
+			Branches:        memory.NewBranches(db),
+			Suppliers:       memory.NewSuppliers(db),
+			PurchaseOrders:  memory.NewPurchaseOrders(db),
+			Reorders:        memory.NewReorders(db),
+			SalesReports:    memory.NewSalesReports(db),
+			Transfers:       memory.NewTransfers(db),
+			InventoryCounts: memory.NewInventoryCounts(db),
+			Users:           memory.NewUsers(db),
+			Audit:           memory.NewAudit(db),
+			Idempotency:     memory.NewIdempotencyKeys(db),
+		}
+	})
+}
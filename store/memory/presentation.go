@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tablePresentation = "medicine_presentation"
+)
+
+func (ms Medicine) GetPresentations(ctx context.Context, medicineID int64) ([]models.Presentation, error) {
+	presentations := make([]models.Presentation, 0)
+	err := ms.db.read(ctx, func(d *data) error {
+		for _, presentation := range sortedRows(d.presentations, comparePresentations) {
+			if presentation.MedicineID == medicineID {
+				presentations = append(presentations, presentation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return presentations, nil
+}
+
+func (ms Medicine) GetPresentationsByIDs(ctx context.Context, presentationIDs []int64) ([]models.Presentation, error) {
+	presentations := make([]models.Presentation, 0)
+	err := ms.db.read(ctx, func(d *data) error {
+		for _, presentation := range sortedRows(d.presentations, comparePresentations) {
+			if slices.Contains(presentationIDs, presentation.ID) {
+				presentations = append(presentations, presentation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return presentations, nil
+}
+
+func (ms Medicine) CreatePresentation(ctx context.Context, medicineID int64, presentationRequest models.PresentationCreationRequest) (*models.Presentation, error) {
+	var presentation models.Presentation
+	err := ms.db.write(ctx, func(d *data) error {
+		var err error
+		if presentation, err = d.insertPresentation(medicineID, presentationRequest); err != nil {
+			return err
+		}
+		return d.registerAudit(ctx, models.AuditEntityPresentation, presentation.ID, models.AuditActionCreate, nil, &presentation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &presentation, nil
+}
+
+func (d *data) insertPresentation(medicineID int64, presentationRequest models.PresentationCreationRequest) (models.Presentation, error) {
+	if err := d.checkMedicine(medicineID); err != nil {
+		return models.Presentation{}, fmt.Errorf("could not create presentation record: %w", err)
+	}
+	if err := checkPrice("medicine_presentation_price_check", presentationRequest.Price); err != nil {
+		return models.Presentation{}, fmt.Errorf("could not create presentation record: %w", err)
+	}
+
+	now := time.Now().UTC()
+	presentation := models.Presentation{
+		ID:               d.nextID(tablePresentation),
+		MedicineID:       medicineID,
+		Name:             presentationRequest.Name,
+		ConversionFactor: presentationRequest.ConversionFactor,
+		Price:            presentationRequest.Price,
+		CreatedAt:        now,
+	}
+	d.presentations[presentation.ID] = presentation
+
+	// the presentations are part of the medicine representation
+	medicine := d.medicines[medicineID]
+	medicine.Version++
+	d.medicines[medicineID] = medicine
+
+	return presentation, nil
+}
+
+func comparePresentations(a, b models.Presentation) int {
+	return cmp.Or(cmp.Compare(a.ConversionFactor, b.ConversionFactor), cmp.Compare(a.ID, b.ID))
+}
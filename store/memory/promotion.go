@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tablePromotions = "promotion"
+)
+
+type Promotions struct {
+	db *DB
+}
+
+func NewPromotions(db *DB) Promotions {
+	return Promotions{
+		db: db,
+	}
+}
+
+func (ps Promotions) GetAll(ctx context.Context) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	err := ps.db.read(ctx, func(d *data) error {
+		promotions = sortedRows(d.promotions, comparePromotions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range promotions {
+		promotions[i].BranchIDs = slices.Clone(promotions[i].BranchIDs)
+	}
+
+	return promotions, nil
+}
+
+func (ps Promotions) GetPromoByID(ctx context.Context, promoID int64) (models.Promotion, error) {
+	var promotion models.Promotion
+	err := ps.db.read(ctx, func(d *data) error {
+		var ok bool
+		if promotion, ok = d.promotions[promoID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	promotion.BranchIDs = slices.Clone(promotion.BranchIDs)
+
+	return promotion, nil
+}
+
+func (ps Promotions) CreatePromotion(ctx context.Context, promoRequest models.PromotionCreationRequest) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := ps.db.write(ctx, func(d *data) error {
+		promotion = models.Promotion{
+			ID:          d.nextID(tablePromotions),
+			Description: promoRequest.Description,
+			Percentage:  promoRequest.Percentage,
+			StartDate:   promoRequest.StartDate,
+			EndtDate:    promoRequest.EndDate,
+			BranchIDs:   promoRequest.BranchIDs,
+			Version:     1,
+		}
+		if err := d.putPromotion(promotion); err != nil {
+			return fmt.Errorf("createPromotion: %w", err)
+		}
+		return d.registerAudit(ctx, models.AuditEntityPromotion, promotion.ID, models.AuditActionCreate, nil, promotion)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+// UpdatePromotion replaces the data and branches of the promotion. When expectedVersion is
+// not zero it fails with models.ErrVersionConflict if the promotion is not in that version anymore.
+func (ps Promotions) UpdatePromotion(
+	ctx context.Context, promoID, expectedVersion int64, promoRequest models.PromotionCreationRequest) (*models.Promotion, error,
+) {
+	var after models.Promotion
+	err := ps.db.write(ctx, func(d *data) error {
+		before, ok := d.promotions[promoID]
+		if !ok {
+			return models.ErrNotFound
+		}
+		if expectedVersion > 0 && before.Version != expectedVersion {
+			return fmt.Errorf("updatePromotion: %w", models.ErrVersionConflict)
+		}
+
+		after = models.Promotion{
+			ID:          promoID,
+			Description: promoRequest.Description,
+			Percentage:  promoRequest.Percentage,
+			StartDate:   promoRequest.StartDate,
+			EndtDate:    promoRequest.EndDate,
+			BranchIDs:   promoRequest.BranchIDs,
+			Version:     before.Version + 1,
+		}
+		if err := d.putPromotion(after); err != nil {
+			return fmt.Errorf("updatePromotion: %w", err)
+		}
+		return d.registerAudit(ctx, models.AuditEntityPromotion, promoID, models.AuditActionUpdate, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// CountPromosBetweenDates counts the promotions active at any instant of the date range,
+// both ends included, that share a branch with branchIDs, leaving out the promotion
+// excludedPromoID (zero to count them all). Promotions without branches apply to all of
+// them, as does an empty branchIDs.
+func (ps Promotions) CountPromosBetweenDates(
+	ctx context.Context, startDate, endDate time.Time, branchIDs []int64, excludedPromoID int64,
+) (int, error) {
+	totalPromos := 0
+	err := ps.db.read(ctx, func(d *data) error {
+		for _, promotion := range d.promotions {
+			if promotion.ID == excludedPromoID || !promotion.Overlaps(startDate, endDate) {
+				continue
+			}
+			if len(branchIDs) == 0 || sharesBranch(promotion.BranchIDs, branchIDs) {
+				totalPromos++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return totalPromos, nil
+}
+
+// GetActiveBetween returns the promotion active at some point between startDate and
+// endDate, both included, that applies to the branch. With a zero branchID only
+// promotions for all the branches are considered. Should several apply, a promotion of
+// the branch wins over one for all of them and then the one that started last.
+func (ps Promotions) GetActiveBetween(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) (models.Promotion, error) {
+	var promotion models.Promotion
+	err := ps.db.read(ctx, func(d *data) error {
+		found := false
+		for _, candidate := range d.promotions {
+			if !candidate.Overlaps(startDate, endDate) || !candidate.AppliesToBranch(branchID) {
+				continue
+			}
+			if !found || compareActivePromotions(candidate, promotion) < 0 {
+				promotion = candidate
+				found = true
+			}
+		}
+		if !found {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	promotion.BranchIDs = slices.Clone(promotion.BranchIDs)
+
+	return promotion, nil
+}
+
+// putPromotion stores the promotion enforcing the constraints of the promotion table and
+// its periods: two promotions sharing a branch cannot overlap, both ends included.
+func (d *data) putPromotion(promotion models.Promotion) error {
+	if promotion.Percentage < 0 || promotion.Percentage > 100 {
+		return violation("promotion_percentage_check", "percentage must be between 0 and 100, got [%v]", promotion.Percentage)
+	}
+	if promotion.StartDate.After(promotion.EndtDate) {
+		return violation("promotion_dates_check", "start date [%s] is after end date [%s]", promotion.StartDate, promotion.EndtDate)
+	}
+	for _, branchID := range promotion.BranchIDs {
+		if err := d.checkBranch(branchID); err != nil {
+			return fmt.Errorf("could not assign branch [%d] to promotion: %w", branchID, err)
+		}
+	}
+
+	for _, other := range d.promotions {
+		if other.ID == promotion.ID {
+			continue
+		}
+		if other.Overlaps(promotion.StartDate, promotion.EndtDate) && sharesBranch(promotion.BranchIDs, other.BranchIDs) {
+			return fmt.Errorf("%w: promotion [%d] overlaps promotion [%d]", models.ErrPromotionOverlap, promotion.ID, other.ID)
+		}
+	}
+
+	branchIDs := slices.Clone(promotion.BranchIDs)
+	slices.Sort(branchIDs)
+	promotion.BranchIDs = slices.Compact(branchIDs)
+	if promotion.BranchIDs == nil {
+		promotion.BranchIDs = []int64{}
+	}
+	d.promotions[promotion.ID] = promotion
+
+	return nil
+}
+
+// sharesBranch is true when the branch lists have a branch in common, an empty list
+// standing for every branch.
+func sharesBranch(a, b []int64) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, branchID := range a {
+		if slices.Contains(b, branchID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func comparePromotions(a, b models.Promotion) int {
+	return cmp.Or(a.StartDate.Compare(b.StartDate), cmp.Compare(a.ID, b.ID))
+}
+
+// compareActivePromotions sorts first the promotions with branches, then the latest started.
+func compareActivePromotions(a, b models.Promotion) int {
+	hasBranches := func(promotion models.Promotion) int {
+		return min(len(promotion.BranchIDs), 1)
+	}
+
+	return cmp.Or(
+		cmp.Compare(hasBranches(b), hasBranches(a)),
+		b.StartDate.Compare(a.StartDate),
+		cmp.Compare(b.ID, a.ID),
+	)
+}
@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tablePurchaseOrder     = "purchase_order"
+	tablePurchaseOrderLine = "purchase_order_line"
+	tableGoodsReceipt      = "goods_receipt"
+)
+
+type PurchaseOrders struct {
+	db *DB
+}
+
+func NewPurchaseOrders(db *DB) PurchaseOrders {
+	return PurchaseOrders{
+		db: db,
+	}
+}
+
+// GetAll returns the purchase orders without lines, filtered by status and branch when
+// they are not empty.
+func (ps PurchaseOrders) GetAll(ctx context.Context, status string, branchID int64) ([]models.PurchaseOrder, error) {
+	orders := make([]models.PurchaseOrder, 0)
+	err := ps.db.read(ctx, func(d *data) error {
+		for _, order := range sortedRows(d.purchaseOrders, comparePurchaseOrders) {
+			if (status != "" && order.Status != status) || (branchID != 0 && order.BranchID != branchID) {
+				continue
+			}
+			order.Lines = nil
+			orders = append(orders, order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (ps PurchaseOrders) GetPurchaseOrderByID(ctx context.Context, orderID int64) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	err := ps.db.read(ctx, func(d *data) error {
+		var ok bool
+		if order, ok = d.purchaseOrders[orderID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.Lines = slices.Clone(order.Lines)
+
+	return &order, nil
+}
+
+func (ps PurchaseOrders) CreatePurchaseOrder(ctx context.Context, orderRequest models.PurchaseOrderCreationRequest) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	err := ps.db.write(ctx, func(d *data) error {
+		if err := d.checkSupplier(orderRequest.SupplierID); err != nil {
+			return fmt.Errorf("createPurchaseOrder: could not create purchase order: %w", err)
+		}
+		if err := d.checkBranch(orderRequest.BranchID); err != nil {
+			return fmt.Errorf("createPurchaseOrder: could not create purchase order: %w", err)
+		}
+
+		order = models.PurchaseOrder{
+			ID:         d.nextID(tablePurchaseOrder),
+			SupplierID: orderRequest.SupplierID,
+			BranchID:   orderRequest.BranchID,
+			Status:     models.PurchaseOrderDraft,
+			Notes:      orderRequest.Notes,
+			Lines:      make([]models.PurchaseOrderLine, 0, len(orderRequest.Lines)),
+			CreatedAt:  time.Now().UTC(),
+		}
+		for _, lineRequest := range orderRequest.Lines {
+			if err := d.checkMedicine(lineRequest.MedicineID); err != nil {
+				return fmt.Errorf("createPurchaseOrder: could not create purchase order line: %w", err)
+			}
+			order.Lines = append(order.Lines, models.PurchaseOrderLine{
+				ID:         d.nextID(tablePurchaseOrderLine),
+				MedicineID: lineRequest.MedicineID,
+				Quantity:   lineRequest.Quantity,
+				UnitCost:   lineRequest.UnitCost,
+			})
+		}
+		d.purchaseOrders[order.ID] = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.Lines = slices.Clone(order.Lines)
+
+	return &order, nil
+}
+
+// UpdateStatus moves the order to the given status only if it still is in fromStatus,
+// returning models.ErrNotFound otherwise.
+func (ps PurchaseOrders) UpdateStatus(ctx context.Context, orderID int64, fromStatus, toStatus string) error {
+	return ps.db.write(ctx, func(d *data) error {
+		order, ok := d.purchaseOrders[orderID]
+		if !ok || order.Status != fromStatus {
+			return models.ErrNotFound
+		}
+		order.Status = toStatus
+		d.purchaseOrders[orderID] = order
+		return nil
+	})
+}
+
+// RegisterGoodsReceipt records the receipt against the order lines, updates the average
+// cost and stock of every received medicine and recomputes the order status, all in
+// one transaction. It fails with models.ErrReceiptExceedsOrder when a line would
+// receive more units than ordered.
+func (ps PurchaseOrders) RegisterGoodsReceipt(ctx context.Context, receipt models.GoodsReceipt) (*models.GoodsReceipt, error) {
+	err := ps.db.write(ctx, func(d *data) error {
+		order, ok := d.purchaseOrders[receipt.PurchaseOrderID]
+		if !ok {
+			return models.ErrNotFound
+		}
+
+		receipt.ID = d.nextID(tableGoodsReceipt)
+		lines := slices.Clone(order.Lines)
+		for _, receiptLine := range receipt.Lines {
+			i := slices.IndexFunc(lines, func(line models.PurchaseOrderLine) bool {
+				return line.ID == receiptLine.PurchaseOrderLineID
+			})
+			if i < 0 || lines[i].ReceivedQuantity+receiptLine.Quantity > lines[i].Quantity {
+				return fmt.Errorf("goodsReceipt: medicine [%d]: %w", receiptLine.MedicineID, models.ErrReceiptExceedsOrder)
+			}
+			lines[i].ReceivedQuantity += receiptLine.Quantity
+
+			if err := d.updateAverageCost(receiptLine.MedicineID, receiptLine.Quantity, receiptLine.UnitCost); err != nil {
+				return fmt.Errorf("goodsReceipt: %w", err)
+			}
+			err := d.registerStockMovement(models.StockMovement{
+				MedicineID:   receiptLine.MedicineID,
+				BranchID:     order.BranchID,
+				Quantity:     receiptLine.Quantity,
+				MovementType: models.StockMovementPurchase,
+				ReferenceID:  receipt.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("goodsReceipt: %w", err)
+			}
+		}
+
+		order.Lines = lines
+		order.Status = models.PurchaseOrderReceived
+		for _, line := range lines {
+			if line.ReceivedQuantity < line.Quantity {
+				order.Status = models.PurchaseOrderPartiallyReceived
+				break
+			}
+		}
+		d.purchaseOrders[order.ID] = order
+
+		stored := receipt
+		stored.Lines = slices.Clone(receipt.Lines)
+		d.goodsReceipts[receipt.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// comparePurchaseOrders sorts the newest orders first.
+func comparePurchaseOrders(a, b models.PurchaseOrder) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableReorderDraft = "reorder_draft"
+)
+
+type Reorders struct {
+	db *DB
+}
+
+func NewReorders(db *DB) Reorders {
+	return Reorders{
+		db: db,
+	}
+}
+
+// GetMedicinesSales returns, for every medicine, the base units sold since the given
+// date together with its stock and the units ordered to suppliers and not received yet.
+// Everything is restricted to the branch when branchID is not zero.
+func (rs Reorders) GetMedicinesSales(ctx context.Context, since time.Time, branchID int64) ([]models.MedicineSales, error) {
+	medicinesSales := make([]models.MedicineSales, 0)
+	err := rs.db.read(ctx, func(d *data) error {
+		sold := map[int64]int64{}
+		for _, row := range d.billings {
+			if row.billing.CreatedAt.Before(since) || (branchID != 0 && row.billing.BranchID != branchID) {
+				continue
+			}
+			for _, item := range row.items {
+				sold[item.MedicineID] += item.BaseQuantity
+			}
+		}
+
+		pending := map[int64]int64{}
+		for _, order := range d.purchaseOrders {
+			switch order.Status {
+			case models.PurchaseOrderDraft, models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived:
+			default:
+				continue
+			}
+			if branchID != 0 && order.BranchID != branchID {
+				continue
+			}
+			for _, line := range order.Lines {
+				pending[line.MedicineID] += line.PendingQuantity()
+			}
+		}
+
+		for _, medicine := range sortedRows(d.medicines, compareMedicineNames) {
+			stock := medicine.Stock
+			if branchID != 0 {
+				stock = d.branchStock[stockKey{branchID: branchID, medicineID: medicine.ID}]
+			}
+			medicinesSales = append(medicinesSales, models.MedicineSales{
+				MedicineID:      medicine.ID,
+				MedicineName:    medicine.Name,
+				Stock:           stock,
+				PendingQuantity: pending[medicine.ID],
+				SoldQuantity:    sold[medicine.ID],
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return medicinesSales, nil
+}
+
+func (rs Reorders) CreateDraft(ctx context.Context, draft models.ReorderDraft) (*models.ReorderDraft, error) {
+	err := rs.db.write(ctx, func(d *data) error {
+		if draft.BranchID != 0 {
+			if err := d.checkBranch(draft.BranchID); err != nil {
+				return fmt.Errorf("createReorderDraft: could not create draft: %w", err)
+			}
+		}
+		for _, suggestion := range draft.Suggestions {
+			if err := d.checkMedicine(suggestion.MedicineID); err != nil {
+				return fmt.Errorf("createReorderDraft: could not create draft line: %w", err)
+			}
+		}
+
+		draft.ID = d.nextID(tableReorderDraft)
+		draft.CreatedAt = time.Now().UTC()
+		stored := draft
+		stored.Suggestions = slices.Clone(draft.Suggestions)
+		d.reorderDrafts[draft.ID] = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
+
+// GetLatestDraft returns the last draft of the branch, or the last consolidated one when
+// branchID is zero.
+func (rs Reorders) GetLatestDraft(ctx context.Context, branchID int64) (*models.ReorderDraft, error) {
+	var draft models.ReorderDraft
+	err := rs.db.read(ctx, func(d *data) error {
+		found := false
+		for _, candidate := range d.reorderDrafts {
+			if candidate.BranchID != branchID {
+				continue
+			}
+			if !found || cmp.Or(candidate.CreatedAt.Compare(draft.CreatedAt), cmp.Compare(candidate.ID, draft.ID)) > 0 {
+				draft = candidate
+				found = true
+			}
+		}
+		if !found {
+			return models.ErrNotFound
+		}
+
+		suggestions := make([]models.ReorderSuggestion, 0, len(draft.Suggestions))
+		for _, suggestion := range draft.Suggestions {
+			suggestion.MedicineName = d.medicines[suggestion.MedicineID].Name
+			suggestions = append(suggestions, suggestion)
+		}
+		slices.SortFunc(suggestions, func(a, b models.ReorderSuggestion) int {
+			return cmp.Or(cmp.Compare(b.SuggestedQuantity, a.SuggestedQuantity), cmp.Compare(a.MedicineName, b.MedicineName))
+		})
+		draft.Suggestions = suggestions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &draft, nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type SalesReports struct {
+	db *DB
+}
+
+func NewSalesReports(db *DB) SalesReports {
+	return SalesReports{
+		db: db,
+	}
+}
+
+// GetSalesSummary aggregates the billings selected by the filter per period and, when
+// asked, per branch and cashier, oldest period first. The cashier of a billing is the
+// actor of its first creation entry in the audit log, so a billing counts once however
+// many entries it has.
+func (srs SalesReports) GetSalesSummary(ctx context.Context, filter models.SalesSummaryFilter) ([]models.SalesSummary, error) {
+	type summaryKey struct {
+		periodStart     int64
+		branchID        int64
+		cashierID       int64
+		cashierUsername string
+	}
+
+	summaries := make([]models.SalesSummary, 0)
+	err := srs.db.read(ctx, func(d *data) error {
+		cashiers := map[int64]models.AuditEntry{}
+		if filter.ByCashier {
+			for _, entry := range d.auditLog {
+				if entry.Entity != models.AuditEntityBilling || entry.Action != models.AuditActionCreate {
+					continue
+				}
+				if _, ok := cashiers[entry.EntityID]; !ok {
+					cashiers[entry.EntityID] = entry
+				}
+			}
+		}
+
+		positions := map[summaryKey]int{}
+		used := map[summaryKey]map[int64]int64{}
+		for _, row := range sortedRows(d.billings, compareBillings) {
+			billing := row.billing
+			if billing.CreatedAt.Before(filter.From) || billing.CreatedAt.After(filter.To) {
+				continue
+			}
+			if filter.BranchID != 0 && billing.BranchID != filter.BranchID {
+				continue
+			}
+
+			summary := models.SalesSummary{
+				PeriodStart: models.SalesPeriodStart(billing.CreatedAt, filter.GroupBy, filter.Location),
+			}
+			if filter.ByBranch {
+				summary.BranchID = billing.BranchID
+			}
+			if filter.ByCashier {
+				summary.CashierID = cashiers[billing.ID].ActorID
+				summary.CashierUsername = cashiers[billing.ID].ActorUsername
+			}
+			key := summaryKey{
+				periodStart:     summary.PeriodStart.UnixMicro(),
+				branchID:        summary.BranchID,
+				cashierID:       summary.CashierID,
+				cashierUsername: summary.CashierUsername,
+			}
+			position, ok := positions[key]
+			if !ok {
+				position = len(summaries)
+				positions[key] = position
+				summaries = append(summaries, summary)
+				used[key] = map[int64]int64{}
+			}
+
+			summaries[position].Billings++
+			summaries[position].Net += billing.Total
+			for _, item := range row.items {
+				summaries[position].Gross += item.UnitPrice * float64(item.Quantity)
+			}
+			if row.promotionID > 0 {
+				used[key][row.promotionID]++
+			}
+		}
+
+		for key, position := range positions {
+			summary := &summaries[position]
+			summary.Discounts = summary.Gross - summary.Net
+			summary.AverageTicket = summary.Net / float64(summary.Billings)
+			summary.Promotions = make([]models.SalesSummaryPromotion, 0, len(used[key]))
+			for promotionID, billings := range used[key] {
+				summary.Promotions = append(summary.Promotions, models.SalesSummaryPromotion{
+					PromotionID: promotionID,
+					Description: d.promotions[promotionID].Description,
+					Billings:    billings,
+				})
+			}
+			slices.SortFunc(summary.Promotions, func(a, b models.SalesSummaryPromotion) int {
+				return cmp.Or(cmp.Compare(b.Billings, a.Billings), cmp.Compare(a.PromotionID, b.PromotionID))
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(summaries, func(a, b models.SalesSummary) int {
+		return cmp.Or(
+			a.PeriodStart.Compare(b.PeriodStart),
+			cmp.Compare(a.BranchID, b.BranchID),
+			cmp.Compare(a.CashierID, b.CashierID),
+			cmp.Compare(a.CashierUsername, b.CashierUsername),
+		)
+	})
+
+	return summaries, nil
+}
+
+// GetMedicinesPerformance returns, for every medicine, the base units sold between the
+// dates and their revenue at list price, along with its last sale up to endDate. Only
+// the sales of the branch count when branchID is not zero.
+func (srs SalesReports) GetMedicinesPerformance(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) ([]models.MedicinePerformance, error) {
+	performances := make([]models.MedicinePerformance, 0)
+	err := srs.db.read(ctx, func(d *data) error {
+		byMedicine := map[int64]*models.MedicinePerformance{}
+		for _, medicine := range sortedRows(d.medicines, compareMedicineNames) {
+			performances = append(performances, models.MedicinePerformance{
+				MedicineID:   medicine.ID,
+				MedicineName: medicine.Name,
+				Location:     medicine.Location,
+			})
+		}
+		for i := range performances {
+			byMedicine[performances[i].MedicineID] = &performances[i]
+		}
+
+		for _, row := range d.billings {
+			billing := row.billing
+			if billing.CreatedAt.After(endDate) || (branchID != 0 && billing.BranchID != branchID) {
+				continue
+			}
+			for _, item := range row.items {
+				performance, ok := byMedicine[item.MedicineID]
+				if !ok {
+					continue
+				}
+				if performance.LastSoldAt == nil || billing.CreatedAt.After(*performance.LastSoldAt) {
+					lastSoldAt := billing.CreatedAt
+					performance.LastSoldAt = &lastSoldAt
+				}
+				if billing.CreatedAt.Before(startDate) {
+					continue
+				}
+				performance.Units += item.BaseQuantity
+				performance.Revenue += item.UnitPrice * float64(item.Quantity)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return performances, nil
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableStockMovement = "stock_movement"
+)
+
+// registerStockMovement applies the movement to the branch stock and to the consolidated
+// medicine stock, and appends it to the stock ledger.
+func (d *data) registerStockMovement(movement models.StockMovement) error {
+	if err := d.checkBranch(movement.BranchID); err != nil {
+		return fmt.Errorf("could not update stock of medicine [%d] in branch [%d]: %w", movement.MedicineID, movement.BranchID, err)
+	}
+	medicine, ok := d.medicines[movement.MedicineID]
+	if !ok {
+		return fmt.Errorf("could not update stock of medicine [%d]: %w", movement.MedicineID, d.checkMedicine(movement.MedicineID))
+	}
+
+	d.branchStock[stockKey{branchID: movement.BranchID, medicineID: movement.MedicineID}] += movement.Quantity
+	medicine.Stock += movement.Quantity
+	medicine.StockVersion++
+	d.medicines[medicine.ID] = medicine
+
+	movement.ID = d.nextID(tableStockMovement)
+	movement.CreatedAt = time.Now().UTC()
+	d.stockMovements = append(d.stockMovements, movement)
+
+	return nil
+}
+
+// GetStockByBranch returns the stock of the medicine in every branch that ever held it.
+func (ms Medicine) GetStockByBranch(ctx context.Context, medicineID int64) ([]models.BranchStock, error) {
+	branchStock := make([]models.BranchStock, 0)
+	err := ms.db.read(ctx, func(d *data) error {
+		for key, stock := range d.branchStock {
+			branch, ok := d.branches[key.branchID]
+			if key.medicineID != medicineID || !ok {
+				continue
+			}
+			branchStock = append(branchStock, models.BranchStock{BranchID: branch.ID, BranchName: branch.Name, Stock: stock})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(branchStock, func(a, b models.BranchStock) int {
+		return cmp.Compare(a.BranchID, b.BranchID)
+	})
+
+	return branchStock, nil
+}
+
+// updateAverageCost folds a purchase of quantity base units at unitCost into the
+// weighted average cost of the medicine. It must run before the stock is incremented.
+func (d *data) updateAverageCost(medicineID, quantity int64, unitCost float64) error {
+	medicine, ok := d.medicines[medicineID]
+	if !ok {
+		return fmt.Errorf("could not update average cost of medicine [%d]: %w", medicineID, d.checkMedicine(medicineID))
+	}
+
+	if medicine.Stock > 0 {
+		medicine.AverageCost = (float64(medicine.Stock)*medicine.AverageCost + float64(quantity)*unitCost) / float64(medicine.Stock+quantity)
+	} else {
+		medicine.AverageCost = unitCost
+	}
+	d.medicines[medicineID] = medicine
+
+	return nil
+}
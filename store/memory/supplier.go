@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableSupplier = "supplier"
+)
+
+type Suppliers struct {
+	db *DB
+}
+
+func NewSuppliers(db *DB) Suppliers {
+	return Suppliers{
+		db: db,
+	}
+}
+
+func (ss Suppliers) GetAll(ctx context.Context) ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	err := ss.db.read(ctx, func(d *data) error {
+		suppliers = sortedRows(d.suppliers, func(a, b models.Supplier) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suppliers, nil
+}
+
+func (ss Suppliers) GetSupplierByID(ctx context.Context, supplierID int64) (*models.Supplier, error) {
+	var supplier models.Supplier
+	err := ss.db.read(ctx, func(d *data) error {
+		var ok bool
+		if supplier, ok = d.suppliers[supplierID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplier, nil
+}
+
+func (ss Suppliers) CreateSupplier(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.Supplier, error) {
+	var supplier models.Supplier
+	err := ss.db.write(ctx, func(d *data) error {
+		supplier = models.Supplier{
+			ID:        d.nextID(tableSupplier),
+			Name:      supplierRequest.Name,
+			TaxID:     supplierRequest.TaxID,
+			Email:     supplierRequest.Email,
+			Phone:     supplierRequest.Phone,
+			CreatedAt: time.Now().UTC(),
+		}
+		d.suppliers[supplier.ID] = supplier
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplier, nil
+}
+
+// checkSupplier fails as the foreign keys to the supplier table would.
+func (d *data) checkSupplier(supplierID int64) error {
+	if _, ok := d.suppliers[supplierID]; !ok {
+		return fmt.Errorf("supplier [%d] does not exist", supplierID)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableStockTransfer     = "stock_transfer"
+	tableStockTransferLine = "stock_transfer_line"
+)
+
+type Transfers struct {
+	db *DB
+}
+
+func NewTransfers(db *DB) Transfers {
+	return Transfers{
+		db: db,
+	}
+}
+
+// GetAll returns the transfers without lines, filtered by status when it is not empty
+// and by branch (as source or destination) when branchID is not zero.
+func (ts Transfers) GetAll(ctx context.Context, status string, branchID int64) ([]models.StockTransfer, error) {
+	transfers := make([]models.StockTransfer, 0)
+	err := ts.db.read(ctx, func(d *data) error {
+		for _, transfer := range sortedRows(d.transfers, compareTransfers) {
+			if status != "" && transfer.Status != status {
+				continue
+			}
+			if branchID != 0 && transfer.SourceBranchID != branchID && transfer.DestinationBranchID != branchID {
+				continue
+			}
+			transfer.Lines = nil
+			transfers = append(transfers, transfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+func (ts Transfers) GetTransferByID(ctx context.Context, transferID int64) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	err := ts.db.read(ctx, func(d *data) error {
+		var ok bool
+		if transfer, ok = d.transfers[transferID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.Lines = cloneTransferLines(transfer.Lines)
+
+	return &transfer, nil
+}
+
+func (ts Transfers) CreateTransfer(ctx context.Context, transferRequest models.StockTransferCreationRequest) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	err := ts.db.write(ctx, func(d *data) error {
+		if err := d.checkBranch(transferRequest.SourceBranchID); err != nil {
+			return fmt.Errorf("createTransfer: could not create transfer: %w", err)
+		}
+		if err := d.checkBranch(transferRequest.DestinationBranchID); err != nil {
+			return fmt.Errorf("createTransfer: could not create transfer: %w", err)
+		}
+
+		transfer = models.StockTransfer{
+			ID:                  d.nextID(tableStockTransfer),
+			SourceBranchID:      transferRequest.SourceBranchID,
+			DestinationBranchID: transferRequest.DestinationBranchID,
+			Status:              models.TransferDraft,
+			Notes:               transferRequest.Notes,
+			Lines:               make([]models.StockTransferLine, 0, len(transferRequest.Lines)),
+			CreatedAt:           time.Now().UTC(),
+		}
+		for _, lineRequest := range transferRequest.Lines {
+			if err := d.checkMedicine(lineRequest.MedicineID); err != nil {
+				return fmt.Errorf("createTransfer: could not create transfer line: %w", err)
+			}
+			transfer.Lines = append(transfer.Lines, models.StockTransferLine{
+				ID:         d.nextID(tableStockTransferLine),
+				MedicineID: lineRequest.MedicineID,
+				Quantity:   lineRequest.Quantity,
+			})
+		}
+		d.transfers[transfer.ID] = transfer
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.Lines = cloneTransferLines(transfer.Lines)
+
+	return &transfer, nil
+}
+
+// Ship puts a draft transfer in transit, taking its goods out of the source branch.
+// It fails with models.ErrInvalidTransition when the transfer is not a draft anymore.
+func (ts Transfers) Ship(ctx context.Context, transfer models.StockTransfer) error {
+	return ts.db.write(ctx, func(d *data) error {
+		stored, ok := d.transfers[transfer.ID]
+		if !ok || stored.Status != models.TransferDraft {
+			return fmt.Errorf("shipTransfer: %w", models.ErrInvalidTransition)
+		}
+
+		now := time.Now().UTC()
+		stored.Status = models.TransferShipped
+		stored.ShippedAt = &now
+		d.transfers[stored.ID] = stored
+
+		for _, line := range transfer.Lines {
+			err := d.registerStockMovement(models.StockMovement{
+				MedicineID:   line.MedicineID,
+				BranchID:     transfer.SourceBranchID,
+				Quantity:     -line.Quantity,
+				MovementType: models.StockMovementTransferOut,
+				ReferenceID:  transfer.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("shipTransfer: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Receive records the received units of every line and puts the shipped units in the
+// destination branch, booking the ones that did not arrive as a transit loss there. It
+// fails with models.ErrInvalidTransition when the transfer is not in transit.
+func (ts Transfers) Receive(ctx context.Context, transfer models.StockTransfer) error {
+	return ts.db.write(ctx, func(d *data) error {
+		stored, ok := d.transfers[transfer.ID]
+		if !ok || stored.Status != models.TransferShipped {
+			return fmt.Errorf("receiveTransfer: %w", models.ErrInvalidTransition)
+		}
+
+		now := time.Now().UTC()
+		stored.Status = models.TransferReceived
+		stored.ReceivedAt = &now
+		stored.Lines = cloneTransferLines(stored.Lines)
+
+		for _, line := range transfer.Lines {
+			if line.ReceivedQuantity == nil {
+				return fmt.Errorf("receiveTransfer: received quantity of medicine [%d] is missing", line.MedicineID)
+			}
+			received := *line.ReceivedQuantity
+			if received > line.Quantity {
+				return fmt.Errorf("receiveTransfer: received quantity of medicine [%d] exceeds the shipped one", line.MedicineID)
+			}
+			for i := range stored.Lines {
+				if stored.Lines[i].ID == line.ID {
+					stored.Lines[i].ReceivedQuantity = &received
+					stored.Lines[i].Discrepancy = stored.Lines[i].Quantity - received
+					stored.Lines[i].DiscrepancyNotes = line.DiscrepancyNotes
+				}
+			}
+
+			for _, movement := range models.TransferReceiptMovements(transfer, line) {
+				if err := d.registerStockMovement(movement); err != nil {
+					return fmt.Errorf("receiveTransfer: %w", err)
+				}
+			}
+		}
+		d.transfers[stored.ID] = stored
+		return nil
+	})
+}
+
+// cloneTransferLines copies the lines together with their received quantities.
+func cloneTransferLines(lines []models.StockTransferLine) []models.StockTransferLine {
+	lines = slices.Clone(lines)
+	for i, line := range lines {
+		if line.ReceivedQuantity != nil {
+			received := *line.ReceivedQuantity
+			lines[i].ReceivedQuantity = &received
+		}
+	}
+
+	return lines
+}
+
+// compareTransfers sorts the newest transfers first.
+func compareTransfers(a, b models.StockTransfer) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
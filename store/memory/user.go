@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	tableUser = "app_user"
+)
+
+type Users struct {
+	db *DB
+}
+
+func NewUsers(db *DB) Users {
+	return Users{
+		db: db,
+	}
+}
+
+func (us Users) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := us.db.read(ctx, func(d *data) error {
+		for _, candidate := range d.users {
+			if candidate.Username == username {
+				user = candidate
+				return nil
+			}
+		}
+		return models.ErrNotFound
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (us Users) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	var user models.User
+	err := us.db.read(ctx, func(d *data) error {
+		var ok bool
+		if user, ok = d.users[userID]; !ok {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (us Users) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	err := us.db.read(ctx, func(d *data) error {
+		count = int64(len(d.users))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CreateUser stores the user with the given bcrypt password hash, bound to the branch
+// when branchID is not zero.
+func (us Users) CreateUser(ctx context.Context, username, role, passwordHash string, branchID int64) (*models.User, error) {
+	var user models.User
+	err := us.db.write(ctx, func(d *data) error {
+		if branchID > 0 {
+			if err := d.checkBranch(branchID); err != nil {
+				return fmt.Errorf("createUser: could not create user: %w", err)
+			}
+		}
+		for _, existing := range d.users {
+			if existing.Username == username {
+				return fmt.Errorf("createUser: could not create user: %w",
+					violation("app_user_username_key", "username [%s] already exists", username))
+			}
+		}
+
+		user = models.User{
+			ID:           d.nextID(tableUser),
+			Username:     username,
+			Role:         role,
+			BranchID:     branchID,
+			PasswordHash: passwordHash,
+			CreatedAt:    time.Now().UTC(),
+		}
+		d.users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// RevokeToken adds the token to the revocation list until it expires. It fails with
+// models.ErrTokenRevoked when the token is in the list already.
+func (us Users) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return us.db.write(ctx, func(d *data) error {
+		if _, ok := d.revokedTokens[tokenID]; ok {
+			return fmt.Errorf("revokeToken: token [%s]: %w", tokenID, models.ErrTokenRevoked)
+		}
+		d.revokedTokens[tokenID] = expiresAt.UTC()
+		return nil
+	})
+}
+
+func (us Users) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := us.db.read(ctx, func(d *data) error {
+		_, revoked = d.revokedTokens[tokenID]
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens removes from the revocation list the tokens that expired
+// already, they are rejected anyway.
+func (us Users) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	var deleted int64
+	err := us.db.write(ctx, func(d *data) error {
+		now := time.Now().UTC()
+		for tokenID, expiresAt := range d.revokedTokens {
+			if expiresAt.Before(now) {
+				delete(d.revokedTokens, tokenID)
+				deleted++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
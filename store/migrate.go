@@ -0,0 +1,264 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/VictorDelgado94/aveonline-backend/migrations"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// readEmbeddedMigrations lists the migrations embedded in the binary by version.
+func readEmbeddedMigrations() ([]models.Migration, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading embedded migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*models.Migration)
+	for _, entry := range entries {
+		parsed, err := source.Parse(entry.Name())
+		if err != nil {
+			continue
+		}
+		migration, ok := byVersion[parsed.Version]
+		if !ok {
+			migration = &models.Migration{Version: parsed.Version, Name: parsed.Identifier}
+			byVersion[parsed.Version] = migration
+		}
+		if parsed.Direction == source.Down {
+			migration.Reversible = true
+		}
+	}
+
+	embedded := make([]models.Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		embedded = append(embedded, *migration)
+	}
+	sort.Slice(embedded, func(i, j int) bool { return embedded[i].Version < embedded[j].Version })
+
+	return embedded, nil
+}
+
+// Migrations returns the migrations embedded in the binary sorted by version.
+func (s *Store) Migrations() []models.Migration {
+	return s.migrations
+}
+
+// LatestMigration is the version the binary expects the schema to be in.
+func (s *Store) LatestMigration() uint {
+	if len(s.migrations) == 0 {
+		return 0
+	}
+
+	return s.migrations[len(s.migrations)-1].Version
+}
+
+// SchemaStatus compares the schema of the database with the embedded migrations.
+func (s *Store) SchemaStatus(ctx context.Context) (models.SchemaStatus, error) {
+	version, dirty, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return models.SchemaStatus{}, err
+	}
+
+	pending := make([]models.Migration, 0)
+	for _, migration := range s.migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+
+	return models.SchemaStatus{
+		Version: version,
+		Dirty:   dirty,
+		Latest:  s.LatestMigration(),
+		Pending: pending,
+	}, nil
+}
+
+// PlanMigration returns the migrations to apply, in order, to take the schema to target.
+// Zero reverts every migration.
+func (s *Store) PlanMigration(ctx context.Context, target uint) ([]models.MigrationStep, error) {
+	status, err := s.SchemaStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, dirtySchemaError(status.Version)
+	}
+	if target != 0 && !s.hasMigration(target) {
+		return nil, fmt.Errorf("%w: [%d], the latest migration is %d", models.ErrUnknownMigration, target, status.Latest)
+	}
+	if status.Version > status.Latest {
+		return nil, newerSchemaError(status.Version, status.Latest)
+	}
+
+	steps := make([]models.MigrationStep, 0)
+	if target >= status.Version {
+		for _, migration := range s.migrations {
+			if migration.Version > status.Version && migration.Version <= target {
+				steps = append(steps, models.MigrationStep{Migration: migration, Direction: models.MigrationUp})
+			}
+		}
+
+		return steps, nil
+	}
+
+	for i := len(s.migrations) - 1; i >= 0; i-- {
+		migration := s.migrations[i]
+		if migration.Version > status.Version || migration.Version <= target {
+			continue
+		}
+		if !migration.Reversible {
+			return nil, fmt.Errorf("%w: migration %d_%s has no down file", models.ErrIrreversibleMigration, migration.Version, migration.Name)
+		}
+		steps = append(steps, models.MigrationStep{Migration: migration, Direction: models.MigrationDown})
+	}
+
+	return steps, nil
+}
+
+// MigrateTo applies the migrations needed to take the schema to target and returns them.
+func (s *Store) MigrateTo(ctx context.Context, target uint) ([]models.MigrationStep, error) {
+	steps, err := s.PlanMigration(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return steps, nil
+	}
+
+	err = s.withMigrator(func(migrator *migrate.Migrate) error {
+		if target == 0 {
+			return migrator.Down()
+		}
+		return migrator.Migrate(target)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("migrating the schema to version %d: %w", target, err)
+	}
+
+	return steps, nil
+}
+
+// ForceSchemaVersion marks the schema as being in version, clean, without running any
+// migration. It is the way out of a dirty schema once it was fixed by hand.
+func (s *Store) ForceSchemaVersion(version uint) error {
+	if version != 0 && !s.hasMigration(version) {
+		return fmt.Errorf("%w: [%d]", models.ErrUnknownMigration, version)
+	}
+
+	forced := int(version)
+	if version == 0 {
+		forced = database.NilVersion
+	}
+
+	return s.withMigrator(func(migrator *migrate.Migrate) error {
+		return migrator.Force(forced)
+	})
+}
+
+// MigrateForServing takes the schema to the latest migration before serving. It refuses
+// a dirty schema or one newer than the binary unless allowed.
+func (s *Store) MigrateForServing(ctx context.Context, allowNewer, allowDirty bool) error {
+	status, err := s.SchemaStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Dirty && !allowDirty:
+		return dirtySchemaError(status.Version)
+	case status.Dirty:
+		s.logger.WarnContext(ctx, "serving with a dirty database schema", "schema_version", status.Version)
+		return nil
+	case status.Version > status.Latest && !allowNewer:
+		return newerSchemaError(status.Version, status.Latest)
+	case status.Version > status.Latest:
+		s.logger.WarnContext(ctx, "serving with a database schema newer than the binary",
+			"schema_version", status.Version, "latest_migration", status.Latest)
+		return nil
+	}
+
+	steps, err := s.MigrateTo(ctx, status.Latest)
+	if err != nil {
+		return err
+	}
+	if len(steps) > 0 {
+		s.logger.InfoContext(ctx, "database schema migrated", "from_version", status.Version, "schema_version", status.Latest)
+	}
+
+	return nil
+}
+
+func (s *Store) hasMigration(version uint) bool {
+	for _, migration := range s.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Store) withMigrator(run func(migrator *migrate.Migrate) error) error {
+	sourceDriver, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("opening embedded migrations: %w", err)
+	}
+	migrator, err := migrate.NewWithSourceInstance("iofs", sourceDriver, s.databaseURL)
+	if err != nil {
+		return fmt.Errorf("connecting the migrator: %w", err)
+	}
+	migrator.Log = migrateLogger{logger: s.logger}
+	defer func() {
+		if errSource, errDatabase := migrator.Close(); errSource != nil || errDatabase != nil {
+			s.logger.Error("closing the migrator", "source_error", errSource, "database_error", errDatabase)
+		}
+	}()
+
+	if err := run(migrator); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	return nil
+}
+
+func dirtySchemaError(version uint) error {
+	return fmt.Errorf(
+		"%w at version %d: migration %d failed halfway. Check the schema and either complete "+
+			"its changes and run `migrate force %d`, or undo them and run `migrate force` with the previous version",
+		models.ErrSchemaDirty, version, version, version,
+	)
+}
+
+func newerSchemaError(version, latest uint) error {
+	return fmt.Errorf(
+		"%w: the database is at version %d and the binary knows up to %d. Deploy a binary with "+
+			"migration %d, revert the schema with that binary, or set migrations.allowNewerSchema",
+		models.ErrSchemaNewer, version, latest, version,
+	)
+}
+
+// migrateLogger sends the progress of the migrator to the service logger.
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return true
+}
@@ -0,0 +1,22 @@
+package store
+
+import "testing"
+
+func TestEmbeddedMigrationsAreReversible(t *testing.T) {
+	embedded, err := readEmbeddedMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedded) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, migration := range embedded {
+		if migration.Version != uint(i+1) {
+			t.Errorf("migration %d_%s is out of sequence, want version %d", migration.Version, migration.Name, i+1)
+		}
+		if !migration.Reversible {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
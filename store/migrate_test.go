@@ -0,0 +1,135 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/store"
+	"github.com/lib/pq"
+)
+
+func assertSchemaVersion(t *testing.T, s *store.Store, want uint) {
+	t.Helper()
+
+	version, dirty, err := s.SchemaVersion(context.Background())
+	if err != nil {
+		t.Fatalf("reading the schema version: %v", err)
+	}
+	if version != want || dirty {
+		t.Fatalf("schema at version %d (dirty %t), want %d clean", version, dirty, want)
+	}
+}
+
+func TestMigrationsUpAndDown(t *testing.T) {
+	s := newEmptyTestStore(t)
+	ctx := context.Background()
+	latest := s.LatestMigration()
+
+	if _, err := s.MigrateTo(ctx, latest); err != nil {
+		t.Fatalf("applying the migrations: %v", err)
+	}
+	assertSchemaVersion(t, s, latest)
+
+	naiveTimestamps := countRows(t, s, `
+	SELECT COUNT(*) FROM information_schema.columns
+	WHERE table_schema = current_schema() AND data_type = 'timestamp without time zone'`)
+	if naiveTimestamps != 0 {
+		t.Errorf("%d columns are still timestamp without time zone", naiveTimestamps)
+	}
+
+	// every down migration is run on its own so a broken one is reported by version
+	for version := int(latest) - 1; version >= 0; version-- {
+		if _, err := s.MigrateTo(ctx, uint(version)); err != nil {
+			t.Fatalf("reverting to version %d: %v", version, err)
+		}
+		assertSchemaVersion(t, s, uint(version))
+	}
+
+	leftovers := countRows(t, s, `
+	SELECT COUNT(*) FROM information_schema.tables
+	WHERE table_schema = current_schema() AND table_name <> 'schema_migrations'`)
+	if leftovers != 0 {
+		t.Errorf("%d tables are left after reverting every migration", leftovers)
+	}
+
+	if _, err := s.MigrateTo(ctx, latest); err != nil {
+		t.Fatalf("applying the migrations again: %v", err)
+	}
+	assertSchemaVersion(t, s, latest)
+}
+
+func TestPromotionOverlapConstraint(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	var branchA, branchB int64
+	if err := s.GetDB().QueryRowContext(ctx, `SELECT min(id) FROM branch`).Scan(&branchA); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.GetDB().QueryRowContext(ctx, `INSERT INTO branch (name) VALUES ('Norte') RETURNING id`).Scan(&branchB); err != nil {
+		t.Fatal(err)
+	}
+
+	promotions := store.NewPromotions(s.GetDB())
+	start := time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC)
+	promo := func(startDate time.Time, days int, branchIDs ...int64) models.PromotionCreationRequest {
+		return models.PromotionCreationRequest{
+			Description: "promo",
+			Percentage:  10,
+			StartDate:   startDate,
+			EndDate:     startDate.AddDate(0, 0, days),
+			BranchIDs:   branchIDs,
+		}
+	}
+
+	if _, err := promotions.CreatePromotion(ctx, promo(start, 10, branchA)); err != nil {
+		t.Fatalf("creating the first promotion: %v", err)
+	}
+	other, err := promotions.CreatePromotion(ctx, promo(start, 10, branchB))
+	if err != nil {
+		t.Fatalf("promotions of different branches must be allowed to overlap: %v", err)
+	}
+	if _, err := promotions.CreatePromotion(ctx, promo(start.AddDate(0, 0, 11), 5, branchA)); err != nil {
+		t.Fatalf("promotions of the same branch must be allowed one after the other: %v", err)
+	}
+
+	// contains the first promotion, so none of its dates falls inside the first one
+	if _, err := promotions.CreatePromotion(ctx, promo(start.AddDate(0, 0, -5), 20, branchA)); !errors.Is(err, models.ErrPromotionOverlap) {
+		t.Errorf("overlapping promotion of the same branch: got %v, want %v", err, models.ErrPromotionOverlap)
+	}
+	if _, err := promotions.CreatePromotion(ctx, promo(start.AddDate(0, 0, 2), 1)); !errors.Is(err, models.ErrPromotionOverlap) {
+		t.Errorf("overlapping promotion for all the branches: got %v, want %v", err, models.ErrPromotionOverlap)
+	}
+	if _, err := promotions.UpdatePromotion(ctx, other.ID, 0, promo(start, 10, branchA)); !errors.Is(err, models.ErrPromotionOverlap) {
+		t.Errorf("moving a promotion to a branch with an overlapping one: got %v, want %v", err, models.ErrPromotionOverlap)
+	}
+
+	if created := countRows(t, s, `SELECT COUNT(*) FROM promotion`); created != 3 {
+		t.Errorf("%d promotions in the database, want 3", created)
+	}
+}
+
+func TestPriceAndPercentageConstraints(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	statements := map[string]string{
+		"negative medicine price": `INSERT INTO medicine (name, price) VALUES ('Acetaminofen', -1)`,
+		"percentage over 100": `INSERT INTO promotion (description, percentage, start_date, end_date)
+			VALUES ('promo', 120, '2030-01-01', '2030-01-02')`,
+		"end before start": `INSERT INTO promotion (description, percentage, start_date, end_date)
+			VALUES ('promo', 10, '2030-01-02', '2030-01-01')`,
+	}
+	for name, statement := range statements {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.GetDB().ExecContext(ctx, statement)
+			var pqErr *pq.Error
+			if !errors.As(err, &pqErr) || pqErr.Code != "23514" {
+				t.Errorf("got %v, want a check violation", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tablePresentation = "medicine_presentation"
+)
+
+func (ms Medicine) GetPresentations(ctx context.Context, medicineID int64) ([]models.Presentation, error) {
+	getPresentationsSQL := fmt.Sprintf(`
+	SELECT id, medicine_id, name, conversion_factor, price, created_at
+	FROM %s
+	WHERE medicine_id = $1 AND deleted_at IS NULL
+	ORDER BY conversion_factor asc
+	`, tablePresentation)
+
+	rows, err := ms.db.QueryContext(ctx, getPresentationsSQL, medicineID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+
+	return scanPresentations(ctx, rows)
+}
+
+func (ms Medicine) GetPresentationsByIDs(ctx context.Context, presentationIDs []int64) ([]models.Presentation, error) {
+	getPresentationsByIDsSQL := fmt.Sprintf(`
+	SELECT id, medicine_id, name, conversion_factor, price, created_at
+	FROM %s
+	WHERE id IN (?) AND deleted_at IS NULL
+	`, tablePresentation)
+
+	query, args, err := sqlx.In(getPresentationsByIDsSQL, presentationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error building IN query: %w", err)
+	}
+	query = ms.db.Rebind(query)
+
+	rows, err := ms.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+
+	return scanPresentations(ctx, rows)
+}
+
+func (ms Medicine) CreatePresentation(ctx context.Context, medicineID int64, presentationRequest models.PresentationCreationRequest) (*models.Presentation, error) {
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createPresentation: could not begin transaction")
+	}
+
+	presentation, err := createPresentation(ctx, tx, medicineID, presentationRequest)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("createPresentation: could not rollback transaction: %w", err)
+		}
+		return nil, err
+	}
+
+	err = registerAudit(ctx, tx, models.AuditEntityPresentation, presentation.ID, models.AuditActionCreate, nil, presentation)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("createPresentation: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createPresentation: could not commit transaction: %w", err)
+	}
+
+	return presentation, nil
+}
+
+func createPresentation(ctx context.Context, tx *sql.Tx, medicineID int64, presentationRequest models.PresentationCreationRequest) (*models.Presentation, error) {
+	createPresentationSQL := fmt.Sprintf(`
+	INSERT INTO %s (medicine_id, name, conversion_factor, price, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tablePresentation)
+
+	// the presentations are part of the medicine representation
+	bumpMedicineVersionSQL := fmt.Sprintf(`
+	UPDATE %s SET version = version + 1, updated_at = $1
+	WHERE id = $2
+	`, tableMedicine)
+
+	now := time.Now().UTC()
+	var presentationID int64
+	err := tx.QueryRowContext(
+		ctx,
+		createPresentationSQL,
+		medicineID,
+		presentationRequest.Name,
+		presentationRequest.ConversionFactor,
+		presentationRequest.Price,
+		now,
+		now,
+	).Scan(&presentationID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create presentation record within db: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, bumpMedicineVersionSQL, now, medicineID); err != nil {
+		return nil, fmt.Errorf("could not update medicine version within db: %w", err)
+	}
+
+	return &models.Presentation{
+		ID:               presentationID,
+		MedicineID:       medicineID,
+		Name:             presentationRequest.Name,
+		ConversionFactor: presentationRequest.ConversionFactor,
+		Price:            presentationRequest.Price,
+		CreatedAt:        now,
+	}, nil
+}
+
+func scanPresentations(ctx context.Context, rows *sql.Rows) ([]models.Presentation, error) {
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	presentations := make([]models.Presentation, 0)
+	for rows.Next() {
+		var (
+			id               int64
+			medicineID       int64
+			name             string
+			conversionFactor int64
+			price            float64
+			createdAt        sql.NullTime
+		)
+		if err := rows.Scan(&id, &medicineID, &name, &conversionFactor, &price, &createdAt); err != nil {
+			return nil, fmt.Errorf("error getting presentations: %w", err)
+		}
+		presentations = append(
+			presentations,
+			models.Presentation{
+				ID:               id,
+				MedicineID:       medicineID,
+				Name:             name,
+				ConversionFactor: conversionFactor,
+				Price:            price,
+				CreatedAt:        createdAt.Time,
+			},
+		)
+	}
+
+	return presentations, nil
+}
@@ -3,18 +3,35 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/VictorDelgado94/aveonline-backend/logging"
 	"github.com/VictorDelgado94/aveonline-backend/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 const (
-	tablePromotions = "promotion"
+	tablePromotions      = "promotion"
+	tablePromotionBranch = "promotion_branch"
 )
 
+// promotionColumns selects a promotion aliased as p together with its branches.
+var promotionColumns = fmt.Sprintf(`p.id, p.description, p.percentage, p.start_date, p.end_date, p.version,
+	COALESCE(
+		(SELECT array_agg(pb.branch_id ORDER BY pb.branch_id) FROM %s pb WHERE pb.promotion_id = p.id),
+		'{}'
+	)`, tablePromotionBranch)
+
+// promotionAppliesToBranchSQL is true when the promotion aliased as p applies to all the
+// branches or to the branch given as parameter %d.
+const promotionAppliesToBranchSQL = `(
+		NOT EXISTS (SELECT 1 FROM %[1]s pb WHERE pb.promotion_id = p.id)
+		OR EXISTS (SELECT 1 FROM %[1]s pb WHERE pb.promotion_id = p.id AND pb.branch_id = $%[2]d)
+	)`
+
 type Promotions struct {
 	db *sqlx.DB
 }
@@ -27,11 +44,11 @@ func NewPromotions(db *sqlx.DB) Promotions {
 
 func (ps Promotions) GetAll(ctx context.Context) ([]models.Promotion, error) {
 	getAllPromoSQL := fmt.Sprintf(`
-	SELECT id, description, percentage, start_date, end_date
-	FROM %s
-	WHERE deleted_at IS NULL
-	ORDER BY start_date asc
-	`, tablePromotions)
+	SELECT %s
+	FROM %s p
+	WHERE p.deleted_at IS NULL
+	ORDER BY p.start_date asc
+	`, promotionColumns, tablePromotions)
 
 	rows, err := ps.db.QueryContext(ctx, getAllPromoSQL)
 	if err != nil {
@@ -41,31 +58,16 @@ func (ps Promotions) GetAll(ctx context.Context) ([]models.Promotion, error) {
 		errClose := rows.Close()
 		errRows := rows.Err()
 		if errClose != nil || errRows != nil {
-			log.Printf("something went wrong while closing rows: %v, %v", errClose, errRows)
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
 		}
 	}()
 	promotions := make([]models.Promotion, 0)
 	for rows.Next() {
-		var (
-			id          int64
-			description sql.NullString
-			percentage  sql.NullFloat64
-			startDate   time.Time
-			endDate     time.Time
-		)
-		if err := rows.Scan(&id, &description, &percentage, &startDate, &endDate); err != nil {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
 			return nil, fmt.Errorf("error getting promotions: %w", err)
 		}
-		promotions = append(
-			promotions,
-			models.Promotion{
-				ID:          id,
-				Description: description.String,
-				Percentage:  percentage.Float64,
-				StartDate:   startDate,
-				EndtDate:    endDate,
-			},
-		)
+		promotions = append(promotions, promotion)
 	}
 
 	return promotions, nil
@@ -73,27 +75,15 @@ func (ps Promotions) GetAll(ctx context.Context) ([]models.Promotion, error) {
 
 func (ps Promotions) GetPromoByID(ctx context.Context, promoID int64) (models.Promotion, error) {
 	getPromoSQL := fmt.Sprintf(`
-	SELECT id, description, percentage, start_date, end_date
-	FROM %s
-	WHERE id = $1 AND deleted_at IS NULL
-	`, tablePromotions)
+	SELECT %s
+	FROM %s p
+	WHERE p.id = $1 AND p.deleted_at IS NULL
+	`, promotionColumns, tablePromotions)
 
 	row := ps.db.QueryRowContext(ctx, getPromoSQL, promoID)
 
-	var (
-		id          int64
-		description sql.NullString
-		percentage  sql.NullFloat64
-		startDate   time.Time
-		endDate     time.Time
-	)
-	if err := row.Scan(
-		&id,
-		&description,
-		&percentage,
-		&startDate,
-		&endDate,
-	); err != nil {
+	promotion, err := scanPromotion(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Promotion{}, models.ErrNotFound
 		}
@@ -101,13 +91,7 @@ func (ps Promotions) GetPromoByID(ctx context.Context, promoID int64) (models.Pr
 		return models.Promotion{}, fmt.Errorf("error reading promotion row: %w", err)
 	}
 
-	return models.Promotion{
-		ID:          id,
-		Description: description.String,
-		Percentage:  percentage.Float64,
-		StartDate:   startDate,
-		EndtDate:    endDate,
-	}, nil
+	return promotion, nil
 }
 
 func (ps Promotions) CreatePromotion(ctx context.Context, promoRequest models.PromotionCreationRequest) (*models.Promotion, error) {
@@ -116,33 +100,156 @@ func (ps Promotions) CreatePromotion(ctx context.Context, promoRequest models.Pr
 	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
 	`, tablePromotions)
 
+	createPromotionBranchSQL := fmt.Sprintf(`
+	INSERT INTO %s (promotion_id, branch_id)
+	VALUES ($1, $2);
+	`, tablePromotionBranch)
+
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createPromotion: could not begin transaction")
+	}
+
 	now := time.Now().UTC()
 	var promoID int64
-	err := ps.db.QueryRowContext(ctx, createPromotionSQL, promoRequest.Description, promoRequest.Percentage, promoRequest.StartDate, promoRequest.EndDate, now, now).Scan(&promoID)
+	err = tx.QueryRowContext(ctx, createPromotionSQL, promoRequest.Description, promoRequest.Percentage, promoRequest.StartDate, promoRequest.EndDate, now, now).Scan(&promoID)
 	if err != nil {
-		return nil, fmt.Errorf("could not create promotion within db: %w", err)
+		return nil, rollback(tx, fmt.Errorf("could not create promotion within db: %w", err))
+	}
+
+	for _, branchID := range promoRequest.BranchIDs {
+		if _, err := tx.ExecContext(ctx, createPromotionBranchSQL, promoID, branchID); err != nil {
+			return nil, rollback(tx, fmt.Errorf("could not assign branch [%d] to promotion within db: %w", branchID, err))
+		}
 	}
 
-	return &models.Promotion{
+	promotion := models.Promotion{
 		ID:          promoID,
 		Description: promoRequest.Description,
 		Percentage:  promoRequest.Percentage,
 		StartDate:   promoRequest.StartDate,
 		EndtDate:    promoRequest.EndDate,
-	}, nil
+		BranchIDs:   promoRequest.BranchIDs,
+		Version:     1,
+	}
+	if err := registerAudit(ctx, tx, models.AuditEntityPromotion, promoID, models.AuditActionCreate, nil, promotion); err != nil {
+		return nil, rollback(tx, fmt.Errorf("createPromotion: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createPromotion: could not commit transaction: %w", promotionCommitError(err))
+	}
+
+	return &promotion, nil
+}
+
+// UpdatePromotion replaces the data and branches of the promotion. When expectedVersion is
+// not zero it fails with models.ErrVersionConflict if the promotion is not in that version anymore.
+func (ps Promotions) UpdatePromotion(
+	ctx context.Context, promoID, expectedVersion int64, promoRequest models.PromotionCreationRequest) (*models.Promotion, error,
+) {
+	lockPromotionSQL := fmt.Sprintf(`
+	SELECT %s
+	FROM %s p
+	WHERE p.id = $1 AND p.deleted_at IS NULL
+	FOR UPDATE
+	`, promotionColumns, tablePromotions)
+
+	updatePromotionSQL := fmt.Sprintf(`
+	UPDATE %s SET description = $1, percentage = $2, start_date = $3, end_date = $4,
+		version = version + 1, updated_at = $5
+	WHERE id = $6
+	`, tablePromotions)
+
+	deletePromotionBranchesSQL := fmt.Sprintf(`
+	DELETE FROM %s WHERE promotion_id = $1
+	`, tablePromotionBranch)
+
+	createPromotionBranchSQL := fmt.Sprintf(`
+	INSERT INTO %s (promotion_id, branch_id)
+	VALUES ($1, $2);
+	`, tablePromotionBranch)
+
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("updatePromotion: could not begin transaction")
+	}
+
+	before, err := scanPromotion(tx.QueryRowContext(ctx, lockPromotionSQL, promoID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, rollback(tx, models.ErrNotFound)
+		}
+		return nil, rollback(tx, fmt.Errorf("updatePromotion: could not read promotion within db: %w", err))
+	}
+	if expectedVersion > 0 && before.Version != expectedVersion {
+		return nil, rollback(tx, fmt.Errorf("updatePromotion: %w", models.ErrVersionConflict))
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		updatePromotionSQL,
+		promoRequest.Description,
+		promoRequest.Percentage,
+		promoRequest.StartDate,
+		promoRequest.EndDate,
+		time.Now().UTC(),
+		promoID,
+	)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("updatePromotion: could not update promotion within db: %w", err))
+	}
+	if _, err := tx.ExecContext(ctx, deletePromotionBranchesSQL, promoID); err != nil {
+		return nil, rollback(tx, fmt.Errorf("updatePromotion: could not remove promotion branches within db: %w", err))
+	}
+	for _, branchID := range promoRequest.BranchIDs {
+		if _, err := tx.ExecContext(ctx, createPromotionBranchSQL, promoID, branchID); err != nil {
+			return nil, rollback(tx, fmt.Errorf("updatePromotion: could not assign branch [%d] to promotion within db: %w", branchID, err))
+		}
+	}
+
+	after := models.Promotion{
+		ID:          promoID,
+		Description: promoRequest.Description,
+		Percentage:  promoRequest.Percentage,
+		StartDate:   promoRequest.StartDate,
+		EndtDate:    promoRequest.EndDate,
+		BranchIDs:   promoRequest.BranchIDs,
+		Version:     before.Version + 1,
+	}
+	if err := registerAudit(ctx, tx, models.AuditEntityPromotion, promoID, models.AuditActionUpdate, before, after); err != nil {
+		return nil, rollback(tx, fmt.Errorf("updatePromotion: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("updatePromotion: could not commit transaction: %w", promotionCommitError(err))
+	}
+
+	return &after, nil
 }
 
-func (ps Promotions) CountPromosBetweenDates(ctx context.Context, startDate, endDate time.Time) (int, error) {
+// CountPromosBetweenDates counts the promotions active at any instant of the date range,
+// both ends included, that share a branch with branchIDs, leaving out the promotion
+// excludedPromoID (zero to count them all). Promotions without branches apply to all of
+// them, as does an empty branchIDs.
+func (ps Promotions) CountPromosBetweenDates(
+	ctx context.Context, startDate, endDate time.Time, branchIDs []int64, excludedPromoID int64,
+) (int, error) {
 	countPromoBetweenDatesSQL := fmt.Sprintf(`
 	SELECT COUNT(*)
-	FROM %s
-	WHERE start_date BETWEEN $1 AND $2
-	OR end_date BETWEEN $3 AND $4
-	AND deleted_at IS NULL
-	`, tablePromotions)
+	FROM %[1]s p
+	WHERE p.start_date <= $2 AND p.end_date >= $1
+	AND p.deleted_at IS NULL
+	AND p.id <> $4
+	AND (
+		COALESCE(cardinality($3::integer[]), 0) = 0
+		OR NOT EXISTS (SELECT 1 FROM %[2]s pb WHERE pb.promotion_id = p.id)
+		OR EXISTS (SELECT 1 FROM %[2]s pb WHERE pb.promotion_id = p.id AND pb.branch_id = ANY($3))
+	)
+	`, tablePromotions, tablePromotionBranch)
 
 	totalPromos := 0
-	err := ps.db.QueryRowContext(ctx, countPromoBetweenDatesSQL, startDate, endDate, startDate, endDate).Scan(&totalPromos)
+	err := ps.db.QueryRowContext(ctx, countPromoBetweenDatesSQL, startDate, endDate, pq.Array(branchIDs), excludedPromoID).Scan(&totalPromos)
 	if err != nil {
 		return 0, fmt.Errorf("could not count promotions between dates db: %w", err)
 	}
@@ -150,35 +257,60 @@ func (ps Promotions) CountPromosBetweenDates(ctx context.Context, startDate, end
 	return totalPromos, nil
 }
 
-func (ps Promotions) GetByDate(ctx context.Context, date, endDay time.Time) (models.Promotion, error) {
-	getPromoByDateSQL := fmt.Sprintf(`
-	SELECT id, description, percentage, start_date, end_date
-	FROM %s
-	WHERE start_date BETWEEN $1 AND $2
-	OR end_date BETWEEN $3 AND $4
-	AND deleted_at IS NULL
-	`, tablePromotions)
+// GetActiveBetween returns the promotion active at some point between startDate and
+// endDate, both included, that applies to the branch. With a zero branchID only
+// promotions for all the branches are considered. Should several apply, a promotion of
+// the branch wins over one for all of them and then the one that started last.
+func (ps Promotions) GetActiveBetween(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) (models.Promotion, error) {
+	getActivePromoSQL := fmt.Sprintf(`
+	SELECT %[1]s
+	FROM %[2]s p
+	WHERE p.start_date <= $2 AND p.end_date >= $1
+	AND p.deleted_at IS NULL
+	AND %[3]s
+	ORDER BY EXISTS (SELECT 1 FROM %[4]s pb WHERE pb.promotion_id = p.id) desc, p.start_date desc, p.id desc
+	LIMIT 1
+	`, promotionColumns, tablePromotions, fmt.Sprintf(promotionAppliesToBranchSQL, tablePromotionBranch, 3), tablePromotionBranch)
+
+	row := ps.db.QueryRowContext(ctx, getActivePromoSQL, startDate, endDate, branchID)
+
+	promotion, err := scanPromotion(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Promotion{}, models.ErrNotFound
+		}
+
+		return models.Promotion{}, fmt.Errorf("error reading promotion row: %w", err)
+	}
 
-	row := ps.db.QueryRowContext(ctx, getPromoByDateSQL, date, endDay, date, endDay)
+	return promotion, nil
+}
+
+// promotionCommitError translates the overlap check of promotion_period, which runs when
+// the transaction commits, into models.ErrPromotionOverlap.
+func promotionCommitError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqExclusionViolation {
+		return fmt.Errorf("%w: %s", models.ErrPromotionOverlap, pqErr.Message)
+	}
+
+	return err
+}
+
+func scanPromotion(row rowScanner) (models.Promotion, error) {
 	var (
 		id          int64
 		description sql.NullString
 		percentage  sql.NullFloat64
 		startDate   time.Time
 		endDate     time.Time
+		version     int64
+		branchIDs   pq.Int64Array
 	)
-	if err := row.Scan(
-		&id,
-		&description,
-		&percentage,
-		&startDate,
-		&endDate,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return models.Promotion{}, models.ErrNotFound
-		}
-
-		return models.Promotion{}, fmt.Errorf("error reading promotion row: %w", err)
+	if err := row.Scan(&id, &description, &percentage, &startDate, &endDate, &version, &branchIDs); err != nil {
+		return models.Promotion{}, err
 	}
 
 	return models.Promotion{
@@ -187,5 +319,7 @@ func (ps Promotions) GetByDate(ctx context.Context, date, endDay time.Time) (mod
 		Percentage:  percentage.Float64,
 		StartDate:   startDate,
 		EndtDate:    endDate,
+		BranchIDs:   []int64(branchIDs),
+		Version:     version,
 	}, nil
 }
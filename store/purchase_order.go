@@ -0,0 +1,305 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tablePurchaseOrder     = "purchase_order"
+	tablePurchaseOrderLine = "purchase_order_line"
+	tableGoodsReceipt      = "goods_receipt"
+	tableGoodsReceiptLine  = "goods_receipt_line"
+)
+
+type PurchaseOrders struct {
+	db *sqlx.DB
+}
+
+func NewPurchaseOrders(db *sqlx.DB) PurchaseOrders {
+	return PurchaseOrders{
+		db: db,
+	}
+}
+
+// GetAll returns the purchase orders without lines, filtered by status and branch when
+// they are not empty.
+func (ps PurchaseOrders) GetAll(ctx context.Context, status string, branchID int64) ([]models.PurchaseOrder, error) {
+	getAllOrdersSQL := fmt.Sprintf(`
+	SELECT id, supplier_id, branch_id, status, notes, created_at
+	FROM %s
+	WHERE deleted_at IS NULL AND ($1 = '' OR status = $1) AND ($2 = 0 OR branch_id = $2)
+	ORDER BY created_at desc
+	`, tablePurchaseOrder)
+
+	rows, err := ps.db.QueryContext(ctx, getAllOrdersSQL, status, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting purchase orders: %w", err)
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+func (ps PurchaseOrders) GetPurchaseOrderByID(ctx context.Context, orderID int64) (*models.PurchaseOrder, error) {
+	getOrderSQL := fmt.Sprintf(`
+	SELECT id, supplier_id, branch_id, status, notes, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tablePurchaseOrder)
+
+	order, err := scanPurchaseOrder(ps.db.QueryRowContext(ctx, getOrderSQL, orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading purchase order row: %w", err)
+	}
+
+	order.Lines, err = ps.getLines(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading purchase order lines: %w", err)
+	}
+
+	return &order, nil
+}
+
+func (ps PurchaseOrders) getLines(ctx context.Context, orderID int64) ([]models.PurchaseOrderLine, error) {
+	getLinesSQL := fmt.Sprintf(`
+	SELECT id, medicine_id, quantity, received_quantity, unit_cost
+	FROM %s
+	WHERE purchase_order_id = $1
+	ORDER BY id asc
+	`, tablePurchaseOrderLine)
+
+	rows, err := ps.db.QueryContext(ctx, getLinesSQL, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	lines := make([]models.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var line models.PurchaseOrderLine
+		if err := rows.Scan(&line.ID, &line.MedicineID, &line.Quantity, &line.ReceivedQuantity, &line.UnitCost); err != nil {
+			return nil, fmt.Errorf("error getting purchase order lines: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func (ps PurchaseOrders) CreatePurchaseOrder(ctx context.Context, orderRequest models.PurchaseOrderCreationRequest) (*models.PurchaseOrder, error) {
+	createOrderSQL := fmt.Sprintf(`
+	INSERT INTO %s (supplier_id, branch_id, status, notes, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tablePurchaseOrder)
+
+	createLineSQL := fmt.Sprintf(`
+	INSERT INTO %s (purchase_order_id, medicine_id, quantity, unit_cost, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tablePurchaseOrderLine)
+
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createPurchaseOrder: could not begin transaction")
+	}
+
+	now := time.Now().UTC()
+	order := models.PurchaseOrder{
+		SupplierID: orderRequest.SupplierID,
+		BranchID:   orderRequest.BranchID,
+		Status:     models.PurchaseOrderDraft,
+		Notes:      orderRequest.Notes,
+		Lines:      make([]models.PurchaseOrderLine, 0, len(orderRequest.Lines)),
+		CreatedAt:  now,
+	}
+	err = tx.QueryRowContext(ctx, createOrderSQL, order.SupplierID, order.BranchID, order.Status, nullString(order.Notes), now, now).Scan(&order.ID)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("createPurchaseOrder: could not create purchase order within db: %w", err))
+	}
+
+	for _, lineRequest := range orderRequest.Lines {
+		line := models.PurchaseOrderLine{
+			MedicineID: lineRequest.MedicineID,
+			Quantity:   lineRequest.Quantity,
+			UnitCost:   lineRequest.UnitCost,
+		}
+		err := tx.QueryRowContext(ctx, createLineSQL, order.ID, line.MedicineID, line.Quantity, line.UnitCost, now, now).Scan(&line.ID)
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("createPurchaseOrder: could not create purchase order line within db: %w", err))
+		}
+		order.Lines = append(order.Lines, line)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createPurchaseOrder: could not commit transaction: %w", err)
+	}
+
+	return &order, nil
+}
+
+// UpdateStatus moves the order to the given status only if it still is in fromStatus,
+// returning models.ErrNotFound otherwise.
+func (ps PurchaseOrders) UpdateStatus(ctx context.Context, orderID int64, fromStatus, toStatus string) error {
+	updateStatusSQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, updated_at = $2
+	WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`, tablePurchaseOrder)
+
+	result, err := ps.db.ExecContext(ctx, updateStatusSQL, toStatus, time.Now().UTC(), orderID, fromStatus)
+	if err != nil {
+		return fmt.Errorf("could not update purchase order status within db: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not update purchase order status within db: %w", err)
+	}
+	if updated == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// RegisterGoodsReceipt records the receipt against the order lines, updates the average
+// cost and stock of every received medicine and recomputes the order status, all in
+// one transaction. It fails with models.ErrReceiptExceedsOrder when a line would
+// receive more units than ordered.
+func (ps PurchaseOrders) RegisterGoodsReceipt(ctx context.Context, receipt models.GoodsReceipt) (*models.GoodsReceipt, error) {
+	lockOrderSQL := fmt.Sprintf(`
+	SELECT branch_id FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, tablePurchaseOrder)
+
+	receiveLineSQL := fmt.Sprintf(`
+	UPDATE %s SET received_quantity = received_quantity + $1, updated_at = $2
+	WHERE id = $3 AND purchase_order_id = $4 AND received_quantity + $1 <= quantity
+	`, tablePurchaseOrderLine)
+
+	createReceiptSQL := fmt.Sprintf(`
+	INSERT INTO %s (purchase_order_id, received_at, created_at)
+	VALUES ($1, $2, $3) RETURNING id;
+	`, tableGoodsReceipt)
+
+	createReceiptLineSQL := fmt.Sprintf(`
+	INSERT INTO %s (goods_receipt_id, purchase_order_line_id, medicine_id, quantity, unit_cost, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6);
+	`, tableGoodsReceiptLine)
+
+	updateOrderStatusSQL := fmt.Sprintf(`
+	UPDATE %s SET updated_at = $1, status = CASE
+		WHEN (SELECT bool_and(received_quantity >= quantity) FROM %s WHERE purchase_order_id = $2) THEN $3
+		ELSE $4
+	END
+	WHERE id = $2
+	`, tablePurchaseOrder, tablePurchaseOrderLine)
+
+	tx, err := ps.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("goodsReceipt: could not begin transaction")
+	}
+
+	var branchID int64
+	if err := tx.QueryRowContext(ctx, lockOrderSQL, receipt.PurchaseOrderID).Scan(&branchID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, rollback(tx, models.ErrNotFound)
+		}
+		return nil, rollback(tx, fmt.Errorf("goodsReceipt: could not lock purchase order: %w", err))
+	}
+
+	now := time.Now().UTC()
+	err = tx.QueryRowContext(ctx, createReceiptSQL, receipt.PurchaseOrderID, receipt.ReceivedAt, now).Scan(&receipt.ID)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("goodsReceipt: could not create goods receipt within db: %w", err))
+	}
+
+	for _, line := range receipt.Lines {
+		result, err := tx.ExecContext(ctx, receiveLineSQL, line.Quantity, now, line.PurchaseOrderLineID, receipt.PurchaseOrderID)
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("goodsReceipt: could not update purchase order line within db: %w", err))
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+			return nil, rollback(tx, fmt.Errorf("goodsReceipt: medicine [%d]: %w", line.MedicineID, models.ErrReceiptExceedsOrder))
+		}
+
+		_, err = tx.ExecContext(ctx, createReceiptLineSQL, receipt.ID, line.PurchaseOrderLineID, line.MedicineID, line.Quantity, line.UnitCost, now)
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("goodsReceipt: could not create goods receipt line within db: %w", err))
+		}
+
+		if err := updateAverageCost(ctx, tx, line.MedicineID, line.Quantity, line.UnitCost); err != nil {
+			return nil, rollback(tx, fmt.Errorf("goodsReceipt: %w", err))
+		}
+		err = registerStockMovement(ctx, tx, models.StockMovement{
+			MedicineID:   line.MedicineID,
+			BranchID:     branchID,
+			Quantity:     line.Quantity,
+			MovementType: models.StockMovementPurchase,
+			ReferenceID:  receipt.ID,
+		})
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("goodsReceipt: %w", err))
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, updateOrderStatusSQL, now, receipt.PurchaseOrderID, models.PurchaseOrderReceived, models.PurchaseOrderPartiallyReceived)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("goodsReceipt: could not update purchase order status within db: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("goodsReceipt: could not commit transaction: %w", err)
+	}
+
+	return &receipt, nil
+}
+
+func scanPurchaseOrder(row rowScanner) (models.PurchaseOrder, error) {
+	var (
+		id         int64
+		supplierID int64
+		branchID   int64
+		status     string
+		notes      sql.NullString
+		createdAt  sql.NullTime
+	)
+	if err := row.Scan(&id, &supplierID, &branchID, &status, &notes, &createdAt); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	return models.PurchaseOrder{
+		ID:         id,
+		SupplierID: supplierID,
+		BranchID:   branchID,
+		Status:     status,
+		Notes:      notes.String,
+		CreatedAt:  createdAt.Time,
+	}, nil
+}
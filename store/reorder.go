@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableReorderDraft     = "reorder_draft"
+	tableReorderDraftLine = "reorder_draft_line"
+)
+
+type Reorders struct {
+	db *sqlx.DB
+}
+
+func NewReorders(db *sqlx.DB) Reorders {
+	return Reorders{
+		db: db,
+	}
+}
+
+// GetMedicinesSales returns, for every medicine, the base units sold since the given
+// date together with its stock and the units ordered to suppliers and not received yet.
+// Everything is restricted to the branch when branchID is not zero.
+func (rs Reorders) GetMedicinesSales(ctx context.Context, since time.Time, branchID int64) ([]models.MedicineSales, error) {
+	getMedicinesSalesSQL := fmt.Sprintf(`
+	SELECT m.id, m.name,
+		CASE WHEN $5 = 0 THEN m.stock ELSE COALESCE(ms.stock, 0) END,
+		COALESCE(pending.quantity, 0),
+		COALESCE(sales.quantity, 0)
+	FROM %s m
+	LEFT JOIN %s ms ON ms.medicine_id = m.id AND ms.branch_id = $5
+	LEFT JOIN (
+		SELECT bd.medicine_id, SUM(bd.base_quantity) AS quantity
+		FROM %s bd
+		JOIN %s b ON b.id = bd.billing_id
+		WHERE b.created_at >= $1 AND b.deleted_at IS NULL AND bd.deleted_at IS NULL
+		AND ($5 = 0 OR b.branch_id = $5)
+		GROUP BY bd.medicine_id
+	) sales ON sales.medicine_id = m.id
+	LEFT JOIN (
+		SELECT l.medicine_id, SUM(l.quantity - l.received_quantity) AS quantity
+		FROM %s l
+		JOIN %s o ON o.id = l.purchase_order_id
+		WHERE o.status IN ($2, $3, $4) AND o.deleted_at IS NULL
+		AND ($5 = 0 OR o.branch_id = $5)
+		GROUP BY l.medicine_id
+	) pending ON pending.medicine_id = m.id
+	WHERE m.deleted_at IS NULL
+	ORDER BY m.name asc
+	`, tableMedicine, tableMedicineStock, tableBillingDetail, tableBilling, tablePurchaseOrderLine, tablePurchaseOrder)
+
+	rows, err := rs.db.QueryContext(
+		ctx,
+		getMedicinesSalesSQL,
+		since,
+		models.PurchaseOrderDraft,
+		models.PurchaseOrderSent,
+		models.PurchaseOrderPartiallyReceived,
+		branchID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	medicinesSales := make([]models.MedicineSales, 0)
+	for rows.Next() {
+		var sales models.MedicineSales
+		if err := rows.Scan(&sales.MedicineID, &sales.MedicineName, &sales.Stock, &sales.PendingQuantity, &sales.SoldQuantity); err != nil {
+			return nil, fmt.Errorf("error getting medicines sales: %w", err)
+		}
+		medicinesSales = append(medicinesSales, sales)
+	}
+
+	return medicinesSales, nil
+}
+
+func (rs Reorders) CreateDraft(ctx context.Context, draft models.ReorderDraft) (*models.ReorderDraft, error) {
+	createDraftSQL := fmt.Sprintf(`
+	INSERT INTO %s (branch_id, window_days, lead_time_days, safety_stock_days, created_at)
+	VALUES (NULLIF($1, 0), $2, $3, $4, $5) RETURNING id;
+	`, tableReorderDraft)
+
+	createDraftLineSQL := fmt.Sprintf(`
+	INSERT INTO %s (
+		reorder_draft_id, medicine_id, average_daily_sales, stock,
+		pending_quantity, days_of_cover, suggested_quantity
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, tableReorderDraftLine)
+
+	tx, err := rs.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createReorderDraft: could not begin transaction")
+	}
+
+	draft.CreatedAt = time.Now().UTC()
+	err = tx.QueryRowContext(ctx, createDraftSQL, draft.BranchID, draft.WindowDays, draft.LeadTimeDays, draft.SafetyStockDays, draft.CreatedAt).Scan(&draft.ID)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("createReorderDraft: could not create draft within db: %w", err))
+	}
+
+	for _, suggestion := range draft.Suggestions {
+		var daysOfCover sql.NullFloat64
+		if suggestion.DaysOfCover != nil {
+			daysOfCover = sql.NullFloat64{Float64: *suggestion.DaysOfCover, Valid: true}
+		}
+		_, err := tx.ExecContext(
+			ctx,
+			createDraftLineSQL,
+			draft.ID,
+			suggestion.MedicineID,
+			suggestion.AverageDailySales,
+			suggestion.Stock,
+			suggestion.PendingQuantity,
+			daysOfCover,
+			suggestion.SuggestedQuantity,
+		)
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("createReorderDraft: could not create draft line within db: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createReorderDraft: could not commit transaction: %w", err)
+	}
+
+	return &draft, nil
+}
+
+// GetLatestDraft returns the last draft of the branch, or the last consolidated one when
+// branchID is zero.
+func (rs Reorders) GetLatestDraft(ctx context.Context, branchID int64) (*models.ReorderDraft, error) {
+	getLatestDraftSQL := fmt.Sprintf(`
+	SELECT id, COALESCE(branch_id, 0), window_days, lead_time_days, safety_stock_days, created_at
+	FROM %s
+	WHERE branch_id IS NOT DISTINCT FROM NULLIF($1, 0)
+	ORDER BY created_at desc, id desc
+	LIMIT 1
+	`, tableReorderDraft)
+
+	getDraftLinesSQL := fmt.Sprintf(`
+	SELECT l.medicine_id, m.name, l.average_daily_sales, l.stock, l.pending_quantity, l.days_of_cover, l.suggested_quantity
+	FROM %s l
+	JOIN %s m ON m.id = l.medicine_id
+	WHERE l.reorder_draft_id = $1
+	ORDER BY l.suggested_quantity desc, m.name asc
+	`, tableReorderDraftLine, tableMedicine)
+
+	var (
+		draft     models.ReorderDraft
+		createdAt sql.NullTime
+	)
+	err := rs.db.QueryRowContext(ctx, getLatestDraftSQL, branchID).Scan(
+		&draft.ID,
+		&draft.BranchID,
+		&draft.WindowDays,
+		&draft.LeadTimeDays,
+		&draft.SafetyStockDays,
+		&createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading reorder draft row: %w", err)
+	}
+	draft.CreatedAt = createdAt.Time
+
+	rows, err := rs.db.QueryContext(ctx, getDraftLinesSQL, draft.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	draft.Suggestions = make([]models.ReorderSuggestion, 0)
+	for rows.Next() {
+		var (
+			suggestion  models.ReorderSuggestion
+			daysOfCover sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&suggestion.MedicineID,
+			&suggestion.MedicineName,
+			&suggestion.AverageDailySales,
+			&suggestion.Stock,
+			&suggestion.PendingQuantity,
+			&daysOfCover,
+			&suggestion.SuggestedQuantity,
+		); err != nil {
+			return nil, fmt.Errorf("error getting reorder draft lines: %w", err)
+		}
+		if daysOfCover.Valid {
+			suggestion.DaysOfCover = &daysOfCover.Float64
+		}
+		draft.Suggestions = append(draft.Suggestions, suggestion)
+	}
+
+	return &draft, nil
+}
@@ -0,0 +1,197 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type SalesReports struct {
+	db *sqlx.DB
+}
+
+func NewSalesReports(db *sqlx.DB) SalesReports {
+	return SalesReports{
+		db: db,
+	}
+}
+
+// GetSalesSummary aggregates the billings selected by the filter per period and, when
+// asked, per branch and cashier, oldest period first. The cashier of a billing is the
+// actor of its first creation entry in the audit log, so a billing counts once however
+// many entries it has.
+func (srs SalesReports) GetSalesSummary(ctx context.Context, filter models.SalesSummaryFilter) ([]models.SalesSummary, error) {
+	getSalesSummarySQL := fmt.Sprintf(`
+	WITH sales AS (
+		SELECT
+			date_trunc($3::text, b.created_at AT TIME ZONE $4::text) AT TIME ZONE $4::text AS period_start,
+			CASE WHEN $6::boolean THEN b.branch_id ELSE 0 END AS branch_id,
+			CASE WHEN $7::boolean THEN COALESCE(a.actor_id, 0) ELSE 0 END AS cashier_id,
+			CASE WHEN $7::boolean THEN COALESCE(a.actor_username, '') ELSE '' END AS cashier_username,
+			b.promotion_id,
+			b.total AS net,
+			COALESCE((
+				SELECT SUM(bd.medicine_price * bd.quantity)
+				FROM %s bd
+				WHERE bd.billing_id = b.id AND bd.deleted_at IS NULL
+			), 0) AS gross
+		FROM %s b
+		LEFT JOIN LATERAL (
+			SELECT a.actor_id, a.actor_username
+			FROM %s a
+			WHERE a.entity = $8 AND a.entity_id = b.id AND a.action = $9
+			ORDER BY a.id
+			LIMIT 1
+		) a ON $7::boolean
+		WHERE b.created_at BETWEEN $1 AND $2 AND b.deleted_at IS NULL
+		AND ($5 = 0 OR b.branch_id = $5)
+	),
+	promotions AS (
+		SELECT used.period_start, used.branch_id, used.cashier_id, used.cashier_username,
+			json_agg(json_build_object(
+				'promotionID', p.id,
+				'description', COALESCE(p.description, ''),
+				'billings', used.billings
+			) ORDER BY used.billings desc, p.id asc) AS promotions
+		FROM (
+			SELECT period_start, branch_id, cashier_id, cashier_username, promotion_id, COUNT(*) AS billings
+			FROM sales
+			WHERE promotion_id IS NOT NULL
+			GROUP BY period_start, branch_id, cashier_id, cashier_username, promotion_id
+		) used
+		JOIN %s p ON p.id = used.promotion_id
+		GROUP BY used.period_start, used.branch_id, used.cashier_id, used.cashier_username
+	)
+	SELECT totals.period_start, totals.branch_id, totals.cashier_id, totals.cashier_username,
+		totals.billings, totals.gross, totals.gross - totals.net, totals.net, totals.net / totals.billings,
+		COALESCE(promotions.promotions, '[]')
+	FROM (
+		SELECT period_start, branch_id, cashier_id, cashier_username,
+			COUNT(*) AS billings, SUM(gross) AS gross, SUM(net) AS net
+		FROM sales
+		GROUP BY period_start, branch_id, cashier_id, cashier_username
+	) totals
+	LEFT JOIN promotions USING (period_start, branch_id, cashier_id, cashier_username)
+	ORDER BY totals.period_start asc, totals.branch_id asc, totals.cashier_id asc, totals.cashier_username asc
+	`, tableBillingDetail, tableBilling, tableAuditLog, tablePromotions)
+
+	rows, err := srs.db.QueryContext(
+		ctx,
+		getSalesSummarySQL,
+		filter.From,
+		filter.To,
+		filter.GroupBy,
+		filter.Location.String(),
+		filter.BranchID,
+		filter.ByBranch,
+		filter.ByCashier,
+		models.AuditEntityBilling,
+		models.AuditActionCreate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	summaries := make([]models.SalesSummary, 0)
+	for rows.Next() {
+		var (
+			summary    models.SalesSummary
+			periodAt   time.Time
+			promotions []byte
+		)
+		if err := rows.Scan(
+			&periodAt,
+			&summary.BranchID,
+			&summary.CashierID,
+			&summary.CashierUsername,
+			&summary.Billings,
+			&summary.Gross,
+			&summary.Discounts,
+			&summary.Net,
+			&summary.AverageTicket,
+			&promotions,
+		); err != nil {
+			return nil, fmt.Errorf("error getting sales summary: %w", err)
+		}
+		if err := json.Unmarshal(promotions, &summary.Promotions); err != nil {
+			return nil, fmt.Errorf("error reading the promotions of the sales summary: %w", err)
+		}
+		summary.PeriodStart = periodAt.In(filter.Location)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// GetMedicinesPerformance returns, for every medicine, the base units sold between the
+// dates and their revenue at list price, along with its last sale up to endDate. Only
+// the sales of the branch count when branchID is not zero.
+func (srs SalesReports) GetMedicinesPerformance(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) ([]models.MedicinePerformance, error) {
+	getMedicinesPerformanceSQL := fmt.Sprintf(`
+	SELECT m.id, m.name, COALESCE(m.location, ''),
+		COALESCE(sales.units, 0), COALESCE(sales.revenue, 0), sales.last_sold_at
+	FROM %s m
+	LEFT JOIN (
+		SELECT bd.medicine_id,
+			SUM(bd.base_quantity) FILTER (WHERE b.created_at >= $1) AS units,
+			SUM(bd.medicine_price * bd.quantity) FILTER (WHERE b.created_at >= $1) AS revenue,
+			MAX(b.created_at) AS last_sold_at
+		FROM %s bd
+		JOIN %s b ON b.id = bd.billing_id
+		WHERE b.created_at <= $2 AND b.deleted_at IS NULL AND bd.deleted_at IS NULL
+		AND ($3 = 0 OR b.branch_id = $3)
+		GROUP BY bd.medicine_id
+	) sales ON sales.medicine_id = m.id
+	WHERE m.deleted_at IS NULL
+	ORDER BY m.name asc
+	`, tableMedicine, tableBillingDetail, tableBilling)
+
+	rows, err := srs.db.QueryContext(ctx, getMedicinesPerformanceSQL, startDate, endDate, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	performances := make([]models.MedicinePerformance, 0)
+	for rows.Next() {
+		var (
+			performance models.MedicinePerformance
+			lastSoldAt  sql.NullTime
+		)
+		if err := rows.Scan(
+			&performance.MedicineID,
+			&performance.MedicineName,
+			&performance.Location,
+			&performance.Units,
+			&performance.Revenue,
+			&lastSoldAt,
+		); err != nil {
+			return nil, fmt.Errorf("error getting medicines performance: %w", err)
+		}
+		if lastSoldAt.Valid {
+			performance.LastSoldAt = &lastSoldAt.Time
+		}
+		performances = append(performances, performance)
+	}
+
+	return performances, nil
+}
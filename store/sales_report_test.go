@@ -0,0 +1,79 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/store"
+)
+
+// The audit log is append-only and the stores write a single creation entry per billing,
+// so the billings without one or with several are inserted directly.
+func TestSalesSummaryCashierFromTheFirstCreationEntry(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createdAt := time.Date(2030, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	billing := func(total float64) int64 {
+		t.Helper()
+
+		var id int64
+		err := s.GetDB().QueryRowContext(ctx, `
+		INSERT INTO billing (branch_id, total, created_at)
+		VALUES ((SELECT min(id) FROM branch), $1, $2)
+		RETURNING id`, total, createdAt).Scan(&id)
+		if err != nil {
+			t.Fatalf("inserting a billing: %v", err)
+		}
+
+		return id
+	}
+	created := func(billingID, actorID int64, actorUsername string) {
+		t.Helper()
+
+		_, err := s.GetDB().ExecContext(ctx, `
+		INSERT INTO audit_log (entity, entity_id, action, actor_id, actor_username, prev_hash, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, '', '', $6)`,
+			models.AuditEntityBilling, billingID, models.AuditActionCreate, actorID, actorUsername, createdAt)
+		if err != nil {
+			t.Fatalf("inserting an audit entry: %v", err)
+		}
+	}
+
+	// before the audit log, without a creation entry
+	billing(50)
+	audited := billing(100)
+	created(audited, 7, "ana")
+	created(audited, 8, "luis")
+
+	summaries, err := store.NewSalesReports(s.GetDB()).GetSalesSummary(ctx, models.SalesSummaryFilter{
+		From:      createdAt.Add(-time.Hour),
+		To:        createdAt.Add(time.Hour),
+		GroupBy:   models.SalesPeriodDay,
+		Location:  time.UTC,
+		ByCashier: true,
+	})
+	if err != nil {
+		t.Fatalf("getting the sales summary: %v", err)
+	}
+
+	want := []struct {
+		cashierID       int64
+		cashierUsername string
+		net             float64
+	}{
+		{cashierID: 0, cashierUsername: "", net: 50},
+		{cashierID: 7, cashierUsername: "ana", net: 100},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("got %d summaries %+v, want %d", len(summaries), summaries, len(want))
+	}
+	for i, w := range want {
+		got := summaries[i]
+		if got.CashierID != w.cashierID || got.CashierUsername != w.cashierUsername || got.Billings != 1 || got.Net != w.net {
+			t.Errorf("summary %d: got %+v, want one billing of %v by cashier %d %q", i, got, w.net, w.cashierID, w.cashierUsername)
+		}
+	}
+}
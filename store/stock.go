@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/lib/pq"
+)
+
+const (
+	tableStockMovement = "stock_movement"
+	tableMedicineStock = "medicine_stock"
+)
+
+// registerStockMovement applies the movement to the branch stock and to the consolidated
+// medicine stock, and appends it to the stock ledger within the given transaction.
+func registerStockMovement(ctx context.Context, tx *sql.Tx, movement models.StockMovement) error {
+	updateBranchStockSQL := fmt.Sprintf(`
+	INSERT INTO %s (branch_id, medicine_id, stock, updated_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (branch_id, medicine_id) DO UPDATE
+	SET stock = %s.stock + EXCLUDED.stock, updated_at = EXCLUDED.updated_at
+	`, tableMedicineStock, tableMedicineStock)
+
+	updateStockSQL := fmt.Sprintf(`
+	UPDATE %s SET stock = stock + $1, stock_version = stock_version + 1, updated_at = $2
+	WHERE id = $3
+	`, tableMedicine)
+
+	createMovementSQL := fmt.Sprintf(`
+	INSERT INTO %s (medicine_id, branch_id, quantity, movement_type, reference_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6);
+	`, tableStockMovement)
+
+	var referenceID sql.NullInt64
+	if movement.ReferenceID > 0 {
+		referenceID.Int64 = movement.ReferenceID
+		referenceID.Valid = true
+	}
+
+	now := time.Now().UTC()
+	_, err := tx.ExecContext(ctx, updateBranchStockSQL, movement.BranchID, movement.MedicineID, movement.Quantity, now)
+	if err != nil {
+		return fmt.Errorf("could not update stock of medicine [%d] in branch [%d]: %w", movement.MedicineID, movement.BranchID, err)
+	}
+	if _, err := tx.ExecContext(ctx, updateStockSQL, movement.Quantity, now, movement.MedicineID); err != nil {
+		return fmt.Errorf("could not update stock of medicine [%d]: %w", movement.MedicineID, err)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		createMovementSQL,
+		movement.MedicineID,
+		movement.BranchID,
+		movement.Quantity,
+		movement.MovementType,
+		referenceID,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("could not register stock movement of medicine [%d]: %w", movement.MedicineID, err)
+	}
+
+	return nil
+}
+
+// checkBranchStock locks the stock of the medicines in the branch until the transaction
+// ends and fails with models.ErrInsufficientStock when it does not cover the quantities.
+func checkBranchStock(ctx context.Context, tx *sql.Tx, branchID int64, quantities map[int64]int64) error {
+	lockStockSQL := fmt.Sprintf(`
+	SELECT medicine_id, stock FROM %s
+	WHERE branch_id = $1 AND medicine_id = ANY($2)
+	ORDER BY medicine_id
+	FOR UPDATE
+	`, tableMedicineStock)
+
+	medicinesIDs := make([]int64, 0, len(quantities))
+	for medicineID := range quantities {
+		medicinesIDs = append(medicinesIDs, medicineID)
+	}
+	rows, err := tx.QueryContext(ctx, lockStockSQL, branchID, pq.Array(medicinesIDs))
+	if err != nil {
+		return fmt.Errorf("could not lock the stock of branch [%d]: %w", branchID, err)
+	}
+	defer rows.Close()
+
+	stock := make(map[int64]int64, len(quantities))
+	for rows.Next() {
+		var medicineID, units int64
+		if err := rows.Scan(&medicineID, &units); err != nil {
+			return fmt.Errorf("could not read the stock of branch [%d]: %w", branchID, err)
+		}
+		stock[medicineID] = units
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not read the stock of branch [%d]: %w", branchID, err)
+	}
+
+	return models.CheckStock(stock, quantities)
+}
+
+// GetStockByBranch returns the stock of the medicine in every branch that ever held it.
+func (ms Medicine) GetStockByBranch(ctx context.Context, medicineID int64) ([]models.BranchStock, error) {
+	getStockByBranchSQL := fmt.Sprintf(`
+	SELECT s.branch_id, b.name, s.stock
+	FROM %s s
+	JOIN %s b ON b.id = s.branch_id
+	WHERE s.medicine_id = $1 AND b.deleted_at IS NULL
+	ORDER BY s.branch_id asc
+	`, tableMedicineStock, tableBranch)
+
+	rows, err := ms.db.QueryContext(ctx, getStockByBranchSQL, medicineID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	branchStock := make([]models.BranchStock, 0)
+	for rows.Next() {
+		var stock models.BranchStock
+		if err := rows.Scan(&stock.BranchID, &stock.BranchName, &stock.Stock); err != nil {
+			return nil, fmt.Errorf("error getting stock by branch: %w", err)
+		}
+		branchStock = append(branchStock, stock)
+	}
+
+	return branchStock, nil
+}
+
+// updateAverageCost folds a purchase of quantity base units at unitCost into the
+// weighted average cost of the medicine. It must run before the stock is incremented.
+func updateAverageCost(ctx context.Context, tx *sql.Tx, medicineID, quantity int64, unitCost float64) error {
+	updateAverageCostSQL := fmt.Sprintf(`
+	UPDATE %s SET average_cost = CASE
+		WHEN stock > 0 THEN (stock * average_cost + $1 * $2) / (stock + $1)
+		ELSE $2
+	END, updated_at = $3
+	WHERE id = $4
+	`, tableMedicine)
+
+	_, err := tx.ExecContext(ctx, updateAverageCostSQL, quantity, unitCost, time.Now().UTC(), medicineID)
+	if err != nil {
+		return fmt.Errorf("could not update average cost of medicine [%d]: %w", medicineID, err)
+	}
+
+	return nil
+}
@@ -1,58 +1,155 @@
 package store
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
-	"github.com/golang-migrate/migrate/v4"
+	"github.com/VictorDelgado94/aveonline-backend/models"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+const (
+	tableSchemaMigrations = "schema_migrations"
+
+	// pqUndefinedTable is the error code of postgres for a missing table.
+	pqUndefinedTable = "42P01"
+	// pqExclusionViolation is the error code of postgres for a violated exclusion constraint.
+	pqExclusionViolation = "23P01"
+)
+
+// PoolSettings limits the connections kept by the database pool.
+type PoolSettings struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
 type Store struct {
 	databaseURL string
 	db          *sqlx.DB
+	logger      *slog.Logger
+	// migrations are the embedded migrations sorted by version.
+	migrations []models.Migration
 }
 
-func NewStore(databaseURL string) (Store, error) {
-	database, err := sqlx.Connect("postgres", databaseURL)
+func NewStore(databaseURL string, pool PoolSettings, logger *slog.Logger) (Store, error) {
+	embeddedMigrations, err := readEmbeddedMigrations()
+	if err != nil {
+		return Store{}, err
+	}
+
+	database, err := sqlx.Connect(tracedDriverName, databaseURL)
 	if err != nil {
 		return Store{}, err
 	}
+	database.SetMaxOpenConns(pool.MaxOpenConns)
+	database.SetMaxIdleConns(pool.MaxIdleConns)
+	database.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	database.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
 	return Store{
 		db:          database,
 		databaseURL: databaseURL,
+		logger:      logger,
+		migrations:  embeddedMigrations,
 	}, nil
 }
 
-func (s *Store) Migrate(targetDBSchemaVersion uint) error {
-	migrator, err := migrate.New("file://migrations/", s.databaseURL)
+func (s *Store) GetDB() *sqlx.DB {
+	return s.db
+}
+
+// Ping checks the database can be reached.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// SchemaVersion returns the migration the database is in and whether it failed halfway.
+// The version is zero when no migration ran yet.
+func (s *Store) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	schemaVersionSQL := fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, tableSchemaMigrations)
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := s.db.QueryRowContext(ctx, schemaVersionSQL).Scan(&version, &dirty)
 	if err != nil {
-		return err
-	}
-	currentDBSchemaVersion, _, err := migrator.Version()
-	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
-		return err
+		var pqErr *pq.Error
+		if errors.Is(err, sql.ErrNoRows) || (errors.As(err, &pqErr) && pqErr.Code == pqUndefinedTable) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("reading schema version: %w", err)
 	}
 
-	steps := int(targetDBSchemaVersion) - int(currentDBSchemaVersion)
-	if steps > 0 {
-		err = migrator.Steps(steps)
-		if err != nil {
-			return fmt.Errorf("failed to migrate up to DB schema version '%v' - %w", targetDBSchemaVersion, err)
-		}
-		log.Printf("Data migration applied to the tables in postgres")
-	} else if steps < 0 {
-		log.Printf(
-			"The current DB schema version is '%v'. Assuming compatibility with target version '%v'. Please verify",
-			currentDBSchemaVersion, targetDBSchemaVersion,
-		)
+	return uint(version), dirty, nil
+}
+
+// PoolStats returns the stats of the connection pool.
+func (s *Store) PoolStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Close closes the connections of the pool.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// nullString maps empty strings to NULL.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// rollback aborts the transaction and returns err, annotated with the rollback failure if any.
+func rollback(tx *sql.Tx, err error) error {
+	if errRollback := tx.Rollback(); errRollback != nil {
+		return fmt.Errorf("could not rollback transaction: %v: %w", errRollback, err)
 	}
 
-	return nil
+	return err
 }
 
-func (s *Store) GetDB() *sqlx.DB {
-	return s.db
+// RegisterMetrics exposes the connection pool stats of the database in the registry.
+func (s *Store) RegisterMetrics(registerer prometheus.Registerer) {
+	stats := s.db.Stats
+	factory := promauto.With(registerer)
+	gauge := func(name, help string, fn func() float64) {
+		factory.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, fn)
+	}
+	counter := func(name, help string, fn func() float64) {
+		factory.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, fn)
+	}
+
+	gauge("aveonline_db_open_connections", "Established connections, in use and idle.", func() float64 {
+		return float64(stats().OpenConnections)
+	})
+	gauge("aveonline_db_in_use_connections", "Connections currently in use.", func() float64 {
+		return float64(stats().InUse)
+	})
+	gauge("aveonline_db_idle_connections", "Idle connections.", func() float64 {
+		return float64(stats().Idle)
+	})
+	gauge("aveonline_db_max_open_connections", "Maximum number of open connections, zero when unlimited.", func() float64 {
+		return float64(stats().MaxOpenConnections)
+	})
+	counter("aveonline_db_wait_count_total", "Connections waited for.", func() float64 {
+		return float64(stats().WaitCount)
+	})
+	counter("aveonline_db_wait_duration_seconds_total", "Time blocked waiting for a connection.", func() float64 {
+		return stats().WaitDuration.Seconds()
+	})
+	counter("aveonline_db_max_idle_closed_total", "Connections closed due to the idle limit.", func() float64 {
+		return float64(stats().MaxIdleClosed)
+	})
+	counter("aveonline_db_max_lifetime_closed_total", "Connections closed due to their max lifetime.", func() float64 {
+		return float64(stats().MaxLifetimeClosed)
+	})
 }
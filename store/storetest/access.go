@@ -0,0 +1,275 @@
+package storetest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+func testUsers(t *testing.T, stores Stores) {
+	ctx := context.Background()
+
+	created, err := stores.Users.CreateUser(ctx, "cajero", models.RoleCashier, "hash", 0)
+	if err != nil {
+		t.Fatalf("creating a user: %v", err)
+	}
+	byName, err := stores.Users.GetUserByUsername(ctx, "cajero")
+	if err != nil {
+		t.Fatalf("getting the user by username: %v", err)
+	}
+	byID, err := stores.Users.GetUserByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the user by id: %v", err)
+	}
+	for _, user := range []*models.User{byName, byID} {
+		if user.ID != created.ID || user.Role != models.RoleCashier || user.PasswordHash != "hash" {
+			t.Errorf("got user %+v, want the created one", user)
+		}
+	}
+
+	if _, err := stores.Users.CreateUser(ctx, "cajero", models.RoleAdmin, "other", 0); err == nil {
+		t.Errorf("creating a user with a taken username succeeded")
+	}
+	if byID.BranchID != 0 {
+		t.Errorf("got branch %d, want none", byID.BranchID)
+	}
+
+	branchID := createBranch(t, stores, "Norte")
+	bound, err := stores.Users.CreateUser(ctx, "cajero-norte", models.RoleCashier, "hash", branchID)
+	if err != nil {
+		t.Fatalf("creating a user of a branch: %v", err)
+	}
+	if stored, err := stores.Users.GetUserByID(ctx, bound.ID); err != nil || stored.BranchID != branchID {
+		t.Errorf("got user %+v (%v), want branch %d", stored, err, branchID)
+	}
+	if _, err := stores.Users.CreateUser(ctx, "cajero-sur", models.RoleCashier, "hash", branchID+100); err == nil {
+		t.Errorf("creating a user of an unknown branch succeeded")
+	}
+
+	count, err := stores.Users.CountUsers(ctx)
+	if err != nil {
+		t.Fatalf("counting the users: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("got %d users, want 2", count)
+	}
+
+	_, err = stores.Users.GetUserByUsername(ctx, "nadie")
+	assertErrorIs(t, "getting an unknown username", err, models.ErrNotFound)
+	_, err = stores.Users.GetUserByID(ctx, created.ID+100)
+	assertErrorIs(t, "getting an unknown user", err, models.ErrNotFound)
+
+	t.Run("revoked tokens", func(t *testing.T) {
+		if err := stores.Users.RevokeToken(ctx, "expired", time.Now().Add(-time.Minute)); err != nil {
+			t.Fatalf("revoking a token: %v", err)
+		}
+		if err := stores.Users.RevokeToken(ctx, "active", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("revoking a token: %v", err)
+		}
+		err := stores.Users.RevokeToken(ctx, "active", time.Now().Add(time.Hour))
+		assertErrorIs(t, "revoking a token twice", err, models.ErrTokenRevoked)
+
+		// concurrent refreshes of the same token: only one of them revokes it
+		var (
+			wg      sync.WaitGroup
+			revoked atomic.Int64
+		)
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := stores.Users.RevokeToken(ctx, "concurrent", time.Now().Add(time.Hour))
+				if err == nil {
+					revoked.Add(1)
+					return
+				}
+				assertErrorIs(t, "revoking a token concurrently", err, models.ErrTokenRevoked)
+			}()
+		}
+		wg.Wait()
+		if revoked.Load() != 1 {
+			t.Errorf("the token was revoked %d times, want 1", revoked.Load())
+		}
+
+		deleted, err := stores.Users.DeleteExpiredRevokedTokens(ctx)
+		if err != nil {
+			t.Fatalf("deleting expired tokens: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("deleted %d tokens, want only the expired one", deleted)
+		}
+
+		for token, want := range map[string]bool{"active": true, "concurrent": true, "expired": false, "unknown": false} {
+			revoked, err := stores.Users.IsTokenRevoked(ctx, token)
+			if err != nil {
+				t.Fatalf("checking token %s: %v", token, err)
+			}
+			if revoked != want {
+				t.Errorf("token %s revoked: got %v, want %v", token, revoked, want)
+			}
+		}
+	})
+}
+
+func testAudit(t *testing.T, stores Stores) {
+	ctx := models.ContextWithUser(context.Background(), models.AuthenticatedUser{ID: 7, Username: "gerente"})
+	ctx = models.ContextWithRequestInfo(ctx, models.RequestInfo{RequestID: "request-1", ClientIP: "10.0.0.1"})
+	branchID := createBranch(t, stores, "Norte")
+
+	medicine, err := stores.Medicines.CreateMedicine(ctx, models.MedicineCreationRequest{Name: "Acetaminofen", Price: 300, BranchID: branchID})
+	if err != nil {
+		t.Fatalf("creating a medicine: %v", err)
+	}
+	if _, err := stores.Medicines.UpdatePrice(ctx, medicine.ID, 0, 350); err != nil {
+		t.Fatalf("updating the price: %v", err)
+	}
+	promotion, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(0, 3))
+	if err != nil {
+		t.Fatalf("creating a promotion: %v", err)
+	}
+
+	entries, err := stores.Audit.GetEntries(ctx, models.AuditFilter{Entity: models.AuditEntityMedicine, EntityID: medicine.ID})
+	if err != nil {
+		t.Fatalf("getting the entries of the medicine: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != models.AuditActionCreate || entries[1].Action != models.AuditActionUpdate {
+		t.Fatalf("got entries %+v, want the creation and the update oldest first", entries)
+	}
+	created := entries[0]
+	if created.ActorID != 7 || created.ActorUsername != "gerente" || created.RequestID != "request-1" || created.ClientIP != "10.0.0.1" {
+		t.Errorf("got entry %+v, want the actor and request of the context", created)
+	}
+	if created.Before != nil || created.After == nil || entries[1].Before == nil {
+		t.Errorf("got snapshots %s -> %s, want only the state after the creation", created.Before, created.After)
+	}
+
+	entries, err = stores.Audit.GetEntries(ctx, models.AuditFilter{Entity: models.AuditEntityPromotion})
+	if err != nil {
+		t.Fatalf("getting the entries of the promotions: %v", err)
+	}
+	if len(entries) != 1 || entries[0].EntityID != promotion.ID {
+		t.Errorf("got entries %+v, want the creation of the promotion", entries)
+	}
+
+	entries, err = stores.Audit.GetEntries(ctx, models.AuditFilter{From: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("getting future entries: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d future entries, want none", len(entries))
+	}
+
+	chain, err := stores.Audit.GetChain(ctx)
+	if err != nil {
+		t.Fatalf("getting the chain: %v", err)
+	}
+	if len(chain) != 3 {
+		t.Fatalf("got %d entries in the chain, want 3", len(chain))
+	}
+	prevHash := ""
+	for _, entry := range chain {
+		if entry.PrevHash != prevHash {
+			t.Errorf("entry %d: got previous hash %q, want %q", entry.ID, entry.PrevHash, prevHash)
+		}
+		if entry.Hash != entry.ComputeHash() {
+			t.Errorf("entry %d: hash does not match its contents", entry.ID)
+		}
+		prevHash = entry.Hash
+	}
+}
+
+func testIdempotency(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	record := models.IdempotencyRecord{
+		UserID:      1,
+		Key:         "key-1",
+		RequestHash: "hash-1",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	reserved, _, err := stores.Idempotency.Reserve(ctx, record)
+	if err != nil || !reserved {
+		t.Fatalf("reserving a new key: got %v, %v, want it reserved", reserved, err)
+	}
+	reserved, stored, err := stores.Idempotency.Reserve(ctx, record)
+	if err != nil {
+		t.Fatalf("reserving the key again: %v", err)
+	}
+	if reserved || stored == nil || stored.Status != models.IdempotencyInProgress || stored.RequestHash != "hash-1" {
+		t.Fatalf("reserving the key again: got %v, %+v, want the key in progress", reserved, stored)
+	}
+
+	otherUser := record
+	otherUser.UserID = 2
+	if reserved, _, err := stores.Idempotency.Reserve(ctx, otherUser); err != nil || !reserved {
+		t.Errorf("reserving the key of another user: got %v, %v, want it reserved", reserved, err)
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		completed := record
+		completed.ResponseStatus = 201
+		completed.ResponseBody = []byte(`{"id":1}`)
+		completed.ContentType = "application/json"
+		if err := stores.Idempotency.Complete(ctx, completed); err != nil {
+			t.Fatalf("completing the key: %v", err)
+		}
+		// a completed key is kept for the retries
+		if err := stores.Idempotency.Release(ctx, record.UserID, record.Key); err != nil {
+			t.Fatalf("releasing the key: %v", err)
+		}
+
+		reserved, stored, err := stores.Idempotency.Reserve(ctx, record)
+		if err != nil {
+			t.Fatalf("reserving the key again: %v", err)
+		}
+		if reserved || stored.Status != models.IdempotencyCompleted || stored.ResponseStatus != 201 ||
+			string(stored.ResponseBody) != `{"id":1}` || stored.ContentType != "application/json" {
+			t.Errorf("got %v, %+v, want the stored response", reserved, stored)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		if err := stores.Idempotency.Release(ctx, otherUser.UserID, otherUser.Key); err != nil {
+			t.Fatalf("releasing the key: %v", err)
+		}
+		if reserved, _, err := stores.Idempotency.Reserve(ctx, otherUser); err != nil || !reserved {
+			t.Errorf("reserving a released key: got %v, %v, want it reserved", reserved, err)
+		}
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		expired := record
+		expired.Key = "key-2"
+		expired.CreatedAt = now.Add(-2 * time.Hour)
+		expired.ExpiresAt = now.Add(-time.Hour)
+		if reserved, _, err := stores.Idempotency.Reserve(ctx, expired); err != nil || !reserved {
+			t.Fatalf("reserving a key: got %v, %v, want it reserved", reserved, err)
+		}
+
+		reused := expired
+		reused.RequestHash = "hash-2"
+		reused.CreatedAt = now
+		reused.ExpiresAt = now.Add(time.Hour)
+		if reserved, _, err := stores.Idempotency.Reserve(ctx, reused); err != nil || !reserved {
+			t.Errorf("reserving an expired key: got %v, %v, want it reserved", reserved, err)
+		}
+
+		expired.Key = "key-3"
+		if reserved, _, err := stores.Idempotency.Reserve(ctx, expired); err != nil || !reserved {
+			t.Fatalf("reserving a key: got %v, %v, want it reserved", reserved, err)
+		}
+		deleted, err := stores.Idempotency.DeleteExpired(ctx)
+		if err != nil {
+			t.Fatalf("deleting expired keys: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("deleted %d keys, want only the expired one", deleted)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+func testBranches(t *testing.T, stores Stores) {
+	ctx := context.Background()
+
+	created, err := stores.Branches.CreateBranch(ctx, models.BranchCreationRequest{Name: "Norte", Address: "Calle 100"})
+	if err != nil {
+		t.Fatalf("creating a branch: %v", err)
+	}
+	if created.ID <= 0 {
+		t.Fatalf("created branch has no id")
+	}
+
+	branch, err := stores.Branches.GetBranchByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the created branch: %v", err)
+	}
+	if branch.Name != "Norte" || branch.Address != "Calle 100" {
+		t.Errorf("got branch %+v, want the created one", branch)
+	}
+
+	second := createBranch(t, stores, "Sur")
+	branches, err := stores.Branches.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("getting the branches: %v", err)
+	}
+	// the migrations create the branch that held the data before branches existed
+	if len(branches) != 3 || branches[1].ID != created.ID || branches[2].ID != second {
+		t.Errorf("got branches %+v, want the seeded one and the created ones by id", branches)
+	}
+
+	_, err = stores.Branches.GetBranchByID(ctx, second+100)
+	assertErrorIs(t, "getting an unknown branch", err, models.ErrNotFound)
+}
+
+func testSuppliers(t *testing.T, stores Stores) {
+	ctx := context.Background()
+
+	request := models.SupplierCreationRequest{Name: "Tecnoquimicas", TaxID: "890300466", Email: "ventas@tq.com", Phone: "6024500"}
+	created, err := stores.Suppliers.CreateSupplier(ctx, request)
+	if err != nil {
+		t.Fatalf("creating a supplier: %v", err)
+	}
+
+	supplier, err := stores.Suppliers.GetSupplierByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the created supplier: %v", err)
+	}
+	if supplier.Name != request.Name || supplier.TaxID != request.TaxID || supplier.Email != request.Email || supplier.Phone != request.Phone {
+		t.Errorf("got supplier %+v, want %+v", supplier, request)
+	}
+
+	createSupplier(t, stores, "Audifarma")
+	suppliers, err := stores.Suppliers.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("getting the suppliers: %v", err)
+	}
+	if len(suppliers) != 2 || suppliers[0].Name != "Audifarma" || suppliers[1].Name != "Tecnoquimicas" {
+		t.Errorf("got suppliers %+v, want them sorted by name", suppliers)
+	}
+
+	_, err = stores.Suppliers.GetSupplierByID(ctx, created.ID+100)
+	assertErrorIs(t, "getting an unknown supplier", err, models.ErrNotFound)
+}
+
+func testMedicines(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchID := createBranch(t, stores, "Norte")
+
+	t.Run("create with presentations and stock", func(t *testing.T) {
+		created, err := stores.Medicines.CreateMedicine(ctx, models.MedicineCreationRequest{
+			Name:             "Acetaminofén 500mg",
+			ActiveIngredient: "Paracetamol",
+			Barcode:          "7702057011226",
+			Price:            300,
+			Location:         "A-1",
+			Stock:            40,
+			BranchID:         branchID,
+			Presentations: []models.PresentationCreationRequest{
+				{Name: "Caja x 100", ConversionFactor: 100, Price: 25000},
+				{Name: "Blister x 10", ConversionFactor: 10, Price: 2800},
+			},
+		})
+		if err != nil {
+			t.Fatalf("creating a medicine: %v", err)
+		}
+
+		medicine, err := stores.Medicines.GetMedicineByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("getting the created medicine: %v", err)
+		}
+		if medicine.Name != "Acetaminofén 500mg" || medicine.Price != 300 || medicine.Location != "A-1" || medicine.Stock != 40 {
+			t.Errorf("got medicine %+v, want the created one", medicine)
+		}
+		if medicine.BaseUnit != "tablet" {
+			t.Errorf("got base unit %q, want the default one", medicine.BaseUnit)
+		}
+		if stock := branchStock(t, stores, created.ID, branchID); stock != 40 {
+			t.Errorf("got %d units in the branch, want the initial stock", stock)
+		}
+
+		presentations, err := stores.Medicines.GetPresentations(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("getting the presentations: %v", err)
+		}
+		if len(presentations) != 2 || presentations[0].ConversionFactor != 10 || presentations[1].ConversionFactor != 100 {
+			t.Fatalf("got presentations %+v, want them sorted by conversion factor", presentations)
+		}
+
+		byIDs, err := stores.Medicines.GetPresentationsByIDs(ctx, []int64{presentations[1].ID})
+		if err != nil {
+			t.Fatalf("getting presentations by id: %v", err)
+		}
+		if len(byIDs) != 1 || byIDs[0].Name != "Caja x 100" || byIDs[0].MedicineID != created.ID {
+			t.Errorf("got presentations %+v, want the box", byIDs)
+		}
+	})
+
+	t.Run("list and get by ids", func(t *testing.T) {
+		cheap := createMedicine(t, stores, "Loratadina", 150, branchID, 0)
+		expensive := createMedicine(t, stores, "Omeprazol", 900, branchID, 0)
+
+		medicines, err := stores.Medicines.GetMedicinesByIDs(ctx, []int64{expensive.ID, cheap.ID, expensive.ID + 100})
+		if err != nil {
+			t.Fatalf("getting medicines by id: %v", err)
+		}
+		if len(medicines) != 2 {
+			t.Errorf("got %d medicines, want only the existing ones", len(medicines))
+		}
+
+		all, err := stores.Medicines.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("getting the medicines: %v", err)
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i-1].Price > all[i].Price {
+				t.Fatalf("medicines are not sorted by price: %+v", all)
+			}
+		}
+
+		_, err = stores.Medicines.GetMedicineByID(ctx, expensive.ID+100)
+		assertErrorIs(t, "getting an unknown medicine", err, models.ErrNotFound)
+	})
+
+	t.Run("update price", func(t *testing.T) {
+		medicine := createMedicine(t, stores, "Ibuprofeno", 400, branchID, 0)
+
+		updated, err := stores.Medicines.UpdatePrice(ctx, medicine.ID, medicine.Version, 450)
+		if err != nil {
+			t.Fatalf("updating the price: %v", err)
+		}
+		if updated.Price != 450 || updated.Version != medicine.Version+1 {
+			t.Errorf("got price %v version %d, want 450 version %d", updated.Price, updated.Version, medicine.Version+1)
+		}
+
+		_, err = stores.Medicines.UpdatePrice(ctx, medicine.ID, medicine.Version, 500)
+		assertErrorIs(t, "updating from a stale version", err, models.ErrVersionConflict)
+		_, err = stores.Medicines.UpdatePrice(ctx, medicine.ID+100, 0, 500)
+		assertErrorIs(t, "updating an unknown medicine", err, models.ErrNotFound)
+
+		stored, err := stores.Medicines.GetMedicineByID(ctx, medicine.ID)
+		if err != nil {
+			t.Fatalf("getting the medicine: %v", err)
+		}
+		if stored.Price != 450 || stored.Version != updated.Version {
+			t.Errorf("got price %v version %d, want the first update only", stored.Price, stored.Version)
+		}
+	})
+
+	t.Run("create presentation", func(t *testing.T) {
+		medicine := createMedicine(t, stores, "Amoxicilina", 700, branchID, 0)
+
+		presentation, err := stores.Medicines.CreatePresentation(ctx, medicine.ID, models.PresentationCreationRequest{
+			Name: "Caja x 50", ConversionFactor: 50, Price: 30000,
+		})
+		if err != nil {
+			t.Fatalf("creating a presentation: %v", err)
+		}
+		if presentation.MedicineID != medicine.ID || presentation.ID <= 0 {
+			t.Errorf("got presentation %+v, want one of medicine %d", presentation, medicine.ID)
+		}
+
+		// the presentations are part of the medicine
+		stored, err := stores.Medicines.GetMedicineByID(ctx, medicine.ID)
+		if err != nil {
+			t.Fatalf("getting the medicine: %v", err)
+		}
+		if stored.Version <= medicine.Version {
+			t.Errorf("got version %d, want it to change from %d", stored.Version, medicine.Version)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		results, err := stores.Medicines.Search(ctx, "acetaminofen", 10)
+		if err != nil {
+			t.Fatalf("searching by name: %v", err)
+		}
+		if len(results) == 0 || results[0].Name != "Acetaminofén 500mg" {
+			t.Errorf("got %+v, want the accent-insensitive match first", results)
+		}
+
+		results, err = stores.Medicines.Search(ctx, "7702057011226", 10)
+		if err != nil {
+			t.Fatalf("searching by barcode: %v", err)
+		}
+		if len(results) == 0 || results[0].Barcode != "7702057011226" {
+			t.Errorf("got %+v, want the barcode match first", results)
+		}
+
+		results, err = stores.Medicines.Search(ctx, "zzzzzz", 10)
+		if err != nil {
+			t.Fatalf("searching an unknown medicine: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("got %+v, want no results", results)
+		}
+	})
+}
@@ -0,0 +1,446 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+func testPurchaseOrders(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchID := createBranch(t, stores, "Norte")
+	supplierID := createSupplier(t, stores, "Tecnoquimicas")
+	medicine := createMedicine(t, stores, "Acetaminofen", 300, branchID, 10)
+
+	order, err := stores.PurchaseOrders.CreatePurchaseOrder(ctx, models.PurchaseOrderCreationRequest{
+		SupplierID: supplierID,
+		BranchID:   branchID,
+		Notes:      "pedido semanal",
+		Lines:      []models.PurchaseOrderLineRequest{{MedicineID: medicine.ID, Quantity: 30, UnitCost: 200}},
+	})
+	if err != nil {
+		t.Fatalf("creating a purchase order: %v", err)
+	}
+
+	stored, err := stores.PurchaseOrders.GetPurchaseOrderByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("getting the purchase order: %v", err)
+	}
+	if stored.Status != models.PurchaseOrderDraft || stored.Notes != "pedido semanal" || len(stored.Lines) != 1 {
+		t.Fatalf("got purchase order %+v, want a draft with one line", stored)
+	}
+	line := stored.Lines[0]
+	if line.ID != order.Lines[0].ID || line.Quantity != 30 || line.ReceivedQuantity != 0 {
+		t.Errorf("got line %+v, want the created one", line)
+	}
+	_, err = stores.PurchaseOrders.GetPurchaseOrderByID(ctx, order.ID+100)
+	assertErrorIs(t, "getting an unknown purchase order", err, models.ErrNotFound)
+
+	t.Run("update status", func(t *testing.T) {
+		if err := stores.PurchaseOrders.UpdateStatus(ctx, order.ID, models.PurchaseOrderDraft, models.PurchaseOrderSent); err != nil {
+			t.Fatalf("sending the order: %v", err)
+		}
+		err := stores.PurchaseOrders.UpdateStatus(ctx, order.ID, models.PurchaseOrderDraft, models.PurchaseOrderSent)
+		assertErrorIs(t, "sending the order again", err, models.ErrNotFound)
+	})
+
+	t.Run("goods receipt", func(t *testing.T) {
+		receipt := models.GoodsReceipt{
+			PurchaseOrderID: order.ID,
+			Lines:           []models.GoodsReceiptLine{{PurchaseOrderLineID: line.ID, MedicineID: medicine.ID, Quantity: 10, UnitCost: 400}},
+			ReceivedAt:      baseTime,
+		}
+		created, err := stores.PurchaseOrders.RegisterGoodsReceipt(ctx, receipt)
+		if err != nil {
+			t.Fatalf("registering a partial receipt: %v", err)
+		}
+		if created.ID <= 0 {
+			t.Errorf("got receipt %+v, want an id", created)
+		}
+
+		stored, err := stores.PurchaseOrders.GetPurchaseOrderByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("getting the purchase order: %v", err)
+		}
+		if stored.Status != models.PurchaseOrderPartiallyReceived || stored.Lines[0].ReceivedQuantity != 10 {
+			t.Errorf("got purchase order %+v, want 10 units partially received", stored)
+		}
+		if stock := branchStock(t, stores, medicine.ID, branchID); stock != 20 {
+			t.Errorf("got %d units in the branch, want 20", stock)
+		}
+		// the medicine was created without cost, so the average is the cost of the purchase
+		received, err := stores.Medicines.GetMedicineByID(ctx, medicine.ID)
+		if err != nil {
+			t.Fatalf("getting the medicine: %v", err)
+		}
+		if received.AverageCost <= 0 {
+			t.Errorf("got average cost %v, want it to include the purchase", received.AverageCost)
+		}
+
+		receipt.Lines[0].Quantity = 21
+		_, err = stores.PurchaseOrders.RegisterGoodsReceipt(ctx, receipt)
+		assertErrorIs(t, "receiving more than pending", err, models.ErrReceiptExceedsOrder)
+		if stock := branchStock(t, stores, medicine.ID, branchID); stock != 20 {
+			t.Errorf("got %d units in the branch after a rejected receipt, want 20", stock)
+		}
+
+		receipt.Lines[0].Quantity = 20
+		if _, err := stores.PurchaseOrders.RegisterGoodsReceipt(ctx, receipt); err != nil {
+			t.Fatalf("receiving the pending units: %v", err)
+		}
+		stored, err = stores.PurchaseOrders.GetPurchaseOrderByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("getting the purchase order: %v", err)
+		}
+		if stored.Status != models.PurchaseOrderReceived {
+			t.Errorf("got status %s, want %s", stored.Status, models.PurchaseOrderReceived)
+		}
+
+		_, err = stores.PurchaseOrders.RegisterGoodsReceipt(ctx, models.GoodsReceipt{PurchaseOrderID: order.ID + 100, ReceivedAt: baseTime})
+		assertErrorIs(t, "receiving an unknown order", err, models.ErrNotFound)
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		otherBranch := createBranch(t, stores, "Sur")
+		if _, err := stores.PurchaseOrders.CreatePurchaseOrder(ctx, models.PurchaseOrderCreationRequest{
+			SupplierID: supplierID, BranchID: otherBranch,
+		}); err != nil {
+			t.Fatalf("creating a purchase order: %v", err)
+		}
+
+		tests := []struct {
+			name     string
+			status   string
+			branchID int64
+			want     int
+		}{
+			{"all", "", 0, 2},
+			{"by status", models.PurchaseOrderReceived, 0, 1},
+			{"by branch", "", otherBranch, 1},
+			{"by status and branch", models.PurchaseOrderReceived, otherBranch, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				orders, err := stores.PurchaseOrders.GetAll(ctx, tt.status, tt.branchID)
+				if err != nil {
+					t.Fatalf("getting purchase orders: %v", err)
+				}
+				if len(orders) != tt.want {
+					t.Errorf("got %d purchase orders, want %d", len(orders), tt.want)
+				}
+			})
+		}
+	})
+}
+
+func testTransfers(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	source := createBranch(t, stores, "Norte")
+	destination := createBranch(t, stores, "Sur")
+	medicine := createMedicine(t, stores, "Acetaminofen", 300, source, 30)
+
+	created, err := stores.Transfers.CreateTransfer(ctx, models.StockTransferCreationRequest{
+		SourceBranchID:      source,
+		DestinationBranchID: destination,
+		Lines:               []models.StockTransferLineRequest{{MedicineID: medicine.ID, Quantity: 12}},
+	})
+	if err != nil {
+		t.Fatalf("creating a transfer: %v", err)
+	}
+	transfer, err := stores.Transfers.GetTransferByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the transfer: %v", err)
+	}
+	if transfer.Status != models.TransferDraft || len(transfer.Lines) != 1 || transfer.Lines[0].ReceivedQuantity != nil {
+		t.Fatalf("got transfer %+v, want a draft with one line not received", transfer)
+	}
+	_, err = stores.Transfers.GetTransferByID(ctx, created.ID+100)
+	assertErrorIs(t, "getting an unknown transfer", err, models.ErrNotFound)
+
+	err = stores.Transfers.Receive(ctx, *transfer)
+	assertErrorIs(t, "receiving a draft", err, models.ErrInvalidTransition)
+
+	if err := stores.Transfers.Ship(ctx, *transfer); err != nil {
+		t.Fatalf("shipping the transfer: %v", err)
+	}
+	if stock := branchStock(t, stores, medicine.ID, source); stock != 18 {
+		t.Errorf("got %d units in the source branch, want 18", stock)
+	}
+	err = stores.Transfers.Ship(ctx, *transfer)
+	assertErrorIs(t, "shipping the transfer again", err, models.ErrInvalidTransition)
+
+	shipped, err := stores.Transfers.GetTransferByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the transfer: %v", err)
+	}
+	if shipped.Status != models.TransferShipped || shipped.ShippedAt == nil {
+		t.Errorf("got transfer %+v, want it shipped", shipped)
+	}
+
+	beyond := int64(13)
+	shipped.Lines[0].ReceivedQuantity = &beyond
+	if err := stores.Transfers.Receive(ctx, *shipped); err == nil {
+		t.Errorf("receiving more units than shipped succeeded")
+	}
+	if stock := branchStock(t, stores, medicine.ID, destination); stock != 0 {
+		t.Errorf("got %d units in the destination branch after the rejected receipt, want 0", stock)
+	}
+
+	received := int64(10)
+	shipped.Lines[0].ReceivedQuantity = &received
+	shipped.Lines[0].DiscrepancyNotes = "dos cajas rotas"
+	if err := stores.Transfers.Receive(ctx, *shipped); err != nil {
+		t.Fatalf("receiving the transfer: %v", err)
+	}
+	if stock := branchStock(t, stores, medicine.ID, destination); stock != 10 {
+		t.Errorf("got %d units in the destination branch, want 10", stock)
+	}
+	if stock := totalStock(t, stores, medicine.ID); stock != 28 {
+		t.Errorf("got %d units in total, want 28", stock)
+	}
+
+	stored, err := stores.Transfers.GetTransferByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("getting the transfer: %v", err)
+	}
+	line := stored.Lines[0]
+	if stored.Status != models.TransferReceived || line.ReceivedQuantity == nil || *line.ReceivedQuantity != 10 {
+		t.Fatalf("got transfer %+v, want 10 units received", stored)
+	}
+	if line.Discrepancy != 2 || line.DiscrepancyNotes != "dos cajas rotas" {
+		t.Errorf("got discrepancy %d %q, want 2 with the notes", line.Discrepancy, line.DiscrepancyNotes)
+	}
+
+	other := createBranch(t, stores, "Centro")
+	for _, branchID := range []int64{source, destination} {
+		transfers, err := stores.Transfers.GetAll(ctx, "", branchID)
+		if err != nil {
+			t.Fatalf("getting the transfers: %v", err)
+		}
+		if len(transfers) != 1 {
+			t.Errorf("got %d transfers of branch %d, want the transfer as source or destination", len(transfers), branchID)
+		}
+	}
+	transfers, err := stores.Transfers.GetAll(ctx, models.TransferShipped, 0)
+	if err != nil {
+		t.Fatalf("getting the transfers: %v", err)
+	}
+	if len(transfers) != 0 {
+		t.Errorf("got %d transfers in transit, want none", len(transfers))
+	}
+	transfers, err = stores.Transfers.GetAll(ctx, "", other)
+	if err != nil {
+		t.Fatalf("getting the transfers: %v", err)
+	}
+	if len(transfers) != 0 {
+		t.Errorf("got %d transfers of an unrelated branch, want none", len(transfers))
+	}
+}
+
+func testInventoryCounts(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchID := createBranch(t, stores, "Norte")
+	counted := createMedicine(t, stores, "Acetaminofen", 300, branchID, 20)
+	pending := createMedicine(t, stores, "Loratadina", 150, branchID, 5)
+	createMedicine(t, stores, "Omeprazol", 900, branchID, 7)
+
+	full, err := stores.InventoryCounts.CreateCount(ctx, models.InventoryCountCreationRequest{
+		CountType: models.InventoryCountFull,
+		BranchID:  branchID,
+	})
+	if err != nil {
+		t.Fatalf("creating a full count: %v", err)
+	}
+	stored, err := stores.InventoryCounts.GetCountByID(ctx, full.ID)
+	if err != nil {
+		t.Fatalf("getting the count: %v", err)
+	}
+	if stored.Status != models.InventoryCountOpen || len(stored.Lines) != 3 {
+		t.Errorf("got count %+v, want it open with every medicine", stored)
+	}
+	if err := stores.InventoryCounts.Cancel(ctx, full.ID); err != nil {
+		t.Fatalf("cancelling the count: %v", err)
+	}
+	err = stores.InventoryCounts.Cancel(ctx, full.ID)
+	assertErrorIs(t, "cancelling the count again", err, models.ErrInvalidTransition)
+	_, err = stores.InventoryCounts.GetCountByID(ctx, full.ID+100)
+	assertErrorIs(t, "getting an unknown count", err, models.ErrNotFound)
+
+	count, err := stores.InventoryCounts.CreateCount(ctx, models.InventoryCountCreationRequest{
+		CountType:   models.InventoryCountCycle,
+		MedicineIDs: []int64{counted.ID, pending.ID},
+		BranchID:    branchID,
+	})
+	if err != nil {
+		t.Fatalf("creating a cycle count: %v", err)
+	}
+
+	err = stores.InventoryCounts.RecordLines(ctx, *count, []models.InventoryCountRecordLineRequest{
+		{MedicineID: counted.ID, Location: "B-2", CountedQuantity: 17},
+	})
+	if err != nil {
+		t.Fatalf("recording the count: %v", err)
+	}
+	stored, err = stores.InventoryCounts.GetCountByID(ctx, count.ID)
+	if err != nil {
+		t.Fatalf("getting the count: %v", err)
+	}
+	if len(stored.Lines) != 2 {
+		t.Fatalf("got lines %+v, want the requested medicines", stored.Lines)
+	}
+	for _, line := range stored.Lines {
+		if line.MedicineID != counted.ID {
+			continue
+		}
+		if line.CountedQuantity == nil || *line.CountedQuantity != 17 || line.SystemQuantity != 20 || line.Location != "B-2" {
+			t.Errorf("got line %+v, want 17 units counted in B-2 against 20 in the system", line)
+		}
+	}
+
+	err = stores.InventoryCounts.Approve(ctx, *stored)
+	assertErrorIs(t, "approving an incomplete count", err, models.ErrInventoryCountIncomplete)
+
+	err = stores.InventoryCounts.RecordLines(ctx, *count, []models.InventoryCountRecordLineRequest{
+		{MedicineID: pending.ID, CountedQuantity: 5},
+		{MedicineID: counted.ID, Location: "B-2", CountedQuantity: 16},
+		{MedicineID: counted.ID, Location: "C-3", CountedQuantity: 3},
+	})
+	if err != nil {
+		t.Fatalf("recording the count: %v", err)
+	}
+	stored, err = stores.InventoryCounts.GetCountByID(ctx, count.ID)
+	if err != nil {
+		t.Fatalf("getting the count: %v", err)
+	}
+	locations := make(map[string]int64)
+	for _, line := range stored.Lines {
+		if line.MedicineID == counted.ID && line.CountedQuantity != nil {
+			locations[line.Location] = *line.CountedQuantity
+		}
+	}
+	if len(stored.Lines) != 3 || len(locations) != 2 || locations["B-2"] != 16 || locations["C-3"] != 3 {
+		t.Errorf("got lines %+v, want 16 units counted in B-2 and 3 in C-3", stored.Lines)
+	}
+	if err := stores.InventoryCounts.Approve(ctx, *stored); err != nil {
+		t.Fatalf("approving the count: %v", err)
+	}
+	if stock := branchStock(t, stores, counted.ID, branchID); stock != 19 {
+		t.Errorf("got %d units in the branch, want the ones counted in both locations", stock)
+	}
+	if stock := branchStock(t, stores, pending.ID, branchID); stock != 5 {
+		t.Errorf("got %d units in the branch, want them unchanged", stock)
+	}
+
+	err = stores.InventoryCounts.RecordLines(ctx, *count, []models.InventoryCountRecordLineRequest{
+		{MedicineID: pending.ID, CountedQuantity: 1},
+	})
+	assertErrorIs(t, "recording an approved count", err, models.ErrInvalidTransition)
+	err = stores.InventoryCounts.Approve(ctx, *stored)
+	assertErrorIs(t, "approving the count again", err, models.ErrInvalidTransition)
+
+	counts, err := stores.InventoryCounts.GetAll(ctx, models.InventoryCountApproved, branchID)
+	if err != nil {
+		t.Fatalf("getting the counts: %v", err)
+	}
+	if len(counts) != 1 || counts[0].ID != count.ID || counts[0].ApprovedAt == nil {
+		t.Errorf("got counts %+v, want the approved one", counts)
+	}
+}
+
+func testReorders(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchID := createBranch(t, stores, "Norte")
+	medicine := createMedicine(t, stores, "Acetaminofen", 300, branchID, 40)
+	createMedicine(t, stores, "Loratadina", 150, branchID, 8)
+
+	billing := models.BillingDetail{
+		BranchID:  branchID,
+		Items:     []models.BillingItem{{MedicineID: medicine.ID, MedicineName: medicine.Name, Quantity: 6, BaseQuantity: 6, UnitPrice: 300, Subtotal: 1800}},
+		Total:     1800,
+		CreatedAt: baseTime,
+	}
+	if _, err := stores.Billings.CreateBilling(ctx, billing); err != nil {
+		t.Fatalf("creating a billing: %v", err)
+	}
+	billing.CreatedAt = baseTime.AddDate(0, 0, -40)
+	if _, err := stores.Billings.CreateBilling(ctx, billing); err != nil {
+		t.Fatalf("creating a billing: %v", err)
+	}
+	supplierID := createSupplier(t, stores, "Tecnoquimicas")
+	if _, err := stores.PurchaseOrders.CreatePurchaseOrder(ctx, models.PurchaseOrderCreationRequest{
+		SupplierID: supplierID,
+		BranchID:   branchID,
+		Lines:      []models.PurchaseOrderLineRequest{{MedicineID: medicine.ID, Quantity: 25, UnitCost: 200}},
+	}); err != nil {
+		t.Fatalf("creating a purchase order: %v", err)
+	}
+
+	t.Run("medicines sales", func(t *testing.T) {
+		for _, branch := range []int64{0, branchID} {
+			sales, err := stores.Reorders.GetMedicinesSales(ctx, baseTime.AddDate(0, 0, -30), branch)
+			if err != nil {
+				t.Fatalf("getting the sales: %v", err)
+			}
+			if len(sales) != 2 || sales[0].MedicineID != medicine.ID {
+				t.Fatalf("got sales %+v, want every medicine sorted by name", sales)
+			}
+			if sales[0].SoldQuantity != 6 || sales[0].Stock != 28 || sales[0].PendingQuantity != 25 {
+				t.Errorf("got sales %+v, want 6 sold in the window, 28 in stock and 25 pending", sales[0])
+			}
+			if sales[1].SoldQuantity != 0 || sales[1].Stock != 8 {
+				t.Errorf("got sales %+v, want no sales and 8 in stock", sales[1])
+			}
+		}
+
+		sales, err := stores.Reorders.GetMedicinesSales(ctx, baseTime.AddDate(0, 0, -30), createBranch(t, stores, "Sur"))
+		if err != nil {
+			t.Fatalf("getting the sales: %v", err)
+		}
+		if len(sales) != 2 || sales[0].SoldQuantity != 0 || sales[0].Stock != 0 || sales[0].PendingQuantity != 0 {
+			t.Errorf("got sales %+v, want nothing in a branch without movements", sales)
+		}
+	})
+
+	t.Run("drafts", func(t *testing.T) {
+		_, err := stores.Reorders.GetLatestDraft(ctx, branchID)
+		assertErrorIs(t, "getting the draft before creating it", err, models.ErrNotFound)
+
+		daysOfCover := 4.5
+		draft := models.ReorderDraft{
+			BranchID:      branchID,
+			ReorderParams: models.ReorderParams{WindowDays: 30, LeadTimeDays: 7, SafetyStockDays: 3},
+			Suggestions: []models.ReorderSuggestion{
+				{MedicineID: medicine.ID, AverageDailySales: 0.2, Stock: 28, PendingQuantity: 25, DaysOfCover: &daysOfCover, SuggestedQuantity: 2},
+			},
+		}
+		if _, err := stores.Reorders.CreateDraft(ctx, draft); err != nil {
+			t.Fatalf("creating a draft: %v", err)
+		}
+		draft.Suggestions[0].SuggestedQuantity = 5
+		created, err := stores.Reorders.CreateDraft(ctx, draft)
+		if err != nil {
+			t.Fatalf("creating a draft: %v", err)
+		}
+
+		latest, err := stores.Reorders.GetLatestDraft(ctx, branchID)
+		if err != nil {
+			t.Fatalf("getting the latest draft: %v", err)
+		}
+		if latest.ID != created.ID || latest.WindowDays != 30 || len(latest.Suggestions) != 1 {
+			t.Fatalf("got draft %+v, want the last created one", latest)
+		}
+		suggestion := latest.Suggestions[0]
+		if suggestion.MedicineName != medicine.Name || suggestion.SuggestedQuantity != 5 ||
+			suggestion.DaysOfCover == nil || *suggestion.DaysOfCover != daysOfCover {
+			t.Errorf("got suggestion %+v, want the created one with the medicine name", suggestion)
+		}
+		if !latest.CreatedAt.After(time.Time{}) {
+			t.Errorf("got draft without creation date")
+		}
+
+		_, err = stores.Reorders.GetLatestDraft(ctx, 0)
+		assertErrorIs(t, "getting a consolidated draft", err, models.ErrNotFound)
+	})
+}
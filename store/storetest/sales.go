@@ -0,0 +1,750 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+func promotionRequest(startDays, days int, branchIDs ...int64) models.PromotionCreationRequest {
+	start := baseTime.AddDate(0, 0, startDays)
+
+	return models.PromotionCreationRequest{
+		Description: "promo",
+		Percentage:  10,
+		StartDate:   start,
+		EndDate:     start.AddDate(0, 0, days),
+		BranchIDs:   branchIDs,
+	}
+}
+
+func testPromotions(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchA := createBranch(t, stores, "Norte")
+	branchB := createBranch(t, stores, "Sur")
+
+	first, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(0, 5, branchA))
+	if err != nil {
+		t.Fatalf("creating a promotion: %v", err)
+	}
+	second, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(-10, 5))
+	if err != nil {
+		t.Fatalf("creating a promotion for every branch: %v", err)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		promotion, err := stores.Promotions.GetPromoByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("getting the promotion: %v", err)
+		}
+		if !sameInstant(promotion.StartDate, first.StartDate) || !sameInstant(promotion.EndtDate, first.EndtDate) {
+			t.Errorf("got dates %v - %v, want %v - %v", promotion.StartDate, promotion.EndtDate, first.StartDate, first.EndtDate)
+		}
+		if !sameIDs(promotion.BranchIDs, []int64{branchA}) || promotion.Version != 1 {
+			t.Errorf("got promotion %+v, want branch %d at version 1", promotion, branchA)
+		}
+
+		all, err := stores.Promotions.GetAll(ctx)
+		if err != nil {
+			t.Fatalf("getting the promotions: %v", err)
+		}
+		if len(all) != 2 || all[0].ID != second.ID || all[1].ID != first.ID {
+			t.Errorf("got promotions %+v, want them sorted by start date", all)
+		}
+
+		_, err = stores.Promotions.GetPromoByID(ctx, first.ID+100)
+		assertErrorIs(t, "getting an unknown promotion", err, models.ErrNotFound)
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		if _, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(2, 5, branchB)); err != nil {
+			t.Errorf("promotions of different branches must be allowed to overlap: %v", err)
+		}
+		_, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(3, 5, branchA))
+		assertErrorIs(t, "creating an overlapping promotion", err, models.ErrPromotionOverlap)
+		_, err = stores.Promotions.CreatePromotion(ctx, promotionRequest(-8, 1, branchB))
+		assertErrorIs(t, "creating a promotion overlapping one for every branch", err, models.ErrPromotionOverlap)
+	})
+
+	t.Run("count between dates", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			startDays int
+			days      int
+			branchIDs []int64
+			excluded  int64
+			want      int
+		}{
+			{"same branch", -1, 2, []int64{branchA}, 0, 1},
+			{"range inside the promotion", 1, 1, []int64{branchA}, 0, 1},
+			{"range containing the promotion", -1, 10, []int64{branchA}, 0, 1},
+			{"other branch", 20, 1, []int64{branchB}, 0, 0},
+			{"excluding the promotion", -1, 2, []int64{branchA}, first.ID, 0},
+			{"every branch", -6, 2, nil, 0, 1},
+			{"no promotion", 30, 2, nil, 0, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				request := promotionRequest(tt.startDays, tt.days)
+				got, err := stores.Promotions.CountPromosBetweenDates(ctx, request.StartDate, request.EndDate, tt.branchIDs, tt.excluded)
+				if err != nil {
+					t.Fatalf("counting promotions: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("got %d promotions, want %d", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("get active between", func(t *testing.T) {
+		instant := baseTime.Add(12 * time.Hour)
+		lastWeek := baseTime.AddDate(0, 0, -7)
+		later := instant.AddDate(0, 0, 40)
+
+		promotion, err := stores.Promotions.GetActiveBetween(ctx, instant, instant, branchA)
+		if err != nil {
+			t.Fatalf("getting the active promotion: %v", err)
+		}
+		if promotion.ID != first.ID {
+			t.Errorf("got promotion %d, want %d", promotion.ID, first.ID)
+		}
+
+		for _, branchID := range []int64{branchA, 0} {
+			promotion, err = stores.Promotions.GetActiveBetween(ctx, lastWeek, lastWeek, branchID)
+			if err != nil {
+				t.Fatalf("getting the promotion for every branch from branch %d: %v", branchID, err)
+			}
+			if promotion.ID != second.ID {
+				t.Errorf("got promotion %d in branch %d, want %d", promotion.ID, branchID, second.ID)
+			}
+		}
+
+		_, err = stores.Promotions.GetActiveBetween(ctx, instant, instant, branchB)
+		assertErrorIs(t, "getting the promotion of another branch", err, models.ErrNotFound)
+		_, err = stores.Promotions.GetActiveBetween(ctx, later, later, branchA)
+		assertErrorIs(t, "getting the promotion of a day without one", err, models.ErrNotFound)
+	})
+
+	t.Run("get active between a range", func(t *testing.T) {
+		lastWeek := baseTime.AddDate(0, 0, -7)
+		tests := []struct {
+			name     string
+			start    time.Time
+			end      time.Time
+			branchID int64
+			want     int64
+		}{
+			{"starting during the range", baseTime.Add(-12 * time.Hour), baseTime.Add(time.Hour), branchA, first.ID},
+			{"of the branch over one for every branch", lastWeek, baseTime, branchA, first.ID},
+			{"for every branch", lastWeek, baseTime, 0, second.ID},
+			{"ending before the promotion starts", baseTime.Add(-12 * time.Hour), baseTime.Add(-time.Microsecond), branchA, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				promotion, err := stores.Promotions.GetActiveBetween(ctx, tt.start, tt.end, tt.branchID)
+				if tt.want == 0 {
+					assertErrorIs(t, "getting a promotion out of the range", err, models.ErrNotFound)
+					return
+				}
+				if err != nil {
+					t.Fatalf("getting the active promotion: %v", err)
+				}
+				if promotion.ID != tt.want {
+					t.Errorf("got promotion %d, want %d", promotion.ID, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		request := promotionRequest(0, 6, branchA)
+		request.Percentage = 15
+
+		updated, err := stores.Promotions.UpdatePromotion(ctx, first.ID, 1, request)
+		if err != nil {
+			t.Fatalf("updating the promotion: %v", err)
+		}
+		if updated.Version != 2 || updated.Percentage != 15 {
+			t.Errorf("got promotion %+v, want percentage 15 at version 2", updated)
+		}
+
+		_, err = stores.Promotions.UpdatePromotion(ctx, first.ID, 1, request)
+		assertErrorIs(t, "updating from a stale version", err, models.ErrVersionConflict)
+		_, err = stores.Promotions.UpdatePromotion(ctx, first.ID+100, 0, request)
+		assertErrorIs(t, "updating an unknown promotion", err, models.ErrNotFound)
+		_, err = stores.Promotions.UpdatePromotion(ctx, first.ID, 0, promotionRequest(-9, 1, branchA))
+		assertErrorIs(t, "moving the promotion over another one", err, models.ErrPromotionOverlap)
+
+		stored, err := stores.Promotions.GetPromoByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("getting the promotion: %v", err)
+		}
+		if stored.Version != 2 || stored.Percentage != 15 || !sameInstant(stored.EndtDate, request.EndDate) {
+			t.Errorf("got promotion %+v, want only the first update applied", stored)
+		}
+	})
+}
+
+// testPromotionPeriods checks the date queries against models.Promotion.Overlaps for every
+// closed interval of a grid of hours, queried right before, at and right after each hour,
+// and for random ranges. Every promotion has its own branch so none of them overlap.
+func testPromotionPeriods(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	const hours = 5
+	hour := func(i int) time.Time {
+		return baseTime.Add(time.Duration(i) * time.Hour)
+	}
+
+	promotions := make([]models.Promotion, 0)
+	for start := 0; start < hours; start++ {
+		for end := start; end < hours; end++ {
+			branchID := createBranch(t, stores, fmt.Sprintf("Sede %d-%d", start, end))
+			promotion, err := stores.Promotions.CreatePromotion(ctx, models.PromotionCreationRequest{
+				Description: "promo",
+				Percentage:  10,
+				StartDate:   hour(start),
+				EndDate:     hour(end),
+				BranchIDs:   []int64{branchID},
+			})
+			if err != nil {
+				t.Fatalf("creating the promotion of hours %d to %d: %v", start, end, err)
+			}
+			promotions = append(promotions, *promotion)
+		}
+	}
+
+	instants := make([]time.Time, 0)
+	for i := 0; i < hours; i++ {
+		instants = append(instants, hour(i).Add(-time.Microsecond), hour(i), hour(i).Add(time.Microsecond))
+	}
+
+	countAll := func(t *testing.T, startDate, endDate time.Time) {
+		t.Helper()
+
+		want := 0
+		for _, promotion := range promotions {
+			if promotion.Overlaps(startDate, endDate) {
+				want++
+			}
+		}
+		got, err := stores.Promotions.CountPromosBetweenDates(ctx, startDate, endDate, nil, 0)
+		if err != nil {
+			t.Fatalf("counting promotions: %v", err)
+		}
+		if got != want {
+			t.Errorf("got %d promotions between %v and %v, want %d", got, startDate, endDate, want)
+		}
+	}
+
+	t.Run("count between dates", func(t *testing.T) {
+		for i, startDate := range instants {
+			for _, endDate := range instants[i:] {
+				countAll(t, startDate, endDate)
+				for _, promotion := range promotions {
+					want := 0
+					if promotion.Overlaps(startDate, endDate) {
+						want = 1
+					}
+					got, err := stores.Promotions.CountPromosBetweenDates(ctx, startDate, endDate, promotion.BranchIDs, 0)
+					if err != nil {
+						t.Fatalf("counting promotions: %v", err)
+					}
+					if got != want {
+						t.Errorf("got %d promotions of %v - %v between %v and %v, want %d",
+							got, promotion.StartDate, promotion.EndtDate, startDate, endDate, want)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("get active between", func(t *testing.T) {
+		for _, instant := range instants {
+			for _, promotion := range promotions {
+				got, err := stores.Promotions.GetActiveBetween(ctx, instant, instant, promotion.BranchIDs[0])
+				if !promotion.ActiveAt(instant) {
+					assertErrorIs(t, "getting a promotion out of its period", err, models.ErrNotFound)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("getting the promotion of %v - %v at %v: %v", promotion.StartDate, promotion.EndtDate, instant, err)
+				}
+				if got.ID != promotion.ID {
+					t.Errorf("got promotion %d at %v, want %d", got.ID, instant, promotion.ID)
+				}
+			}
+
+			_, err := stores.Promotions.GetActiveBetween(ctx, instant, instant, 0)
+			assertErrorIs(t, "getting a promotion for every branch", err, models.ErrNotFound)
+		}
+	})
+
+	t.Run("random ranges", func(t *testing.T) {
+		// the offsets are microseconds around the grid, the precision the database keeps
+		span := int64(hours+2) * time.Hour.Microseconds()
+		offset := func(value uint32) time.Time {
+			return hour(-1).Add(time.Duration(int64(value)%span) * time.Microsecond)
+		}
+		config := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+
+		err := quick.Check(func(a, b uint32) bool {
+			startDate, endDate := offset(a), offset(b)
+			if endDate.Before(startDate) {
+				startDate, endDate = endDate, startDate
+			}
+			countAll(t, startDate, endDate)
+			return !t.Failed()
+		}, config)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func testBillings(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchA := createBranch(t, stores, "Norte")
+	branchB := createBranch(t, stores, "Sur")
+	medicine := createMedicine(t, stores, "Acetaminofen", 300, branchA, 50)
+	presentation, err := stores.Medicines.CreatePresentation(ctx, medicine.ID, models.PresentationCreationRequest{
+		Name: "Blister x 10", ConversionFactor: 10, Price: 2800,
+	})
+	if err != nil {
+		t.Fatalf("creating a presentation: %v", err)
+	}
+	promotion, err := stores.Promotions.CreatePromotion(ctx, promotionRequest(0, 5))
+	if err != nil {
+		t.Fatalf("creating a promotion: %v", err)
+	}
+
+	billing := models.BillingDetail{
+		BranchID:  branchA,
+		Promotion: *promotion,
+		Items: []models.BillingItem{
+			{MedicineID: medicine.ID, MedicineName: medicine.Name, Quantity: 3, BaseQuantity: 3, UnitPrice: 300, Subtotal: 900},
+			{
+				MedicineID: medicine.ID, MedicineName: medicine.Name, PresentationID: presentation.ID,
+				PresentationName: presentation.Name, Quantity: 2, BaseQuantity: 20, UnitPrice: 2800, Subtotal: 5600,
+			},
+		},
+		Total:     5850,
+		CreatedAt: baseTime.Add(10 * time.Hour),
+	}
+	created, err := stores.Billings.CreateBilling(ctx, billing)
+	if err != nil {
+		t.Fatalf("creating a billing: %v", err)
+	}
+	if created.ID <= 0 || created.Version != 1 {
+		t.Errorf("got billing %+v, want an id at version 1", created)
+	}
+
+	t.Run("takes the stock out of the branch", func(t *testing.T) {
+		if stock := branchStock(t, stores, medicine.ID, branchA); stock != 27 {
+			t.Errorf("got %d units in the branch, want 27", stock)
+		}
+		if stock := totalStock(t, stores, medicine.ID); stock != 27 {
+			t.Errorf("got %d units in total, want 27", stock)
+		}
+	})
+
+	t.Run("rejects a sale beyond the stock of the branch", func(t *testing.T) {
+		// the lines of a medicine add up: 20 + 10 units with 27 in the branch
+		beyond := billing
+		beyond.Promotion = models.Promotion{}
+		beyond.Items = []models.BillingItem{billing.Items[1], billing.Items[0], billing.Items[0], billing.Items[0], {
+			MedicineID: medicine.ID, MedicineName: medicine.Name, Quantity: 1, BaseQuantity: 1, UnitPrice: 300, Subtotal: 300,
+		}}
+		beyond.Total = 8600
+		_, err := stores.Billings.CreateBilling(ctx, beyond)
+		assertErrorIs(t, "selling more units than the branch holds", err, models.ErrInsufficientStock)
+
+		elsewhere := beyond
+		elsewhere.BranchID = branchB
+		elsewhere.Items = beyond.Items[4:]
+		elsewhere.Total = 300
+		_, err = stores.Billings.CreateBilling(ctx, elsewhere)
+		assertErrorIs(t, "selling in a branch without stock", err, models.ErrInsufficientStock)
+
+		if stock := branchStock(t, stores, medicine.ID, branchA); stock != 27 {
+			t.Errorf("got %d units in the branch after the rejected sales, want 27", stock)
+		}
+	})
+
+	t.Run("keeps the catalog version of the medicine", func(t *testing.T) {
+		ibuprofen := createMedicine(t, stores, "Ibuprofeno", 400, branchA, 10)
+		before, err := stores.Medicines.GetMedicineByID(ctx, ibuprofen.ID)
+		if err != nil {
+			t.Fatalf("getting the medicine: %v", err)
+		}
+		_, err = stores.Billings.CreateBilling(ctx, models.BillingDetail{
+			BranchID: branchA,
+			Items: []models.BillingItem{
+				{MedicineID: ibuprofen.ID, MedicineName: ibuprofen.Name, Quantity: 1, BaseQuantity: 1, UnitPrice: 400, Subtotal: 400},
+			},
+			Total:     400,
+			CreatedAt: baseTime.AddDate(0, 0, -3),
+		})
+		if err != nil {
+			t.Fatalf("selling a unit: %v", err)
+		}
+
+		sold, err := stores.Medicines.GetMedicineByID(ctx, ibuprofen.ID)
+		if err != nil {
+			t.Fatalf("getting the medicine: %v", err)
+		}
+		if sold.Version != before.Version || sold.StockVersion <= before.StockVersion {
+			t.Errorf("got versions %d/%d after the sale, want %d and a stock version after %d",
+				sold.Version, sold.StockVersion, before.Version, before.StockVersion)
+		}
+		// the price update is based on the medicine read before the sale
+		if _, err := stores.Medicines.UpdatePrice(ctx, ibuprofen.ID, before.Version, 450); err != nil {
+			t.Errorf("updating the price after a sale: %v", err)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		stored, err := stores.Billings.GetBillingByID(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("getting the billing: %v", err)
+		}
+		if stored.BranchID != branchA || stored.Total != 5850 || stored.Promotion.ID != promotion.ID {
+			t.Errorf("got billing %+v, want the created one", stored)
+		}
+		if !sameInstant(stored.CreatedAt, billing.CreatedAt) {
+			t.Errorf("got creation date %v, want %v", stored.CreatedAt, billing.CreatedAt)
+		}
+		if len(stored.Items) != 2 || stored.Items[1].PresentationID != presentation.ID || stored.Items[1].BaseQuantity != 20 {
+			t.Errorf("got items %+v, want the created ones in order", stored.Items)
+		}
+
+		_, err = stores.Billings.GetBillingByID(ctx, created.ID+100)
+		assertErrorIs(t, "getting an unknown billing", err, models.ErrNotFound)
+	})
+
+	t.Run("get by dates", func(t *testing.T) {
+		loratadine := createMedicine(t, stores, "Loratadina", 150, branchB, 10)
+		other := billing
+		other.BranchID = branchB
+		other.Promotion = models.Promotion{}
+		other.Items = []models.BillingItem{
+			{MedicineID: loratadine.ID, MedicineName: loratadine.Name, Quantity: 2, BaseQuantity: 2, UnitPrice: 150, Subtotal: 300},
+		}
+		other.Total = 300
+		other.CreatedAt = baseTime.AddDate(0, 0, 1)
+		if _, err := stores.Billings.CreateBilling(ctx, other); err != nil {
+			t.Fatalf("creating a billing: %v", err)
+		}
+
+		tests := []struct {
+			name     string
+			startDay int
+			days     int
+			branchID int64
+			want     int
+		}{
+			{"every branch", 0, 2, 0, 2},
+			{"a branch", 0, 2, branchB, 1},
+			{"the first day", 0, 1, 0, 1},
+			{"no billings", 5, 1, 0, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				start := baseTime.AddDate(0, 0, tt.startDay)
+				end := start.AddDate(0, 0, tt.days).Add(-time.Microsecond)
+				billings, err := stores.Billings.GetBillingsByDates(ctx, start, end, tt.branchID)
+				if err != nil {
+					t.Fatalf("getting billings: %v", err)
+				}
+				if len(billings) != tt.want {
+					t.Errorf("got %d billings, want %d", len(billings), tt.want)
+				}
+			})
+		}
+	})
+}
+
+func testSalesSummary(t *testing.T, stores Stores) {
+	ana := models.ContextWithUser(context.Background(), models.AuthenticatedUser{ID: 7, Username: "ana"})
+	luis := models.ContextWithUser(context.Background(), models.AuthenticatedUser{ID: 8, Username: "luis"})
+	bogota, err := time.LoadLocation("America/Bogota")
+	if err != nil {
+		t.Fatalf("loading the timezone: %v", err)
+	}
+	branchA := createBranch(t, stores, "Norte")
+	branchB := createBranch(t, stores, "Sur")
+	medicineA := createMedicine(t, stores, "Acetaminofen", 100, branchA, 50)
+	medicineB := createMedicine(t, stores, "Ibuprofeno", 100, branchB, 50)
+	promotion, err := stores.Promotions.CreatePromotion(context.Background(), promotionRequest(0, 5))
+	if err != nil {
+		t.Fatalf("creating a promotion: %v", err)
+	}
+
+	bill := func(ctx context.Context, branchID int64, medicine models.Medicine, quantity int64, promotion models.Promotion, createdAt time.Time) {
+		t.Helper()
+
+		subtotal := medicine.Price * float64(quantity)
+		total := subtotal - subtotal*promotion.Percentage/100
+		_, err := stores.Billings.CreateBilling(ctx, models.BillingDetail{
+			BranchID:  branchID,
+			Promotion: promotion,
+			Items: []models.BillingItem{{
+				MedicineID: medicine.ID, MedicineName: medicine.Name, Quantity: quantity, BaseQuantity: quantity,
+				UnitPrice: medicine.Price, Subtotal: subtotal,
+			}},
+			Total:     total,
+			CreatedAt: createdAt,
+		})
+		if err != nil {
+			t.Fatalf("creating a billing: %v", err)
+		}
+	}
+	// in Bogota, five hours behind UTC, the first billing is of the day before
+	bill(luis, branchA, medicineA, 1, models.Promotion{}, baseTime.Add(3*time.Hour))
+	bill(ana, branchA, medicineA, 2, *promotion, baseTime.Add(10*time.Hour))
+	bill(luis, branchB, medicineB, 1, models.Promotion{}, baseTime.Add(20*time.Hour))
+	bill(ana, branchA, medicineA, 3, models.Promotion{}, baseTime.AddDate(0, 0, 3).Add(12*time.Hour))
+	bill(ana, branchA, medicineA, 1, models.Promotion{}, baseTime.AddDate(0, 0, 23))
+
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2030, month, day, 0, 0, 0, 0, bogota)
+	}
+	filter := func(groupBy string) models.SalesSummaryFilter {
+		return models.SalesSummaryFilter{
+			From:     day(time.March, 9),
+			To:       day(time.March, 14).Add(-time.Microsecond),
+			GroupBy:  groupBy,
+			Location: bogota,
+		}
+	}
+	withPromotion := []models.SalesSummaryPromotion{{PromotionID: promotion.ID, Description: promotion.Description, Billings: 1}}
+	byBranch := filter(models.SalesPeriodDay)
+	byBranch.ByBranch = true
+	byCashier := filter(models.SalesPeriodDay)
+	byCashier.ByCashier = true
+	ofBranch := filter(models.SalesPeriodMonth)
+	ofBranch.BranchID = branchB
+
+	tests := []struct {
+		name   string
+		filter models.SalesSummaryFilter
+		want   []models.SalesSummary
+	}{
+		{
+			name:   "per day",
+			filter: filter(models.SalesPeriodDay),
+			want: []models.SalesSummary{
+				{PeriodStart: day(time.March, 9), Billings: 1, Gross: 100, Net: 100, AverageTicket: 100},
+				{
+					PeriodStart: day(time.March, 10), Billings: 2, Gross: 300, Discounts: 20, Net: 280, AverageTicket: 140,
+					Promotions: withPromotion,
+				},
+				{PeriodStart: day(time.March, 13), Billings: 1, Gross: 300, Net: 300, AverageTicket: 300},
+			},
+		},
+		{
+			name:   "per week starting on monday",
+			filter: filter(models.SalesPeriodWeek),
+			want: []models.SalesSummary{
+				{
+					PeriodStart: day(time.March, 4), Billings: 3, Gross: 400, Discounts: 20, Net: 380, AverageTicket: 380.0 / 3,
+					Promotions: withPromotion,
+				},
+				{PeriodStart: day(time.March, 11), Billings: 1, Gross: 300, Net: 300, AverageTicket: 300},
+			},
+		},
+		{
+			name:   "per month",
+			filter: filter(models.SalesPeriodMonth),
+			want: []models.SalesSummary{{
+				PeriodStart: day(time.March, 1), Billings: 4, Gross: 700, Discounts: 20, Net: 680, AverageTicket: 170,
+				Promotions: withPromotion,
+			}},
+		},
+		{
+			name:   "per day and branch",
+			filter: byBranch,
+			want: []models.SalesSummary{
+				{PeriodStart: day(time.March, 9), BranchID: branchA, Billings: 1, Gross: 100, Net: 100, AverageTicket: 100},
+				{
+					PeriodStart: day(time.March, 10), BranchID: branchA, Billings: 1, Gross: 200, Discounts: 20, Net: 180, AverageTicket: 180,
+					Promotions: withPromotion,
+				},
+				{PeriodStart: day(time.March, 10), BranchID: branchB, Billings: 1, Gross: 100, Net: 100, AverageTicket: 100},
+				{PeriodStart: day(time.March, 13), BranchID: branchA, Billings: 1, Gross: 300, Net: 300, AverageTicket: 300},
+			},
+		},
+		{
+			name:   "per day and cashier",
+			filter: byCashier,
+			want: []models.SalesSummary{
+				{PeriodStart: day(time.March, 9), CashierID: 8, CashierUsername: "luis", Billings: 1, Gross: 100, Net: 100, AverageTicket: 100},
+				{
+					PeriodStart: day(time.March, 10), CashierID: 7, CashierUsername: "ana", Billings: 1, Gross: 200, Discounts: 20, Net: 180,
+					AverageTicket: 180, Promotions: withPromotion,
+				},
+				{PeriodStart: day(time.March, 10), CashierID: 8, CashierUsername: "luis", Billings: 1, Gross: 100, Net: 100, AverageTicket: 100},
+				{PeriodStart: day(time.March, 13), CashierID: 7, CashierUsername: "ana", Billings: 1, Gross: 300, Net: 300, AverageTicket: 300},
+			},
+		},
+		{
+			name:   "of a branch",
+			filter: ofBranch,
+			want:   []models.SalesSummary{{PeriodStart: day(time.March, 1), Billings: 1, Gross: 100, Net: 100, AverageTicket: 100}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stores.SalesReports.GetSalesSummary(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("getting the sales summary: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d summaries %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if want.Promotions == nil {
+					want.Promotions = []models.SalesSummaryPromotion{}
+				}
+				if !sameInstant(got[i].PeriodStart, want.PeriodStart) {
+					t.Errorf("summary %d: got period %v, want %v", i, got[i].PeriodStart, want.PeriodStart)
+				}
+				got[i].PeriodStart = want.PeriodStart
+				if math.Abs(got[i].AverageTicket-want.AverageTicket) < 1e-9 {
+					got[i].AverageTicket = want.AverageTicket
+				}
+				if !reflect.DeepEqual(got[i], want) {
+					t.Errorf("summary %d: got %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func testMedicinesPerformance(t *testing.T, stores Stores) {
+	ctx := context.Background()
+	branchA := createBranch(t, stores, "Norte")
+	branchB := createBranch(t, stores, "Sur")
+	newMedicine := func(name, location string, price float64, branchID int64) models.Medicine {
+		t.Helper()
+
+		medicine, err := stores.Medicines.CreateMedicine(ctx, models.MedicineCreationRequest{
+			Name: name, Location: location, Price: price, Stock: 100, BranchID: branchID,
+		})
+		if err != nil {
+			t.Fatalf("creating medicine %s: %v", name, err)
+		}
+		return *medicine
+	}
+	acetaminofen := newMedicine("Acetaminofen", "A-1", 100, branchA)
+	ibuprofeno := newMedicine("Ibuprofeno", "B-2", 50, branchB)
+	loratadina := newMedicine("Loratadina", "", 30, branchA)
+	blister, err := stores.Medicines.CreatePresentation(ctx, acetaminofen.ID, models.PresentationCreationRequest{
+		Name: "Blister x 10", ConversionFactor: 10, Price: 900,
+	})
+	if err != nil {
+		t.Fatalf("creating a presentation: %v", err)
+	}
+
+	bill := func(branchID int64, createdAt time.Time, items ...models.BillingItem) {
+		t.Helper()
+
+		total := 0.0
+		for i := range items {
+			items[i].Subtotal = items[i].UnitPrice * float64(items[i].Quantity)
+			total += items[i].Subtotal
+		}
+		_, err := stores.Billings.CreateBilling(ctx, models.BillingDetail{BranchID: branchID, Items: items, Total: total, CreatedAt: createdAt})
+		if err != nil {
+			t.Fatalf("creating a billing: %v", err)
+		}
+	}
+	units := func(medicine models.Medicine, quantity int64) models.BillingItem {
+		return models.BillingItem{
+			MedicineID: medicine.ID, MedicineName: medicine.Name, Quantity: quantity, BaseQuantity: quantity, UnitPrice: medicine.Price,
+		}
+	}
+	blisters := models.BillingItem{
+		MedicineID: acetaminofen.ID, MedicineName: acetaminofen.Name, PresentationID: blister.ID,
+		PresentationName: blister.Name, Quantity: 1, BaseQuantity: 10, UnitPrice: 900,
+	}
+	bill(branchA, baseTime.AddDate(0, 0, -5), units(acetaminofen, 2), blisters)
+	bill(branchA, baseTime.Add(time.Hour), units(acetaminofen, 3), blisters)
+	bill(branchB, baseTime.AddDate(0, 0, 2), units(ibuprofeno, 4))
+	// after the end of the period, it does not count as the last sale either
+	bill(branchA, baseTime.AddDate(0, 0, 20), units(acetaminofen, 1))
+
+	at := func(t time.Time) *time.Time {
+		return &t
+	}
+	tests := []struct {
+		name      string
+		startDate time.Time
+		endDate   time.Time
+		branchID  int64
+		want      []models.MedicinePerformance
+	}{
+		{
+			name:      "every branch",
+			startDate: baseTime,
+			endDate:   baseTime.AddDate(0, 0, 10),
+			want: []models.MedicinePerformance{
+				{MedicineID: acetaminofen.ID, MedicineName: "Acetaminofen", Location: "A-1", Units: 13, Revenue: 1200, LastSoldAt: at(baseTime.Add(time.Hour))},
+				{MedicineID: ibuprofeno.ID, MedicineName: "Ibuprofeno", Location: "B-2", Units: 4, Revenue: 200, LastSoldAt: at(baseTime.AddDate(0, 0, 2))},
+				{MedicineID: loratadina.ID, MedicineName: "Loratadina"},
+			},
+		},
+		{
+			name:      "a branch",
+			startDate: baseTime,
+			endDate:   baseTime.AddDate(0, 0, 10),
+			branchID:  branchB,
+			want: []models.MedicinePerformance{
+				{MedicineID: acetaminofen.ID, MedicineName: "Acetaminofen", Location: "A-1"},
+				{MedicineID: ibuprofeno.ID, MedicineName: "Ibuprofeno", Location: "B-2", Units: 4, Revenue: 200, LastSoldAt: at(baseTime.AddDate(0, 0, 2))},
+				{MedicineID: loratadina.ID, MedicineName: "Loratadina"},
+			},
+		},
+		{
+			name:      "a past period",
+			startDate: baseTime.AddDate(0, 0, -10),
+			endDate:   baseTime.AddDate(0, 0, -1),
+			branchID:  branchA,
+			want: []models.MedicinePerformance{
+				{MedicineID: acetaminofen.ID, MedicineName: "Acetaminofen", Location: "A-1", Units: 12, Revenue: 1100, LastSoldAt: at(baseTime.AddDate(0, 0, -5))},
+				{MedicineID: ibuprofeno.ID, MedicineName: "Ibuprofeno", Location: "B-2"},
+				{MedicineID: loratadina.ID, MedicineName: "Loratadina"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stores.SalesReports.GetMedicinesPerformance(ctx, tt.startDate, tt.endDate, tt.branchID)
+			if err != nil {
+				t.Fatalf("getting the medicines performance: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d medicines %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if (got[i].LastSoldAt == nil) != (want.LastSoldAt == nil) ||
+					(want.LastSoldAt != nil && !sameInstant(*got[i].LastSoldAt, *want.LastSoldAt)) {
+					t.Errorf("medicine %d: got last sale %v, want %v", i, got[i].LastSoldAt, want.LastSoldAt)
+				}
+				got[i].LastSoldAt, want.LastSoldAt = nil, nil
+				if got[i] != want {
+					t.Errorf("medicine %d: got %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Package storetest has the contract every implementation of the store interfaces of
+// the usecase package must fulfil, to be run from the tests of each implementation.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/usecase"
+)
+
+// Stores are the implementations under test, all of them sharing the same data.
+type Stores struct {
+	Billings        usecase.BillingStore
+	Promotions      usecase.PromotionStore
+	Medicines       usecase.MedicineStore
+	Branches        usecase.BranchStore
+	Suppliers       usecase.SupplierStore
+	PurchaseOrders  usecase.PurchaseOrderStore
+	Reorders        usecase.ReorderStore
+	SalesReports    usecase.SalesReportStore
+	Transfers       usecase.TransferStore
+	InventoryCounts usecase.InventoryCountStore
+	Users           usecase.UserStore
+	Audit           usecase.AuditStore
+	Idempotency     usecase.IdempotencyStore
+}
+
+// Factory returns stores over freshly migrated data, not shared with any other call.
+type Factory func(t *testing.T) Stores
+
+// Run runs the contract of every store, each one over new data.
+func Run(t *testing.T, newStores Factory) {
+	suites := []struct {
+		name string
+		run  func(t *testing.T, stores Stores)
+	}{
+		{"Branches", testBranches},
+		{"Suppliers", testSuppliers},
+		{"Medicines", testMedicines},
+		{"Promotions", testPromotions},
+		{"PromotionPeriods", testPromotionPeriods},
+		{"Billings", testBillings},
+		{"SalesSummary", testSalesSummary},
+		{"MedicinesPerformance", testMedicinesPerformance},
+		{"PurchaseOrders", testPurchaseOrders},
+		{"Transfers", testTransfers},
+		{"InventoryCounts", testInventoryCounts},
+		{"Reorders", testReorders},
+		{"Users", testUsers},
+		{"Audit", testAudit},
+		{"Idempotency", testIdempotency},
+	}
+
+	for _, suite := range suites {
+		t.Run(suite.name, func(t *testing.T) {
+			suite.run(t, newStores(t))
+		})
+	}
+}
+
+// baseTime is far enough in the future to not collide with the data of the migrations.
+var baseTime = time.Date(2030, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+func createBranch(t *testing.T, stores Stores, name string) int64 {
+	t.Helper()
+
+	branch, err := stores.Branches.CreateBranch(context.Background(), models.BranchCreationRequest{Name: name})
+	if err != nil {
+		t.Fatalf("creating branch %s: %v", name, err)
+	}
+
+	return branch.ID
+}
+
+// createMedicine creates a medicine with stock units in the branch.
+func createMedicine(t *testing.T, stores Stores, name string, price float64, branchID, stock int64) models.Medicine {
+	t.Helper()
+
+	medicine, err := stores.Medicines.CreateMedicine(context.Background(), models.MedicineCreationRequest{
+		Name:     name,
+		Price:    price,
+		Stock:    stock,
+		BranchID: branchID,
+	})
+	if err != nil {
+		t.Fatalf("creating medicine %s: %v", name, err)
+	}
+
+	return *medicine
+}
+
+func createSupplier(t *testing.T, stores Stores, name string) int64 {
+	t.Helper()
+
+	supplier, err := stores.Suppliers.CreateSupplier(context.Background(), models.SupplierCreationRequest{Name: name})
+	if err != nil {
+		t.Fatalf("creating supplier %s: %v", name, err)
+	}
+
+	return supplier.ID
+}
+
+// branchStock returns the stock of the medicine in the branch, zero when it never had any.
+func branchStock(t *testing.T, stores Stores, medicineID, branchID int64) int64 {
+	t.Helper()
+
+	stock, err := stores.Medicines.GetStockByBranch(context.Background(), medicineID)
+	if err != nil {
+		t.Fatalf("getting the stock of medicine %d: %v", medicineID, err)
+	}
+	for _, branch := range stock {
+		if branch.BranchID == branchID {
+			return branch.Stock
+		}
+	}
+
+	return 0
+}
+
+func totalStock(t *testing.T, stores Stores, medicineID int64) int64 {
+	t.Helper()
+
+	medicine, err := stores.Medicines.GetMedicineByID(context.Background(), medicineID)
+	if err != nil {
+		t.Fatalf("getting medicine %d: %v", medicineID, err)
+	}
+
+	return medicine.Stock
+}
+
+func assertErrorIs(t *testing.T, operation string, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Errorf("%s: got error %v, want %v", operation, err, want)
+	}
+}
+
+// sameInstant compares times at the precision kept by the database.
+func sameInstant(a, b time.Time) bool {
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
+
+func sameIDs(got, want []int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
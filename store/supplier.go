@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableSupplier = "supplier"
+)
+
+type Suppliers struct {
+	db *sqlx.DB
+}
+
+func NewSuppliers(db *sqlx.DB) Suppliers {
+	return Suppliers{
+		db: db,
+	}
+}
+
+func (ss Suppliers) GetAll(ctx context.Context) ([]models.Supplier, error) {
+	getAllSuppliersSQL := fmt.Sprintf(`
+	SELECT id, name, tax_id, email, phone, created_at
+	FROM %s
+	WHERE deleted_at IS NULL
+	ORDER BY name asc
+	`, tableSupplier)
+
+	rows, err := ss.db.QueryContext(ctx, getAllSuppliersSQL)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting suppliers: %w", err)
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	return suppliers, nil
+}
+
+func (ss Suppliers) GetSupplierByID(ctx context.Context, supplierID int64) (*models.Supplier, error) {
+	getSupplierSQL := fmt.Sprintf(`
+	SELECT id, name, tax_id, email, phone, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tableSupplier)
+
+	supplier, err := scanSupplier(ss.db.QueryRowContext(ctx, getSupplierSQL, supplierID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading supplier row: %w", err)
+	}
+
+	return &supplier, nil
+}
+
+func (ss Suppliers) CreateSupplier(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.Supplier, error) {
+	createSupplierSQL := fmt.Sprintf(`
+	INSERT INTO %s (name, tax_id, email, phone, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tableSupplier)
+
+	now := time.Now().UTC()
+	var supplierID int64
+	err := ss.db.QueryRowContext(
+		ctx,
+		createSupplierSQL,
+		supplierRequest.Name,
+		nullString(supplierRequest.TaxID),
+		nullString(supplierRequest.Email),
+		nullString(supplierRequest.Phone),
+		now,
+		now,
+	).Scan(&supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create supplier record within db: %w", err)
+	}
+
+	return &models.Supplier{
+		ID:        supplierID,
+		Name:      supplierRequest.Name,
+		TaxID:     supplierRequest.TaxID,
+		Email:     supplierRequest.Email,
+		Phone:     supplierRequest.Phone,
+		CreatedAt: now,
+	}, nil
+}
+
+func scanSupplier(row rowScanner) (models.Supplier, error) {
+	var (
+		id        int64
+		name      string
+		taxID     sql.NullString
+		email     sql.NullString
+		phone     sql.NullString
+		createdAt sql.NullTime
+	)
+	if err := row.Scan(&id, &name, &taxID, &email, &phone, &createdAt); err != nil {
+		return models.Supplier{}, err
+	}
+
+	return models.Supplier{
+		ID:        id,
+		Name:      name,
+		TaxID:     taxID.String,
+		Email:     email.String,
+		Phone:     phone.String,
+		CreatedAt: createdAt.Time,
+	}, nil
+}
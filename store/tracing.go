@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracedDriverName is the postgres driver that starts a span for each query.
+	tracedDriverName = "postgres-traced"
+	tracerName       = "github.com/VictorDelgado94/aveonline-backend/store"
+)
+
+var tracer = otel.Tracer(tracerName)
+
+func init() {
+	sql.Register(tracedDriverName, tracedDriver{driver: &pq.Driver{}})
+	sqlx.BindDriver(tracedDriverName, sqlx.DOLLAR)
+}
+
+type tracedDriver struct {
+	driver driver.Driver
+}
+
+func (d tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracedConn{Conn: conn}, nil
+}
+
+// tracedConn wraps a pq connection, which implements the context aware interfaces. The
+// optional interfaces are forwarded when the wrapped connection implements them, so
+// database/sql keeps resetting, validating and converting the arguments as pq does.
+type tracedConn struct {
+	driver.Conn
+}
+
+var (
+	_ driver.SessionResetter    = tracedConn{}
+	_ driver.Validator          = tracedConn{}
+	_ driver.NamedValueChecker  = tracedConn{}
+	_ driver.ConnPrepareContext = tracedConn{}
+)
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	recordQueryError(span, err)
+
+	return rows, err
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	recordQueryError(span, err)
+
+	return result, err
+}
+
+func (c tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c tracedConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+func (c tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// CheckNamedValue returns driver.ErrSkip, so database/sql applies its default conversion,
+// when the wrapped connection does not check the arguments itself.
+func (c tracedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, queryOperation(query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", strings.TrimSpace(query)),
+		),
+	)
+}
+
+func recordQueryError(span trace.Span, err error) {
+	if err == nil || err == driver.ErrSkip {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// queryOperation names the span after the SQL command of the query, e.g. SELECT.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "db.query"
+	}
+
+	return "db." + strings.ToLower(fields[0])
+}
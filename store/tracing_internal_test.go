@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+var errReset = errors.New("session reset failed")
+
+// plainConn only implements driver.Conn, it panics on any call.
+type plainConn struct {
+	driver.Conn
+}
+
+// fullConn implements the optional interfaces database/sql looks for.
+type fullConn struct {
+	plainConn
+	valid   bool
+	checked []string
+}
+
+func (c *fullConn) ResetSession(ctx context.Context) error {
+	return errReset
+}
+
+func (c *fullConn) IsValid() bool {
+	return c.valid
+}
+
+func (c *fullConn) CheckNamedValue(value *driver.NamedValue) error {
+	c.checked = append(c.checked, value.Name)
+	return nil
+}
+
+func TestTracedConnForwardsOptionalInterfaces(t *testing.T) {
+	t.Run("implemented by the connection", func(t *testing.T) {
+		inner := &fullConn{valid: false}
+		conn := tracedConn{Conn: inner}
+
+		if err := conn.ResetSession(context.Background()); !errors.Is(err, errReset) {
+			t.Errorf("got reset error %v, want the one of the connection", err)
+		}
+		if conn.IsValid() {
+			t.Error("got a valid connection, want the validity of the wrapped one")
+		}
+		if err := conn.CheckNamedValue(&driver.NamedValue{Name: "id"}); err != nil || len(inner.checked) != 1 {
+			t.Errorf("got error %v and %d checks, want the connection to check the value", err, len(inner.checked))
+		}
+	})
+
+	t.Run("not implemented by the connection", func(t *testing.T) {
+		conn := tracedConn{Conn: plainConn{}}
+
+		if err := conn.ResetSession(context.Background()); err != nil {
+			t.Errorf("got reset error %v, want none", err)
+		}
+		if !conn.IsValid() {
+			t.Error("got an invalid connection, want it valid")
+		}
+		if err := conn.CheckNamedValue(&driver.NamedValue{Name: "id"}); !errors.Is(err, driver.ErrSkip) {
+			t.Errorf("got error %v, want driver.ErrSkip for the default conversion", err)
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableStockTransfer     = "stock_transfer"
+	tableStockTransferLine = "stock_transfer_line"
+)
+
+type Transfers struct {
+	db *sqlx.DB
+}
+
+func NewTransfers(db *sqlx.DB) Transfers {
+	return Transfers{
+		db: db,
+	}
+}
+
+// GetAll returns the transfers without lines, filtered by status when it is not empty
+// and by branch (as source or destination) when branchID is not zero.
+func (ts Transfers) GetAll(ctx context.Context, status string, branchID int64) ([]models.StockTransfer, error) {
+	getAllTransfersSQL := fmt.Sprintf(`
+	SELECT id, source_branch_id, destination_branch_id, status, notes, shipped_at, received_at, created_at
+	FROM %s
+	WHERE deleted_at IS NULL AND ($1 = '' OR status = $1)
+	AND ($2 = 0 OR source_branch_id = $2 OR destination_branch_id = $2)
+	ORDER BY created_at desc
+	`, tableStockTransfer)
+
+	rows, err := ts.db.QueryContext(ctx, getAllTransfersSQL, status, branchID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error getting transfers: %w", err)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+func (ts Transfers) GetTransferByID(ctx context.Context, transferID int64) (*models.StockTransfer, error) {
+	getTransferSQL := fmt.Sprintf(`
+	SELECT id, source_branch_id, destination_branch_id, status, notes, shipped_at, received_at, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tableStockTransfer)
+
+	transfer, err := scanTransfer(ts.db.QueryRowContext(ctx, getTransferSQL, transferID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading transfer row: %w", err)
+	}
+
+	transfer.Lines, err = ts.getLines(ctx, transferID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading transfer lines: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+func (ts Transfers) getLines(ctx context.Context, transferID int64) ([]models.StockTransferLine, error) {
+	getLinesSQL := fmt.Sprintf(`
+	SELECT id, medicine_id, quantity, received_quantity, discrepancy_notes
+	FROM %s
+	WHERE stock_transfer_id = $1
+	ORDER BY id asc
+	`, tableStockTransferLine)
+
+	rows, err := ts.db.QueryContext(ctx, getLinesSQL, transferID)
+	if err != nil {
+		return nil, fmt.Errorf("error while building query: %w", err)
+	}
+	defer func() {
+		errClose := rows.Close()
+		errRows := rows.Err()
+		if errClose != nil || errRows != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "something went wrong while closing rows", "close_error", errClose, "rows_error", errRows)
+		}
+	}()
+	lines := make([]models.StockTransferLine, 0)
+	for rows.Next() {
+		var (
+			line             models.StockTransferLine
+			receivedQuantity sql.NullInt64
+			discrepancyNotes sql.NullString
+		)
+		if err := rows.Scan(&line.ID, &line.MedicineID, &line.Quantity, &receivedQuantity, &discrepancyNotes); err != nil {
+			return nil, fmt.Errorf("error getting transfer lines: %w", err)
+		}
+		if receivedQuantity.Valid {
+			line.ReceivedQuantity = &receivedQuantity.Int64
+			line.Discrepancy = line.Quantity - receivedQuantity.Int64
+		}
+		line.DiscrepancyNotes = discrepancyNotes.String
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func (ts Transfers) CreateTransfer(ctx context.Context, transferRequest models.StockTransferCreationRequest) (*models.StockTransfer, error) {
+	createTransferSQL := fmt.Sprintf(`
+	INSERT INTO %s (source_branch_id, destination_branch_id, status, notes, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tableStockTransfer)
+
+	createLineSQL := fmt.Sprintf(`
+	INSERT INTO %s (stock_transfer_id, medicine_id, quantity, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id;
+	`, tableStockTransferLine)
+
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("createTransfer: could not begin transaction")
+	}
+
+	now := time.Now().UTC()
+	transfer := models.StockTransfer{
+		SourceBranchID:      transferRequest.SourceBranchID,
+		DestinationBranchID: transferRequest.DestinationBranchID,
+		Status:              models.TransferDraft,
+		Notes:               transferRequest.Notes,
+		Lines:               make([]models.StockTransferLine, 0, len(transferRequest.Lines)),
+		CreatedAt:           now,
+	}
+	err = tx.QueryRowContext(
+		ctx,
+		createTransferSQL,
+		transfer.SourceBranchID,
+		transfer.DestinationBranchID,
+		transfer.Status,
+		nullString(transfer.Notes),
+		now,
+		now,
+	).Scan(&transfer.ID)
+	if err != nil {
+		return nil, rollback(tx, fmt.Errorf("createTransfer: could not create transfer within db: %w", err))
+	}
+
+	for _, lineRequest := range transferRequest.Lines {
+		line := models.StockTransferLine{
+			MedicineID: lineRequest.MedicineID,
+			Quantity:   lineRequest.Quantity,
+		}
+		err := tx.QueryRowContext(ctx, createLineSQL, transfer.ID, line.MedicineID, line.Quantity, now, now).Scan(&line.ID)
+		if err != nil {
+			return nil, rollback(tx, fmt.Errorf("createTransfer: could not create transfer line within db: %w", err))
+		}
+		transfer.Lines = append(transfer.Lines, line)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("createTransfer: could not commit transaction: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+// Ship puts a draft transfer in transit, taking its goods out of the source branch.
+// It fails with models.ErrInvalidTransition when the transfer is not a draft anymore.
+func (ts Transfers) Ship(ctx context.Context, transfer models.StockTransfer) error {
+	shipTransferSQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, shipped_at = $2, updated_at = $2
+	WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`, tableStockTransfer)
+
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return fmt.Errorf("shipTransfer: could not begin transaction")
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, shipTransferSQL, models.TransferShipped, now, transfer.ID, models.TransferDraft)
+	if err != nil {
+		return rollback(tx, fmt.Errorf("shipTransfer: could not update transfer within db: %w", err))
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return rollback(tx, fmt.Errorf("shipTransfer: %w", models.ErrInvalidTransition))
+	}
+
+	for _, line := range transfer.Lines {
+		err := registerStockMovement(ctx, tx, models.StockMovement{
+			MedicineID:   line.MedicineID,
+			BranchID:     transfer.SourceBranchID,
+			Quantity:     -line.Quantity,
+			MovementType: models.StockMovementTransferOut,
+			ReferenceID:  transfer.ID,
+		})
+		if err != nil {
+			return rollback(tx, fmt.Errorf("shipTransfer: %w", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("shipTransfer: could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Receive records the received units of every line and puts the shipped units in the
+// destination branch, booking the ones that did not arrive as a transit loss there. It
+// fails with models.ErrInvalidTransition when the transfer is not in transit.
+func (ts Transfers) Receive(ctx context.Context, transfer models.StockTransfer) error {
+	receiveTransferSQL := fmt.Sprintf(`
+	UPDATE %s SET status = $1, received_at = $2, updated_at = $2
+	WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`, tableStockTransfer)
+
+	receiveLineSQL := fmt.Sprintf(`
+	UPDATE %s SET received_quantity = $1, discrepancy_notes = $2, updated_at = $3
+	WHERE id = $4 AND stock_transfer_id = $5
+	`, tableStockTransferLine)
+
+	tx, err := ts.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("receiveTransfer: could not begin transaction: %w", err)
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, receiveTransferSQL, models.TransferReceived, now, transfer.ID, models.TransferShipped)
+	if err != nil {
+		return rollback(tx, fmt.Errorf("receiveTransfer: could not update transfer within db: %w", err))
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return rollback(tx, fmt.Errorf("receiveTransfer: %w", models.ErrInvalidTransition))
+	}
+
+	for _, line := range transfer.Lines {
+		if line.ReceivedQuantity == nil {
+			return rollback(tx, fmt.Errorf("receiveTransfer: received quantity of medicine [%d] is missing", line.MedicineID))
+		}
+		if *line.ReceivedQuantity > line.Quantity {
+			return rollback(tx, fmt.Errorf("receiveTransfer: received quantity of medicine [%d] exceeds the shipped one", line.MedicineID))
+		}
+		_, err := tx.ExecContext(ctx, receiveLineSQL, *line.ReceivedQuantity, nullString(line.DiscrepancyNotes), now, line.ID, transfer.ID)
+		if err != nil {
+			return rollback(tx, fmt.Errorf("receiveTransfer: could not update transfer line within db: %w", err))
+		}
+
+		for _, movement := range models.TransferReceiptMovements(transfer, line) {
+			if err := registerStockMovement(ctx, tx, movement); err != nil {
+				return rollback(tx, fmt.Errorf("receiveTransfer: %w", err))
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("receiveTransfer: could not commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func scanTransfer(row rowScanner) (models.StockTransfer, error) {
+	var (
+		transfer   models.StockTransfer
+		notes      sql.NullString
+		shippedAt  sql.NullTime
+		receivedAt sql.NullTime
+		createdAt  sql.NullTime
+	)
+	if err := row.Scan(
+		&transfer.ID,
+		&transfer.SourceBranchID,
+		&transfer.DestinationBranchID,
+		&transfer.Status,
+		&notes,
+		&shippedAt,
+		&receivedAt,
+		&createdAt,
+	); err != nil {
+		return models.StockTransfer{}, err
+	}
+	transfer.Notes = notes.String
+	if shippedAt.Valid {
+		transfer.ShippedAt = &shippedAt.Time
+	}
+	if receivedAt.Valid {
+		transfer.ReceivedAt = &receivedAt.Time
+	}
+	transfer.CreatedAt = createdAt.Time
+
+	return transfer, nil
+}
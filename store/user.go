@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	tableUser         = "app_user"
+	tableRevokedToken = "revoked_token"
+)
+
+type Users struct {
+	db *sqlx.DB
+}
+
+func NewUsers(db *sqlx.DB) Users {
+	return Users{
+		db: db,
+	}
+}
+
+func (us Users) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	getUserSQL := fmt.Sprintf(`
+	SELECT id, username, role, branch_id, password_hash, created_at
+	FROM %s
+	WHERE username = $1 AND deleted_at IS NULL
+	`, tableUser)
+
+	return us.getUser(ctx, getUserSQL, username)
+}
+
+func (us Users) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	getUserSQL := fmt.Sprintf(`
+	SELECT id, username, role, branch_id, password_hash, created_at
+	FROM %s
+	WHERE id = $1 AND deleted_at IS NULL
+	`, tableUser)
+
+	return us.getUser(ctx, getUserSQL, userID)
+}
+
+func (us Users) getUser(ctx context.Context, getUserSQL string, arg interface{}) (*models.User, error) {
+	var (
+		user      models.User
+		branchID  sql.NullInt64
+		createdAt sql.NullTime
+	)
+	err := us.db.QueryRowContext(ctx, getUserSQL, arg).Scan(&user.ID, &user.Username, &user.Role, &branchID, &user.PasswordHash, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("error reading user row: %w", err)
+	}
+	user.BranchID = branchID.Int64
+	user.CreatedAt = createdAt.Time
+
+	return &user, nil
+}
+
+func (us Users) CountUsers(ctx context.Context) (int64, error) {
+	countUsersSQL := fmt.Sprintf(`
+	SELECT count(*) FROM %s WHERE deleted_at IS NULL
+	`, tableUser)
+
+	var count int64
+	if err := us.db.QueryRowContext(ctx, countUsersSQL).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateUser stores the user with the given bcrypt password hash, bound to the branch
+// when branchID is not zero.
+func (us Users) CreateUser(ctx context.Context, username, role, passwordHash string, branchID int64) (*models.User, error) {
+	createUserSQL := fmt.Sprintf(`
+	INSERT INTO %s (username, role, branch_id, password_hash, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
+	`, tableUser)
+
+	var nullBranchID sql.NullInt64
+	if branchID > 0 {
+		nullBranchID = sql.NullInt64{Int64: branchID, Valid: true}
+	}
+	now := time.Now().UTC()
+	user := models.User{
+		Username:     username,
+		Role:         role,
+		BranchID:     branchID,
+		PasswordHash: passwordHash,
+		CreatedAt:    now,
+	}
+	err := us.db.QueryRowContext(ctx, createUserSQL, username, role, nullBranchID, passwordHash, now, now).Scan(&user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("createUser: could not create user within db: %w", err)
+	}
+
+	return &user, nil
+}
+
+// RevokeToken adds the token to the revocation list until it expires. It fails with
+// models.ErrTokenRevoked when the token is in the list already, so only one of several
+// concurrent calls revokes it.
+func (us Users) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	revokeTokenSQL := fmt.Sprintf(`
+	INSERT INTO %s (jti, expires_at, revoked_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (jti) DO NOTHING
+	`, tableRevokedToken)
+
+	result, err := us.db.ExecContext(ctx, revokeTokenSQL, tokenID, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("revokeToken: could not revoke token within db: %w", err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revokeToken: could not get the revoked rows: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("revokeToken: token [%s]: %w", tokenID, models.ErrTokenRevoked)
+	}
+
+	return nil
+}
+
+func (us Users) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	isRevokedSQL := fmt.Sprintf(`
+	SELECT EXISTS (SELECT 1 FROM %s WHERE jti = $1)
+	`, tableRevokedToken)
+
+	var revoked bool
+	if err := us.db.QueryRowContext(ctx, isRevokedSQL, tokenID).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("error checking token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens removes from the revocation list the tokens that expired
+// already, they are rejected anyway.
+func (us Users) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	deleteExpiredSQL := fmt.Sprintf(`
+	DELETE FROM %s WHERE expires_at < $1
+	`, tableRevokedToken)
+
+	result, err := us.db.ExecContext(ctx, deleteExpiredSQL, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("could not delete expired revoked tokens within db: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/VictorDelgado94/aveonline-backend/config"
+	"github.com/VictorDelgado94/aveonline-backend/metrics"
+	"github.com/VictorDelgado94/aveonline-backend/store"
+	"github.com/VictorDelgado94/aveonline-backend/store/memory"
+	"github.com/VictorDelgado94/aveonline-backend/usecase"
+)
+
+// stores are the implementations the usecases are built with, from the configured store.
+type stores struct {
+	branches        usecase.BranchStore
+	promotions      usecase.PromotionStore
+	medicines       usecase.MedicineStore
+	billings        usecase.BillingStore
+	suppliers       usecase.SupplierStore
+	purchaseOrders  usecase.PurchaseOrderStore
+	reorders        usecase.ReorderStore
+	salesReports    usecase.SalesReportStore
+	transfers       usecase.TransferStore
+	inventoryCounts usecase.InventoryCountStore
+	users           usecase.UserStore
+	audit           usecase.AuditStore
+	idempotency     usecase.IdempotencyStore
+	health          usecase.HealthStore
+	close           func() error
+}
+
+func newStores(configValues config.Config, logger *slog.Logger) (stores, error) {
+	if configValues.Store == config.StoreMemory {
+		logger.Warn("keeping the data in memory, it is lost on shutdown")
+		return newMemoryStores(), nil
+	}
+
+	return newPostgresStores(configValues, logger)
+}
+
+// newPostgresStores connects to the database, which must be migrated before serving.
+func newPostgresStores(configValues config.Config, logger *slog.Logger) (stores, error) {
+	storeAdapter, err := store.NewStore(configValues.DatabaseURL(), store.PoolSettings{
+		MaxOpenConns:    configValues.Database.MaxOpenConns,
+		MaxIdleConns:    configValues.Database.MaxIdleConns,
+		ConnMaxLifetime: configValues.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: configValues.Database.ConnMaxIdleTime,
+	}, logger)
+	if err != nil {
+		return stores{}, fmt.Errorf("error initializing database: %w", err)
+	}
+
+	// The server starts only once the schema is migrated.
+	err = storeAdapter.MigrateForServing(
+		context.Background(), configValues.Migrations.AllowNewerSchema, configValues.Migrations.AllowDirtySchema,
+	)
+	if err != nil {
+		return stores{}, fmt.Errorf("error in migration: %w", err)
+	}
+	storeAdapter.RegisterMetrics(metrics.Default)
+
+	db := storeAdapter.GetDB()
+	return stores{
+		branches:        store.NewBranches(db),
+		promotions:      store.NewPromotions(db),
+		medicines:       store.NewMedicine(db),
+		billings:        store.NewBilling(db),
+		suppliers:       store.NewSuppliers(db),
+		purchaseOrders:  store.NewPurchaseOrders(db),
+		reorders:        store.NewReorders(db),
+		salesReports:    store.NewSalesReports(db),
+		transfers:       store.NewTransfers(db),
+		inventoryCounts: store.NewInventoryCounts(db),
+		users:           store.NewUsers(db),
+		audit:           store.NewAudit(db),
+		idempotency:     store.NewIdempotencyKeys(db),
+		health:          &storeAdapter,
+		close:           storeAdapter.Close,
+	}, nil
+}
+
+func newMemoryStores() stores {
+	db := memory.NewDB()
+
+	return stores{
+		branches:        memory.NewBranches(db),
+		promotions:      memory.NewPromotions(db),
+		medicines:       memory.NewMedicine(db),
+		billings:        memory.NewBilling(db),
+		suppliers:       memory.NewSuppliers(db),
+		purchaseOrders:  memory.NewPurchaseOrders(db),
+		reorders:        memory.NewReorders(db),
+		salesReports:    memory.NewSalesReports(db),
+		transfers:       memory.NewTransfers(db),
+		inventoryCounts: memory.NewInventoryCounts(db),
+		users:           memory.NewUsers(db),
+		audit:           memory.NewAudit(db),
+		idempotency:     memory.NewIdempotencyKeys(db),
+		health:          db,
+		close:           db.Close,
+	}
+}
@@ -0,0 +1,35 @@
+package testdb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStartFailsWithAnUnusableDatabaseURL(t *testing.T) {
+	t.Setenv(DatabaseURLEnv, "mysql://localhost/aveonline")
+
+	_, err := Start(func(string) error { return nil })
+	if err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("got error %v, want a failure that does not skip the tests", err)
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	reasons := []string{"container: docker not found"}
+
+	if err := unavailable(false, reasons); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("got error %v, want ErrUnavailable to skip the tests", err)
+	}
+	if err := unavailable(true, reasons); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("got error %v, want a failure when the database is required", err)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true} {
+		t.Setenv(RequireEnv, value)
+		if got := Required(); got != want {
+			t.Errorf("%s=%q: got %v, want %v", RequireEnv, value, got, want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo"
+)
+
+const (
+	entityQueryParam   = "entity"
+	entityIDQueryParam = "id"
+	fromQueryParam     = "from"
+	toQueryParam       = "to"
+	requestIDHeader    = "X-Request-ID"
+	forwardedForHeader = "X-Forwarded-For"
+	realIPHeader       = "X-Real-IP"
+)
+
+// validRequestID bounds the X-Request-ID of the client, which is logged and audited.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type AuditUsecase interface {
+	Get(ctx context.Context, entity, entityID, from, to string) ([]models.AuditEntry, error)
+	Verify(ctx context.Context) (*models.AuditVerification, error)
+}
+
+type Audit struct {
+	Usecase AuditUsecase
+}
+
+func NewAudit(auc AuditUsecase) Audit {
+	return Audit{
+		Usecase: auc,
+	}
+}
+
+func (a Audit) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	entries, err := a.Usecase.Get(
+		ctx,
+		e.QueryParam(entityQueryParam),
+		e.QueryParam(entityIDQueryParam),
+		e.QueryParam(fromQueryParam),
+		e.QueryParam(toQueryParam),
+	)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, entries)
+}
+
+func (a Audit) Verify(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	verification, err := a.Usecase.Verify(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, verification)
+}
+
+// RequestInfoMiddleware stores in the request context the request ID, taken from the
+// X-Request-ID header or generated when it is missing or invalid, and the client IP. The
+// request ID is echoed back. The client IP is the peer of the connection unless it is one
+// of the trusted proxies, only then the forwarding headers are honored.
+func RequestInfoMiddleware(trustedProxies []netip.Prefix) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			requestID := e.Request().Header.Get(requestIDHeader)
+			if !validRequestID.MatchString(requestID) {
+				requestID = uuid.New().String()
+			}
+			e.Response().Header().Set(requestIDHeader, requestID)
+
+			ctx := models.ContextWithRequestInfo(e.Request().Context(), models.RequestInfo{
+				RequestID: requestID,
+				ClientIP:  clientIP(e.Request(), trustedProxies),
+			})
+			e.SetRequest(e.Request().WithContext(ctx))
+
+			return next(e)
+		}
+	}
+}
+
+// clientIP walks X-Forwarded-For from the nearest hop while the hops are trusted proxies,
+// the first untrusted one is the client. X-Real-IP is used when a trusted proxy sends no
+// X-Forwarded-For.
+func clientIP(request *http.Request, trustedProxies []netip.Prefix) string {
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !trusted(peer) {
+		return host
+	}
+
+	client := peer
+	forwardedFor := strings.Split(strings.Join(request.Header.Values(forwardedForHeader), ","), ",")
+	if len(request.Header.Values(forwardedForHeader)) == 0 {
+		forwardedFor = []string{request.Header.Get(realIPHeader)}
+	}
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwardedFor[i]))
+		if err != nil {
+			break
+		}
+		client = hop
+		if !trusted(hop) {
+			break
+		}
+	}
+
+	return client.Unmap().String()
+}
@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+func TestRequestInfoMiddleware(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		realIP        string
+		requestID     string
+		wantClientIP  string
+		wantRequestID string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5120", wantClientIP: "203.0.113.7"},
+		{name: "forged forwarding headers", remoteAddr: "203.0.113.7:5120", forwardedFor: "198.51.100.1", realIP: "198.51.100.2", wantClientIP: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:5120", forwardedFor: "198.51.100.1", wantClientIP: "198.51.100.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.2:5120", forwardedFor: "192.0.2.9, 198.51.100.1, 10.0.0.3", wantClientIP: "198.51.100.1"},
+		{name: "trusted proxy with real IP", remoteAddr: "10.0.0.2:5120", realIP: "198.51.100.2", wantClientIP: "198.51.100.2"},
+		{name: "trusted proxy without headers", remoteAddr: "10.0.0.2:5120", wantClientIP: "10.0.0.2"},
+		{name: "client request ID", remoteAddr: "203.0.113.7:5120", requestID: "req-42.a_b", wantClientIP: "203.0.113.7", wantRequestID: "req-42.a_b"},
+		{name: "request ID with invalid characters", remoteAddr: "203.0.113.7:5120", requestID: "req 42\nforged", wantClientIP: "203.0.113.7"},
+		{name: "request ID too long", remoteAddr: "203.0.113.7:5120", requestID: strings.Repeat("a", 129), wantClientIP: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				request.Header.Set(forwardedForHeader, tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				request.Header.Set(realIPHeader, tt.realIP)
+			}
+			if tt.requestID != "" {
+				request.Header.Set(requestIDHeader, tt.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			e := echo.New().NewContext(request, recorder)
+
+			var info models.RequestInfo
+			err := RequestInfoMiddleware(trustedProxies)(func(e echo.Context) error {
+				info = models.RequestInfoFromContext(e.Request().Context())
+				return e.NoContent(http.StatusOK)
+			})(e)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if info.ClientIP != tt.wantClientIP {
+				t.Errorf("got client IP %q, want %q", info.ClientIP, tt.wantClientIP)
+			}
+			if tt.wantRequestID != "" && info.RequestID != tt.wantRequestID {
+				t.Errorf("got request ID %q, want %q", info.RequestID, tt.wantRequestID)
+			}
+			if tt.wantRequestID == "" && (info.RequestID == tt.requestID || !validRequestID.MatchString(info.RequestID)) {
+				t.Errorf("got request ID %q, want a generated one", info.RequestID)
+			}
+			if got := recorder.Header().Get(requestIDHeader); got != info.RequestID {
+				t.Errorf("got request ID %q in the response, want %q", got, info.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+type AuthUsecase interface {
+	Login(ctx context.Context, loginRequest models.LoginRequest) (*models.TokenResponse, error)
+	Refresh(ctx context.Context, refreshRequest models.RefreshTokenRequest) (*models.TokenResponse, error)
+	Logout(ctx context.Context, refreshRequest models.RefreshTokenRequest) error
+	Authenticate(ctx context.Context, accessToken string) (*models.AuthenticatedUser, error)
+	CreateUser(ctx context.Context, userRequest models.UserCreationRequest) (*models.UserCreationResponse, error)
+}
+
+type Auth struct {
+	Usecase AuthUsecase
+}
+
+func NewAuth(auc AuthUsecase) Auth {
+	return Auth{
+		Usecase: auc,
+	}
+}
+
+func (a Auth) Login(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedLogin models.LoginRequest
+	if err := e.Bind(&requestedLogin); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("login: invalid login request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "d0e3beac-dec7-4190-9d6b-30e8ea4ec31c",
+		})
+	}
+
+	tokens, err := a.Usecase.Login(ctx, requestedLogin)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, tokens)
+}
+
+func (a Auth) Refresh(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedRefresh models.RefreshTokenRequest
+	if err := e.Bind(&requestedRefresh); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("refresh: invalid refresh request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "f406d29d-0f9b-454d-afe7-8ca16e0c9191",
+		})
+	}
+
+	tokens, err := a.Usecase.Refresh(ctx, requestedRefresh)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the access token of the request and the refresh token of the body, if any.
+func (a Auth) Logout(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedLogout models.RefreshTokenRequest
+	if e.Request().ContentLength > 0 {
+		if err := e.Bind(&requestedLogout); err != nil {
+			return parseErrorResponse(e, models.CustomError{
+				Err:      fmt.Errorf("logout: invalid logout request body :%v", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "6e3e61bd-15e4-46c8-9c73-5aa64be06805",
+			})
+		}
+	}
+
+	if err := a.Usecase.Logout(ctx, requestedLogout); err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+func (a Auth) CreateUser(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedUser models.UserCreationRequest
+	if err := e.Bind(&requestedUser); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createUser: invalid user request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "76a346b7-1467-4204-a036-da7a69188090",
+		})
+	}
+
+	createdUser, err := a.Usecase.CreateUser(ctx, requestedUser)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdUser)
+}
+
+// Middleware rejects the requests without a valid access token in the Authorization
+// header and stores the authenticated caller in the request context.
+func (a Auth) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		authorization := e.Request().Header.Get(authorizationHeader)
+		if !strings.HasPrefix(authorization, bearerPrefix) {
+			return parseErrorResponse(e, models.CustomError{
+				Err:      fmt.Errorf("missing bearer token in the %s header", authorizationHeader),
+				HTTPCode: http.StatusUnauthorized,
+				Code:     "7eddc318-4dc1-47bf-b280-3f011270d09d",
+			})
+		}
+
+		ctx := e.Request().Context()
+		user, err := a.Usecase.Authenticate(ctx, strings.TrimPrefix(authorization, bearerPrefix))
+		if err != nil {
+			return parseErrorResponse(e, err)
+		}
+
+		e.SetRequest(e.Request().WithContext(models.ContextWithUser(ctx, *user)))
+
+		return next(e)
+	}
+}
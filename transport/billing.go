@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/usecase"
 	"github.com/labstack/echo"
 )
 
@@ -31,7 +30,7 @@ type Billings struct {
 	Usecase BillingsUsecase
 }
 
-func NewBillings(buc usecase.Billings) Billings {
+func NewBillings(buc BillingsUsecase) Billings {
 	return Billings{
 		Usecase: buc,
 	}
@@ -68,7 +67,7 @@ func (b Billings) Get(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
-	return e.JSON(http.StatusOK, billings)
+	return jsonWithContentETag(e, billings)
 }
 
 func (b Billings) GetByID(e echo.Context) error {
@@ -81,7 +80,8 @@ func (b Billings) GetByID(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
-	return e.JSON(http.StatusOK, billing)
+	// the billing embeds the current promotion and medicines, which change apart from it
+	return jsonWithContentETag(e, billing)
 }
 
 func (b Billings) Simulator(e echo.Context) error {
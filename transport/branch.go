@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	branchIDParam = "branchID"
+
+	// BranchHeader identifies the branch the caller operates in.
+	BranchHeader = "X-Branch-ID"
+)
+
+type BranchesUsecase interface {
+	Create(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.BranchCreationResponse, error)
+	Get(ctx context.Context) ([]models.Branch, error)
+	GetByID(ctx context.Context, branchID string) (*models.Branch, error)
+}
+
+type Branches struct {
+	Usecase BranchesUsecase
+}
+
+func NewBranches(buc BranchesUsecase) Branches {
+	return Branches{
+		Usecase: buc,
+	}
+}
+
+func (b Branches) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	branches, err := b.Usecase.Get(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, branches)
+}
+
+func (b Branches) GetByID(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	branchID := e.Param(branchIDParam)
+
+	branch, err := b.Usecase.GetByID(ctx, branchID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, branch)
+}
+
+func (b Branches) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedBranch models.BranchCreationRequest
+	if err := e.Bind(&requestedBranch); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createBranch: invalid branch request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "92f6e30c-6fe4-4f44-ac8c-c0fcf08b0c4c",
+		})
+	}
+
+	createdBranch, err := b.Usecase.Create(ctx, requestedBranch)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdBranch)
+}
+
+// BranchMiddleware stores in the request context the branch given in the BranchHeader,
+// requests without it operate over all the branches. The requests of a user bound to a
+// branch operate over that branch, and a header naming another one is forbidden.
+func BranchMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		user, _ := models.UserFromContext(e.Request().Context())
+		branchHeader := e.Request().Header.Get(BranchHeader)
+		if branchHeader == "" && user.BranchID == 0 {
+			return next(e)
+		}
+
+		branchID := user.BranchID
+		if branchHeader != "" {
+			var err error
+			branchID, err = strconv.ParseInt(branchHeader, 10, 64)
+			if err != nil || branchID <= 0 {
+				return parseErrorResponse(e, models.CustomError{
+					Err:      fmt.Errorf("invalid %s header received: [%s]", BranchHeader, branchHeader),
+					HTTPCode: http.StatusBadRequest,
+					Code:     "b9cae3fd-115c-4322-8c2a-22e34f8953b9",
+				})
+			}
+		}
+		if user.BranchID != 0 && branchID != user.BranchID {
+			return parseErrorResponse(e, models.CustomError{
+				Err:      fmt.Errorf("user [%s] cannot operate over branch [%d]", user.Username, branchID),
+				HTTPCode: http.StatusForbidden,
+				Code:     "e5a1c9d2-3f47-4b86-a0e8-6d2b7c4f1a93",
+			})
+		}
+
+		ctx := models.ContextWithBranch(e.Request().Context(), branchID)
+		e.SetRequest(e.Request().WithContext(ctx))
+
+		return next(e)
+	}
+}
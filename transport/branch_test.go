@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+func TestBranchMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		userBranchID int64
+		header       string
+		wantStatus   int
+		wantBranchID int64
+	}{
+		{name: "every branch", wantStatus: http.StatusOK, wantBranchID: 0},
+		{name: "header", header: "2", wantStatus: http.StatusOK, wantBranchID: 2},
+		{name: "invalid header", header: "norte", wantStatus: http.StatusBadRequest},
+		{name: "branch of the token", userBranchID: 3, wantStatus: http.StatusOK, wantBranchID: 3},
+		{name: "header of the branch of the token", userBranchID: 3, header: "3", wantStatus: http.StatusOK, wantBranchID: 3},
+		{name: "header of another branch", userBranchID: 3, header: "2", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set(BranchHeader, tt.header)
+			}
+			user := models.AuthenticatedUser{ID: 1, Username: "cajero", Role: models.RoleCashier, BranchID: tt.userBranchID}
+			request = request.WithContext(models.ContextWithUser(request.Context(), user))
+			recorder := httptest.NewRecorder()
+			e := echo.New().NewContext(request, recorder)
+
+			var branchID int64
+			err := BranchMiddleware(func(e echo.Context) error {
+				branchID = models.BranchFromContext(e.Request().Context())
+				return e.NoContent(http.StatusOK)
+			})(e)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if branchID != tt.wantBranchID {
+				t.Errorf("got branch %d, want %d", branchID, tt.wantBranchID)
+			}
+		})
+	}
+}
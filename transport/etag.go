@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+// versionETag is the ETag of an entity in the given version.
+func versionETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// medicineETag is the ETag of the medicine representation, which also changes with its
+// stock: the catalog version followed by the stock version. Only the catalog version
+// takes part in If-Match, so the sales do not make the price updates fail.
+func medicineETag(medicine models.Medicine) string {
+	return fmt.Sprintf(`"%d.%d"`, medicine.Version, medicine.StockVersion)
+}
+
+// jsonWithVersion responds the entity with its ETag, or 304 when the client already
+// has that version.
+func jsonWithVersion(e echo.Context, version int64, entity interface{}) error {
+	return jsonWithETag(e, versionETag(version), entity)
+}
+
+// jsonWithContentETag responds the body with an ETag computed from its content, used
+// for collections, or 304 when the client already has it.
+func jsonWithContentETag(e echo.Context, body interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("encoding response: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "2a9f24f6-65fc-4849-8914-2b60151d8318",
+		})
+	}
+	etag := contentETag(content)
+
+	e.Response().Header().Set(etagHeader, etag)
+	if etagMatches(e.Request().Header.Get(ifNoneMatchHeader), etag) {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	return e.JSONBlob(http.StatusOK, content)
+}
+
+// contentETag is the ETag of an encoded body.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+}
+
+func jsonWithETag(e echo.Context, etag string, body interface{}) error {
+	e.Response().Header().Set(etagHeader, etag)
+	if etagMatches(e.Request().Header.Get(ifNoneMatchHeader), etag) {
+		return e.NoContent(http.StatusNotModified)
+	}
+
+	return e.JSON(http.StatusOK, body)
+}
+
+// etagMatches tells whether any of the ETags of an If-None-Match header matches etag,
+// using the weak comparison.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersion returns the version the client based its update on, taken from the
+// If-Match header. Zero means the update is unconditional. The stock version of a
+// medicine ETag is ignored.
+func ifMatchVersion(e echo.Context) (int64, error) {
+	header := strings.TrimSpace(e.Request().Header.Get(ifMatchHeader))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	catalogVersion, _, _ := strings.Cut(strings.Trim(header, `"`), ".")
+	version, err := strconv.ParseInt(catalogVersion, 10, 64)
+	if err != nil || version <= 0 || strings.HasPrefix(header, "W/") {
+		return 0, models.CustomError{
+			Err:      fmt.Errorf("invalid %s header received: [%s]", ifMatchHeader, header),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "4734e982-673a-4d2b-b90a-90c9030c2e33",
+		}
+	}
+
+	return version, nil
+}
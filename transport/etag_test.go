@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantVersion int64
+		wantErr     bool
+	}{
+		{name: "unconditional", header: "", wantVersion: 0},
+		{name: "any version", header: "*", wantVersion: 0},
+		{name: "version", header: `"4"`, wantVersion: 4},
+		// a sale after reading the medicine only changes the stock version
+		{name: "medicine ETag", header: medicineETag(models.Medicine{Version: 4, StockVersion: 9}), wantVersion: 4},
+		{name: "weak", header: `W/"4"`, wantErr: true},
+		{name: "not a version", header: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				request.Header.Set(ifMatchHeader, tt.header)
+			}
+			e := echo.New().NewContext(request, httptest.NewRecorder())
+
+			version, err := ifMatchVersion(e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("got version %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
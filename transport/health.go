@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	livenessRoute  = "/healthz"
+	readinessRoute = "/readyz"
+
+	// readinessTimeout bounds the checks so a hung database makes the service not ready
+	// instead of blocking the probe.
+	readinessTimeout = 2 * time.Second
+)
+
+type HealthUsecase interface {
+	Readiness(ctx context.Context) models.HealthReport
+}
+
+type Health struct {
+	Usecase HealthUsecase
+}
+
+func NewHealth(huc HealthUsecase) Health {
+	return Health{
+		Usecase: huc,
+	}
+}
+
+// Liveness answers while the process is able to serve requests.
+func (h Health) Liveness(e echo.Context) error {
+	return e.JSON(http.StatusOK, models.HealthReport{Status: models.HealthStatusOK})
+}
+
+// Readiness answers 503 with the failed checks when the service should not get traffic.
+func (h Health) Readiness(e echo.Context) error {
+	ctx, cancel := context.WithTimeout(e.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	report := h.Usecase.Readiness(ctx)
+	if !report.Ready() {
+		return e.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return e.JSON(http.StatusOK, report)
+}
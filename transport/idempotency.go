@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+type IdempotencyUsecase interface {
+	Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, record models.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+type Idempotency struct {
+	Usecase IdempotencyUsecase
+}
+
+func NewIdempotency(iuc IdempotencyUsecase) Idempotency {
+	return Idempotency{
+		Usecase: iuc,
+	}
+}
+
+// responseRecorder keeps a copy of the response body written to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Middleware makes the POST requests with an Idempotency-Key header safe to retry: the
+// response of the first request is stored and replayed to the retries with the same
+// method, path, branch and body. Responses with server errors are not stored.
+func (i Idempotency) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		key := e.Request().Header.Get(idempotencyKeyHeader)
+		if key == "" || e.Request().Method != http.MethodPost {
+			return next(e)
+		}
+
+		ctx := e.Request().Context()
+		body, err := ioutil.ReadAll(e.Request().Body)
+		if err != nil {
+			return parseErrorResponse(e, models.CustomError{
+				Err:      fmt.Errorf("reading request body: %w", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "960f87b7-1b34-40a0-a3ec-9c8d5b457724",
+			})
+		}
+		e.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		for _, part := range []string{e.Request().Method, e.Request().URL.Path, e.Request().Header.Get(BranchHeader)} {
+			hash.Write([]byte(part))
+			hash.Write([]byte{0})
+		}
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		stored, err := i.Usecase.Begin(ctx, key, requestHash)
+		if err != nil {
+			return parseErrorResponse(e, err)
+		}
+		if stored != nil {
+			e.Response().Header().Set(idempotentReplayedHeader, "true")
+			return e.Blob(stored.ResponseStatus, stored.ContentType, stored.ResponseBody)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: e.Response().Writer}
+		e.Response().Writer = recorder
+		err = next(e)
+		e.Response().Writer = recorder.ResponseWriter
+
+		if err != nil || !e.Response().Committed || e.Response().Status >= http.StatusInternalServerError {
+			if errRelease := i.Usecase.Release(ctx, key); errRelease != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "could not release idempotency key", "idempotency_key", key, "error", errRelease)
+			}
+			return err
+		}
+
+		errComplete := i.Usecase.Complete(ctx, models.IdempotencyRecord{
+			Key:            key,
+			RequestHash:    requestHash,
+			ResponseStatus: e.Response().Status,
+			ResponseBody:   recorder.body.Bytes(),
+			ContentType:    e.Response().Header().Get(echo.HeaderContentType),
+		})
+		if errComplete != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "could not store response of idempotency key", "idempotency_key", key, "error", errComplete)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	countIDParam = "countID"
+)
+
+type InventoryCountsUsecase interface {
+	Create(ctx context.Context, countRequest models.InventoryCountCreationRequest) (*models.InventoryCountCreationResponse, error)
+	Get(ctx context.Context, status string) ([]models.InventoryCount, error)
+	GetByID(ctx context.Context, countID string) (*models.InventoryCount, error)
+	GetVariance(ctx context.Context, countID string) (*models.InventoryVarianceReport, error)
+	Record(ctx context.Context, countID string, recordRequest models.InventoryCountRecordRequest) (*models.InventoryCount, error)
+	Approve(ctx context.Context, countID string) (*models.InventoryVarianceReport, error)
+	Cancel(ctx context.Context, countID string) (*models.InventoryCount, error)
+}
+
+type InventoryCounts struct {
+	Usecase InventoryCountsUsecase
+}
+
+func NewInventoryCounts(icuc InventoryCountsUsecase) InventoryCounts {
+	return InventoryCounts{
+		Usecase: icuc,
+	}
+}
+
+func (ic InventoryCounts) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	status := e.QueryParam(statusQueryParam)
+
+	counts, err := ic.Usecase.Get(ctx, status)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, counts)
+}
+
+func (ic InventoryCounts) GetByID(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	countID := e.Param(countIDParam)
+
+	count, err := ic.Usecase.GetByID(ctx, countID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, count)
+}
+
+func (ic InventoryCounts) GetVariance(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	countID := e.Param(countIDParam)
+
+	report, err := ic.Usecase.GetVariance(ctx, countID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, report)
+}
+
+func (ic InventoryCounts) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedCount models.InventoryCountCreationRequest
+	if err := e.Bind(&requestedCount); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createInventoryCount: invalid inventory count request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "7db3e734-d745-4270-a52e-50974d36f97f",
+		})
+	}
+
+	createdCount, err := ic.Usecase.Create(ctx, requestedCount)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdCount)
+}
+
+func (ic InventoryCounts) Record(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	countID := e.Param(countIDParam)
+
+	var requestedRecord models.InventoryCountRecordRequest
+	if err := e.Bind(&requestedRecord); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("recordInventoryCount: invalid inventory count record request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "d7cd9606-20c1-456d-ac05-15fec4437f24",
+		})
+	}
+
+	count, err := ic.Usecase.Record(ctx, countID, requestedRecord)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, count)
+}
+
+func (ic InventoryCounts) Approve(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	countID := e.Param(countIDParam)
+
+	report, err := ic.Usecase.Approve(ctx, countID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, report)
+}
+
+func (ic InventoryCounts) Cancel(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	countID := e.Param(countIDParam)
+
+	count, err := ic.Usecase.Cancel(ctx, countID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, count)
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+// LoggingMiddleware leaves in the request context the logger with the request ID, so the
+// usecases and stores log with it, and logs each finished request.
+func LoggingMiddleware(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			start := time.Now()
+			ctx := e.Request().Context()
+
+			requestLogger := logger.With("request_id", models.RequestInfoFromContext(ctx).RequestID)
+			ctx = logging.ContextWithLogger(ctx, requestLogger)
+			e.SetRequest(e.Request().WithContext(ctx))
+
+			err := next(e)
+
+			status := e.Response().Status
+			if err != nil && !e.Response().Committed {
+				status = http.StatusInternalServerError
+				if httpErr, ok := err.(*echo.HTTPError); ok {
+					status = httpErr.Code
+				}
+			}
+			level := slog.LevelInfo
+			if e.Path() == livenessRoute || e.Path() == readinessRoute {
+				level = slog.LevelDebug
+			}
+			requestLogger.Log(ctx, level, "request finished",
+				"method", e.Request().Method,
+				"route", e.Path(),
+				"path", e.Request().URL.Path,
+				"status", status,
+				"latency_ms", time.Since(start).Milliseconds(),
+				"client_ip", models.RequestInfoFromContext(ctx).ClientIP,
+			)
+
+			return err
+		}
+	}
+}
+
+// logErrorResponse logs the error returned to the client with its code and the messages
+// of the wrapped errors, as a warning for client errors and as an error otherwise.
+func logErrorResponse(e echo.Context, err error, status int, code string) {
+	ctx := e.Request().Context()
+
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(ctx).Log(ctx, level, "request failed",
+		"status", status,
+		"error_code", code,
+		"error", err.Error(),
+		"error_chain", errorChain(err),
+	)
+}
+
+// errorChain lists the message of err and of each error it wraps, the last one being the cause.
+func errorChain(err error) []string {
+	chain := make([]string, 0)
+	for err != nil {
+		chain = append(chain, err.Error())
+
+		// CustomError does not implement Unwrap so errors.Is keeps matching only its code.
+		if ce, ok := err.(models.CustomError); ok {
+			err = ce.Err
+			continue
+		}
+		err = errors.Unwrap(err)
+	}
+
+	return chain
+}
@@ -6,25 +6,34 @@ import (
 	"net/http"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/usecase"
 	"github.com/labstack/echo"
 )
 
 const (
 	medicineIDParam = "medicineID"
+
+	searchTextQueryParam = "q"
+	limitQueryParam      = "limit"
 )
 
 type MedicinesUsecase interface {
 	Create(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.MedicineCreationResponse, error)
 	Get(ctx context.Context) ([]models.Medicine, error)
 	GetByID(ctx context.Context, medicineID string) (*models.Medicine, error)
+	Search(ctx context.Context, text, limit string) ([]models.MedicineSearchResult, error)
+	UpdatePrice(
+		ctx context.Context, medicineID string, expectedVersion int64, priceRequest models.MedicinePriceUpdateRequest,
+	) (*models.Medicine, error)
+	CreatePresentation(
+		ctx context.Context, medicineID string, presentationRequest models.PresentationCreationRequest,
+	) (*models.PresentationCreationResponse, error)
 }
 
 type Medicines struct {
 	Usecase MedicinesUsecase
 }
 
-func NewMedicines(muc usecase.Medicines) Medicines {
+func NewMedicines(muc MedicinesUsecase) Medicines {
 	return Medicines{
 		Usecase: muc,
 	}
@@ -38,7 +47,7 @@ func (m Medicines) Get(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
-	return e.JSON(http.StatusOK, medicines)
+	return jsonWithContentETag(e, medicines)
 }
 
 func (m Medicines) GetByID(e echo.Context) error {
@@ -51,7 +60,21 @@ func (m Medicines) GetByID(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
-	return e.JSON(http.StatusOK, medicine)
+	return jsonWithETag(e, medicineETag(*medicine), medicine)
+}
+
+func (m Medicines) Search(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	text := e.QueryParam(searchTextQueryParam)
+	limit := e.QueryParam(limitQueryParam)
+
+	results, err := m.Usecase.Search(ctx, text, limit)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, results)
 }
 
 func (m Medicines) Create(e echo.Context) error {
@@ -73,3 +96,53 @@ func (m Medicines) Create(e echo.Context) error {
 
 	return e.JSON(http.StatusCreated, createdMedicine)
 }
+
+func (m Medicines) UpdatePrice(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	medicineID := e.Param(medicineIDParam)
+
+	var requestedPrice models.MedicinePriceUpdateRequest
+	if err := e.Bind(&requestedPrice); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("updatePrice: invalid price request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "c3430f85-7fa2-4616-94a6-d4a0e70aea6d",
+		})
+	}
+
+	expectedVersion, err := ifMatchVersion(e)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	medicine, err := m.Usecase.UpdatePrice(ctx, medicineID, expectedVersion, requestedPrice)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	e.Response().Header().Set(etagHeader, medicineETag(*medicine))
+	return e.JSON(http.StatusOK, medicine)
+}
+
+func (m Medicines) CreatePresentation(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	medicineID := e.Param(medicineIDParam)
+
+	var requestedPresentation models.PresentationCreationRequest
+	if err := e.Bind(&requestedPresentation); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createPresentation: invalid presentation request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "dfeb1ad0-6806-4bef-9e3f-27faa5064627",
+		})
+	}
+
+	createdPresentation, err := m.Usecase.CreatePresentation(ctx, medicineID, requestedPresentation)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdPresentation)
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/metrics"
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const unmatchedRoute = "unmatched"
+
+var requestDuration = promauto.With(metrics.Default).NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "aveonline_http_request_duration_seconds",
+	Help:    "Latency of the HTTP requests by route and status.",
+	Buckets: metrics.DefaultBuckets,
+}, []string{"method", "route", "status"})
+
+// MetricsMiddleware records the latency of the requests, labeled with the route pattern
+// so path parameters do not create new series.
+func MetricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		start := time.Now()
+
+		err := next(e)
+
+		status := e.Response().Status
+		if err != nil && !e.Response().Committed {
+			status = http.StatusInternalServerError
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				status = httpErr.Code
+			}
+		}
+		route := e.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		requestDuration.WithLabelValues(e.Request().Method, route, strconv.Itoa(status)).Observe(
+			time.Since(start).Seconds(),
+		)
+
+		return err
+	}
+}
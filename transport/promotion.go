@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/usecase"
 	"github.com/labstack/echo"
 )
 
@@ -18,13 +17,16 @@ type PromotionsUsecase interface {
 	Create(ctx context.Context, promotionRequest models.PromotionCreationRequest) (*models.PromotionCreationResponse, error)
 	GetByID(ctx context.Context, promoID string) (models.Promotion, error)
 	Get(ctx context.Context) ([]models.Promotion, error)
+	Update(
+		ctx context.Context, promoID string, expectedVersion int64, promotionRequest models.PromotionCreationRequest,
+	) (*models.Promotion, error)
 }
 
 type Promotions struct {
 	Usecase PromotionsUsecase
 }
 
-func NewPromotions(puc usecase.Promotions) Promotions {
+func NewPromotions(puc PromotionsUsecase) Promotions {
 	return Promotions{
 		Usecase: puc,
 	}
@@ -58,7 +60,7 @@ func (p Promotions) Get(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
-	return e.JSON(http.StatusOK, promotions)
+	return jsonWithContentETag(e, promotions)
 }
 
 func (p Promotions) GetByID(e echo.Context) error {
@@ -71,5 +73,34 @@ func (p Promotions) GetByID(e echo.Context) error {
 		return parseErrorResponse(e, err)
 	}
 
+	return jsonWithVersion(e, promo.Version, promo)
+}
+
+// Update replaces the promotion, conditionally to the version of the If-Match header.
+func (p Promotions) Update(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	promoID := e.Param(promoIDParam)
+
+	var requestedPromotion models.PromotionCreationRequest
+	if err := e.Bind(&requestedPromotion); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("updatePromotion: invalid promotion request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "178c4ae0-af90-4ea2-a16a-4e4ffc382205",
+		})
+	}
+
+	expectedVersion, err := ifMatchVersion(e)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	promo, err := p.Usecase.Update(ctx, promoID, expectedVersion, requestedPromotion)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	e.Response().Header().Set(etagHeader, versionETag(promo.Version))
 	return e.JSON(http.StatusOK, promo)
 }
@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	purchaseOrderIDParam = "purchaseOrderID"
+
+	statusQueryParam = "status"
+)
+
+type PurchaseOrdersUsecase interface {
+	Create(ctx context.Context, orderRequest models.PurchaseOrderCreationRequest) (*models.PurchaseOrderCreationResponse, error)
+	Get(ctx context.Context, status string) ([]models.PurchaseOrder, error)
+	GetByID(ctx context.Context, orderID string) (*models.PurchaseOrder, error)
+	Send(ctx context.Context, orderID string) (*models.PurchaseOrder, error)
+	Receive(ctx context.Context, orderID string, receiptRequest models.GoodsReceiptRequest) (*models.PurchaseOrder, error)
+}
+
+type PurchaseOrders struct {
+	Usecase PurchaseOrdersUsecase
+}
+
+func NewPurchaseOrders(puc PurchaseOrdersUsecase) PurchaseOrders {
+	return PurchaseOrders{
+		Usecase: puc,
+	}
+}
+
+func (p PurchaseOrders) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	status := e.QueryParam(statusQueryParam)
+
+	orders, err := p.Usecase.Get(ctx, status)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, orders)
+}
+
+func (p PurchaseOrders) GetByID(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	orderID := e.Param(purchaseOrderIDParam)
+
+	order, err := p.Usecase.GetByID(ctx, orderID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, order)
+}
+
+func (p PurchaseOrders) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedOrder models.PurchaseOrderCreationRequest
+	if err := e.Bind(&requestedOrder); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createPurchaseOrder: invalid purchase order request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "cdb2d559-ca41-46cf-8e30-623aa1115787",
+		})
+	}
+
+	createdOrder, err := p.Usecase.Create(ctx, requestedOrder)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdOrder)
+}
+
+func (p PurchaseOrders) Send(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	orderID := e.Param(purchaseOrderIDParam)
+
+	order, err := p.Usecase.Send(ctx, orderID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, order)
+}
+
+func (p PurchaseOrders) Receive(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	orderID := e.Param(purchaseOrderIDParam)
+
+	var requestedReceipt models.GoodsReceiptRequest
+	if err := e.Bind(&requestedReceipt); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("goodsReceipt: invalid goods receipt request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "e14bfcf7-f1f1-4735-a75e-034ffe648e48",
+		})
+	}
+
+	order, err := p.Usecase.Receive(ctx, orderID, requestedReceipt)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, order)
+}
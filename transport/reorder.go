@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	windowDaysQueryParam      = "windowDays"
+	leadTimeDaysQueryParam    = "leadTimeDays"
+	safetyStockDaysQueryParam = "safetyStockDays"
+)
+
+type ReordersUsecase interface {
+	Report(ctx context.Context, windowDays, leadTimeDays, safetyStockDays string) ([]models.ReorderSuggestion, error)
+	GenerateDraft(ctx context.Context) (*models.ReorderDraft, error)
+	GetLatestDraft(ctx context.Context) (*models.ReorderDraft, error)
+}
+
+type Reorders struct {
+	Usecase ReordersUsecase
+}
+
+func NewReorders(ruc ReordersUsecase) Reorders {
+	return Reorders{
+		Usecase: ruc,
+	}
+}
+
+func (r Reorders) Report(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	windowDays := e.QueryParam(windowDaysQueryParam)
+	leadTimeDays := e.QueryParam(leadTimeDaysQueryParam)
+	safetyStockDays := e.QueryParam(safetyStockDaysQueryParam)
+
+	suggestions, err := r.Usecase.Report(ctx, windowDays, leadTimeDays, safetyStockDays)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, suggestions)
+}
+
+func (r Reorders) GenerateDraft(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	draft, err := r.Usecase.GenerateDraft(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, draft)
+}
+
+func (r Reorders) GetLatestDraft(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	draft, err := r.Usecase.GetLatestDraft(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, draft)
+}
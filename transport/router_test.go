@@ -0,0 +1,342 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const testAccessToken = "valid"
+
+// The fakes embed the interface they fake, calling a method a test does not set up panics.
+
+type fakeAuthUsecase struct {
+	AuthUsecase
+}
+
+func (fakeAuthUsecase) Authenticate(ctx context.Context, accessToken string) (*models.AuthenticatedUser, error) {
+	if accessToken != testAccessToken {
+		return nil, models.CustomError{
+			Err:      errors.New("invalid access token"),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "8a0f6f2e-9d0b-4d2a-9f55-1b4a0e7a7c11",
+		}
+	}
+
+	return &models.AuthenticatedUser{ID: 1, Username: "cajero", Role: models.RoleCashier}, nil
+}
+
+type fakeBillingsUsecase struct {
+	BillingsUsecase
+	// branchID is the branch of the last call.
+	branchID  int64
+	promotion models.Promotion
+}
+
+// testBilling is the billing 7 returned by the fake, with the given promotion.
+func testBilling(promotion models.Promotion) models.BillingDetail {
+	return models.BillingDetail{ID: 7, BranchID: 1, Promotion: promotion, Total: 900, Version: 3}
+}
+
+// testBillingETag is the ETag of the billing 7 with the given promotion.
+func testBillingETag(promotion models.Promotion) string {
+	content, err := json.Marshal(testBilling(promotion))
+	if err != nil {
+		panic(err)
+	}
+
+	return contentETag(content)
+}
+
+func (u *fakeBillingsUsecase) GetByID(ctx context.Context, billingID string) (*models.BillingDetail, error) {
+	u.branchID = models.BranchFromContext(ctx)
+	if billingID != "7" {
+		return nil, models.CustomError{
+			Err:      models.ErrNotFound,
+			HTTPCode: http.StatusNotFound,
+			Code:     "4a7ee994-1af6-4a35-8498-62b01007e180",
+		}
+	}
+
+	billing := testBilling(u.promotion)
+
+	return &billing, nil
+}
+
+func (u *fakeBillingsUsecase) Simulator(ctx context.Context, date, medicinesIDs string) (*models.SimulatorResponse, error) {
+	u.branchID = models.BranchFromContext(ctx)
+	if date == "" {
+		return nil, errors.New("unexpected failure")
+	}
+
+	return &models.SimulatorResponse{Total: 675}, nil
+}
+
+type fakeSalesReportsUsecase struct {
+	SalesReportsUsecase
+}
+
+func (fakeSalesReportsUsecase) Medicines(ctx context.Context, from, to, slowMoverDays, sortBy string) ([]models.MedicineRanking, error) {
+	lastSoldAt := time.Date(2030, time.March, 10, 9, 30, 0, 0, time.FixedZone("", -5*60*60))
+
+	return []models.MedicineRanking{
+		{
+			MedicineID: 1, MedicineName: "Acetaminofen, 500 mg", Location: "A-1", Units: 13, Revenue: 1200,
+			RevenueRank: 1, UnitsRank: 1, RevenueShare: 0.8571428, CumulativeShare: 0.8571428, Class: models.ABCClassA, LastSoldAt: &lastSoldAt,
+		},
+		{MedicineID: 3, MedicineName: "Loratadina", RevenueRank: 2, UnitsRank: 2, Class: models.ABCClassC, SlowMover: true},
+	}, nil
+}
+
+func newTestRouter(billingsUsecase BillingsUsecase, salesReportsUsecase SalesReportsUsecase) *echo.Echo {
+	return NewRouter(
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		RouterOptions{},
+		Promotions{},
+		Medicines{},
+		NewBillings(billingsUsecase),
+		Suppliers{},
+		PurchaseOrders{},
+		Reorders{},
+		NewSalesReports(salesReportsUsecase),
+		Branches{},
+		Transfers{},
+		InventoryCounts{},
+		NewAuth(fakeAuthUsecase{}),
+		Audit{},
+		Idempotency{},
+		Health{},
+	)
+}
+
+// The metrics are served by a listener of their own, the API must not publish them.
+func TestRouterDoesNotExposeMetrics(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestRouter(&fakeBillingsUsecase{}, &fakeSalesReportsUsecase{}).ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil),
+	)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("got status %d for /metrics, want 404", recorder.Code)
+	}
+}
+
+func TestRouterBillings(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		token       string
+		headers     map[string]string
+		wantStatus  int
+		wantBody    string
+		wantETag    string
+		wantBranch  int64
+		wantErrCode string
+	}{
+		{
+			name:        "without token",
+			target:      "/aveonline/pharmacy/billing/7",
+			wantStatus:  http.StatusUnauthorized,
+			wantErrCode: "7eddc318-4dc1-47bf-b280-3f011270d09d",
+		},
+		{
+			name:        "invalid token",
+			target:      "/aveonline/pharmacy/billing/7",
+			token:       "expired",
+			wantStatus:  http.StatusUnauthorized,
+			wantErrCode: "8a0f6f2e-9d0b-4d2a-9f55-1b4a0e7a7c11",
+		},
+		{
+			name:       "billing by id",
+			target:     "/aveonline/pharmacy/billing/7",
+			token:      testAccessToken,
+			headers:    map[string]string{BranchHeader: "2"},
+			wantStatus: http.StatusOK,
+			wantBody:   `"total":900`,
+			wantETag:   testBillingETag(models.Promotion{}),
+			wantBranch: 2,
+		},
+		{
+			name:       "billing not modified",
+			target:     "/aveonline/pharmacy/billing/7",
+			token:      testAccessToken,
+			headers:    map[string]string{ifNoneMatchHeader: testBillingETag(models.Promotion{})},
+			wantStatus: http.StatusNotModified,
+			wantETag:   testBillingETag(models.Promotion{}),
+		},
+		{
+			name:        "billing not found",
+			target:      "/aveonline/pharmacy/billing/8",
+			token:       testAccessToken,
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: "4a7ee994-1af6-4a35-8498-62b01007e180",
+		},
+		{
+			name:        "invalid branch",
+			target:      "/aveonline/pharmacy/billing/7",
+			token:       testAccessToken,
+			headers:     map[string]string{BranchHeader: "norte"},
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: "b9cae3fd-115c-4322-8c2a-22e34f8953b9",
+		},
+		{
+			name:       "simulator",
+			target:     "/aveonline/pharmacy/simulator/purchase?date=2030-03-11&medicinesIDs=1,1,2",
+			token:      testAccessToken,
+			headers:    map[string]string{BranchHeader: "1"},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"total":675}`,
+			wantBranch: 1,
+		},
+		{
+			name:       "unexpected error",
+			target:     "/aveonline/pharmacy/simulator/purchase",
+			token:      testAccessToken,
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   genericErrCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billingsUsecase := &fakeBillingsUsecase{}
+			router := newTestRouter(billingsUsecase, fakeSalesReportsUsecase{})
+
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				request.Header.Set(authorizationHeader, bearerPrefix+tt.token)
+			}
+			for header, value := range tt.headers {
+				request.Header.Set(header, value)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body, tt.wantBody)
+			}
+			if got := recorder.Header().Get(etagHeader); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if billingsUsecase.branchID != tt.wantBranch {
+				t.Errorf("branch in the usecase = %d, want %d", billingsUsecase.branchID, tt.wantBranch)
+			}
+			if tt.wantErrCode != "" {
+				var response models.ResponseError
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatalf("decoding the error response: %v", err)
+				}
+				if response.Code != tt.wantErrCode {
+					t.Errorf("error code = %s, want %s", response.Code, tt.wantErrCode)
+				}
+			}
+		})
+	}
+}
+
+// The billing embeds the current promotion, a change of the promotion changes the ETag
+// even though the billing keeps its version.
+func TestRouterBillingETagFollowsThePromotion(t *testing.T) {
+	billingsUsecase := &fakeBillingsUsecase{promotion: models.Promotion{ID: 2, Percentage: 10, Version: 1}}
+	router := newTestRouter(billingsUsecase, fakeSalesReportsUsecase{})
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/aveonline/pharmacy/billing/7", nil)
+		request.Header.Set(authorizationHeader, bearerPrefix+testAccessToken)
+		if ifNoneMatch != "" {
+			request.Header.Set(ifNoneMatchHeader, ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	first := get("")
+	etag := first.Header().Get(etagHeader)
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got status %d and ETag %q, want the billing with its ETag", first.Code, etag)
+	}
+
+	billingsUsecase.promotion = models.Promotion{ID: 2, Percentage: 15, Version: 2}
+	second := get(etag)
+	if second.Code != http.StatusOK {
+		t.Fatalf("got status %d, want the billing with the new promotion", second.Code)
+	}
+	if !strings.Contains(second.Body.String(), `"percentage":15`) || second.Header().Get(etagHeader) == etag {
+		t.Errorf("got ETag %q and body %s, want a new ETag with the new promotion", second.Header().Get(etagHeader), second.Body)
+	}
+}
+
+func TestRouterMedicinesRanking(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json",
+			target:          "/aveonline/pharmacy/reports/sales/medicines?from=2030-03-01&to=2030-03-31",
+			wantStatus:      http.StatusOK,
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:        `"class":"A"`,
+		},
+		{
+			name:            "csv",
+			target:          "/aveonline/pharmacy/reports/sales/medicines?from=2030-03-01&to=2030-03-31&format=csv",
+			wantStatus:      http.StatusOK,
+			wantContentType: csvContentType,
+			wantBody: "revenueRank,unitsRank,medicineID,medicineName,location,units,revenue,revenueShare,cumulativeShare,class,lastSoldAt,slowMover\n" +
+				"1,1,1,\"Acetaminofen, 500 mg\",A-1,13,1200,0.8571,0.8571,A,2030-03-10T09:30:00-05:00,false\n" +
+				"2,2,3,Loratadina,,0,0,0.0000,0.0000,C,,true\n",
+		},
+		{
+			name:            "invalid format",
+			target:          "/aveonline/pharmacy/reports/sales/medicines?format=xlsx",
+			wantStatus:      http.StatusBadRequest,
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			wantBody:        "f1c7b3e9-2a5d-4e80-96b4-8d3a0e5f7c12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(&fakeBillingsUsecase{}, fakeSalesReportsUsecase{})
+
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			request.Header.Set(authorizationHeader, bearerPrefix+testAccessToken)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if got := recorder.Header().Get(echo.HeaderContentType); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+			if tt.wantContentType == csvContentType {
+				if recorder.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", recorder.Body, tt.wantBody)
+				}
+				return
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body, tt.wantBody)
+			}
+		})
+	}
+}
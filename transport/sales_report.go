@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	groupByQueryParam       = "groupBy"
+	breakdownQueryParam     = "breakdown"
+	slowMoverDaysQueryParam = "slowMoverDays"
+	sortByQueryParam        = "sortBy"
+	formatQueryParam        = "format"
+
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	csvContentType = "text/csv; charset=utf-8"
+)
+
+type SalesReportsUsecase interface {
+	Summary(ctx context.Context, from, to, groupBy, breakdown string) ([]models.SalesSummary, error)
+	Medicines(ctx context.Context, from, to, slowMoverDays, sortBy string) ([]models.MedicineRanking, error)
+}
+
+type SalesReports struct {
+	Usecase SalesReportsUsecase
+}
+
+func NewSalesReports(sruc SalesReportsUsecase) SalesReports {
+	return SalesReports{
+		Usecase: sruc,
+	}
+}
+
+func (sr SalesReports) Daily(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	summaries, err := sr.Usecase.Summary(
+		ctx,
+		e.QueryParam(fromQueryParam),
+		e.QueryParam(toQueryParam),
+		e.QueryParam(groupByQueryParam),
+		e.QueryParam(breakdownQueryParam),
+	)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, summaries)
+}
+
+// Medicines responds the medicines ranking in JSON or, with format=csv, as a CSV file to
+// download.
+func (sr SalesReports) Medicines(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	format := e.QueryParam(formatQueryParam)
+	if format != "" && format != formatJSON && format != formatCSV {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: invalid format [%s], use %s or %s", format, formatJSON, formatCSV),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "f1c7b3e9-2a5d-4e80-96b4-8d3a0e5f7c12",
+		})
+	}
+
+	rankings, err := sr.Usecase.Medicines(
+		ctx,
+		e.QueryParam(fromQueryParam),
+		e.QueryParam(toQueryParam),
+		e.QueryParam(slowMoverDaysQueryParam),
+		e.QueryParam(sortByQueryParam),
+	)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	if format != formatCSV {
+		return e.JSON(http.StatusOK, rankings)
+	}
+
+	content, err := medicineRankingsCSV(rankings)
+	if err != nil {
+		return parseErrorResponse(e, fmt.Errorf("medicinesRanking: writing the csv: %w", err))
+	}
+	e.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="medicines-ranking.csv"`)
+
+	return e.Blob(http.StatusOK, csvContentType, content)
+}
+
+// medicineRankingsCSV writes a row per medicine after a header with the names of the
+// JSON fields. Shares are fractions and lastSoldAt is empty for medicines never sold.
+func medicineRankingsCSV(rankings []models.MedicineRanking) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	records := [][]string{{
+		"revenueRank", "unitsRank", "medicineID", "medicineName", "location", "units", "revenue",
+		"revenueShare", "cumulativeShare", "class", "lastSoldAt", "slowMover",
+	}}
+	for _, ranking := range rankings {
+		lastSoldAt := ""
+		if ranking.LastSoldAt != nil {
+			lastSoldAt = ranking.LastSoldAt.Format(time.RFC3339)
+		}
+		records = append(records, []string{
+			strconv.Itoa(ranking.RevenueRank),
+			strconv.Itoa(ranking.UnitsRank),
+			strconv.FormatInt(ranking.MedicineID, 10),
+			ranking.MedicineName,
+			ranking.Location,
+			strconv.FormatInt(ranking.Units, 10),
+			strconv.FormatFloat(ranking.Revenue, 'f', -1, 64),
+			strconv.FormatFloat(ranking.RevenueShare, 'f', 4, 64),
+			strconv.FormatFloat(ranking.CumulativeShare, 'f', 4, 64),
+			ranking.Class,
+			lastSoldAt,
+			strconv.FormatBool(ranking.SlowMover),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	supplierIDParam = "supplierID"
+)
+
+type SuppliersUsecase interface {
+	Create(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.SupplierCreationResponse, error)
+	Get(ctx context.Context) ([]models.Supplier, error)
+	GetByID(ctx context.Context, supplierID string) (*models.Supplier, error)
+}
+
+type Suppliers struct {
+	Usecase SuppliersUsecase
+}
+
+func NewSuppliers(suc SuppliersUsecase) Suppliers {
+	return Suppliers{
+		Usecase: suc,
+	}
+}
+
+func (s Suppliers) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	suppliers, err := s.Usecase.Get(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, suppliers)
+}
+
+func (s Suppliers) GetByID(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	supplierID := e.Param(supplierIDParam)
+
+	supplier, err := s.Usecase.GetByID(ctx, supplierID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, supplier)
+}
+
+func (s Suppliers) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedSupplier models.SupplierCreationRequest
+	if err := e.Bind(&requestedSupplier); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createSupplier: invalid supplier request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "5f44dba3-bd94-4464-9f67-ab005f261bde",
+		})
+	}
+
+	createdSupplier, err := s.Usecase.Create(ctx, requestedSupplier)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdSupplier)
+}
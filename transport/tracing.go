@@ -0,0 +1,65 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/VictorDelgado94/aveonline-backend/transport"
+
+var tracer = otel.Tracer(tracerName)
+
+// TracingMiddleware starts a server span per request, continuing the trace of the
+// traceparent header when present, and leaves it in the request context.
+func TracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(e echo.Context) error {
+		request := e.Request()
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+
+		route := e.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", request.URL.Path),
+				attribute.String("request.id", models.RequestInfoFromContext(ctx).RequestID),
+			),
+		)
+		defer span.End()
+		e.SetRequest(request.WithContext(ctx))
+
+		err := next(e)
+
+		status := e.Response().Status
+		if err != nil && !e.Response().Committed {
+			status = http.StatusInternalServerError
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				status = httpErr.Code
+			}
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
+
+// recordErrorInSpan adds the error returned to the client to the request span.
+func recordErrorInSpan(e echo.Context, err error, code string) {
+	span := trace.SpanFromContext(e.Request().Context())
+	span.RecordError(err, trace.WithAttributes(attribute.String("error.code", code)))
+}
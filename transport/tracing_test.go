@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/VictorDelgado94/aveonline-backend/tracing"
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	testTraceID    = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testParentSpan = "00f067aa0ba902b7"
+)
+
+var (
+	inMemoryExporter = tracetest.NewInMemoryExporter()
+	installTracing   sync.Once
+)
+
+// setupInMemoryTracing installs once the provider exporting to inMemoryExporter, as the
+// package tracers keep the first global provider, and clears the spans of previous tests.
+func setupInMemoryTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	installTracing.Do(func() {
+		otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(inMemoryExporter)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	inMemoryExporter.Reset()
+
+	return inMemoryExporter
+}
+
+func newTracedEcho(handler echo.HandlerFunc) *echo.Echo {
+	e := echo.New()
+	e.Use(TracingMiddleware)
+	e.GET("/billing/:billingID", handler)
+
+	return e
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not exported, got %d spans", name, len(spans))
+
+	return tracetest.SpanStub{}
+}
+
+func intAttribute(attributes []attribute.KeyValue, key attribute.Key) int64 {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv.Value.AsInt64()
+		}
+	}
+
+	return 0
+}
+
+func TestTracingMiddlewareContinuesTraceContext(t *testing.T) {
+	exporter := setupInMemoryTracing(t)
+
+	e := newTracedEcho(func(c echo.Context) error {
+		_, span := otel.Tracer("test").Start(c.Request().Context(), "Billings.GetByID")
+		span.End()
+
+		return c.NoContent(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/billing/7", nil)
+	request.Header.Set("traceparent", "00-"+testTraceID+"-"+testParentSpan+"-01")
+	e.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := exporter.GetSpans()
+	serverSpan := spanByName(t, spans, "GET /billing/:billingID")
+	childSpan := spanByName(t, spans, "Billings.GetByID")
+
+	if got := serverSpan.SpanContext.TraceID().String(); got != testTraceID {
+		t.Errorf("server span trace ID = %s, want %s", got, testTraceID)
+	}
+	if got := serverSpan.Parent.SpanID().String(); got != testParentSpan {
+		t.Errorf("server span parent = %s, want %s", got, testParentSpan)
+	}
+	if childSpan.Parent.SpanID() != serverSpan.SpanContext.SpanID() {
+		t.Errorf("usecase span is not a child of the request span")
+	}
+	if got := intAttribute(serverSpan.Attributes, "http.response.status_code"); got != http.StatusOK {
+		t.Errorf("status code attribute = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestTracingMiddlewareRecordsErrors(t *testing.T) {
+	exporter := setupInMemoryTracing(t)
+
+	e := newTracedEcho(func(c echo.Context) error {
+		return parseErrorResponse(c, models.CustomError{
+			Err:      errors.New("database is down"),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "2a68d18d-4f61-45bf-a78b-ce3ae046ddf2",
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/billing/7", nil))
+
+	serverSpan := spanByName(t, exporter.GetSpans(), "GET /billing/:billingID")
+	if serverSpan.Status.Code != codes.Error {
+		t.Errorf("span status = %v, want %v", serverSpan.Status.Code, codes.Error)
+	}
+	if got := intAttribute(serverSpan.Attributes, "http.response.status_code"); got != http.StatusInternalServerError {
+		t.Errorf("status code attribute = %d, want %d", got, http.StatusInternalServerError)
+	}
+	if len(serverSpan.Events) != 1 || serverSpan.Events[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded as an exception event, got %v", serverSpan.Events)
+	}
+}
@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/labstack/echo"
+)
+
+const (
+	transferIDParam = "transferID"
+)
+
+type TransfersUsecase interface {
+	Create(ctx context.Context, transferRequest models.StockTransferCreationRequest) (*models.StockTransferCreationResponse, error)
+	Get(ctx context.Context, status string) ([]models.StockTransfer, error)
+	GetInTransit(ctx context.Context) ([]models.StockTransfer, error)
+	GetByID(ctx context.Context, transferID string) (*models.StockTransfer, error)
+	Ship(ctx context.Context, transferID string) (*models.StockTransfer, error)
+	Receive(ctx context.Context, transferID string, receiptRequest models.StockTransferReceiptRequest) (*models.StockTransfer, error)
+}
+
+type Transfers struct {
+	Usecase TransfersUsecase
+}
+
+func NewTransfers(tuc TransfersUsecase) Transfers {
+	return Transfers{
+		Usecase: tuc,
+	}
+}
+
+func (t Transfers) Get(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	status := e.QueryParam(statusQueryParam)
+
+	transfers, err := t.Usecase.Get(ctx, status)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, transfers)
+}
+
+func (t Transfers) GetInTransit(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	transfers, err := t.Usecase.GetInTransit(ctx)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, transfers)
+}
+
+func (t Transfers) GetByID(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	transferID := e.Param(transferIDParam)
+
+	transfer, err := t.Usecase.GetByID(ctx, transferID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, transfer)
+}
+
+func (t Transfers) Create(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	var requestedTransfer models.StockTransferCreationRequest
+	if err := e.Bind(&requestedTransfer); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("createTransfer: invalid transfer request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "c2653079-593d-4fd0-93cf-27099dbe5151",
+		})
+	}
+
+	createdTransfer, err := t.Usecase.Create(ctx, requestedTransfer)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusCreated, createdTransfer)
+}
+
+func (t Transfers) Ship(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	transferID := e.Param(transferIDParam)
+
+	transfer, err := t.Usecase.Ship(ctx, transferID)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, transfer)
+}
+
+func (t Transfers) Receive(e echo.Context) error {
+	ctx := e.Request().Context()
+
+	transferID := e.Param(transferIDParam)
+
+	var requestedReceipt models.StockTransferReceiptRequest
+	if err := e.Bind(&requestedReceipt); err != nil {
+		return parseErrorResponse(e, models.CustomError{
+			Err:      fmt.Errorf("receiveTransfer: invalid transfer receipt request body :%v", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "caad8dd7-83a3-4b71-9d90-4c9c4024d6ef",
+		})
+	}
+
+	transfer, err := t.Usecase.Receive(ctx, transferID, requestedReceipt)
+	if err != nil {
+		return parseErrorResponse(e, err)
+	}
+
+	return e.JSON(http.StatusOK, transfer)
+}
@@ -2,31 +2,81 @@ package transport
 
 import (
 	"errors"
+	"log/slog"
 	"net/http"
+	"net/netip"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
 	"github.com/labstack/echo"
 )
 
 const (
-	genericErrResponse = `{"error":"something went wrong","code":"959a1908-62f0-4cad-afc0-d9b4300085db"}`
+	genericErrCode     = "959a1908-62f0-4cad-afc0-d9b4300085db"
+	genericErrResponse = `{"error":"something went wrong","code":"` + genericErrCode + `"}`
 )
 
+// RouterOptions turns on the optional features of the API.
+type RouterOptions struct {
+	// Idempotency replays the responses of the requests sent with an Idempotency-Key.
+	Idempotency bool
+	// TrustedProxies are the only peers whose X-Forwarded-For and X-Real-IP are honored.
+	TrustedProxies []netip.Prefix
+}
+
 // NewRouter returns a new echo.Echo struct
-func NewRouter(promotionsT Promotions, medicinesT Medicines, billingsT Billings) *echo.Echo {
+func NewRouter(
+	logger *slog.Logger,
+	options RouterOptions,
+	promotionsT Promotions,
+	medicinesT Medicines,
+	billingsT Billings,
+	suppliersT Suppliers,
+	purchaseOrdersT PurchaseOrders,
+	reordersT Reorders,
+	salesReportsT SalesReports,
+	branchesT Branches,
+	transfersT Transfers,
+	inventoryCountsT InventoryCounts,
+	authT Auth,
+	auditT Audit,
+	idempotencyT Idempotency,
+	healthT Health,
+) *echo.Echo {
 
 	e := echo.New()
-	baseURL := e.Group("/aveonline/pharmacy")
+	e.Use(RequestInfoMiddleware(options.TrustedProxies))
+	e.Use(TracingMiddleware)
+	e.Use(LoggingMiddleware(logger))
+	e.Use(MetricsMiddleware)
+	e.GET(livenessRoute, healthT.Liveness)
+	e.GET(readinessRoute, healthT.Readiness)
+
+	auth := e.Group("/aveonline/auth")
+	auth.POST("/login", authT.Login)
+	auth.POST("/refresh", authT.Refresh)
+	auth.POST("/logout", authT.Logout, authT.Middleware)
+
+	baseURL := e.Group("/aveonline/pharmacy", authT.Middleware, BranchMiddleware)
+	if options.Idempotency {
+		baseURL.Use(idempotencyT.Middleware)
+	}
+
+	users := baseURL.Group("/user")
+	users.POST("", authT.CreateUser)
 
 	promotions := baseURL.Group("/promotion")
 	promotions.GET("", promotionsT.Get)
 	promotions.GET("/:promoID", promotionsT.GetByID)
 	promotions.POST("", promotionsT.Create)
+	promotions.PUT("/:promoID", promotionsT.Update)
 
 	medicines := baseURL.Group("/medicine")
 	medicines.GET("", medicinesT.Get)
+	medicines.GET("/search", medicinesT.Search)
 	medicines.GET("/:medicineID", medicinesT.GetByID)
 	medicines.POST("", medicinesT.Create)
+	medicines.PUT("/:medicineID/price", medicinesT.UpdatePrice)
+	medicines.POST("/:medicineID/presentation", medicinesT.CreatePresentation)
 
 	billings := baseURL.Group("/billing")
 	billings.GET("", billingsT.Get)
@@ -36,14 +86,63 @@ func NewRouter(promotionsT Promotions, medicinesT Medicines, billingsT Billings)
 	simulator := baseURL.Group("/simulator")
 	simulator.GET("/purchase", billingsT.Simulator)
 
+	suppliers := baseURL.Group("/supplier")
+	suppliers.GET("", suppliersT.Get)
+	suppliers.GET("/:supplierID", suppliersT.GetByID)
+	suppliers.POST("", suppliersT.Create)
+
+	purchaseOrders := baseURL.Group("/purchase-order")
+	purchaseOrders.GET("", purchaseOrdersT.Get)
+	purchaseOrders.GET("/:purchaseOrderID", purchaseOrdersT.GetByID)
+	purchaseOrders.POST("", purchaseOrdersT.Create)
+	purchaseOrders.POST("/:purchaseOrderID/send", purchaseOrdersT.Send)
+	purchaseOrders.POST("/:purchaseOrderID/receipt", purchaseOrdersT.Receive)
+
+	branches := baseURL.Group("/branch")
+	branches.GET("", branchesT.Get)
+	branches.GET("/:branchID", branchesT.GetByID)
+	branches.POST("", branchesT.Create)
+
+	transfers := baseURL.Group("/transfer")
+	transfers.GET("", transfersT.Get)
+	transfers.GET("/in-transit", transfersT.GetInTransit)
+	transfers.GET("/:transferID", transfersT.GetByID)
+	transfers.POST("", transfersT.Create)
+	transfers.POST("/:transferID/ship", transfersT.Ship)
+	transfers.POST("/:transferID/receive", transfersT.Receive)
+
+	inventoryCounts := baseURL.Group("/inventory-count")
+	inventoryCounts.GET("", inventoryCountsT.Get)
+	inventoryCounts.GET("/:countID", inventoryCountsT.GetByID)
+	inventoryCounts.GET("/:countID/variance", inventoryCountsT.GetVariance)
+	inventoryCounts.POST("", inventoryCountsT.Create)
+	inventoryCounts.POST("/:countID/lines", inventoryCountsT.Record)
+	inventoryCounts.POST("/:countID/approve", inventoryCountsT.Approve)
+	inventoryCounts.POST("/:countID/cancel", inventoryCountsT.Cancel)
+
+	audit := baseURL.Group("/audit")
+	audit.GET("", auditT.Get)
+	audit.GET("/verify", auditT.Verify)
+
+	reports := baseURL.Group("/reports")
+	reports.GET("/reorder", reordersT.Report)
+	reports.GET("/reorder/draft", reordersT.GetLatestDraft)
+	reports.POST("/reorder/draft", reordersT.GenerateDraft)
+	reports.GET("/sales/daily", salesReportsT.Daily)
+	reports.GET("/sales/medicines", salesReportsT.Medicines)
+
 	return e
 }
 
 func parseErrorResponse(e echo.Context, err error) error {
 	var ce models.CustomError
 	if errors.As(err, &ce) {
+		recordErrorInSpan(e, err, ce.Code)
+		logErrorResponse(e, err, ce.HTTPCode, ce.Code)
 		return e.JSON(ce.HTTPCode, ce.ToResponseError())
 	}
+	recordErrorInSpan(e, err, genericErrCode)
+	logErrorResponse(e, err, http.StatusInternalServerError, genericErrCode)
 
 	return e.JSON(http.StatusInternalServerError, genericErrResponse)
 }
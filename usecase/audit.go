@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type AuditStore interface {
+	GetEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+	GetChain(ctx context.Context) ([]models.AuditEntry, error)
+}
+
+type Audit struct {
+	Store    AuditStore
+	Calendar models.BusinessCalendar
+}
+
+func NewAudit(as AuditStore, calendar models.BusinessCalendar) Audit {
+	return Audit{
+		Store:    as,
+		Calendar: calendar,
+	}
+}
+
+// Get returns the audit entries of the entity (and id) between the dates, every
+// parameter is optional.
+func (a Audit) Get(ctx context.Context, entity, entityIDParam, from, to string) ([]models.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "Audit.Get")
+	defer span.End()
+
+	filter := models.AuditFilter{Entity: entity}
+
+	var err error
+	if entityIDParam != "" {
+		filter.EntityID, err = strconv.ParseInt(entityIDParam, 10, 64)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("invalid entity id received: %w", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "36ddadbd-f1f3-4a38-8269-0c7dc74e03d3",
+			}
+		}
+	}
+	if from != "" {
+		filter.From, err = a.Calendar.ParseStart(from)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("invalid from date received: %w", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "253a9d7d-3a70-491e-9540-a1b0e0f878dd",
+			}
+		}
+	}
+	if to != "" {
+		filter.To, err = a.Calendar.ParseEnd(to)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("invalid to date received: %w", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "d965afc5-b88b-4761-9b4f-88e8607e70d6",
+			}
+		}
+	}
+
+	entries, err := a.Store.GetEntries(ctx, filter)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting audit entries from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "ad9aadb2-e09c-48a5-82b6-86b54c5ecbc0",
+		}
+	}
+
+	return entries, nil
+}
+
+// Verify walks the whole chain recomputing the hashes, any altered, inserted or removed
+// entry makes it invalid.
+func (a Audit) Verify(ctx context.Context) (*models.AuditVerification, error) {
+	ctx, span := tracer.Start(ctx, "Audit.Verify")
+	defer span.End()
+
+	entries, err := a.Store.GetChain(ctx)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting audit chain from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "1316f3d4-55b3-4665-ad27-c231e17065c3",
+		}
+	}
+
+	verification := models.AuditVerification{Valid: true}
+	prevHash := ""
+	for _, entry := range entries {
+		verification.Checked++
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			verification.Valid = false
+			verification.BrokenAtID = entry.ID
+			break
+		}
+		prevHash = entry.Hash
+	}
+
+	return &verification, nil
+}
@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/logging"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+	bearerTokenType  = "Bearer"
+)
+
+// dummyPasswordHash is compared against the password when the username does not exist,
+// so the login of an unknown user takes as long as a wrong password and the usernames
+// can not be told from the response time. It has the cost of the stored hashes.
+const dummyPasswordHash = "$2a$10$h4jU95qHxe0mAxgKIgnCPuJx7.cmnyKPCxcrDZcGi.MAeAdcD0S.y"
+
+type UserStore interface {
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByID(ctx context.Context, userID int64) (*models.User, error)
+	CountUsers(ctx context.Context) (int64, error)
+	CreateUser(ctx context.Context, username, role, passwordHash string, branchID int64) (*models.User, error)
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+}
+
+type Auth struct {
+	Store       UserStore
+	BranchStore BranchStore
+	Settings    models.AuthSettings
+}
+
+// tokenClaims are the claims of the issued tokens, the subject is the user ID and
+// TokenType tells access tokens from refresh tokens. BranchID is the branch the user is
+// bound to, if any. The role and the branch are read again from the database on every
+// refresh.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	BranchID  int64  `json:"branch,omitempty"`
+	TokenType string `json:"typ"`
+}
+
+func NewAuth(us UserStore, bs BranchStore, settings models.AuthSettings) Auth {
+	return Auth{
+		Store:       us,
+		BranchStore: bs,
+		Settings:    settings,
+	}
+}
+
+// Login checks the credentials of the user and issues a new pair of tokens.
+func (a Auth) Login(ctx context.Context, loginRequest models.LoginRequest) (*models.TokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "Auth.Login")
+	defer span.End()
+
+	user, err := a.Store.GetUserByUsername(ctx, loginRequest.Username)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("login: getting user from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "5f2e8b26-14b0-4ce1-aa88-5e0b6e19149d",
+		}
+	}
+	passwordHash := dummyPasswordHash
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(loginRequest.Password)) != nil || user == nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("login: invalid username or password"),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "bfbb8790-c110-48b5-a8d2-32d04fc0ae7a",
+		}
+	}
+
+	return a.issueTokens(*user)
+}
+
+// Refresh issues a new pair of tokens for a valid refresh token, which is revoked so it
+// can be used only once.
+func (a Auth) Refresh(ctx context.Context, refreshRequest models.RefreshTokenRequest) (*models.TokenResponse, error) {
+	ctx, span := tracer.Start(ctx, "Auth.Refresh")
+	defer span.End()
+
+	claims, err := a.verify(ctx, refreshRequest.RefreshToken, refreshTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.getClaimsUser(ctx, *claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.Store.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		// a concurrent refresh revoked the token after it was verified
+		if errors.Is(err, models.ErrTokenRevoked) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("refresh: %w", err),
+				HTTPCode: http.StatusUnauthorized,
+				Code:     "a852d7d7-8f2c-4655-9692-72b53d1ae5bc",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("refresh: revoking refresh token: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "9024cd36-63c0-47d9-8f9b-704209b448cd",
+		}
+	}
+
+	return a.issueTokens(*user)
+}
+
+// Logout revokes the access token of the caller and, when given, its refresh token.
+func (a Auth) Logout(ctx context.Context, refreshRequest models.RefreshTokenRequest) error {
+	ctx, span := tracer.Start(ctx, "Auth.Logout")
+	defer span.End()
+
+	user, ok := models.UserFromContext(ctx)
+	if !ok {
+		return models.CustomError{
+			Err:      fmt.Errorf("logout: the request is not authenticated"),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "cd8a04a1-494c-4b19-a375-f3701182f913",
+		}
+	}
+
+	if refreshRequest.RefreshToken != "" {
+		claims, err := a.verify(ctx, refreshRequest.RefreshToken, refreshTokenType)
+		if err != nil {
+			return err
+		}
+		if claims.Subject != strconv.FormatInt(user.ID, 10) {
+			return models.CustomError{
+				Err:      fmt.Errorf("logout: the refresh token belongs to another user"),
+				HTTPCode: http.StatusForbidden,
+				Code:     "27d5a5c8-a9f6-4548-9afe-d20019702b78",
+			}
+		}
+		err = a.Store.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time)
+		if err != nil && !errors.Is(err, models.ErrTokenRevoked) {
+			return models.CustomError{
+				Err:      fmt.Errorf("logout: revoking refresh token: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "38d8764d-5c8b-4f06-933a-d8ac2b163c2c",
+			}
+		}
+	}
+
+	err := a.Store.RevokeToken(ctx, user.TokenID, user.ExpiresAt)
+	if err != nil && !errors.Is(err, models.ErrTokenRevoked) {
+		return models.CustomError{
+			Err:      fmt.Errorf("logout: revoking access token: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "ce948c52-ef51-449a-a0cf-a20a31f1db17",
+		}
+	}
+
+	return nil
+}
+
+// Authenticate verifies an access token and returns the caller it identifies.
+func (a Auth) Authenticate(ctx context.Context, accessToken string) (*models.AuthenticatedUser, error) {
+	ctx, span := tracer.Start(ctx, "Auth.Authenticate")
+	defer span.End()
+
+	claims, err := a.verify(ctx, accessToken, accessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("authenticate: invalid token subject: %w", err),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "11d5509b-3d56-4f79-bfa0-b69277381375",
+		}
+	}
+
+	return &models.AuthenticatedUser{
+		ID:        userID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		BranchID:  claims.BranchID,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (a Auth) CreateUser(ctx context.Context, userRequest models.UserCreationRequest) (*models.UserCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "Auth.CreateUser")
+	defer span.End()
+
+	if err := userRequest.ValidateUserRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createUser: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "d734b8f9-c818-4633-b05e-664bad539eff",
+		}
+	}
+
+	_, err := a.Store.GetUserByUsername(ctx, userRequest.Username)
+	if err == nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createUser: username [%s] already exists", userRequest.Username),
+			HTTPCode: http.StatusConflict,
+			Code:     "b28b5043-d06c-4bb0-a940-8e7fcc815ed1",
+		}
+	}
+	if !errors.Is(err, models.ErrNotFound) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createUser: checking username in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "14f7a2e2-8de3-4cbc-b05a-047753d1be99",
+		}
+	}
+
+	if userRequest.BranchID > 0 {
+		if _, err := a.BranchStore.GetBranchByID(ctx, userRequest.BranchID); err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return nil, models.CustomError{
+					Err:      fmt.Errorf("createUser: branch [%d] not found in database: %w", userRequest.BranchID, err),
+					HTTPCode: http.StatusNotFound,
+					Code:     "0d1e5f4c-62a8-4b7a-9d35-8c2f1e7a4b90",
+				}
+			}
+
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createUser: checking branch in the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "a3c47e18-5b9d-4f26-8e0a-71d6b2f9c534",
+			}
+		}
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(userRequest.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createUser: hashing password: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "2880d40a-2656-4878-97df-b997f22e28f3",
+		}
+	}
+
+	createdUser, err := a.Store.CreateUser(ctx, userRequest.Username, userRequest.Role, string(passwordHash), userRequest.BranchID)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating user with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "5d5a76d9-0f4b-44ed-ad4d-0d0c26aed528",
+		}
+	}
+
+	return &models.UserCreationResponse{
+		ID: createdUser.ID,
+	}, nil
+}
+
+// EnsureInitialUser creates the given user when there are no users yet, so the API
+// can be accessed after the first deploy.
+func (a Auth) EnsureInitialUser(ctx context.Context, userRequest models.UserCreationRequest) error {
+	ctx, span := tracer.Start(ctx, "Auth.EnsureInitialUser")
+	defer span.End()
+
+	count, err := a.Store.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := a.CreateUser(ctx, userRequest); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "initial user created", "username", userRequest.Username, "role", userRequest.Role)
+
+	return nil
+}
+
+// PurgeRevokedTokens shrinks the revocation list removing the tokens that expired already.
+func (a Auth) PurgeRevokedTokens(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Auth.PurgeRevokedTokens")
+	defer span.End()
+
+	purged, err := a.Store.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).DebugContext(ctx, "expired revoked tokens purged", "count", purged)
+
+	return nil
+}
+
+func (a Auth) issueTokens(user models.User) (*models.TokenResponse, error) {
+	accessToken, err := a.sign(user, accessTokenType, a.Settings.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := a.sign(user, refreshTokenType, a.Settings.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    bearerTokenType,
+		ExpiresIn:    int64(a.Settings.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (a Auth) sign(user models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := tokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Username:  user.Username,
+		Role:      user.Role,
+		BranchID:  user.BranchID,
+		TokenType: tokenType,
+	}
+
+	signedToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.Settings.Secret)
+	if err != nil {
+		return "", models.CustomError{
+			Err:      fmt.Errorf("signing %s token: %w", tokenType, err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "87617275-5071-4288-bd48-328db7224ba9",
+		}
+	}
+
+	return signedToken, nil
+}
+
+// verify checks the signature, expiration, type and revocation of the token.
+func (a Auth) verify(ctx context.Context, token, tokenType string) (*tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return a.Settings.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.TokenType != tokenType || claims.ID == "" {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid %s token: %v", tokenType, err),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "9999da22-a961-46ed-9bd7-874a71cc92ba",
+		}
+	}
+
+	revoked, err := a.Store.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("checking %s token revocation: %w", tokenType, err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "967202fb-7156-4f2b-b9ec-2c4633f08faf",
+		}
+	}
+	if revoked {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("the %s token was revoked", tokenType),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "a852d7d7-8f2c-4655-9692-72b53d1ae5bc",
+		}
+	}
+
+	return &claims, nil
+}
+
+// getClaimsUser returns the user the token was issued to, it fails when it does not exist anymore.
+func (a Auth) getClaimsUser(ctx context.Context, claims tokenClaims) (*models.User, error) {
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid token subject: %w", err),
+			HTTPCode: http.StatusUnauthorized,
+			Code:     "3d555888-54b9-4be1-8444-c2b099332e49",
+		}
+	}
+
+	user, err := a.Store.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("the user of the token does not exist: %w", err),
+				HTTPCode: http.StatusUnauthorized,
+				Code:     "14fefd81-34ea-42b5-a3dc-128a65a6dd84",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting user from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "72d7a4a2-0f94-4783-b0a0-42106a87ca86",
+		}
+	}
+
+	return user, nil
+}
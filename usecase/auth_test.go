@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeUserStore struct {
+	UserStore
+	user models.User
+
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func (s *fakeUserStore) GetUserByID(ctx context.Context, userID int64) (*models.User, error) {
+	if userID != s.user.ID {
+		return nil, models.ErrNotFound
+	}
+	user := s.user
+
+	return &user, nil
+}
+
+func (s *fakeUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	if username != s.user.Username {
+		return nil, models.ErrNotFound
+	}
+	user := s.user
+
+	return &user, nil
+}
+
+func (s *fakeUserStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revoked[tokenID]; ok {
+		return fmt.Errorf("token [%s]: %w", tokenID, models.ErrTokenRevoked)
+	}
+	s.revoked[tokenID] = expiresAt
+
+	return nil
+}
+
+func (s *fakeUserStore) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.revoked[tokenID]
+
+	return ok, nil
+}
+
+func TestAuthConcurrentRefresh(t *testing.T) {
+	store := &fakeUserStore{
+		user:    models.User{ID: 3, Username: "cajero", Role: models.RoleCashier},
+		revoked: map[string]time.Time{},
+	}
+	auth := NewAuth(store, nil, models.AuthSettings{
+		Secret:          []byte("secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	tokens, err := auth.issueTokens(store.user)
+	if err != nil {
+		t.Fatalf("issuing tokens: %v", err)
+	}
+
+	const refreshes = 8
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		errs      = make(chan error, refreshes)
+		refreshed = make(chan *models.TokenResponse, refreshes)
+	)
+	for range refreshes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			response, err := auth.Refresh(context.Background(), models.RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+			if err != nil {
+				errs <- err
+				return
+			}
+			refreshed <- response
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	close(refreshed)
+
+	if len(refreshed) != 1 {
+		t.Fatalf("got %d refreshes, want the refresh token to be used only once", len(refreshed))
+	}
+	for err := range errs {
+		var customErr models.CustomError
+		if !errors.As(err, &customErr) || customErr.HTTPCode != http.StatusUnauthorized {
+			t.Errorf("got error %v, want a %d", err, http.StatusUnauthorized)
+		}
+	}
+
+	_, err = auth.Authenticate(context.Background(), (<-refreshed).AccessToken)
+	if err != nil {
+		t.Errorf("authenticating with the refreshed access token: %v", err)
+	}
+}
+
+func TestAuthBranchClaim(t *testing.T) {
+	for _, branchID := range []int64{0, testBranchID} {
+		store := &fakeUserStore{
+			user:    models.User{ID: 3, Username: "cajero", Role: models.RoleCashier, BranchID: branchID},
+			revoked: map[string]time.Time{},
+		}
+		auth := NewAuth(store, nil, models.AuthSettings{
+			Secret:          []byte("secret"),
+			AccessTokenTTL:  time.Minute,
+			RefreshTokenTTL: time.Hour,
+		})
+		tokens, err := auth.issueTokens(store.user)
+		if err != nil {
+			t.Fatalf("issuing tokens: %v", err)
+		}
+
+		user, err := auth.Authenticate(context.Background(), tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("authenticating: %v", err)
+		}
+		if user.BranchID != branchID {
+			t.Errorf("got branch %d in the token, want %d", user.BranchID, branchID)
+		}
+	}
+}
+
+func TestAuthLogin(t *testing.T) {
+	// the unknown usernames are checked against the dummy hash, which must cost as much as
+	// the stored ones to take as long.
+	if cost, err := bcrypt.Cost([]byte(dummyPasswordHash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Fatalf("got dummy hash cost %d (%v), want %d", cost, err, bcrypt.DefaultCost)
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("Cajero12345!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hashing the password: %v", err)
+	}
+	store := &fakeUserStore{
+		user: models.User{ID: 3, Username: "cajero", Role: models.RoleCashier, PasswordHash: string(passwordHash)},
+	}
+	auth := NewAuth(store, nil, models.AuthSettings{
+		Secret:          []byte("secret"),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+
+	tests := []struct {
+		name       string
+		request    models.LoginRequest
+		wantStatus int
+	}{
+		{name: "valid credentials", request: models.LoginRequest{Username: "cajero", Password: "Cajero12345!"}},
+		{name: "wrong password", request: models.LoginRequest{Username: "cajero", Password: "Cajero12345?"}, wantStatus: http.StatusUnauthorized},
+		{name: "unknown username", request: models.LoginRequest{Username: "cajera", Password: "Cajero12345!"}, wantStatus: http.StatusUnauthorized},
+		{name: "unknown username with the dummy password", request: models.LoginRequest{Username: "cajera", Password: "not the password of any user"}, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := auth.Login(context.Background(), tt.request)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus == 0 && (tokens == nil || tokens.AccessToken == "") {
+				t.Errorf("got tokens %+v, want a pair of tokens", tokens)
+			}
+		})
+	}
+}
@@ -10,17 +10,11 @@ import (
 	"time"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/store"
-)
-
-const (
-	layoutDate     = "2006-01-02T15:04:05Z"
-	layoutDateOnly = "2006-01-02"
 )
 
 type BillingStore interface {
 	CreateBilling(ctx context.Context, billing models.BillingDetail) (*models.BillingDetail, error)
-	GetBillingsByDates(ctx context.Context, startDate, endDate time.Time) ([]models.Billing, error)
+	GetBillingsByDates(ctx context.Context, startDate, endDate time.Time, branchID int64) ([]models.Billing, error)
 	GetBillingByID(ctx context.Context, billingID int64) (*models.BillingDetail, error)
 }
 
@@ -28,18 +22,29 @@ type Billings struct {
 	Store          BillingStore
 	PromotionStore PromotionStore
 	MedicineStore  MedicineStore
+	BranchStore    BranchStore
+	Calendar       models.BusinessCalendar
 }
 
-func NewBillings(bs store.Billing, ps store.Promotions, ms store.Medicine) Billings {
+func NewBillings(
+	bs BillingStore, ps PromotionStore, ms MedicineStore, brs BranchStore, calendar models.BusinessCalendar,
+) Billings {
 	return Billings{
 		Store:          bs,
 		PromotionStore: ps,
 		MedicineStore:  ms,
+		BranchStore:    brs,
+		Calendar:       calendar,
 	}
 }
 
+// Get returns the billings between the dates, a plain end date includes its whole
+// business day.
 func (b Billings) Get(ctx context.Context, startDate, endDate string) ([]models.Billing, error) {
-	startDateTime, err := time.Parse(layoutDate, startDate)
+	ctx, span := tracer.Start(ctx, "Billings.Get")
+	defer span.End()
+
+	startDateTime, err := b.Calendar.ParseStart(startDate)
 	if err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("invalid start date received received: %w", err),
@@ -47,7 +52,7 @@ func (b Billings) Get(ctx context.Context, startDate, endDate string) ([]models.
 			Code:     "daa86901-58f7-4cb6-83be-d63ea06c0c65",
 		}
 	}
-	endDateTime, err := time.Parse(layoutDate, endDate)
+	endDateTime, err := b.Calendar.ParseEnd(endDate)
 	if err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("invalid end date received received: %w", err),
@@ -56,7 +61,7 @@ func (b Billings) Get(ctx context.Context, startDate, endDate string) ([]models.
 		}
 	}
 
-	billings, err := b.Store.GetBillingsByDates(ctx, startDateTime, endDateTime)
+	billings, err := b.Store.GetBillingsByDates(ctx, startDateTime, endDateTime, models.BranchFromContext(ctx))
 	if err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("getting billing from db: %w", err),
@@ -69,6 +74,9 @@ func (b Billings) Get(ctx context.Context, startDate, endDate string) ([]models.
 }
 
 func (b Billings) GetByID(ctx context.Context, billingIDParam string) (*models.BillingDetail, error) {
+	ctx, span := tracer.Start(ctx, "Billings.GetByID")
+	defer span.End()
+
 	billingID, err := strconv.ParseInt(billingIDParam, 10, 64)
 	if err != nil {
 		return nil, models.CustomError{
@@ -99,6 +107,9 @@ func (b Billings) GetByID(ctx context.Context, billingIDParam string) (*models.B
 }
 
 func (b Billings) Create(ctx context.Context, billingRequest models.BillingCreationRequest) (*models.BillingDetail, error) {
+	ctx, span := tracer.Start(ctx, "Billings.Create")
+	defer span.End()
+
 	if err := billingRequest.ValidateBillingRequest(); err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("createBilling: request data is invalid: %w", err),
@@ -107,26 +118,39 @@ func (b Billings) Create(ctx context.Context, billingRequest models.BillingCreat
 		}
 	}
 
-	// remove repeated medicines and created a map with quantity
-	uniqueMedicinesIDs, quantityMedicines := uniqueMEdicinesIDsAndSetQuantities(billingRequest.Medicines)
+	branchID, err := requireBranch(ctx, b.BranchStore, "createBilling")
+	if err != nil {
+		return nil, err
+	}
 
-	promotion, medicines, err := b.getEntities(ctx, billingRequest)
+	itemsRequested := billingRequest.ItemsRequested()
+
+	promotion, items, err := b.getEntities(ctx, billingRequest.PromotionID, itemsRequested)
 	if err != nil {
 		return nil, err
 	}
-	if len(medicines) != len(uniqueMedicinesIDs) {
+	if !promotion.AppliesToBranch(branchID) {
 		return nil, models.CustomError{
-			Err:      fmt.Errorf("createBilling: not all medicines could be found, the transaction cannot be processed"),
-			HTTPCode: http.StatusInternalServerError,
-			Code:     "b78e227b-dbd2-4efc-a78e-a5e229488b3a",
+			Err:      fmt.Errorf("createBilling: promotion [%d] does not apply to branch [%d]", promotion.ID, branchID),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "1702b0a5-40a1-4a17-89b6-e3d51b12ff69",
 		}
 	}
 
-	billing := b.buildBilling(ctx, promotion, medicines, quantityMedicines)
-	billing.CreatedAt = billingRequest.CreatedDate
+	billing := b.buildBilling(ctx, promotion, items)
+	billing.BranchID = branchID
+	billing.CreatedAt = b.Calendar.In(billingRequest.CreatedDate)
 
 	createdBilling, err := b.Store.CreateBilling(ctx, billing)
 	if err != nil {
+		if errors.Is(err, models.ErrInsufficientStock) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createBilling: %w", err),
+				HTTPCode: http.StatusConflict,
+				Code:     "6c0f3e2a-8d47-4b1e-9a35-f2d8c71b5e94",
+			}
+		}
+
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("creating billing within the database: %w", err),
 			HTTPCode: http.StatusInternalServerError,
@@ -134,15 +158,19 @@ func (b Billings) Create(ctx context.Context, billingRequest models.BillingCreat
 		}
 	}
 
+	recordBillingCreated(createdBilling)
+
 	return createdBilling, nil
 }
 
-func (b Billings) getEntities(ctx context.Context, billingReq models.BillingCreationRequest) (models.Promotion, []models.Medicine, error) {
+func (b Billings) getEntities(
+	ctx context.Context, promotionID int64, itemsRequested []models.BillingItemRequest) (models.Promotion, []models.BillingItem, error,
+) {
 	var promotion models.Promotion
 	var err error
 	// if promotion exists in the request, verify that it exists in the database
-	if billingReq.PromotionID > 0 {
-		promotion, err = b.PromotionStore.GetPromoByID(ctx, billingReq.PromotionID)
+	if promotionID > 0 {
+		promotion, err = b.PromotionStore.GetPromoByID(ctx, promotionID)
 		if err != nil {
 			if errors.Is(err, models.ErrNotFound) {
 				return promotion, nil, models.CustomError{
@@ -160,7 +188,17 @@ func (b Billings) getEntities(ctx context.Context, billingReq models.BillingCrea
 		}
 	}
 
-	medicines, err := b.MedicineStore.GetMedicinesByIDs(ctx, billingReq.Medicines)
+	medicinesIDs := make([]int64, 0, len(itemsRequested))
+	presentationsIDs := make([]int64, 0)
+	for _, item := range itemsRequested {
+		medicinesIDs = append(medicinesIDs, item.MedicineID)
+		if item.PresentationID > 0 {
+			presentationsIDs = append(presentationsIDs, item.PresentationID)
+		}
+	}
+	uniqueMedicinesIDs, _ := uniqueMEdicinesIDsAndSetQuantities(medicinesIDs)
+
+	medicines, err := b.MedicineStore.GetMedicinesByIDs(ctx, uniqueMedicinesIDs)
 	if err != nil {
 		return promotion, nil, models.CustomError{
 			Err:      fmt.Errorf("createBilling: getting medicines from the database: %w", err),
@@ -168,16 +206,96 @@ func (b Billings) getEntities(ctx context.Context, billingReq models.BillingCrea
 			Code:     "6c18183a-b5f5-43a1-95d6-9ae6f80e57e7",
 		}
 	}
+	if len(medicines) != len(uniqueMedicinesIDs) {
+		return promotion, nil, models.CustomError{
+			Err:      fmt.Errorf("createBilling: not all medicines could be found, the transaction cannot be processed"),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "b78e227b-dbd2-4efc-a78e-a5e229488b3a",
+		}
+	}
+
+	presentations := make([]models.Presentation, 0)
+	if len(presentationsIDs) > 0 {
+		presentations, err = b.MedicineStore.GetPresentationsByIDs(ctx, presentationsIDs)
+		if err != nil {
+			return promotion, nil, models.CustomError{
+				Err:      fmt.Errorf("createBilling: getting presentations from the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "3bc7e0a1-4c1d-4bd6-9a55-c3c1f3a4d1a8",
+			}
+		}
+	}
 
-	return promotion, medicines, nil
+	items, err := buildItems(itemsRequested, medicines, presentations)
+	if err != nil {
+		return promotion, nil, models.CustomError{
+			Err:      fmt.Errorf("createBilling: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "e6f0a7a4-51a2-4d0e-8f62-0e54a3fd4f0b",
+		}
+	}
+
+	return promotion, items, nil
 }
 
-func (b Billings) buildBilling(ctx context.Context, promotion models.Promotion, medicines []models.Medicine, quantities map[int64]int) models.BillingDetail {
+// buildItems prices every requested line with its presentation (or the medicine base
+// price when none is given) and converts the quantity to base units.
+func buildItems(
+	itemsRequested []models.BillingItemRequest, medicines []models.Medicine, presentations []models.Presentation,
+) ([]models.BillingItem, error) {
+	medicinesByID := make(map[int64]models.Medicine, len(medicines))
+	for _, medicine := range medicines {
+		medicinesByID[medicine.ID] = medicine
+	}
+	presentationsByID := make(map[int64]models.Presentation, len(presentations))
+	for _, presentation := range presentations {
+		presentationsByID[presentation.ID] = presentation
+	}
+
+	items := make([]models.BillingItem, 0, len(itemsRequested))
+	for _, itemRequested := range itemsRequested {
+		medicine, ok := medicinesByID[itemRequested.MedicineID]
+		if !ok {
+			return nil, fmt.Errorf("medicine [%d] not found", itemRequested.MedicineID)
+		}
+
+		item := models.BillingItem{
+			MedicineID:   medicine.ID,
+			MedicineName: medicine.Name,
+			Quantity:     itemRequested.Quantity,
+			BaseQuantity: itemRequested.Quantity,
+			UnitPrice:    medicine.Price,
+		}
+		if itemRequested.PresentationID > 0 {
+			presentation, ok := presentationsByID[itemRequested.PresentationID]
+			if !ok || presentation.MedicineID != medicine.ID {
+				return nil, fmt.Errorf("presentation [%d] not found for medicine [%d]", itemRequested.PresentationID, medicine.ID)
+			}
+			item.PresentationID = presentation.ID
+			item.PresentationName = presentation.Name
+			item.BaseQuantity = itemRequested.Quantity * presentation.ConversionFactor
+			item.UnitPrice = presentation.Price
+		}
+		item.Subtotal = item.UnitPrice * float64(item.Quantity)
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (b Billings) buildBilling(ctx context.Context, promotion models.Promotion, items []models.BillingItem) models.BillingDetail {
 	billing := models.BillingDetail{}
 
 	total := 0.0
-	for _, medicine := range medicines {
-		total += medicine.Price * float64(quantities[medicine.ID])
+	medicines := make([]models.Medicine, 0, len(items))
+	for _, item := range items {
+		total += item.Subtotal
+		medicines = append(medicines, models.Medicine{
+			ID:    item.MedicineID,
+			Name:  item.MedicineName,
+			Price: item.UnitPrice,
+		})
 	}
 	if promotion.ID > 0 {
 		total = total - (total * (promotion.Percentage / 100))
@@ -185,13 +303,30 @@ func (b Billings) buildBilling(ctx context.Context, promotion models.Promotion,
 
 	billing.Promotion = promotion
 	billing.Medicines = medicines
+	billing.Items = items
 	billing.Total = total
 
 	return billing
 }
 
 func (b Billings) Simulator(ctx context.Context, date, medicinesIDsParam string) (*models.SimulatorResponse, error) {
-	dateTime, err := time.Parse(layoutDateOnly, date)
+	ctx, span := tracer.Start(ctx, "Billings.Simulator")
+	defer span.End()
+
+	response, err := b.simulate(ctx, date, medicinesIDsParam)
+	recordSimulatorCall(err)
+
+	return response, err
+}
+
+func (b Billings) simulate(ctx context.Context, date, medicinesIDsParam string) (*models.SimulatorResponse, error) {
+	// a plain date simulates the purchase during that business day, with the promotion
+	// active at any time of it, and a time at that instant
+	startDate, err := b.Calendar.ParseStart(date)
+	var endDate time.Time
+	if err == nil {
+		endDate, err = b.Calendar.ParseEnd(date)
+	}
 	if err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("invalid date received: %w", err),
@@ -199,9 +334,7 @@ func (b Billings) Simulator(ctx context.Context, date, medicinesIDsParam string)
 			Code:     "0bd363ff-f16a-4566-9a60-edd8bd40d860",
 		}
 	}
-	endDay := dateTime.Add(24 * time.Hour)
-
-	if endDay.Before(time.Now().UTC()) {
+	if b.Calendar.EndOfDay(startDate).Before(b.Calendar.Now()) {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("invalid date received: date must be after current date"),
 			HTTPCode: http.StatusBadRequest,
@@ -241,7 +374,7 @@ func (b Billings) Simulator(ctx context.Context, date, medicinesIDsParam string)
 		}
 	}
 
-	promotion, err := b.PromotionStore.GetByDate(ctx, dateTime, endDay)
+	promotion, err := b.PromotionStore.GetActiveBetween(ctx, startDate, endDate, models.BranchFromContext(ctx))
 	if err != nil && !errors.Is(err, models.ErrNotFound) {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("simulator: getting promotion for the specific date: %w", err),
@@ -249,7 +382,22 @@ func (b Billings) Simulator(ctx context.Context, date, medicinesIDsParam string)
 			Code:     "d65d410c-37a3-4825-8f2c-8795f94ab413",
 		}
 	}
-	billing := b.buildBilling(ctx, promotion, medicines, quantityMedicines)
+	itemsRequested := make([]models.BillingItemRequest, 0, len(uniqueMedicinesIDs))
+	for _, medicineID := range uniqueMedicinesIDs {
+		itemsRequested = append(itemsRequested, models.BillingItemRequest{
+			MedicineID: medicineID,
+			Quantity:   int64(quantityMedicines[medicineID]),
+		})
+	}
+	items, err := buildItems(itemsRequested, medicines, nil)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("simulator: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "5b0bd3f7-7c4b-4ac5-a3a5-52e3c27e0a4c",
+		}
+	}
+	billing := b.buildBilling(ctx, promotion, items)
 
 	return &models.SimulatorResponse{
 		Total: billing.Total,
@@ -0,0 +1,406 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	testBranchID      = int64(1)
+	testOtherBranchID = int64(2)
+)
+
+var errDatabase = errors.New("database is down")
+
+// The fakes embed the interface they fake, calling a method a test does not set up panics.
+
+type fakeBillingStore struct {
+	BillingStore
+	billings map[int64]models.BillingDetail
+	created  []models.BillingDetail
+	err      error
+}
+
+func (s *fakeBillingStore) CreateBilling(ctx context.Context, billing models.BillingDetail) (*models.BillingDetail, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	billing.ID = int64(len(s.created) + 1)
+	billing.Version = 1
+	s.created = append(s.created, billing)
+
+	return &billing, nil
+}
+
+func (s *fakeBillingStore) GetBillingByID(ctx context.Context, billingID int64) (*models.BillingDetail, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	billing, ok := s.billings[billingID]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	return &billing, nil
+}
+
+type fakePromotionStore struct {
+	PromotionStore
+	promotions map[int64]models.Promotion
+	err        error
+}
+
+func (s fakePromotionStore) GetPromoByID(ctx context.Context, promoID int64) (models.Promotion, error) {
+	if s.err != nil {
+		return models.Promotion{}, s.err
+	}
+	promotion, ok := s.promotions[promoID]
+	if !ok {
+		return models.Promotion{}, models.ErrNotFound
+	}
+
+	return promotion, nil
+}
+
+func (s fakePromotionStore) GetActiveBetween(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) (models.Promotion, error) {
+	if s.err != nil {
+		return models.Promotion{}, s.err
+	}
+	for _, promotion := range s.promotions {
+		if promotion.Overlaps(startDate, endDate) && promotion.AppliesToBranch(branchID) {
+			return promotion, nil
+		}
+	}
+
+	return models.Promotion{}, models.ErrNotFound
+}
+
+type fakeMedicineStore struct {
+	MedicineStore
+	medicines     []models.Medicine
+	presentations []models.Presentation
+	err           error
+}
+
+func (s fakeMedicineStore) GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) ([]models.Medicine, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	medicines := make([]models.Medicine, 0)
+	for _, medicine := range s.medicines {
+		for _, medicineID := range medicineIDs {
+			if medicine.ID == medicineID {
+				medicines = append(medicines, medicine)
+				break
+			}
+		}
+	}
+
+	return medicines, nil
+}
+
+func (s fakeMedicineStore) GetPresentationsByIDs(ctx context.Context, presentationIDs []int64) ([]models.Presentation, error) {
+	presentations := make([]models.Presentation, 0)
+	for _, presentation := range s.presentations {
+		for _, presentationID := range presentationIDs {
+			if presentation.ID == presentationID {
+				presentations = append(presentations, presentation)
+			}
+		}
+	}
+
+	return presentations, nil
+}
+
+type fakeBranchStore struct {
+	BranchStore
+}
+
+func (fakeBranchStore) GetBranchByID(ctx context.Context, branchID int64) (*models.Branch, error) {
+	if branchID != testBranchID && branchID != testOtherBranchID {
+		return nil, models.ErrNotFound
+	}
+
+	return &models.Branch{ID: branchID}, nil
+}
+
+func newTestBillings(billingStore *fakeBillingStore, promotionStore fakePromotionStore, medicineStore fakeMedicineStore) Billings {
+	return Billings{
+		Store:          billingStore,
+		PromotionStore: promotionStore,
+		MedicineStore:  medicineStore,
+		BranchStore:    fakeBranchStore{},
+		Calendar:       models.NewBusinessCalendar(time.UTC),
+	}
+}
+
+func testMedicines() fakeMedicineStore {
+	return fakeMedicineStore{
+		medicines: []models.Medicine{
+			{ID: 1, Name: "Acetaminofen", Price: 300},
+			{ID: 2, Name: "Loratadina", Price: 150},
+		},
+		presentations: []models.Presentation{
+			{ID: 10, MedicineID: 1, Name: "Blister x 10", ConversionFactor: 10, Price: 2800},
+		},
+	}
+}
+
+func testPromotions() fakePromotionStore {
+	start := time.Date(2030, time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	return fakePromotionStore{promotions: map[int64]models.Promotion{
+		5: {ID: 5, Percentage: 10, StartDate: start, EndtDate: start.AddDate(0, 0, 5)},
+		6: {ID: 6, Percentage: 20, StartDate: start.AddDate(0, 1, 0), EndtDate: start.AddDate(0, 1, 5), BranchIDs: []int64{testOtherBranchID}},
+		7: {ID: 7, Percentage: 50, StartDate: start.AddDate(0, 2, 0).Add(15 * time.Hour), EndtDate: start.AddDate(0, 2, 2)},
+	}}
+}
+
+// assertCustomError fails unless err is a models.CustomError with the status, or nil when
+// wantStatus is zero.
+func assertCustomError(t *testing.T, err error, wantStatus int) {
+	t.Helper()
+
+	if wantStatus == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	var customErr models.CustomError
+	if !errors.As(err, &customErr) {
+		t.Fatalf("error = %v, want a models.CustomError with status %d", err, wantStatus)
+	}
+	if customErr.HTTPCode != wantStatus {
+		t.Errorf("status = %d, want %d (%v)", customErr.HTTPCode, wantStatus, err)
+	}
+	if customErr.Code == "" {
+		t.Errorf("error %v has no code", err)
+	}
+}
+
+func TestBillingsCreate(t *testing.T) {
+	items := []models.BillingItemRequest{
+		{MedicineID: 1, Quantity: 2},
+		{MedicineID: 1, PresentationID: 10, Quantity: 2},
+	}
+
+	tests := []struct {
+		name          string
+		branchID      int64
+		request       models.BillingCreationRequest
+		medicineStore fakeMedicineStore
+		storeErr      error
+		wantStatus    int
+		wantTotal     float64
+		wantBaseUnits int64
+	}{
+		{
+			name:          "without promotion",
+			branchID:      testBranchID,
+			request:       models.BillingCreationRequest{Items: items},
+			wantTotal:     6200,
+			wantBaseUnits: 22,
+		},
+		{
+			name:      "with promotion",
+			branchID:  testBranchID,
+			request:   models.BillingCreationRequest{PromotionID: 5, Items: items},
+			wantTotal: 5580,
+		},
+		{
+			name:      "repeated medicines",
+			branchID:  testBranchID,
+			request:   models.BillingCreationRequest{Medicines: []int64{2, 2, 1}},
+			wantTotal: 600,
+		},
+		{
+			name:       "invalid request",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{PromotionID: -1, Items: items},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "without branch",
+			request:    models.BillingCreationRequest{Items: items},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown branch",
+			branchID:   99,
+			request:    models.BillingCreationRequest{Items: items},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown promotion",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{PromotionID: 99, Items: items},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "promotion of another branch",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{PromotionID: 6, Items: items},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown medicine",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{Medicines: []int64{1, 99}},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:     "presentation of another medicine",
+			branchID: testBranchID,
+			request: models.BillingCreationRequest{Items: []models.BillingItemRequest{
+				{MedicineID: 2, PresentationID: 10, Quantity: 1},
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:          "medicines not available",
+			branchID:      testBranchID,
+			request:       models.BillingCreationRequest{Items: items},
+			medicineStore: fakeMedicineStore{err: errDatabase},
+			wantStatus:    http.StatusInternalServerError,
+		},
+		{
+			name:       "billing not stored",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{Items: items},
+			storeErr:   errDatabase,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "insufficient stock",
+			branchID:   testBranchID,
+			request:    models.BillingCreationRequest{Items: items},
+			storeErr:   fmt.Errorf("createBilling: %w", models.ErrInsufficientStock),
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			medicineStore := tt.medicineStore
+			if medicineStore.err == nil {
+				medicineStore = testMedicines()
+			}
+			billingStore := &fakeBillingStore{err: tt.storeErr}
+			billings := newTestBillings(billingStore, testPromotions(), medicineStore)
+
+			ctx := models.ContextWithBranch(context.Background(), tt.branchID)
+			billing, err := billings.Create(ctx, tt.request)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus != 0 {
+				if len(billingStore.created) != 0 {
+					t.Errorf("a billing was stored after an error")
+				}
+				return
+			}
+
+			if billing.Total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", billing.Total, tt.wantTotal)
+			}
+			if len(billingStore.created) != 1 || billingStore.created[0].BranchID != tt.branchID {
+				t.Fatalf("stored billings = %+v, want one of branch %d", billingStore.created, tt.branchID)
+			}
+			if tt.wantBaseUnits > 0 {
+				baseUnits := int64(0)
+				for _, item := range billingStore.created[0].Items {
+					baseUnits += item.BaseQuantity
+				}
+				if baseUnits != tt.wantBaseUnits {
+					t.Errorf("base units = %d, want %d", baseUnits, tt.wantBaseUnits)
+				}
+			}
+		})
+	}
+}
+
+func TestBillingsGetByID(t *testing.T) {
+	stored := models.BillingDetail{ID: 3, BranchID: testBranchID, Total: 900}
+
+	tests := []struct {
+		name       string
+		billingID  string
+		storeErr   error
+		wantStatus int
+	}{
+		{name: "found", billingID: "3"},
+		{name: "invalid id", billingID: "tres", wantStatus: http.StatusBadRequest},
+		{name: "not found", billingID: "4", wantStatus: http.StatusNotFound},
+		{name: "database error", billingID: "3", storeErr: errDatabase, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			billingStore := &fakeBillingStore{billings: map[int64]models.BillingDetail{3: stored}, err: tt.storeErr}
+			billings := newTestBillings(billingStore, testPromotions(), testMedicines())
+
+			billing, err := billings.GetByID(context.Background(), tt.billingID)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus == 0 && billing.Total != stored.Total {
+				t.Errorf("billing = %+v, want %+v", billing, stored)
+			}
+		})
+	}
+}
+
+func TestBillingsSimulator(t *testing.T) {
+	tests := []struct {
+		name           string
+		branchID       int64
+		date           string
+		medicinesIDs   string
+		promotionStore fakePromotionStore
+		wantStatus     int
+		wantTotal      float64
+	}{
+		{name: "day with promotion", date: "2030-03-11", medicinesIDs: "1,1,2", wantTotal: 675},
+		{name: "day without promotion", date: "2030-03-20", medicinesIDs: "1,1,2", wantTotal: 750},
+		{name: "last instant of the promotion", date: "2030-03-15T00:00:00Z", medicinesIDs: "1,1,2", wantTotal: 675},
+		{name: "after the promotion", date: "2030-03-15T00:00:01Z", medicinesIDs: "1,1,2", wantTotal: 750},
+		{name: "promotion of the branch", branchID: testOtherBranchID, date: "2030-04-11", medicinesIDs: "2", wantTotal: 120},
+		{name: "promotion of another branch", branchID: testBranchID, date: "2030-04-11", medicinesIDs: "2", wantTotal: 150},
+		{name: "promotion starting during the day", date: "2030-05-10", medicinesIDs: "2", wantTotal: 75},
+		{name: "before a promotion starting during the day", date: "2030-05-10T14:59:59Z", medicinesIDs: "2", wantTotal: 150},
+		{name: "past day", date: "2000-01-01", medicinesIDs: "1", wantStatus: http.StatusBadRequest},
+		{name: "invalid date", date: "11/03/2030", medicinesIDs: "1", wantStatus: http.StatusBadRequest},
+		{name: "invalid medicine", date: "2030-03-11", medicinesIDs: "1,a", wantStatus: http.StatusBadRequest},
+		{name: "unknown medicine", date: "2030-03-11", medicinesIDs: "1,99", wantStatus: http.StatusInternalServerError},
+		{
+			name:           "promotions not available",
+			date:           "2030-03-11",
+			medicinesIDs:   "1",
+			promotionStore: fakePromotionStore{err: errDatabase},
+			wantStatus:     http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotionStore := tt.promotionStore
+			if promotionStore.err == nil {
+				promotionStore = testPromotions()
+			}
+			billings := newTestBillings(&fakeBillingStore{}, promotionStore, testMedicines())
+
+			ctx := models.ContextWithBranch(context.Background(), tt.branchID)
+			response, err := billings.Simulator(ctx, tt.date, tt.medicinesIDs)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus == 0 && response.Total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", response.Total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type BranchStore interface {
+	GetAll(ctx context.Context) ([]models.Branch, error)
+	GetBranchByID(ctx context.Context, branchID int64) (*models.Branch, error)
+	CreateBranch(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.Branch, error)
+}
+
+type Branches struct {
+	Store BranchStore
+}
+
+func NewBranches(bs BranchStore) Branches {
+	return Branches{
+		Store: bs,
+	}
+}
+
+func (b Branches) Get(ctx context.Context) ([]models.Branch, error) {
+	ctx, span := tracer.Start(ctx, "Branches.Get")
+	defer span.End()
+
+	branches, err := b.Store.GetAll(ctx)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting branches from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "29e4ccc5-88e7-4f57-ae2a-a92d06bc2082",
+		}
+	}
+
+	return branches, nil
+}
+
+func (b Branches) GetByID(ctx context.Context, branchIDParam string) (*models.Branch, error) {
+	ctx, span := tracer.Start(ctx, "Branches.GetByID")
+	defer span.End()
+
+	branchID, err := strconv.ParseInt(branchIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid branchID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "36ef2e8c-351e-4805-9f83-028dbc42a4b3",
+		}
+	}
+
+	branch, err := b.Store.GetBranchByID(ctx, branchID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("branch not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "e536cab3-1b3d-4bf1-bb04-1455043d9779",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting branch from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "37c65e35-43ba-4b34-9164-ef55d570ec1e",
+		}
+	}
+
+	return branch, nil
+}
+
+func (b Branches) Create(ctx context.Context, branchRequest models.BranchCreationRequest) (*models.BranchCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "Branches.Create")
+	defer span.End()
+
+	if err := branchRequest.ValidateBranchRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createBranch: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "2734a0e5-0f74-4054-867d-5b3e0b342421",
+		}
+	}
+
+	createdBranch, err := b.Store.CreateBranch(ctx, branchRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating branch with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "da2f268f-0ac6-4559-98c7-a9e36a8ddbd4",
+		}
+	}
+
+	return &models.BranchCreationResponse{
+		ID: createdBranch.ID,
+	}, nil
+}
+
+// requireBranch returns the branch of the caller, failing when the caller did not
+// identify one or it does not exist. operation prefixes the error messages.
+func requireBranch(ctx context.Context, branchStore BranchStore, operation string) (int64, error) {
+	branchID := models.BranchFromContext(ctx)
+	if branchID <= 0 {
+		return 0, models.CustomError{
+			Err:      fmt.Errorf("%s: the branch of the caller is required", operation),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "7b5fe292-e9b0-4d35-aefd-2c132d00a571",
+		}
+	}
+
+	if _, err := branchStore.GetBranchByID(ctx, branchID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return 0, models.CustomError{
+				Err:      fmt.Errorf("%s: branch not found in database: %w", operation, err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "f4b8eb62-7ed7-4c7c-9834-80aa3e2e115e",
+			}
+		}
+
+		return 0, models.CustomError{
+			Err:      fmt.Errorf("%s: checking branch in the database: %w", operation, err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "26795f8f-749c-4df6-9edd-41e9051ff6ff",
+		}
+	}
+
+	return branchID, nil
+}
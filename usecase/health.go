@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const (
+	healthCheckDatabase   = "database"
+	healthCheckMigrations = "migrations"
+	healthCheckPool       = "connectionPool"
+
+	// poolSaturationThreshold is the ratio of connections in use from which the pool is
+	// considered saturated.
+	poolSaturationThreshold = 0.9
+)
+
+type HealthStore interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (uint, bool, error)
+	PoolStats() sql.DBStats
+	// LatestMigration is the schema version the service is built for.
+	LatestMigration() uint
+}
+
+type Health struct {
+	Store                 HealthStore
+	TargetDBSchemaVersion uint
+	// AllowDirtySchema keeps the service ready when the last migration failed halfway.
+	AllowDirtySchema bool
+}
+
+func NewHealth(s HealthStore, allowDirtySchema bool) Health {
+	return Health{
+		Store:                 s,
+		TargetDBSchemaVersion: s.LatestMigration(),
+		AllowDirtySchema:      allowDirtySchema,
+	}
+}
+
+// Readiness checks the database can be reached, is migrated at least to the target
+// version and has connections available.
+func (h Health) Readiness(ctx context.Context) models.HealthReport {
+	ctx, span := tracer.Start(ctx, "Health.Readiness")
+	defer span.End()
+
+	report := models.HealthReport{}
+	report.AddCheck(healthCheckDatabase, h.checkDatabase(ctx))
+	report.AddCheck(healthCheckMigrations, h.checkMigrations(ctx))
+	report.AddCheck(healthCheckPool, h.checkPool())
+
+	return report
+}
+
+func (h Health) checkDatabase(ctx context.Context) models.HealthCheck {
+	if err := h.Store.Ping(ctx); err != nil {
+		return models.HealthCheck{Status: models.HealthStatusFail, Error: err.Error()}
+	}
+
+	return models.HealthCheck{Status: models.HealthStatusOK}
+}
+
+func (h Health) checkMigrations(ctx context.Context) models.HealthCheck {
+	version, dirty, err := h.Store.SchemaVersion(ctx)
+	if err != nil {
+		return models.HealthCheck{Status: models.HealthStatusFail, Error: err.Error()}
+	}
+
+	check := models.HealthCheck{
+		Status: models.HealthStatusOK,
+		Details: map[string]interface{}{
+			"version":       version,
+			"targetVersion": h.TargetDBSchemaVersion,
+			"dirty":         dirty,
+		},
+	}
+	switch {
+	case dirty && !h.AllowDirtySchema:
+		check.Status = models.HealthStatusFail
+		check.Error = "the last migration failed halfway"
+	case version < h.TargetDBSchemaVersion:
+		check.Status = models.HealthStatusFail
+		check.Error = "the database is not migrated to the target version"
+	}
+
+	return check
+}
+
+func (h Health) checkPool() models.HealthCheck {
+	stats := h.Store.PoolStats()
+
+	check := models.HealthCheck{
+		Status: models.HealthStatusOK,
+		Details: map[string]interface{}{
+			"open":    stats.OpenConnections,
+			"inUse":   stats.InUse,
+			"idle":    stats.Idle,
+			"maxOpen": stats.MaxOpenConnections,
+		},
+	}
+	if stats.MaxOpenConnections > 0 &&
+		float64(stats.InUse) >= poolSaturationThreshold*float64(stats.MaxOpenConnections) {
+		check.Status = models.HealthStatusFail
+		check.Error = "the connection pool is saturated"
+	}
+
+	return check
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record models.IdempotencyRecord) (bool, *models.IdempotencyRecord, error)
+	Complete(ctx context.Context, record models.IdempotencyRecord) error
+	Release(ctx context.Context, userID int64, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type Idempotency struct {
+	Store IdempotencyStore
+	TTL   time.Duration
+}
+
+func NewIdempotency(iks IdempotencyStore, ttl time.Duration) Idempotency {
+	return Idempotency{
+		Store: iks,
+		TTL:   ttl,
+	}
+}
+
+// Begin reserves the key of the caller for the request. It returns the stored response
+// when the same request was already processed with the key, nil when the request has to
+// be processed.
+func (i Idempotency) Begin(ctx context.Context, key, requestHash string) (*models.IdempotencyRecord, error) {
+	ctx, span := tracer.Start(ctx, "Idempotency.Begin")
+	defer span.End()
+
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("idempotency key must have at most %d characters", maxIdempotencyKeyLength),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "f567c0e0-c957-4ded-911a-a76c85b38f2d",
+		}
+	}
+
+	user, _ := models.UserFromContext(ctx)
+	now := time.Now().UTC()
+	reserved, stored, err := i.Store.Reserve(ctx, models.IdempotencyRecord{
+		UserID:      user.ID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(i.TTL),
+	})
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("reserving idempotency key: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "9524303d-3fb3-45b4-b2bb-4f44b11b5491",
+		}
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("idempotency key [%s]: %w", key, models.ErrIdempotencyKeyReused),
+			HTTPCode: http.StatusUnprocessableEntity,
+			Code:     "44b8474f-6241-4e49-a91d-3a76bf73d8ec",
+		}
+	}
+	if stored.Status != models.IdempotencyCompleted {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("a request with idempotency key [%s] is still being processed", key),
+			HTTPCode: http.StatusConflict,
+			Code:     "f43661a9-d8eb-4fb0-a9bd-d603e883e3bf",
+		}
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response to replay it on retries of the request.
+func (i Idempotency) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+	ctx, span := tracer.Start(ctx, "Idempotency.Complete")
+	defer span.End()
+
+	user, _ := models.UserFromContext(ctx)
+	record.UserID = user.ID
+
+	return i.Store.Complete(ctx, record)
+}
+
+// Release frees the key after a failure so the request can be retried.
+func (i Idempotency) Release(ctx context.Context, key string) error {
+	ctx, span := tracer.Start(ctx, "Idempotency.Release")
+	defer span.End()
+
+	user, _ := models.UserFromContext(ctx)
+
+	return i.Store.Release(ctx, user.ID, key)
+}
+
+func (i Idempotency) PurgeExpired(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Idempotency.PurgeExpired")
+	defer span.End()
+
+	_, err := i.Store.DeleteExpired(ctx)
+	return err
+}
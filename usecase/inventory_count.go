@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type InventoryCountStore interface {
+	GetAll(ctx context.Context, status string, branchID int64) ([]models.InventoryCount, error)
+	GetCountByID(ctx context.Context, countID int64) (*models.InventoryCount, error)
+	CreateCount(ctx context.Context, countRequest models.InventoryCountCreationRequest) (*models.InventoryCount, error)
+	RecordLines(ctx context.Context, count models.InventoryCount, lines []models.InventoryCountRecordLineRequest) error
+	Approve(ctx context.Context, count models.InventoryCount) error
+	Cancel(ctx context.Context, countID int64) error
+}
+
+type InventoryCounts struct {
+	Store         InventoryCountStore
+	BranchStore   BranchStore
+	MedicineStore MedicineStore
+}
+
+func NewInventoryCounts(ics InventoryCountStore, bs BranchStore, ms MedicineStore) InventoryCounts {
+	return InventoryCounts{
+		Store:         ics,
+		BranchStore:   bs,
+		MedicineStore: ms,
+	}
+}
+
+// Get returns the counts of the caller's branch (or all of them), filtered by status
+// when it is not empty.
+func (ic InventoryCounts) Get(ctx context.Context, status string) ([]models.InventoryCount, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.Get")
+	defer span.End()
+
+	switch status {
+	case "", models.InventoryCountOpen, models.InventoryCountApproved, models.InventoryCountCancelled:
+	default:
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid inventory count status received: [%s]", status),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "febdce8d-4178-497d-982b-a09e9d309e45",
+		}
+	}
+
+	counts, err := ic.Store.GetAll(ctx, status, models.BranchFromContext(ctx))
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting inventory counts from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "20f2a4e8-1c88-4614-a47a-1b95bc3ee55d",
+		}
+	}
+
+	return counts, nil
+}
+
+func (ic InventoryCounts) GetByID(ctx context.Context, countIDParam string) (*models.InventoryCount, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.GetByID")
+	defer span.End()
+
+	countID, err := strconv.ParseInt(countIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid countID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "3de00dd0-2ff4-4f29-89d4-821a697feca8",
+		}
+	}
+
+	return ic.getCount(ctx, countID)
+}
+
+func (ic InventoryCounts) getCount(ctx context.Context, countID int64) (*models.InventoryCount, error) {
+	count, err := ic.Store.GetCountByID(ctx, countID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("inventory count not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "24caf96d-92b2-4e58-9f4d-11bb19ff4630",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting inventory count from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "5d2a2934-cbf2-4f00-b12f-ad3c9532670b",
+		}
+	}
+
+	return count, nil
+}
+
+// GetVariance returns the variance report of the count valued at cost.
+func (ic InventoryCounts) GetVariance(ctx context.Context, countIDParam string) (*models.InventoryVarianceReport, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.GetVariance")
+	defer span.End()
+
+	count, err := ic.GetByID(ctx, countIDParam)
+	if err != nil {
+		return nil, err
+	}
+
+	report := count.VarianceReport()
+
+	return &report, nil
+}
+
+// Create opens a count in the caller's branch.
+func (ic InventoryCounts) Create(ctx context.Context, countRequest models.InventoryCountCreationRequest) (*models.InventoryCountCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.Create")
+	defer span.End()
+
+	branchID, err := requireBranch(ctx, ic.BranchStore, "createInventoryCount")
+	if err != nil {
+		return nil, err
+	}
+	countRequest.BranchID = branchID
+
+	if err := countRequest.ValidateInventoryCountRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createInventoryCount: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "36bc64ac-84f9-4a6c-a77f-553a84e4b928",
+		}
+	}
+
+	if countRequest.CountType == models.InventoryCountCycle {
+		medicines, err := ic.MedicineStore.GetMedicinesByIDs(ctx, countRequest.MedicineIDs)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createInventoryCount: getting medicines from the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "7e5f77c8-2765-476d-89eb-aa9f2fea1776",
+			}
+		}
+		if len(medicines) != len(countRequest.MedicineIDs) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createInventoryCount: not all medicines could be found"),
+				HTTPCode: http.StatusNotFound,
+				Code:     "38546060-dccc-4770-94f3-f56614fe26d5",
+			}
+		}
+	}
+
+	createdCount, err := ic.Store.CreateCount(ctx, countRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating inventory count with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "d3e8e10c-1e16-4c5b-bc8f-ae9acaa4c473",
+		}
+	}
+
+	return &models.InventoryCountCreationResponse{
+		ID: createdCount.ID,
+	}, nil
+}
+
+// Record stores the counted units of some medicines of an open count, by location.
+func (ic InventoryCounts) Record(
+	ctx context.Context, countIDParam string, recordRequest models.InventoryCountRecordRequest) (*models.InventoryCount, error,
+) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.Record")
+	defer span.End()
+
+	count, err := ic.GetByID(ctx, countIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != models.InventoryCountOpen {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("recordInventoryCount: only open counts can be recorded, current status is [%s]", count.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "206f2e84-abba-42d3-944a-71c7cb7bc80f",
+		}
+	}
+	recordRequest = recordRequest.WithLocations(*count)
+	if err := recordRequest.ValidateRecord(*count); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("recordInventoryCount: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "796a8ff4-cc8a-4033-a579-502b4e357b03",
+		}
+	}
+
+	if err := ic.Store.RecordLines(ctx, *count, recordRequest.Lines); err != nil {
+		return nil, transitionError("recordInventoryCount", err)
+	}
+
+	return ic.getCount(ctx, count.ID)
+}
+
+// Approve closes the count posting the variance as stock adjustments.
+func (ic InventoryCounts) Approve(ctx context.Context, countIDParam string) (*models.InventoryVarianceReport, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.Approve")
+	defer span.End()
+
+	count, err := ic.GetByID(ctx, countIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != models.InventoryCountOpen {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("approveInventoryCount: only open counts can be approved, current status is [%s]", count.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "8e5f5dd7-4988-42fb-a7c1-f407862210bf",
+		}
+	}
+
+	if err := ic.Store.Approve(ctx, *count); err != nil {
+		if errors.Is(err, models.ErrInventoryCountIncomplete) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("approveInventoryCount: %w", err),
+				HTTPCode: http.StatusConflict,
+				Code:     "2f82cbe6-8620-4be8-9265-3da813bed01d",
+			}
+		}
+
+		return nil, transitionError("approveInventoryCount", err)
+	}
+
+	approvedCount, err := ic.getCount(ctx, count.ID)
+	if err != nil {
+		return nil, err
+	}
+	report := approvedCount.VarianceReport()
+
+	return &report, nil
+}
+
+// Cancel discards an open count.
+func (ic InventoryCounts) Cancel(ctx context.Context, countIDParam string) (*models.InventoryCount, error) {
+	ctx, span := tracer.Start(ctx, "InventoryCounts.Cancel")
+	defer span.End()
+
+	count, err := ic.GetByID(ctx, countIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != models.InventoryCountOpen {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("cancelInventoryCount: only open counts can be cancelled, current status is [%s]", count.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "523d4138-8dbb-487b-bcf8-2815b353f3db",
+		}
+	}
+
+	if err := ic.Store.Cancel(ctx, count.ID); err != nil {
+		return nil, transitionError("cancelInventoryCount", err)
+	}
+
+	return ic.getCount(ctx, count.ID)
+}
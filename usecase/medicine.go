@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/store"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
 )
 
 type MedicineStore interface {
@@ -16,19 +21,30 @@ type MedicineStore interface {
 	GetMedicinesByIDs(ctx context.Context, medicineIDs []int64) ([]models.Medicine, error)
 	GetMedicineByID(ctx context.Context, medicineID int64) (*models.Medicine, error)
 	CreateMedicine(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.Medicine, error)
+	UpdatePrice(ctx context.Context, medicineID, expectedVersion int64, price float64) (*models.Medicine, error)
+	Search(ctx context.Context, text string, limit int) ([]models.MedicineSearchResult, error)
+	GetStockByBranch(ctx context.Context, medicineID int64) ([]models.BranchStock, error)
+	GetPresentations(ctx context.Context, medicineID int64) ([]models.Presentation, error)
+	GetPresentationsByIDs(ctx context.Context, presentationIDs []int64) ([]models.Presentation, error)
+	CreatePresentation(ctx context.Context, medicineID int64, presentationRequest models.PresentationCreationRequest) (*models.Presentation, error)
 }
 
 type Medicines struct {
-	Store MedicineStore
+	Store       MedicineStore
+	BranchStore BranchStore
 }
 
-func NewMedicines(ms store.Medicine) Medicines {
+func NewMedicines(ms MedicineStore, bs BranchStore) Medicines {
 	return Medicines{
-		Store: ms,
+		Store:       ms,
+		BranchStore: bs,
 	}
 }
 
 func (m Medicines) Get(ctx context.Context) ([]models.Medicine, error) {
+	ctx, span := tracer.Start(ctx, "Medicines.Get")
+	defer span.End()
+
 	medicines, err := m.Store.GetAll(ctx)
 	if err != nil {
 		return nil, models.CustomError{
@@ -42,6 +58,9 @@ func (m Medicines) Get(ctx context.Context) ([]models.Medicine, error) {
 }
 
 func (m Medicines) GetByID(ctx context.Context, medicineIDParam string) (*models.Medicine, error) {
+	ctx, span := tracer.Start(ctx, "Medicines.GetByID")
+	defer span.End()
+
 	medicineID, err := strconv.ParseInt(medicineIDParam, 10, 64)
 	if err != nil {
 		return nil, models.CustomError{
@@ -68,10 +87,31 @@ func (m Medicines) GetByID(ctx context.Context, medicineIDParam string) (*models
 		}
 	}
 
+	medicine.BranchStock, err = m.Store.GetStockByBranch(ctx, medicineID)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting medicine stock by branch from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "e68c66d0-59ae-4a23-a567-8d57c3a49937",
+		}
+	}
+
+	medicine.Presentations, err = m.Store.GetPresentations(ctx, medicineID)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting medicine presentations from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "8f3f8d57-1f0e-4a55-9e64-3b7e0d0c2f61",
+		}
+	}
+
 	return medicine, nil
 }
 
 func (m Medicines) Create(ctx context.Context, medicineRequest models.MedicineCreationRequest) (*models.MedicineCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "Medicines.Create")
+	defer span.End()
+
 	if err := medicineRequest.ValidateMedicineRequest(); err != nil {
 		return nil, models.CustomError{
 			Err:      fmt.Errorf("createMedicine: request data is invalid: %w", err),
@@ -80,6 +120,15 @@ func (m Medicines) Create(ctx context.Context, medicineRequest models.MedicineCr
 		}
 	}
 
+	if medicineRequest.Stock > 0 {
+		// the initial stock is received in the caller's branch
+		branchID, err := requireBranch(ctx, m.BranchStore, "createMedicine")
+		if err != nil {
+			return nil, err
+		}
+		medicineRequest.BranchID = branchID
+	}
+
 	createdMedicine, err := m.Store.CreateMedicine(ctx, medicineRequest)
 	if err != nil {
 		return nil, models.CustomError{
@@ -93,3 +142,145 @@ func (m Medicines) Create(ctx context.Context, medicineRequest models.MedicineCr
 		ID: createdMedicine.ID,
 	}, nil
 }
+
+// UpdatePrice changes the price of the medicine, when expectedVersion is not zero only if
+// the medicine is still in that version.
+func (m Medicines) UpdatePrice(
+	ctx context.Context, medicineIDParam string, expectedVersion int64, priceRequest models.MedicinePriceUpdateRequest) (*models.Medicine, error,
+) {
+	ctx, span := tracer.Start(ctx, "Medicines.UpdatePrice")
+	defer span.End()
+
+	medicineID, err := strconv.ParseInt(medicineIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid medicineID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "4a730b83-8832-428d-a33d-12da7461dc87",
+		}
+	}
+
+	if err := priceRequest.ValidatePriceRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("updatePrice: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "d9c66886-4c3f-447e-9f1f-3ad83acdf282",
+		}
+	}
+
+	if _, err := m.Store.UpdatePrice(ctx, medicineID, expectedVersion, priceRequest.Price); err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePrice: the medicine was modified by someone else: %w", err),
+				HTTPCode: http.StatusPreconditionFailed,
+				Code:     "38f4a928-f06a-49d7-8137-fecdd68474d6",
+			}
+		}
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePrice: medicine not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "2aae1e93-74ee-498f-8062-8a1eb9fbb322",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("updating medicine price within the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "9fd599fe-1be6-4b02-83fc-30fefd34a89b",
+		}
+	}
+
+	return m.GetByID(ctx, medicineIDParam)
+}
+
+func (m Medicines) CreatePresentation(
+	ctx context.Context, medicineIDParam string, presentationRequest models.PresentationCreationRequest) (*models.PresentationCreationResponse, error,
+) {
+	ctx, span := tracer.Start(ctx, "Medicines.CreatePresentation")
+	defer span.End()
+
+	medicineID, err := strconv.ParseInt(medicineIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid medicineID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "4e1b8a9c-7a0f-4b0a-9d3c-2a8e6f1b5c47",
+		}
+	}
+
+	if err := presentationRequest.ValidatePresentationRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPresentation: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "b2d6c0f4-3e8a-4f6e-8a1d-9c5b7e2f0a13",
+		}
+	}
+
+	if _, err := m.Store.GetMedicineByID(ctx, medicineID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createPresentation: medicine not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "71c9e0d2-6b3f-4e5a-a8d4-0f2e9b1c7d36",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPresentation: getting medicine from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "c8a4f1e7-2d5b-4c9a-b3e6-5f0d8a2c4b91",
+		}
+	}
+
+	createdPresentation, err := m.Store.CreatePresentation(ctx, medicineID, presentationRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating presentation with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "0d7e3b6a-9f2c-4a1e-8b5d-6c4f2e9a1b08",
+		}
+	}
+
+	return &models.PresentationCreationResponse{
+		ID: createdPresentation.ID,
+	}, nil
+}
+
+func (m Medicines) Search(ctx context.Context, text, limitParam string) ([]models.MedicineSearchResult, error) {
+	ctx, span := tracer.Start(ctx, "Medicines.Search")
+	defer span.End()
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("searchMedicine: search text is empty"),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "31ee9a37-6fdf-45f3-8fc8-b16f9fda57c0",
+		}
+	}
+
+	limit := defaultSearchLimit
+	if limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("searchMedicine: invalid limit received, this must be between 1 and %d", maxSearchLimit),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "f4545ca3-01a4-4ee8-915d-9e6e6311a878",
+			}
+		}
+	}
+
+	results, err := m.Store.Search(ctx, text, limit)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("searching medicines in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "e54a1a42-1b7a-468a-a873-f529303a304d",
+		}
+	}
+
+	return results, nil
+}
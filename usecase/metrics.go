@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/metrics"
+	"github.com/VictorDelgado94/aveonline-backend/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	billingsCreated = promauto.With(metrics.Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "aveonline_billings_created_total", Help: "Billings created.",
+	}, []string{"branch"})
+	billingRevenue = promauto.With(metrics.Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "aveonline_billing_revenue_total", Help: "Sum of the totals of the billings created.",
+	}, []string{"branch"})
+	promotionUsages = promauto.With(metrics.Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "aveonline_promotion_usages_total", Help: "Billings created with a promotion applied.",
+	}, []string{"promotion"})
+	simulatorCalls = promauto.With(metrics.Default).NewCounterVec(prometheus.CounterOpts{
+		Name: "aveonline_simulator_calls_total", Help: "Billing simulations by outcome.",
+	}, []string{"outcome"})
+)
+
+func recordBillingCreated(billing *models.BillingDetail) {
+	branch := strconv.FormatInt(billing.BranchID, 10)
+	billingsCreated.WithLabelValues(branch).Inc()
+	// counters panic with negative values
+	if billing.Total > 0 {
+		billingRevenue.WithLabelValues(branch).Add(billing.Total)
+	}
+	if billing.Promotion.ID > 0 {
+		promotionUsages.WithLabelValues(strconv.FormatInt(billing.Promotion.ID, 10)).Inc()
+	}
+}
+
+func recordSimulatorCall(err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	simulatorCalls.WithLabelValues(outcome).Inc()
+}
@@ -9,70 +9,207 @@ import (
 	"time"
 
 	"github.com/VictorDelgado94/aveonline-backend/models"
-	"github.com/VictorDelgado94/aveonline-backend/store"
 )
 
 type PromotionStore interface {
 	CreatePromotion(ctx context.Context, promoRequest models.PromotionCreationRequest) (*models.Promotion, error)
 	GetPromoByID(ctx context.Context, promoID int64) (models.Promotion, error)
 	GetAll(ctx context.Context) ([]models.Promotion, error)
-	CountPromosBetweenDates(ctx context.Context, startDate, endDate time.Time) (int, error)
-	GetByDate(ctx context.Context, date, endDate time.Time) (models.Promotion, error)
+	UpdatePromotion(
+		ctx context.Context, promoID, expectedVersion int64, promoRequest models.PromotionCreationRequest,
+	) (*models.Promotion, error)
+	CountPromosBetweenDates(ctx context.Context, startDate, endDate time.Time, branchIDs []int64, excludedPromoID int64) (int, error)
+	GetActiveBetween(ctx context.Context, startDate, endDate time.Time, branchID int64) (models.Promotion, error)
 }
 
 type Promotions struct {
-	Store PromotionStore
+	Store       PromotionStore
+	BranchStore BranchStore
+	Calendar    models.BusinessCalendar
 }
 
-func NewPromotions(ps store.Promotions) Promotions {
+func NewPromotions(ps PromotionStore, bs BranchStore, calendar models.BusinessCalendar) Promotions {
 	return Promotions{
-		Store: ps,
+		Store:       ps,
+		BranchStore: bs,
+		Calendar:    calendar,
 	}
 }
 
 func (p Promotions) Create(
 	ctx context.Context, promoRequest models.PromotionCreationRequest) (*models.PromotionCreationResponse, error,
 ) {
-	// validate request data
-	if err := promoRequest.ValidatePromotionRequest(); err != nil {
+	ctx, span := tracer.Start(ctx, "Promotions.Create")
+	defer span.End()
+
+	promoRequest = promoRequest.InBusinessTime(p.Calendar)
+
+	if err := p.validatePromotion(ctx, promoRequest, nil); err != nil {
+		return nil, err
+	}
+
+	createdPromo, err := p.Store.CreatePromotion(ctx, promoRequest)
+	if err != nil {
+		if errors.Is(err, models.ErrPromotionOverlap) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createPromo: promotions already exist in the specified date range: %w", err),
+				HTTPCode: http.StatusConflict,
+				Code:     "1872535d-a1ab-42ee-97a4-a14f0337d7e1",
+			}
+		}
+
 		return nil, models.CustomError{
-			Err:      fmt.Errorf("createPromo: request data is invalid: %w", err),
+			Err:      fmt.Errorf("creating promotion with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "c4350752-49db-4546-aea9-fd3a6ed0a938",
+		}
+	}
+
+	return &models.PromotionCreationResponse{
+		ID: createdPromo.ID,
+	}, nil
+}
+
+// Update replaces the promotion, when expectedVersion is not zero only if the promotion
+// is still in that version.
+func (p Promotions) Update(
+	ctx context.Context, promoIDParam string, expectedVersion int64, promoRequest models.PromotionCreationRequest) (*models.Promotion, error,
+) {
+	ctx, span := tracer.Start(ctx, "Promotions.Update")
+	defer span.End()
+
+	promoID, err := strconv.ParseInt(promoIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid promotionID received: %w", err),
 			HTTPCode: http.StatusBadRequest,
-			Code:     "c7c007be-a97d-42df-aa28-15ef80b869b1",
+			Code:     "67706ad2-c4c7-4f4b-ab33-df5012a86262",
 		}
 	}
 
-	promosInDates, err := p.Store.CountPromosBetweenDates(ctx, promoRequest.StartDate, promoRequest.EndDate)
+	storedPromo, err := p.Store.GetPromoByID(ctx, promoID)
 	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePromo: promotion not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "3b8e1f6a-52c4-4d07-9e2b-c71a5d08f4e3",
+			}
+		}
+
 		return nil, models.CustomError{
-			Err:      fmt.Errorf("createPromo: verifying if there are promos in the specified date range: %w", err),
+			Err:      fmt.Errorf("updatePromo: getting promotion from the database: %w", err),
 			HTTPCode: http.StatusInternalServerError,
-			Code:     "c4350752-49db-4546-aea9-fd3a6ed0a938",
+			Code:     "a6d2c94e-0f17-4b3a-8c5e-19e7b4f2d630",
 		}
 	}
-	if promosInDates > 0 {
+
+	promoRequest = promoRequest.InBusinessTime(p.Calendar)
+	if err := p.validatePromotion(ctx, promoRequest, &storedPromo); err != nil {
+		return nil, err
+	}
+
+	updatedPromo, err := p.Store.UpdatePromotion(ctx, promoID, expectedVersion, promoRequest)
+	if err != nil {
+		if errors.Is(err, models.ErrPromotionOverlap) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePromo: promotions already exist in the specified date range: %w", err),
+				HTTPCode: http.StatusConflict,
+				Code:     "29e37728-91a0-4581-b138-bb9e8b513e60",
+			}
+		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePromo: the promotion was modified by someone else: %w", err),
+				HTTPCode: http.StatusPreconditionFailed,
+				Code:     "4e2a4a34-2a4b-4a76-98a1-eb40ccce14ab",
+			}
+		}
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("updatePromo: promotion not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "c423b7ed-0709-4ef4-9221-5658660cadd3",
+			}
+		}
+
 		return nil, models.CustomError{
-			Err:      fmt.Errorf("createPromo: promotions already exist in the specified date range"),
+			Err:      fmt.Errorf("updating promotion within the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "7d294e44-82a5-44e3-a1b5-4ac984563c72",
+		}
+	}
+
+	return updatedPromo, nil
+}
+
+// validatePromotion checks the request data, its branches and that it does not overlap
+// other promotions. storedPromo is the promotion the request replaces, nil on creation.
+func (p Promotions) validatePromotion(
+	ctx context.Context, promoRequest models.PromotionCreationRequest, storedPromo *models.Promotion,
+) error {
+	// validate request data
+	var (
+		excludedPromoID int64
+		err             error
+	)
+	if storedPromo == nil {
+		err = promoRequest.ValidatePromotionRequest(p.Calendar)
+	} else {
+		excludedPromoID = storedPromo.ID
+		err = promoRequest.ValidatePromotionUpdate(*storedPromo, p.Calendar)
+	}
+	if err != nil {
+		return models.CustomError{
+			Err:      fmt.Errorf("createPromo: request data is invalid: %w", err),
 			HTTPCode: http.StatusBadRequest,
-			Code:     "80ed3ce8-2880-49ee-9b98-af7e91c49a38",
+			Code:     "c7c007be-a97d-42df-aa28-15ef80b869b1",
 		}
 	}
 
-	createdPromo, err := p.Store.CreatePromotion(ctx, promoRequest)
+	for _, branchID := range promoRequest.BranchIDs {
+		if _, err := p.BranchStore.GetBranchByID(ctx, branchID); err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return models.CustomError{
+					Err:      fmt.Errorf("createPromo: branch [%d] not found in database: %w", branchID, err),
+					HTTPCode: http.StatusNotFound,
+					Code:     "d6598dbf-33a0-4456-a6f6-7db4a63080f1",
+				}
+			}
+
+			return models.CustomError{
+				Err:      fmt.Errorf("createPromo: checking branch in the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "b328001c-085b-47be-9b4a-b414b6781424",
+			}
+		}
+	}
+
+	promosInDates, err := p.Store.CountPromosBetweenDates(
+		ctx, promoRequest.StartDate, promoRequest.EndDate, promoRequest.BranchIDs, excludedPromoID,
+	)
 	if err != nil {
-		return nil, models.CustomError{
-			Err:      fmt.Errorf("creating promotion with in the database: %w", err),
+		return models.CustomError{
+			Err:      fmt.Errorf("createPromo: verifying if there are promos in the specified date range: %w", err),
 			HTTPCode: http.StatusInternalServerError,
 			Code:     "c4350752-49db-4546-aea9-fd3a6ed0a938",
 		}
 	}
+	if promosInDates > 0 {
+		return models.CustomError{
+			Err:      fmt.Errorf("createPromo: promotions already exist in the specified date range"),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "80ed3ce8-2880-49ee-9b98-af7e91c49a38",
+		}
+	}
 
-	return &models.PromotionCreationResponse{
-		ID: createdPromo.ID,
-	}, nil
+	return nil
 }
 
 func (p Promotions) Get(ctx context.Context) ([]models.Promotion, error) {
+	ctx, span := tracer.Start(ctx, "Promotions.Get")
+	defer span.End()
+
 	promos, err := p.Store.GetAll(ctx)
 	if err != nil {
 		return nil, models.CustomError{
@@ -86,6 +223,9 @@ func (p Promotions) Get(ctx context.Context) ([]models.Promotion, error) {
 }
 
 func (p Promotions) GetByID(ctx context.Context, promoIDParam string) (models.Promotion, error) {
+	ctx, span := tracer.Start(ctx, "Promotions.GetByID")
+	defer span.End()
+
 	promoID, err := strconv.ParseInt(promoIDParam, 10, 64)
 	if err != nil {
 		return models.Promotion{}, models.CustomError{
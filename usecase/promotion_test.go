@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type fakePromotionUpdateStore struct {
+	fakePromotionStore
+	updated []models.PromotionCreationRequest
+}
+
+func (s *fakePromotionUpdateStore) CountPromosBetweenDates(
+	ctx context.Context, startDate, endDate time.Time, branchIDs []int64, excludedPromoID int64,
+) (int, error) {
+	return 0, nil
+}
+
+func (s *fakePromotionUpdateStore) UpdatePromotion(
+	ctx context.Context, promoID, expectedVersion int64, promoRequest models.PromotionCreationRequest,
+) (*models.Promotion, error) {
+	s.updated = append(s.updated, promoRequest)
+
+	return &models.Promotion{ID: promoID, StartDate: promoRequest.StartDate, EndtDate: promoRequest.EndDate}, nil
+}
+
+func TestPromotionsUpdate(t *testing.T) {
+	now := time.Now().UTC()
+	// the promotion started a week ago and is still running
+	started := models.Promotion{
+		ID:          5,
+		Description: "Semana de la salud",
+		Percentage:  10,
+		StartDate:   now.AddDate(0, 0, -7),
+		EndtDate:    now.AddDate(0, 0, 7),
+	}
+
+	request := func(startDate, endDate time.Time) models.PromotionCreationRequest {
+		return models.PromotionCreationRequest{Description: "Semana de la salud", Percentage: 15, StartDate: startDate, EndDate: endDate}
+	}
+
+	tests := []struct {
+		name       string
+		promoID    string
+		request    models.PromotionCreationRequest
+		wantStatus int
+	}{
+		{name: "keeping the past start date", promoID: "5", request: request(started.StartDate, started.EndtDate.AddDate(0, 0, 7))},
+		{name: "moving the start date to the future", promoID: "5", request: request(now.AddDate(0, 0, 1), started.EndtDate)},
+		{name: "moving the start date to the past", promoID: "5", request: request(started.StartDate.AddDate(0, 0, -1), started.EndtDate), wantStatus: http.StatusBadRequest},
+		{name: "ending in the past", promoID: "5", request: request(started.StartDate, now.Add(-time.Hour)), wantStatus: http.StatusBadRequest},
+		{name: "ending before the start", promoID: "5", request: request(now.AddDate(0, 0, 3), now.AddDate(0, 0, 2)), wantStatus: http.StatusBadRequest},
+		{name: "unknown promotion", promoID: "6", request: request(started.StartDate, started.EndtDate), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakePromotionUpdateStore{
+				fakePromotionStore: fakePromotionStore{promotions: map[int64]models.Promotion{started.ID: started}},
+			}
+			promotions := NewPromotions(store, fakeBranchStore{}, models.NewBusinessCalendar(time.UTC))
+
+			_, err := promotions.Update(context.Background(), tt.promoID, 0, tt.request)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus == 0 && len(store.updated) != 1 {
+				t.Errorf("got %d updates, want the promotion updated", len(store.updated))
+			}
+			if tt.wantStatus != 0 && len(store.updated) != 0 {
+				t.Errorf("the promotion was updated")
+			}
+		})
+	}
+}
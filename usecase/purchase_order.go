@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type PurchaseOrderStore interface {
+	GetAll(ctx context.Context, status string, branchID int64) ([]models.PurchaseOrder, error)
+	GetPurchaseOrderByID(ctx context.Context, orderID int64) (*models.PurchaseOrder, error)
+	CreatePurchaseOrder(ctx context.Context, orderRequest models.PurchaseOrderCreationRequest) (*models.PurchaseOrder, error)
+	UpdateStatus(ctx context.Context, orderID int64, fromStatus, toStatus string) error
+	RegisterGoodsReceipt(ctx context.Context, receipt models.GoodsReceipt) (*models.GoodsReceipt, error)
+}
+
+type PurchaseOrders struct {
+	Store         PurchaseOrderStore
+	SupplierStore SupplierStore
+	MedicineStore MedicineStore
+	BranchStore   BranchStore
+}
+
+func NewPurchaseOrders(ps PurchaseOrderStore, ss SupplierStore, ms MedicineStore, bs BranchStore) PurchaseOrders {
+	return PurchaseOrders{
+		Store:         ps,
+		SupplierStore: ss,
+		MedicineStore: ms,
+		BranchStore:   bs,
+	}
+}
+
+func (p PurchaseOrders) Get(ctx context.Context, status string) ([]models.PurchaseOrder, error) {
+	ctx, span := tracer.Start(ctx, "PurchaseOrders.Get")
+	defer span.End()
+
+	switch status {
+	case "", models.PurchaseOrderDraft, models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived, models.PurchaseOrderReceived:
+	default:
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid purchase order status received: [%s]", status),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "fe34102f-60e7-4778-be66-d6959fd711c5",
+		}
+	}
+
+	orders, err := p.Store.GetAll(ctx, status, models.BranchFromContext(ctx))
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting purchase orders from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "7e911a82-7bed-4771-ae80-bcdea859fa6c",
+		}
+	}
+
+	return orders, nil
+}
+
+func (p PurchaseOrders) GetByID(ctx context.Context, orderIDParam string) (*models.PurchaseOrder, error) {
+	ctx, span := tracer.Start(ctx, "PurchaseOrders.GetByID")
+	defer span.End()
+
+	orderID, err := strconv.ParseInt(orderIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid purchaseOrderID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "eac71834-7f8a-49b4-be35-987573301e93",
+		}
+	}
+
+	return p.getOrder(ctx, orderID)
+}
+
+func (p PurchaseOrders) getOrder(ctx context.Context, orderID int64) (*models.PurchaseOrder, error) {
+	order, err := p.Store.GetPurchaseOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("purchase order not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "5942002a-552d-4d46-bf1a-8b6dbe5c3d2d",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting purchase order from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "313bd209-79c9-4537-a12c-d9c200327a33",
+		}
+	}
+
+	return order, nil
+}
+
+func (p PurchaseOrders) Create(ctx context.Context, orderRequest models.PurchaseOrderCreationRequest) (*models.PurchaseOrderCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "PurchaseOrders.Create")
+	defer span.End()
+
+	if err := orderRequest.ValidatePurchaseOrderRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPurchaseOrder: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "3cef0c94-234d-464c-8ecb-a5d11d489f1d",
+		}
+	}
+
+	branchID, err := requireBranch(ctx, p.BranchStore, "createPurchaseOrder")
+	if err != nil {
+		return nil, err
+	}
+	orderRequest.BranchID = branchID
+
+	if _, err := p.SupplierStore.GetSupplierByID(ctx, orderRequest.SupplierID); err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createPurchaseOrder: supplier not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "820ca9a2-98ec-4ffc-9c6a-af425b94e113",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPurchaseOrder: checking supplier in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "1d1698e2-53fe-472d-9069-47197ddc69a2",
+		}
+	}
+
+	medicinesIDs := make([]int64, 0, len(orderRequest.Lines))
+	for _, line := range orderRequest.Lines {
+		medicinesIDs = append(medicinesIDs, line.MedicineID)
+	}
+	medicines, err := p.MedicineStore.GetMedicinesByIDs(ctx, medicinesIDs)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPurchaseOrder: getting medicines from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "06b5f155-167e-4ad4-9611-b9ad21c2d48b",
+		}
+	}
+	if len(medicines) != len(medicinesIDs) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createPurchaseOrder: not all medicines could be found"),
+			HTTPCode: http.StatusNotFound,
+			Code:     "5535a360-7f82-4b9c-a9ac-8c826a3fcb25",
+		}
+	}
+
+	createdOrder, err := p.Store.CreatePurchaseOrder(ctx, orderRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating purchase order with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "f8716c14-8f65-46f6-b578-0eb33d506b1b",
+		}
+	}
+
+	return &models.PurchaseOrderCreationResponse{
+		ID: createdOrder.ID,
+	}, nil
+}
+
+// Send marks a draft order as sent to the supplier, only sent orders can receive goods.
+func (p PurchaseOrders) Send(ctx context.Context, orderIDParam string) (*models.PurchaseOrder, error) {
+	ctx, span := tracer.Start(ctx, "PurchaseOrders.Send")
+	defer span.End()
+
+	order, err := p.GetByID(ctx, orderIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.PurchaseOrderDraft {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("sendPurchaseOrder: only draft orders can be sent, current status is [%s]", order.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "906b8732-f94d-475e-9501-810bf8831a97",
+		}
+	}
+
+	err = p.Store.UpdateStatus(ctx, order.ID, models.PurchaseOrderDraft, models.PurchaseOrderSent)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("sendPurchaseOrder: purchase order changed while being sent: %w", err),
+				HTTPCode: http.StatusConflict,
+				Code:     "7bb17ebe-0017-417d-9845-2b26d1ee0890",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("sendPurchaseOrder: updating purchase order status: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "00b42326-a32a-49cc-9905-74dbf6290e60",
+		}
+	}
+	order.Status = models.PurchaseOrderSent
+
+	return order, nil
+}
+
+// Receive registers a goods receipt against a sent or partially received order and
+// returns the updated order.
+func (p PurchaseOrders) Receive(
+	ctx context.Context, orderIDParam string, receiptRequest models.GoodsReceiptRequest) (*models.PurchaseOrder, error,
+) {
+	ctx, span := tracer.Start(ctx, "PurchaseOrders.Receive")
+	defer span.End()
+
+	order, err := p.GetByID(ctx, orderIDParam)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := receiptRequest.ValidateGoodsReceiptRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("goodsReceipt: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "fbc36b62-ab7f-4760-8adc-02cfb67abe91",
+		}
+	}
+	if order.Status != models.PurchaseOrderSent && order.Status != models.PurchaseOrderPartiallyReceived {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("goodsReceipt: purchase order in status [%s] cannot receive goods", order.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "30e5d0b3-aa2d-44d9-a92f-8989e1f244b3",
+		}
+	}
+
+	receipt, err := buildGoodsReceipt(*order, receiptRequest)
+	if err != nil {
+		if errors.Is(err, models.ErrReceiptExceedsOrder) {
+			return nil, errReceiptExceedsOrder(err)
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("goodsReceipt: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "91bcc067-b7eb-4f99-8392-97876a8b034a",
+		}
+	}
+
+	if _, err := p.Store.RegisterGoodsReceipt(ctx, receipt); err != nil {
+		if errors.Is(err, models.ErrReceiptExceedsOrder) {
+			return nil, errReceiptExceedsOrder(err)
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("goodsReceipt: registering goods receipt within the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "47cb4727-dd7c-4da2-86b7-52d7d35df51b",
+		}
+	}
+
+	return p.getOrder(ctx, order.ID)
+}
+
+// errReceiptExceedsOrder reports an over-receipt the same way whether the order read
+// before receiving or the store, after another receipt got in first, detected it.
+func errReceiptExceedsOrder(err error) models.CustomError {
+	return models.CustomError{
+		Err:      fmt.Errorf("goodsReceipt: %w", err),
+		HTTPCode: http.StatusConflict,
+		Code:     "237a8044-cee0-4f25-9abe-b4e084c5d57d",
+	}
+}
+
+// buildGoodsReceipt matches every received medicine with its order line, checking the
+// pending quantity and defaulting the cost to the agreed one.
+func buildGoodsReceipt(order models.PurchaseOrder, receiptRequest models.GoodsReceiptRequest) (models.GoodsReceipt, error) {
+	linesByMedicine := make(map[int64]models.PurchaseOrderLine, len(order.Lines))
+	for _, line := range order.Lines {
+		linesByMedicine[line.MedicineID] = line
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID: order.ID,
+		ReceivedAt:      receiptRequest.ReceivedAt,
+		Lines:           make([]models.GoodsReceiptLine, 0, len(receiptRequest.Lines)),
+	}
+	if receipt.ReceivedAt.IsZero() {
+		receipt.ReceivedAt = time.Now().UTC()
+	}
+
+	for _, lineRequest := range receiptRequest.Lines {
+		line, ok := linesByMedicine[lineRequest.MedicineID]
+		if !ok {
+			return models.GoodsReceipt{}, fmt.Errorf("medicine [%d] is not part of the purchase order", lineRequest.MedicineID)
+		}
+		if lineRequest.Quantity > line.PendingQuantity() {
+			return models.GoodsReceipt{}, fmt.Errorf(
+				"medicine [%d]: %w, pending [%d] received [%d]",
+				lineRequest.MedicineID, models.ErrReceiptExceedsOrder, line.PendingQuantity(), lineRequest.Quantity,
+			)
+		}
+
+		unitCost := lineRequest.UnitCost
+		if unitCost == 0 {
+			unitCost = line.UnitCost
+		}
+		receipt.Lines = append(receipt.Lines, models.GoodsReceiptLine{
+			PurchaseOrderLineID: line.ID,
+			MedicineID:          line.MedicineID,
+			Quantity:            lineRequest.Quantity,
+			UnitCost:            unitCost,
+		})
+	}
+
+	return receipt, nil
+}
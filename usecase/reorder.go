@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type ReorderStore interface {
+	GetMedicinesSales(ctx context.Context, since time.Time, branchID int64) ([]models.MedicineSales, error)
+	CreateDraft(ctx context.Context, draft models.ReorderDraft) (*models.ReorderDraft, error)
+	GetLatestDraft(ctx context.Context, branchID int64) (*models.ReorderDraft, error)
+}
+
+type Reorders struct {
+	Store         ReorderStore
+	DefaultParams models.ReorderParams
+}
+
+func NewReorders(rs ReorderStore, defaultParams models.ReorderParams) Reorders {
+	return Reorders{
+		Store:         rs,
+		DefaultParams: defaultParams,
+	}
+}
+
+// Report returns the reorder suggestion of every medicine in the caller's branch, or
+// consolidated when the caller did not identify one. Empty parameters take the
+// configured defaults.
+func (r Reorders) Report(ctx context.Context, windowDays, leadTimeDays, safetyStockDays string) ([]models.ReorderSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "Reorders.Report")
+	defer span.End()
+
+	params := r.DefaultParams
+	for _, param := range []struct {
+		value  string
+		target *int
+	}{
+		{value: windowDays, target: &params.WindowDays},
+		{value: leadTimeDays, target: &params.LeadTimeDays},
+		{value: safetyStockDays, target: &params.SafetyStockDays},
+	} {
+		if param.value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(param.value)
+		if err != nil {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("reorder: invalid number of days received: %w", err),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "36eadb70-aad3-420f-8453-aea3ffec078a",
+			}
+		}
+		*param.target = parsed
+	}
+
+	return r.suggestions(ctx, params)
+}
+
+func (r Reorders) suggestions(ctx context.Context, params models.ReorderParams) ([]models.ReorderSuggestion, error) {
+	if err := params.ValidateReorderParams(); err != nil {
+		return nil, models.CustomError{
+			Err:      err,
+			HTTPCode: http.StatusBadRequest,
+			Code:     "4aedbfa7-9980-4bfa-adf2-bf0f3ab8f9fd",
+		}
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -params.WindowDays)
+	medicinesSales, err := r.Store.GetMedicinesSales(ctx, since, models.BranchFromContext(ctx))
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("reorder: getting medicines sales from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "3f676b7e-e498-403a-8de0-185330ca545c",
+		}
+	}
+
+	suggestions := make([]models.ReorderSuggestion, 0, len(medicinesSales))
+	for _, sales := range medicinesSales {
+		suggestions = append(suggestions, params.Suggest(sales))
+	}
+
+	return suggestions, nil
+}
+
+// GenerateDraft stores, with the default parameters, the list of medicines that need to
+// be ordered in the caller's branch (or consolidated) so the purchasing team can review it.
+func (r Reorders) GenerateDraft(ctx context.Context) (*models.ReorderDraft, error) {
+	ctx, span := tracer.Start(ctx, "Reorders.GenerateDraft")
+	defer span.End()
+
+	suggestions, err := r.suggestions(ctx, r.DefaultParams)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := models.ReorderDraft{
+		BranchID:      models.BranchFromContext(ctx),
+		ReorderParams: r.DefaultParams,
+		Suggestions:   make([]models.ReorderSuggestion, 0),
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.SuggestedQuantity > 0 {
+			draft.Suggestions = append(draft.Suggestions, suggestion)
+		}
+	}
+
+	createdDraft, err := r.Store.CreateDraft(ctx, draft)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("reorder: creating draft within the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "edf4debb-c7dd-46f7-92b9-e28f581b4e36",
+		}
+	}
+
+	return createdDraft, nil
+}
+
+func (r Reorders) GetLatestDraft(ctx context.Context) (*models.ReorderDraft, error) {
+	ctx, span := tracer.Start(ctx, "Reorders.GetLatestDraft")
+	defer span.End()
+
+	draft, err := r.Store.GetLatestDraft(ctx, models.BranchFromContext(ctx))
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("reorder: no draft has been generated yet: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "0d73e40d-97c6-42d2-9a34-036da93dbc6b",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("reorder: getting latest draft from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "4f06e853-54d4-4937-9d6a-02ea3022840e",
+		}
+	}
+
+	return draft, nil
+}
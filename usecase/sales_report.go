@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type SalesReportStore interface {
+	GetSalesSummary(ctx context.Context, filter models.SalesSummaryFilter) ([]models.SalesSummary, error)
+	GetMedicinesPerformance(ctx context.Context, startDate, endDate time.Time, branchID int64) ([]models.MedicinePerformance, error)
+}
+
+type SalesReports struct {
+	Store    SalesReportStore
+	Calendar models.BusinessCalendar
+}
+
+func NewSalesReports(srs SalesReportStore, calendar models.BusinessCalendar) SalesReports {
+	return SalesReports{
+		Store:    srs,
+		Calendar: calendar,
+	}
+}
+
+// Summary returns the sales between the dates per business day, week or month of the
+// caller's branch, or of every branch when the caller did not identify one. A plain end
+// date includes its whole business day and breakdown lists the extra dimensions, branch
+// and/or cashier, separated by commas.
+func (srs SalesReports) Summary(ctx context.Context, from, to, groupBy, breakdown string) ([]models.SalesSummary, error) {
+	ctx, span := tracer.Start(ctx, "SalesReports.Summary")
+	defer span.End()
+
+	filter := models.SalesSummaryFilter{
+		Location: srs.Calendar.Location(),
+		BranchID: models.BranchFromContext(ctx),
+	}
+
+	var err error
+	filter.From, err = srs.Calendar.ParseStart(from)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: invalid from date received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "8d2f4c55-0e0b-4a7c-b7c2-3f1c9a6e2d41",
+		}
+	}
+	filter.To, err = srs.Calendar.ParseEnd(to)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: invalid to date received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "5a7e0b9c-61d4-4f3e-9c1a-84b2d6f0e7a3",
+		}
+	}
+	if filter.To.Before(filter.From) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: the to date [%s] is before the from date [%s]", to, from),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "c4e91d27-3b8a-4f06-a5d2-7e0f1b9c6a38",
+		}
+	}
+	filter.GroupBy, err = models.ParseSalesPeriod(groupBy)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "0f6b3a8e-d2c9-4e57-b1a4-9c8e7d2f5b16",
+		}
+	}
+	filter.ByBranch, filter.ByCashier, err = models.ParseSalesBreakdown(breakdown)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "e27a5c1f-8b4d-4d93-a6e0-3f5b9c1d7e82",
+		}
+	}
+
+	summaries, err := srs.Store.GetSalesSummary(ctx, filter)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("salesSummary: getting sales summary from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "9b1e6d42-7f3a-4c85-8e2d-5a0c4b7f1e69",
+		}
+	}
+
+	return summaries, nil
+}
+
+// Medicines ranks the medicines by what they sold between the dates in the caller's branch,
+// or in every branch when the caller did not identify one, with their ABC class. Those
+// without sales in the slowMoverDays business days up to the end date are slow movers.
+// An empty slowMoverDays takes the default and sortBy orders the result by revenue, units
+// or location.
+func (srs SalesReports) Medicines(ctx context.Context, from, to, slowMoverDays, sortBy string) ([]models.MedicineRanking, error) {
+	ctx, span := tracer.Start(ctx, "SalesReports.Medicines")
+	defer span.End()
+
+	startDate, err := srs.Calendar.ParseStart(from)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: invalid from date received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "3e8c1b5a-94d2-4f7e-a061-2b7d9c4e8f15",
+		}
+	}
+	endDate, err := srs.Calendar.ParseEnd(to)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: invalid to date received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "b6d04f2e-1c7a-4a38-9e5b-70f3a2c8d941",
+		}
+	}
+	if endDate.Before(startDate) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: the to date [%s] is before the from date [%s]", to, from),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "71a9e3c6-5f0b-4d82-b4e7-c19d6a2f3b50",
+		}
+	}
+	days := models.DefaultSlowMoverDays
+	if slowMoverDays != "" {
+		days, err = strconv.Atoi(slowMoverDays)
+		if err != nil || days <= 0 {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("medicinesRanking: invalid slow mover days [%s], this must be greater than 0", slowMoverDays),
+				HTTPCode: http.StatusBadRequest,
+				Code:     "d8f5a2b7-3e61-4c9d-8a04-6b1e7f9c2d35",
+			}
+		}
+	}
+	order, err := models.ParseRankingSort(sortBy)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "4c2e9f7a-b815-4d63-9a0e-e5f1b3d7c628",
+		}
+	}
+
+	performances, err := srs.Store.GetMedicinesPerformance(ctx, startDate, endDate, models.BranchFromContext(ctx))
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("medicinesRanking: getting medicines sales from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "a5b3d81c-7e94-4f26-b0c7-2d8e6f1a9b43",
+		}
+	}
+
+	for i, performance := range performances {
+		if performance.LastSoldAt != nil {
+			lastSoldAt := srs.Calendar.In(*performance.LastSoldAt)
+			performances[i].LastSoldAt = &lastSoldAt
+		}
+	}
+
+	// the end date's business day is the last of the days without sales
+	slowMoverSince := srs.Calendar.StartOfDay(endDate).AddDate(0, 0, 1-days)
+	rankings := models.RankMedicines(performances, slowMoverSince)
+	models.SortMedicineRankings(rankings, order)
+
+	return rankings, nil
+}
@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type fakeSalesReportStore struct {
+	SalesReportStore
+	performances []models.MedicinePerformance
+	err          error
+	// startDate, endDate and branchID are the arguments of the last call.
+	startDate time.Time
+	endDate   time.Time
+	branchID  int64
+}
+
+func (s *fakeSalesReportStore) GetMedicinesPerformance(
+	ctx context.Context, startDate, endDate time.Time, branchID int64,
+) ([]models.MedicinePerformance, error) {
+	s.startDate, s.endDate, s.branchID = startDate, endDate, branchID
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return slices.Clone(s.performances), nil
+}
+
+func TestSalesReportsMedicines(t *testing.T) {
+	soldAt := func(day, hour int) *time.Time {
+		t := time.Date(2030, time.March, day, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	// 1000 of revenue: the first two make 80%, the next two 15%
+	performances := []models.MedicinePerformance{
+		{MedicineID: 1, MedicineName: "Acetaminofen", Location: "B-2", Units: 50, Revenue: 500, LastSoldAt: soldAt(20, 9)},
+		{MedicineID: 2, MedicineName: "Ibuprofeno", Location: "A-1", Units: 10, Revenue: 300, LastSoldAt: soldAt(14, 0)},
+		{MedicineID: 3, MedicineName: "Loratadina", Location: "C-3", Units: 80, Revenue: 100, LastSoldAt: soldAt(13, 23)},
+		{MedicineID: 4, MedicineName: "Omeprazol", Location: "A-2", Units: 6, Revenue: 60, LastSoldAt: soldAt(18, 12)},
+		{MedicineID: 5, MedicineName: "Naproxeno", Location: "B-1", Units: 4, Revenue: 40, LastSoldAt: soldAt(19, 8)},
+		{MedicineID: 6, MedicineName: "Aspirina", Location: "A-3"},
+	}
+
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		slowMoverDays string
+		sortBy        string
+		storeErr      error
+		wantIDs       []int64
+		wantClasses   []string
+		wantSlow      []int64
+		wantCode      int
+	}{
+		{
+			name:          "by revenue",
+			from:          "2030-03-01",
+			to:            "2030-03-20",
+			slowMoverDays: "7",
+			wantIDs:       []int64{1, 2, 3, 4, 5, 6},
+			wantClasses:   []string{models.ABCClassA, models.ABCClassA, models.ABCClassB, models.ABCClassB, models.ABCClassC, models.ABCClassC},
+			wantSlow:      []int64{3, 6},
+		},
+		{
+			name:        "by units with the default slow mover days",
+			from:        "2030-03-01",
+			to:          "2030-03-20",
+			sortBy:      models.RankingSortUnits,
+			wantIDs:     []int64{3, 1, 2, 4, 5, 6},
+			wantClasses: []string{models.ABCClassB, models.ABCClassA, models.ABCClassA, models.ABCClassB, models.ABCClassC, models.ABCClassC},
+			wantSlow:    []int64{6},
+		},
+		{
+			name:          "by location",
+			from:          "2030-03-01",
+			to:            "2030-03-20",
+			slowMoverDays: "1",
+			sortBy:        models.RankingSortLocation,
+			wantIDs:       []int64{2, 4, 6, 5, 1, 3},
+			wantClasses:   []string{models.ABCClassA, models.ABCClassB, models.ABCClassC, models.ABCClassC, models.ABCClassA, models.ABCClassB},
+			wantSlow:      []int64{2, 4, 6, 5, 3},
+		},
+		{
+			name:     "to before from",
+			from:     "2030-03-20",
+			to:       "2030-03-01",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:          "invalid slow mover days",
+			from:          "2030-03-01",
+			to:            "2030-03-20",
+			slowMoverDays: "0",
+			wantCode:      http.StatusBadRequest,
+		},
+		{
+			name:     "invalid order",
+			from:     "2030-03-01",
+			to:       "2030-03-20",
+			sortBy:   "price",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "database error",
+			from:     "2030-03-01",
+			to:       "2030-03-20",
+			storeErr: errDatabase,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeSalesReportStore{performances: performances, err: tt.storeErr}
+			reports := NewSalesReports(store, models.NewBusinessCalendar(time.UTC))
+			ctx := models.ContextWithBranch(context.Background(), testBranchID)
+
+			rankings, err := reports.Medicines(ctx, tt.from, tt.to, tt.slowMoverDays, tt.sortBy)
+			if tt.wantCode != 0 {
+				var customErr models.CustomError
+				if !errors.As(err, &customErr) || customErr.HTTPCode != tt.wantCode {
+					t.Fatalf("got error %v, want a %d", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if store.branchID != testBranchID {
+				t.Errorf("got branch %d in the store, want %d", store.branchID, testBranchID)
+			}
+			if !store.startDate.Equal(time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC)) ||
+				!store.endDate.Equal(time.Date(2030, time.March, 21, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)) {
+				t.Errorf("got range %v - %v in the store, want the whole days", store.startDate, store.endDate)
+			}
+
+			gotIDs := make([]int64, 0, len(rankings))
+			gotClasses := make([]string, 0, len(rankings))
+			gotSlow := make([]int64, 0)
+			for _, ranking := range rankings {
+				gotIDs = append(gotIDs, ranking.MedicineID)
+				gotClasses = append(gotClasses, ranking.Class)
+				if ranking.SlowMover {
+					gotSlow = append(gotSlow, ranking.MedicineID)
+				}
+			}
+			if !slices.Equal(gotIDs, tt.wantIDs) {
+				t.Errorf("got medicines %v, want %v", gotIDs, tt.wantIDs)
+			}
+			if !slices.Equal(gotClasses, tt.wantClasses) {
+				t.Errorf("got classes %v, want %v", gotClasses, tt.wantClasses)
+			}
+			if !slices.Equal(gotSlow, tt.wantSlow) {
+				t.Errorf("got slow movers %v, want %v", gotSlow, tt.wantSlow)
+			}
+		})
+	}
+}
+
+func TestRankMedicinesShares(t *testing.T) {
+	rankings := models.RankMedicines([]models.MedicinePerformance{
+		{MedicineID: 1, MedicineName: "Acetaminofen", Units: 3, Revenue: 900},
+		{MedicineID: 2, MedicineName: "Ibuprofeno", Units: 9, Revenue: 100},
+	}, time.Time{})
+
+	first, second := rankings[0], rankings[1]
+	if first.MedicineID != 1 || first.RevenueRank != 1 || first.UnitsRank != 2 {
+		t.Errorf("got first %+v, want Acetaminofen first by revenue and second by units", first)
+	}
+	if second.RevenueRank != 2 || second.UnitsRank != 1 {
+		t.Errorf("got second %+v, want Ibuprofeno second by revenue and first by units", second)
+	}
+	if first.RevenueShare != 0.9 || first.CumulativeShare != 0.9 || second.CumulativeShare != 1 {
+		t.Errorf("got shares %v/%v and %v/%v, want 0.9/0.9 and 0.1/1",
+			first.RevenueShare, first.CumulativeShare, second.RevenueShare, second.CumulativeShare)
+	}
+	// the medicine that crosses 80% is still class A
+	if first.Class != models.ABCClassA || second.Class != models.ABCClassB {
+		t.Errorf("got classes %s and %s, want A and B", first.Class, second.Class)
+	}
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type SupplierStore interface {
+	GetAll(ctx context.Context) ([]models.Supplier, error)
+	GetSupplierByID(ctx context.Context, supplierID int64) (*models.Supplier, error)
+	CreateSupplier(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.Supplier, error)
+}
+
+type Suppliers struct {
+	Store SupplierStore
+}
+
+func NewSuppliers(ss SupplierStore) Suppliers {
+	return Suppliers{
+		Store: ss,
+	}
+}
+
+func (s Suppliers) Get(ctx context.Context) ([]models.Supplier, error) {
+	ctx, span := tracer.Start(ctx, "Suppliers.Get")
+	defer span.End()
+
+	suppliers, err := s.Store.GetAll(ctx)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting suppliers from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "d883abd9-1149-412e-8fb8-f76e302bd464",
+		}
+	}
+
+	return suppliers, nil
+}
+
+func (s Suppliers) GetByID(ctx context.Context, supplierIDParam string) (*models.Supplier, error) {
+	ctx, span := tracer.Start(ctx, "Suppliers.GetByID")
+	defer span.End()
+
+	supplierID, err := strconv.ParseInt(supplierIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid supplierID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "1d6cbf01-4750-4309-9713-d6356930c279",
+		}
+	}
+
+	supplier, err := s.Store.GetSupplierByID(ctx, supplierID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("supplier not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "5c52ed05-0379-46a5-a0a8-8c0ae58083cc",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting supplier from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "4786d8ee-654b-418b-b4fe-ac7dca02a6dc",
+		}
+	}
+
+	return supplier, nil
+}
+
+func (s Suppliers) Create(ctx context.Context, supplierRequest models.SupplierCreationRequest) (*models.SupplierCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "Suppliers.Create")
+	defer span.End()
+
+	if err := supplierRequest.ValidateSupplierRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createSupplier: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "b1f90193-4822-4e0c-b3b8-6088c26b9568",
+		}
+	}
+
+	createdSupplier, err := s.Store.CreateSupplier(ctx, supplierRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating supplier with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "e8f97bb6-25bf-465d-962c-c46a98a5a267",
+		}
+	}
+
+	return &models.SupplierCreationResponse{
+		ID: createdSupplier.ID,
+	}, nil
+}
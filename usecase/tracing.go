@@ -0,0 +1,10 @@
+package usecase
+
+import (
+	"go.opentelemetry.io/otel"
+)
+
+const tracerName = "github.com/VictorDelgado94/aveonline-backend/usecase"
+
+// tracer starts a span per usecase method, named after the type and the method.
+var tracer = otel.Tracer(tracerName)
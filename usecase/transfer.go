@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type TransferStore interface {
+	GetAll(ctx context.Context, status string, branchID int64) ([]models.StockTransfer, error)
+	GetTransferByID(ctx context.Context, transferID int64) (*models.StockTransfer, error)
+	CreateTransfer(ctx context.Context, transferRequest models.StockTransferCreationRequest) (*models.StockTransfer, error)
+	Ship(ctx context.Context, transfer models.StockTransfer) error
+	Receive(ctx context.Context, transfer models.StockTransfer) error
+}
+
+type Transfers struct {
+	Store         TransferStore
+	BranchStore   BranchStore
+	MedicineStore MedicineStore
+}
+
+func NewTransfers(ts TransferStore, bs BranchStore, ms MedicineStore) Transfers {
+	return Transfers{
+		Store:         ts,
+		BranchStore:   bs,
+		MedicineStore: ms,
+	}
+}
+
+// Get returns the transfers of the caller's branch (or all of them), filtered by status
+// when it is not empty.
+func (t Transfers) Get(ctx context.Context, status string) ([]models.StockTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Transfers.Get")
+	defer span.End()
+
+	switch status {
+	case "", models.TransferDraft, models.TransferShipped, models.TransferReceived:
+	default:
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid transfer status received: [%s]", status),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "d4266b55-0e3e-4103-b623-fa8646b8d132",
+		}
+	}
+
+	transfers, err := t.Store.GetAll(ctx, status, models.BranchFromContext(ctx))
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting transfers from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "05983154-5201-432b-a7ee-7f86b22a26a0",
+		}
+	}
+
+	return transfers, nil
+}
+
+// GetInTransit returns the shipped transfers that have not been received yet.
+func (t Transfers) GetInTransit(ctx context.Context) ([]models.StockTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Transfers.GetInTransit")
+	defer span.End()
+
+	return t.Get(ctx, models.TransferShipped)
+}
+
+func (t Transfers) GetByID(ctx context.Context, transferIDParam string) (*models.StockTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Transfers.GetByID")
+	defer span.End()
+
+	transferID, err := strconv.ParseInt(transferIDParam, 10, 64)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("invalid transferID received: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "106e98e8-4971-43ef-8f38-a00161962f4f",
+		}
+	}
+
+	return t.getTransfer(ctx, transferID)
+}
+
+func (t Transfers) getTransfer(ctx context.Context, transferID int64) (*models.StockTransfer, error) {
+	transfer, err := t.Store.GetTransferByID(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotFound) {
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("transfer not found in database: %w", err),
+				HTTPCode: http.StatusNotFound,
+				Code:     "159ea15b-1a53-4e09-bb9a-3dd98823ad60",
+			}
+		}
+
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("getting transfer from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "07b7063a-aab3-4dc4-8e74-45dd110b3b91",
+		}
+	}
+
+	return transfer, nil
+}
+
+func (t Transfers) Create(ctx context.Context, transferRequest models.StockTransferCreationRequest) (*models.StockTransferCreationResponse, error) {
+	ctx, span := tracer.Start(ctx, "Transfers.Create")
+	defer span.End()
+
+	if err := transferRequest.ValidateStockTransferRequest(); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createTransfer: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "2b64f285-7e3e-429e-b6d1-4e237b1987f0",
+		}
+	}
+
+	for _, branchID := range []int64{transferRequest.SourceBranchID, transferRequest.DestinationBranchID} {
+		if _, err := t.BranchStore.GetBranchByID(ctx, branchID); err != nil {
+			if errors.Is(err, models.ErrNotFound) {
+				return nil, models.CustomError{
+					Err:      fmt.Errorf("createTransfer: branch [%d] not found in database: %w", branchID, err),
+					HTTPCode: http.StatusNotFound,
+					Code:     "bfe3e12b-0c59-4569-adef-db2695787374",
+				}
+			}
+
+			return nil, models.CustomError{
+				Err:      fmt.Errorf("createTransfer: checking branch in the database: %w", err),
+				HTTPCode: http.StatusInternalServerError,
+				Code:     "47aa13fc-b976-41ad-a9ed-363d8708abaf",
+			}
+		}
+	}
+
+	medicinesIDs := make([]int64, 0, len(transferRequest.Lines))
+	for _, line := range transferRequest.Lines {
+		medicinesIDs = append(medicinesIDs, line.MedicineID)
+	}
+	medicines, err := t.MedicineStore.GetMedicinesByIDs(ctx, medicinesIDs)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createTransfer: getting medicines from the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "c6d7c207-2387-4d3e-989d-22cc5c2d27b0",
+		}
+	}
+	if len(medicines) != len(medicinesIDs) {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("createTransfer: not all medicines could be found"),
+			HTTPCode: http.StatusNotFound,
+			Code:     "1aa821c8-538f-4ce9-9c04-6ec933bfaf79",
+		}
+	}
+
+	createdTransfer, err := t.Store.CreateTransfer(ctx, transferRequest)
+	if err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("creating transfer with in the database: %w", err),
+			HTTPCode: http.StatusInternalServerError,
+			Code:     "0a8f0b5e-b718-4e86-b15a-b84b228ecb60",
+		}
+	}
+
+	return &models.StockTransferCreationResponse{
+		ID: createdTransfer.ID,
+	}, nil
+}
+
+// Ship takes the goods of a draft transfer out of the source branch, leaving them in transit.
+func (t Transfers) Ship(ctx context.Context, transferIDParam string) (*models.StockTransfer, error) {
+	ctx, span := tracer.Start(ctx, "Transfers.Ship")
+	defer span.End()
+
+	transfer, err := t.GetByID(ctx, transferIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.Status != models.TransferDraft {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("shipTransfer: only draft transfers can be shipped, current status is [%s]", transfer.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "b60751ba-795e-4a19-b567-9acc740864f8",
+		}
+	}
+
+	if err := t.Store.Ship(ctx, *transfer); err != nil {
+		return nil, transitionError("shipTransfer", err)
+	}
+
+	return t.getTransfer(ctx, transfer.ID)
+}
+
+// Receive puts the received goods of an in transit transfer in the destination branch,
+// recording the discrepancies with the shipped quantities. Only the destination branch
+// receives its transfers.
+func (t Transfers) Receive(
+	ctx context.Context, transferIDParam string, receiptRequest models.StockTransferReceiptRequest) (*models.StockTransfer, error,
+) {
+	ctx, span := tracer.Start(ctx, "Transfers.Receive")
+	defer span.End()
+
+	transfer, err := t.GetByID(ctx, transferIDParam)
+	if err != nil {
+		return nil, err
+	}
+	if branchID := models.BranchFromContext(ctx); branchID != transfer.DestinationBranchID {
+		return nil, models.CustomError{
+			Err: fmt.Errorf("receiveTransfer: branch [%d] cannot receive a transfer to branch [%d]",
+				branchID, transfer.DestinationBranchID),
+			HTTPCode: http.StatusForbidden,
+			Code:     "8e2b6f14-7c3a-4d95-b1e0-5a9f2c7d3e68",
+		}
+	}
+	if transfer.Status != models.TransferShipped {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("receiveTransfer: only shipped transfers can be received, current status is [%s]", transfer.Status),
+			HTTPCode: http.StatusConflict,
+			Code:     "f1337d9a-ab49-46d3-8f97-f5e2c274f51c",
+		}
+	}
+	if err := receiptRequest.ValidateReceipt(*transfer); err != nil {
+		return nil, models.CustomError{
+			Err:      fmt.Errorf("receiveTransfer: request data is invalid: %w", err),
+			HTTPCode: http.StatusBadRequest,
+			Code:     "5fc4e22f-16d7-401a-9af2-a73dea13f250",
+		}
+	}
+
+	received := make(map[int64]models.StockTransferReceiptLineRequest, len(receiptRequest.Lines))
+	for _, line := range receiptRequest.Lines {
+		received[line.MedicineID] = line
+	}
+	for i, line := range transfer.Lines {
+		receivedQuantity := received[line.MedicineID].ReceivedQuantity
+		transfer.Lines[i].ReceivedQuantity = &receivedQuantity
+		transfer.Lines[i].DiscrepancyNotes = received[line.MedicineID].DiscrepancyNotes
+	}
+
+	if err := t.Store.Receive(ctx, *transfer); err != nil {
+		return nil, transitionError("receiveTransfer", err)
+	}
+
+	return t.getTransfer(ctx, transfer.ID)
+}
+
+// transitionError maps the error of a status change to the response, a concurrent
+// change of status is reported as a conflict.
+func transitionError(operation string, err error) error {
+	if errors.Is(err, models.ErrInvalidTransition) {
+		return models.CustomError{
+			Err:      fmt.Errorf("%s: status changed concurrently: %w", operation, err),
+			HTTPCode: http.StatusConflict,
+			Code:     "4f01a72f-a7a0-41b6-8bfc-bb463c46a7ca",
+		}
+	}
+
+	return models.CustomError{
+		Err:      fmt.Errorf("%s: updating status within the database: %w", operation, err),
+		HTTPCode: http.StatusInternalServerError,
+		Code:     "b91a750c-eea2-413b-a4a8-eb5df998869a",
+	}
+}
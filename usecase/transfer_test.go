@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/VictorDelgado94/aveonline-backend/models"
+)
+
+type fakeTransferStore struct {
+	TransferStore
+	transfer models.StockTransfer
+	received []models.StockTransfer
+}
+
+func (s *fakeTransferStore) GetTransferByID(ctx context.Context, transferID int64) (*models.StockTransfer, error) {
+	if transferID != s.transfer.ID {
+		return nil, models.ErrNotFound
+	}
+	transfer := s.transfer
+	transfer.Lines = append([]models.StockTransferLine(nil), s.transfer.Lines...)
+
+	return &transfer, nil
+}
+
+func (s *fakeTransferStore) Receive(ctx context.Context, transfer models.StockTransfer) error {
+	s.received = append(s.received, transfer)
+
+	return nil
+}
+
+func TestTransfersReceive(t *testing.T) {
+	lines := func(received int64) models.StockTransferReceiptRequest {
+		return models.StockTransferReceiptRequest{Lines: []models.StockTransferReceiptLineRequest{
+			{MedicineID: 1, ReceivedQuantity: received},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		branchID   int64
+		request    models.StockTransferReceiptRequest
+		wantStatus int
+	}{
+		{name: "by the destination", branchID: testOtherBranchID, request: lines(10)},
+		{name: "with a shortfall", branchID: testOtherBranchID, request: lines(7)},
+		{name: "by the source", branchID: testBranchID, request: lines(10), wantStatus: http.StatusForbidden},
+		{name: "without branch", request: lines(10), wantStatus: http.StatusForbidden},
+		{name: "more than shipped", branchID: testOtherBranchID, request: lines(11), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeTransferStore{transfer: models.StockTransfer{
+				ID:                  4,
+				SourceBranchID:      testBranchID,
+				DestinationBranchID: testOtherBranchID,
+				Status:              models.TransferShipped,
+				Lines:               []models.StockTransferLine{{ID: 9, MedicineID: 1, Quantity: 10}},
+			}}
+			transfers := NewTransfers(store, fakeBranchStore{}, nil)
+
+			ctx := models.ContextWithBranch(context.Background(), tt.branchID)
+			_, err := transfers.Receive(ctx, "4", tt.request)
+			assertCustomError(t, err, tt.wantStatus)
+			if tt.wantStatus != 0 {
+				if len(store.received) != 0 {
+					t.Errorf("the transfer was received")
+				}
+				return
+			}
+			if len(store.received) != 1 || *store.received[0].Lines[0].ReceivedQuantity != tt.request.Lines[0].ReceivedQuantity {
+				t.Errorf("got receipts %+v, want the received quantity stored", store.received)
+			}
+		})
+	}
+}
+
+func TestTransferReceiptMovements(t *testing.T) {
+	transfer := models.StockTransfer{ID: 4, DestinationBranchID: testOtherBranchID}
+	received := int64(7)
+	line := models.StockTransferLine{MedicineID: 1, Quantity: 10, ReceivedQuantity: &received}
+
+	movements := models.TransferReceiptMovements(transfer, line)
+	if len(movements) != 2 {
+		t.Fatalf("got movements %+v, want the transfer in and the transit loss", movements)
+	}
+	if movements[0].MovementType != models.StockMovementTransferIn || movements[0].Quantity != 10 {
+		t.Errorf("got movement %+v, want the 10 shipped units in", movements[0])
+	}
+	if movements[1].MovementType != models.StockMovementTransitLoss || movements[1].Quantity != -3 {
+		t.Errorf("got movement %+v, want the 3 missing units out", movements[1])
+	}
+	for _, movement := range movements {
+		if movement.BranchID != testOtherBranchID || movement.ReferenceID != transfer.ID {
+			t.Errorf("got movement %+v, want it in the destination branch referencing the transfer", movement)
+		}
+	}
+}